@@ -0,0 +1,73 @@
+// Package vhs accessibility.go makes SVG outputs accessible to screen
+// readers.
+//
+// With a Description, the SVG is an image named by its title (the window bar
+// title, if any) and described by the description, so that terminal demos
+// embedded in docs are announced instead of skipped. AccessibleTranscript
+// also embeds the text of the terminal in a visually hidden block which
+// describes the image: the full scrollback in the browser backend, otherwise
+// the final screen.
+//
+// Set Description "Installing the CLI with Homebrew"
+// Set AccessibleTranscript true
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// defaultAccessibleTitle is the title of SVG outputs without a window bar
+// title.
+const defaultAccessibleTitle = "Terminal recording"
+
+// IDs of the accessible elements of SVG outputs.
+const (
+	accessibleTitleID      = "vhs-title"
+	accessibleDescID       = "vhs-desc"
+	accessibleTranscriptID = "vhs-transcript"
+)
+
+// ariaAttributes returns the attributes of the SVG root element which name
+// and describe it, if it has a description.
+func (g *SVGGenerator) ariaAttributes() string {
+	if g.options.Description == "" {
+		return ""
+	}
+	describedBy := accessibleDescID
+	if len(g.options.AccessibleTranscript) > 0 {
+		describedBy += " " + accessibleTranscriptID
+	}
+	return fmt.Sprintf(` role="img" aria-labelledby="%s" aria-describedby="%s"`, accessibleTitleID, describedBy)
+}
+
+// generateAccessibility writes the title and description of the SVG, which
+// must be the first children of the root element.
+func (g *SVGGenerator) generateAccessibility(sb *strings.Builder) {
+	if g.options.Description == "" {
+		return
+	}
+	title := defaultAccessibleTitle
+	if g.options.Style != nil && g.options.Style.WindowBarTitle != "" {
+		title = g.options.Style.WindowBarTitle
+	}
+	fmt.Fprintf(sb, `<title id="%s">%s</title>`, accessibleTitleID, html.EscapeString(title))
+	g.writeNewline(sb)
+	fmt.Fprintf(sb, `<desc id="%s">%s</desc>`, accessibleDescID, html.EscapeString(g.options.Description))
+	g.writeNewline(sb)
+}
+
+// generateAccessibleTranscript writes the transcript as text which is read
+// by screen readers but not shown.
+func (g *SVGGenerator) generateAccessibleTranscript(sb *strings.Builder) {
+	if len(g.options.AccessibleTranscript) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, `<text id="%s" x="0" y="0" font-size="1" opacity="0" xml:space="preserve">`, accessibleTranscriptID)
+	for _, line := range g.options.AccessibleTranscript {
+		fmt.Fprintf(sb, `<tspan x="0" dy="1em">%s</tspan>`, html.EscapeString(line))
+	}
+	sb.WriteString("</text>")
+	g.writeNewline(sb)
+}
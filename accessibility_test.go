@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestSVGGenerator_Description(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Description = `Running "make" & tests`
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `role="img" aria-labelledby="vhs-title" aria-describedby="vhs-desc">`, "Accessible root element")
+	assertContains(t, svg, `<title id="vhs-title">Terminal recording</title>`, "Default title")
+	assertContains(t, svg, `<desc id="vhs-desc">Running &#34;make&#34; &amp; tests</desc>`, "Escaped description")
+	if strings.Index(svg, "<title") > strings.Index(svg, "<rect") {
+		t.Error("expected the title to be the first child of the root element")
+	}
+
+	opts.Style.WindowBarTitle = "Build"
+	svg = NewSVGGenerator(opts).Generate()
+	assertContains(t, svg, `<title id="vhs-title">Build</title>`, "Window bar title")
+}
+
+func TestSVGGenerator_AccessibleTranscript(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Description = "Counting"
+	opts.AccessibleTranscript = []string{"$ seq 2", "1", "2 <done>"}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `aria-describedby="vhs-desc vhs-transcript"`, "Transcript describes the image")
+	assertContains(t, svg, `<text id="vhs-transcript" x="0" y="0" font-size="1" opacity="0" xml:space="preserve">`, "Hidden transcript")
+	assertContains(t, svg, `<tspan x="0" dy="1em">2 &lt;done&gt;</tspan></text>`, "Escaped transcript lines")
+}
+
+func TestSVGGenerator_NoDescription(t *testing.T) {
+	svg := NewSVGGenerator(createTestSVGConfig()).Generate()
+	for _, s := range []string{"role=", "<title", "<desc", "vhs-transcript"} {
+		if strings.Contains(svg, s) {
+			t.Errorf("expected no %s without a description", s)
+		}
+	}
+}
+
+func TestAccessibleTranscript(t *testing.T) {
+	v := &VHS{Options: &Options{}}
+	v.svgFrames = []SVGFrame{{Lines: []string{"> ls  ", "a b", ""}}}
+	if got := strings.Join(v.accessibleTranscript(), "\n"); got != "> ls\na b" {
+		t.Errorf("expected the final screen, got %q", got)
+	}
+	if v.svgFrames[0].Lines[0] != "> ls  " {
+		t.Error("expected the frame to be left untouched")
+	}
+
+	v.scrollback = []string{"$ seq 100", "1"}
+	if got := v.accessibleTranscript(); len(got) != 2 {
+		t.Errorf("expected the scrollback, got %q", got)
+	}
+
+	if v.transcript() != nil {
+		t.Error("expected no visible transcript without Scrollback")
+	}
+}
+
+func TestExecuteSetAccessibility(t *testing.T) {
+	v := &VHS{Options: &Options{}}
+	if err := ExecuteSetDescription(parser.Command{Args: "A demo"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExecuteSetAccessibleTranscript(parser.Command{Args: "true"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.SVG.Description != "A demo" || !v.Options.SVG.AccessibleTranscript {
+		t.Errorf("unexpected SVG options %+v", v.Options.SVG)
+	}
+	if err := ExecuteSetAccessibleTranscript(parser.Command{Args: "maybe"}, v); err == nil {
+		t.Error("expected an error for a non-boolean value")
+	}
+}
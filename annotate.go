@@ -0,0 +1,259 @@
+// Package vhs annotate.go overlays callouts on the terminal.
+//
+// Annotate shows a callout box with the text, optionally with an arrow
+// pointing at a cell, for a duration. Rows and columns are 1-based. Without
+// --at, the callout appears immediately and the following commands keep
+// running; --at places it at a time since the start of the recording. The
+// callout is placed near the arrow (or at the top of the terminal) unless a
+// --position is given.
+//
+// Every renderer draws the same overlay shapes: videos overlay a transparent
+// image of every callout with ffmpeg, SVG outputs draw them above the
+// terminal.
+//
+// Annotate "Click here" --arrow 40,12 --at 5s --for 3s
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// defaultAnnotationDuration is how long a callout is shown by default.
+const defaultAnnotationDuration = 2 * time.Second
+
+// annotationStream is the ffmpeg input of the image of the first callout,
+// following the text and cursor frames.
+const annotationStream = 2
+
+// Annotation is a callout overlaid on the terminal.
+type Annotation struct {
+	Text string
+	// Row and Col are the top-left cell (0-based) of the callout.
+	Row, Col int
+	// Arrow points at the cell (0-based) ArrowRow, ArrowCol.
+	Arrow              bool
+	ArrowRow, ArrowCol int
+	// Cols and Rows are the size of the terminal the positions refer to.
+	Cols, Rows int
+	// Start and End are the captured frames (1-based) between which the
+	// callout is shown.
+	Start, End int
+	// Foreground colors the box and arrow, Background the text.
+	Foreground, Background string
+}
+
+// point is a position in pixels.
+type point struct{ X, Y float64 }
+
+// annotationShape is the layout of a callout in pixels, shared by the
+// renderers.
+type annotationShape struct {
+	X, Y, Width, Height float64
+	// TextX and TextY are the start of the baseline of the text.
+	TextX, TextY float64
+	Arrow        bool
+	// The arrow is a line from the box to the base of its head, a triangle
+	// pointing at the cell.
+	From, To point
+	Head     [3]point
+}
+
+// shape returns the layout of the callout on a terminal with the given cell
+// size.
+func (a Annotation) shape(cellWidth, cellHeight float64) annotationShape {
+	s := annotationShape{
+		X:      float64(a.Col) * cellWidth,
+		Y:      float64(a.Row) * cellHeight,
+		Width:  float64(a.calloutCells()) * cellWidth,
+		Height: cellHeight * 1.5,
+		Arrow:  a.Arrow,
+	}
+	s.TextX = s.X + cellWidth
+	s.TextY = s.Y + s.Height/2 + cellHeight*0.3
+	if !a.Arrow {
+		return s
+	}
+
+	tip := point{(float64(a.ArrowCol) + 0.5) * cellWidth, (float64(a.ArrowRow) + 0.5) * cellHeight}
+	center := point{s.X + s.Width/2, s.Y + s.Height/2}
+	dx, dy := tip.X-center.X, tip.Y-center.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		s.Arrow = false
+		return s
+	}
+
+	// The line starts where it leaves the box.
+	edge := 1.0
+	if dx != 0 {
+		edge = math.Min(edge, s.Width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		edge = math.Min(edge, s.Height/2/math.Abs(dy))
+	}
+	s.From = point{center.X + dx*edge, center.Y + dy*edge}
+
+	ux, uy := dx/length, dy/length
+	headLength := cellHeight * 0.6
+	headWidth := cellHeight * 0.5
+	s.To = point{tip.X - ux*headLength, tip.Y - uy*headLength}
+	s.Head = [3]point{
+		tip,
+		{s.To.X - uy*headWidth/2, s.To.Y + ux*headWidth/2},
+		{s.To.X + uy*headWidth/2, s.To.Y - ux*headWidth/2},
+	}
+	return s
+}
+
+// calloutCells returns the width of the callout in cells, with a cell of
+// padding on each side of its text.
+func (a Annotation) calloutCells() int {
+	return cellWidth(a.Text) + 2 //nolint:mnd
+}
+
+// placeCallout places the callout above the cell the arrow points at (or
+// below it at the top of the terminal), or at the top center of the terminal
+// without an arrow.
+func (a *Annotation) placeCallout() {
+	width := a.calloutCells()
+	if !a.Arrow {
+		a.Row, a.Col = 1, (a.Cols-width)/2
+	} else {
+		a.Row, a.Col = a.ArrowRow-3, a.ArrowCol-2
+		if a.Row < 0 {
+			a.Row = a.ArrowRow + 2
+		}
+	}
+	a.Col = max(0, min(a.Col, a.Cols-width))
+	a.Row = max(0, min(a.Row, a.Rows-1))
+}
+
+// MakeAnnotationImage draws the callout on a transparent image of the
+// terminal with the given dimensions and saves it to a file.
+func MakeAnnotationImage(a Annotation, width, height int, fontFamily string, fontSize float64, file string) error {
+	cellWidth := float64(width) / float64(max(1, a.Cols))
+	cellHeight := float64(height) / float64(max(1, a.Rows))
+	s := a.shape(cellWidth, cellHeight)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fg, _ := parseHexColor(a.Foreground)
+	bg, _ := parseHexColor(a.Background)
+
+	if s.Arrow {
+		thickness := math.Max(2, cellHeight/8)
+		draw.DrawMask(img, img.Bounds(), &image.Uniform{fg}, image.Point{},
+			&segment{s.From, s.To, thickness}, image.Point{}, draw.Over)
+		draw.DrawMask(img, img.Bounds(), &image.Uniform{fg}, image.Point{},
+			&triangle{s.Head}, image.Point{}, draw.Over)
+	}
+
+	box := image.Rect(int(s.X), int(s.Y), int(s.X+s.Width), int(s.Y+s.Height))
+	draw.DrawMask(img, box, &image.Uniform{fg}, image.Point{},
+		&roundedrect{pb: image.Pt(box.Dx(), box.Dy()), radius: int(cellHeight / 4)}, image.Point{}, draw.Over)
+
+	d := font.Drawer{
+		Dst:  img,
+		Src:  &image.Uniform{bg},
+		Face: getWindowBarFont(fontFamily, fontSize),
+		Dot:  fixed.P(int(s.TextX), int(s.TextY)),
+	}
+	d.DrawString(a.Text)
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create annotation image: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("failed to encode annotation image: %w", err)
+	}
+	return nil
+}
+
+// segment is an antialiased line mask.
+type segment struct {
+	a, b      point
+	thickness float64
+}
+
+func (s *segment) ColorModel() color.Model { return color.AlphaModel }
+
+func (s *segment) Bounds() image.Rectangle {
+	pad := s.thickness
+	return image.Rect(
+		int(math.Min(s.a.X, s.b.X)-pad), int(math.Min(s.a.Y, s.b.Y)-pad),
+		int(math.Max(s.a.X, s.b.X)+pad)+1, int(math.Max(s.a.Y, s.b.Y)+pad)+1,
+	)
+}
+
+func (s *segment) At(x, y int) color.Color {
+	px, py := float64(x)+0.5, float64(y)+0.5
+	dx, dy := s.b.X-s.a.X, s.b.Y-s.a.Y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((px-s.a.X)*dx+(py-s.a.Y)*dy)/l))
+	}
+	dist := math.Hypot(px-(s.a.X+t*dx), py-(s.a.Y+t*dy)) - s.thickness/2
+	return coverage(dist)
+}
+
+// triangle is an antialiased triangle mask.
+type triangle struct {
+	p [3]point
+}
+
+func (t *triangle) ColorModel() color.Model { return color.AlphaModel }
+
+func (t *triangle) Bounds() image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range t.p {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	return image.Rect(int(minX)-1, int(minY)-1, int(maxX)+2, int(maxY)+2)
+}
+
+func (t *triangle) At(x, y int) color.Color {
+	px, py := float64(x)+0.5, float64(y)+0.5
+	// The distance outside of the triangle is the largest distance outside
+	// of one of its edges.
+	dist := math.Inf(-1)
+	area := (t.p[1].X-t.p[0].X)*(t.p[2].Y-t.p[0].Y) - (t.p[1].Y-t.p[0].Y)*(t.p[2].X-t.p[0].X)
+	for i := range t.p {
+		a, b := t.p[i], t.p[(i+1)%3]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if length == 0 {
+			continue
+		}
+		d := ((b.X-a.X)*(py-a.Y) - (b.Y-a.Y)*(px-a.X)) / length
+		if area > 0 {
+			d = -d
+		}
+		dist = math.Max(dist, d)
+	}
+	return coverage(dist)
+}
+
+// coverage returns the opacity of a pixel whose center is at the given
+// distance outside of a shape.
+func coverage(dist float64) color.Color {
+	switch {
+	case dist <= -0.5:
+		return color.Alpha{white}
+	case dist >= 0.5:
+		return color.Alpha{0x00}
+	default:
+		return color.Alpha{uint8((0.5 - dist) * white)}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotationShape(t *testing.T) {
+	a := Annotation{Text: "hi", Row: 1, Col: 2, Arrow: true, ArrowRow: 5, ArrowCol: 3}
+	s := a.shape(10, 20)
+
+	if s.X != 20 || s.Y != 20 || s.Width != 40 || s.Height != 30 {
+		t.Errorf("unexpected box %v,%v %vx%v", s.X, s.Y, s.Width, s.Height)
+	}
+	if s.Head[0] != (point{35, 110}) {
+		t.Errorf("expected the arrow to point at the center of the cell, got %v", s.Head[0])
+	}
+	if s.From.Y != s.Y+s.Height {
+		t.Errorf("expected the arrow to start at the bottom of the box, got %v", s.From)
+	}
+	if s.To.Y >= s.Head[0].Y || s.To.Y <= s.From.Y {
+		t.Errorf("expected the line to end before the head, got %v", s.To)
+	}
+}
+
+func TestPlaceCallout(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        Annotation
+		row, col int
+	}{
+		{"top center", Annotation{Text: "hello", Cols: 80, Rows: 24}, 1, 36},
+		{"above arrow", Annotation{Text: "hello", Cols: 80, Rows: 24, Arrow: true, ArrowRow: 10, ArrowCol: 20}, 7, 18},
+		{"below arrow", Annotation{Text: "hello", Cols: 80, Rows: 24, Arrow: true, ArrowRow: 1, ArrowCol: 78}, 3, 73},
+		{"emoji", Annotation{Text: "✅ done 🎉", Cols: 80, Rows: 24}, 1, 34},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.a.placeCallout()
+			if tc.a.Row != tc.row || tc.a.Col != tc.col {
+				t.Errorf("expected the callout at %d,%d, got %d,%d", tc.row, tc.col, tc.a.Row, tc.a.Col)
+			}
+		})
+	}
+}
+
+func TestMakeAnnotationImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotation.png")
+	a := Annotation{
+		Text: "hi", Row: 1, Col: 1, Arrow: true, ArrowRow: 8, ArrowCol: 8,
+		Cols: 10, Rows: 10, Foreground: "#ff0000", Background: "#000000",
+	}
+	if err := MakeAnnotationImage(a, 100, 200, defaultFontFamily, 12, path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, alpha := img.At(0, 0).RGBA(); alpha != 0 {
+		t.Error("expected the image to be transparent outside of the callout")
+	}
+	if r, g, _, alpha := img.At(12, 22).RGBA(); alpha == 0 || r>>8 != 0xff || g != 0 {
+		t.Errorf("expected the box to be drawn in the foreground color, got %v", img.At(12, 22))
+	}
+	if _, _, _, alpha := img.At(57, 102).RGBA(); alpha == 0 {
+		t.Error("expected the arrow to be drawn")
+	}
+}
+
+func TestVideoFilterBuilder_Annotations(t *testing.T) {
+	opts := DefaultVideoOptions()
+	opts.Style = DefaultStyleOptions()
+	opts.Annotations = []Annotation{{Start: 11, End: 21}, {Start: 31, End: 41}}
+
+	filter := NewVideoFilterBuilder(&opts).filterComplex.String()
+	for _, want := range []string{
+		"[boxed][2]overlay=enable='between(n,10,19)'[annotated0]",
+		"[annotated0][3]overlay=enable='between(n,30,39)'[annotated1]",
+		"[annotated1]fps=",
+	} {
+		if !strings.Contains(filter, want) {
+			t.Errorf("expected %q in:\n%s", want, filter)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+//go:build !js
+
+// Package vhs asciicast.go reads asciinema recordings.
+//
+// Asciicasts are replayed as the output of a terminal, timed by their events,
+// so that existing recordings are rendered like the recordings of tapes. The
+// versions 1, 2 and 3 of the format are read, along with their theme.
+//
+// vhs convert session.cast -o demo.gif
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// castExt is the extension of asciicasts.
+const castExt = ".cast"
+
+// termEvent is the output of a terminal, or its resize, at a time of a
+// recording in seconds.
+type termEvent struct {
+	Time       float64
+	Data       string
+	Cols, Rows int
+}
+
+// termRecording is a recording of the output of a terminal.
+type termRecording struct {
+	Cols, Rows int
+	// IdleTimeLimit is the longest pause between events, in seconds, if any.
+	IdleTimeLimit float64
+	Theme         *Theme
+	Events        []termEvent
+}
+
+// castTheme is the theme of an asciicast.
+type castTheme struct {
+	Fg      string `json:"fg"`
+	Bg      string `json:"bg"`
+	Palette string `json:"palette"`
+}
+
+// castHeader is the header of an asciicast, the first line of the versions 2
+// and 3, and the whole recording in the version 1.
+type castHeader struct {
+	Version       int        `json:"version"`
+	Width         int        `json:"width"`
+	Height        int        `json:"height"`
+	IdleTimeLimit float64    `json:"idle_time_limit"` //nolint:tagliatelle
+	Theme         *castTheme `json:"theme"`
+	Term          struct {
+		Cols  int        `json:"cols"`
+		Rows  int        `json:"rows"`
+		Theme *castTheme `json:"theme"`
+	} `json:"term"`
+	Stdout [][2]json.RawMessage `json:"stdout"`
+}
+
+// parseAsciicast reads an asciicast.
+func parseAsciicast(r io.Reader) (termRecording, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return termRecording{}, fmt.Errorf("failed to read asciicast: %w", err)
+	}
+
+	// The version 1 is a single JSON document, the others JSON lines.
+	var header castHeader
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&header); err != nil {
+		return termRecording{}, fmt.Errorf("invalid asciicast header: %w", err)
+	}
+
+	rec := termRecording{IdleTimeLimit: header.IdleTimeLimit}
+	switch header.Version {
+	case 1:
+		rec.Cols, rec.Rows = header.Width, header.Height
+		rec.Events, err = castV1Events(header.Stdout)
+	case 2: //nolint:mnd
+		rec.Cols, rec.Rows = header.Width, header.Height
+		rec.Theme = header.Theme.theme()
+		rec.Events, err = castEvents(bytes.NewReader(data[dec.InputOffset():]), false)
+	case 3: //nolint:mnd
+		rec.Cols, rec.Rows = header.Term.Cols, header.Term.Rows
+		rec.Theme = header.Term.Theme.theme()
+		rec.Events, err = castEvents(bytes.NewReader(data[dec.InputOffset():]), true)
+	default:
+		return termRecording{}, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+	if err != nil {
+		return termRecording{}, err
+	}
+	if rec.Cols <= 0 || rec.Rows <= 0 {
+		return termRecording{}, errors.New("the asciicast has no terminal size")
+	}
+	return rec, nil
+}
+
+// castV1Events returns the output of an asciicast of the version 1, whose
+// events are timed by their delay.
+func castV1Events(stdout [][2]json.RawMessage) ([]termEvent, error) {
+	var events []termEvent
+	var t float64
+	for _, event := range stdout {
+		var delay float64
+		var data string
+		if err := json.Unmarshal(event[0], &delay); err != nil {
+			return nil, fmt.Errorf("invalid asciicast event: %w", err)
+		}
+		if err := json.Unmarshal(event[1], &data); err != nil {
+			return nil, fmt.Errorf("invalid asciicast event: %w", err)
+		}
+		t += delay
+		events = append(events, termEvent{Time: t, Data: data})
+	}
+	return events, nil
+}
+
+// castEvents returns the output and resizes of an asciicast, one event per
+// line. The events of the version 3 are timed by the interval since the
+// previous one.
+func castEvents(r io.Reader, intervals bool) ([]termEvent, error) {
+	var events []termEvent
+	var t float64
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24) //nolint:mnd
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var event [3]json.RawMessage
+		var at float64
+		var code, data string
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			return nil, fmt.Errorf("invalid asciicast event on line %d: %w", line+1, err)
+		}
+		if err := json.Unmarshal(event[0], &at); err != nil {
+			return nil, fmt.Errorf("invalid asciicast event on line %d: %w", line+1, err)
+		}
+		_ = json.Unmarshal(event[1], &code)
+		_ = json.Unmarshal(event[2], &data)
+		if intervals {
+			t += at
+		} else {
+			t = at
+		}
+
+		switch code {
+		case "o":
+			events = append(events, termEvent{Time: t, Data: data})
+		case "r":
+			w, h, _ := strings.Cut(data, "x")
+			cols, _ := strconv.Atoi(w)
+			rows, _ := strconv.Atoi(h)
+			if cols > 0 && rows > 0 {
+				events = append(events, termEvent{Time: t, Cols: cols, Rows: rows})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read asciicast: %w", err)
+	}
+	return events, nil
+}
+
+// theme returns the theme of the asciicast, over the default theme for the
+// colors it leaves out.
+func (c *castTheme) theme() *Theme {
+	if c == nil {
+		return nil
+	}
+	theme := DefaultTheme
+	theme.Name = ""
+	theme.Foreground = hexColorOr(c.Fg, theme.Foreground)
+	theme.Background = hexColorOr(c.Bg, theme.Background)
+	ansi := []*string{
+		&theme.Black, &theme.Red, &theme.Green, &theme.Yellow, &theme.Blue, &theme.Magenta, &theme.Cyan, &theme.White,
+		&theme.BrightBlack, &theme.BrightRed, &theme.BrightGreen, &theme.BrightYellow,
+		&theme.BrightBlue, &theme.BrightMagenta, &theme.BrightCyan, &theme.BrightWhite,
+	}
+	palette := strings.Split(c.Palette, ":")
+	for i, color := range palette {
+		if i < len(ansi) {
+			*ansi[i] = hexColorOr(color, *ansi[i])
+		}
+	}
+	// Palettes of 8 colors are bright in the same colors.
+	if len(palette) == 8 { //nolint:mnd
+		for i := range 8 {
+			*ansi[i+8] = *ansi[i]
+		}
+	}
+	return &theme
+}
+
+// hexColorOr returns the color if it is a hex color, or the fallback.
+func hexColorOr(color, fallback string) string {
+	if _, err := parseHexColor(color); err != nil {
+		return fallback
+	}
+	return color
+}
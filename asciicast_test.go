@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAsciicast(t *testing.T) {
+	tests := []struct {
+		name string
+		cast string
+	}{
+		{"v1", `{"version": 1, "width": 20, "height": 4, "stdout": [[0.5, "$ "], [0.25, "ls"], [0.25, "\r\n"]]}`},
+		{"v2", "{\"version\": 2, \"width\": 20, \"height\": 4}\n[0.5, \"o\", \"$ \"]\n[0.6, \"i\", \"l\"]\n[0.75, \"o\", \"ls\"]\n[1.0, \"o\", \"\\r\\n\"]\n"},
+		{"v3", "{\"version\": 3, \"term\": {\"cols\": 20, \"rows\": 4}}\n# comment\n[0.5, \"o\", \"$ \"]\n[0.25, \"o\", \"ls\"]\n[0.25, \"o\", \"\\r\\n\"]\n"},
+	}
+	for _, tt := range tests {
+		rec, err := parseAsciicast(strings.NewReader(tt.cast))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if rec.Cols != 20 || rec.Rows != 4 {
+			t.Errorf("%s: expected a 20x4 terminal, got %dx%d", tt.name, rec.Cols, rec.Rows)
+		}
+		want := []termEvent{{Time: 0.5, Data: "$ "}, {Time: 0.75, Data: "ls"}, {Time: 1, Data: "\r\n"}}
+		if len(rec.Events) != len(want) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, want, rec.Events)
+		}
+		for i := range want {
+			if rec.Events[i] != want[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, want[i], rec.Events[i])
+			}
+		}
+	}
+
+	for _, cast := range []string{`{"version": 4, "width": 20, "height": 4}`, `{"version": 2}`, "not json"} {
+		if _, err := parseAsciicast(strings.NewReader(cast)); err == nil {
+			t.Errorf("%q: expected an error", cast)
+		}
+	}
+}
+
+func TestParseAsciicastResizeAndTheme(t *testing.T) {
+	cast := `{"version": 2, "width": 20, "height": 4, "theme": {"fg": "#eeeeee", "bg": "#111111", "palette": "#000000:#aa0000:#00aa00:#aaaa00:#0000aa:#aa00aa:#00aaaa:#aaaaaa"}}
+[1.0, "r", "30x8"]
+`
+	rec, err := parseAsciicast(strings.NewReader(cast))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Events) != 1 || rec.Events[0].Cols != 30 || rec.Events[0].Rows != 8 {
+		t.Errorf("expected a resize to 30x8, got %v", rec.Events)
+	}
+	if rec.Theme == nil || rec.Theme.Background != "#111111" || rec.Theme.Red != "#aa0000" || rec.Theme.BrightRed != "#aa0000" {
+		t.Errorf("expected the theme of the asciicast, got %+v", rec.Theme)
+	}
+}
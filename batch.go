@@ -0,0 +1,242 @@
+//go:build !js
+
+// Package vhs batch.go renders every tape of a directory.
+//
+// vhs batch finds the tapes of a directory (recursively), renders them a few
+// at a time and prints a summary of the outputs. The workers each launch a
+// browser once and reuse it for all of their tapes, instead of launching one
+// per tape. Outputs are written where the tapes say, or into the --out
+// directory.
+//
+// vhs batch ./tapes/ --out ./assets/ --parallel 4
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/spf13/cobra"
+)
+
+// defaultBatchParallel is the default number of tapes rendered at once.
+const defaultBatchParallel = 2
+
+var (
+	batchOut      string
+	batchParallel int
+
+	batchCmd = &cobra.Command{
+		Use:   "batch <dir>",
+		Short: "Render all the tapes of a directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if backendFlag != nativeBackend {
+				if err := ensureDependencies(); err != nil {
+					return err
+				}
+			}
+
+			tapes, err := findTapes(args[0])
+			if err != nil {
+				return err
+			}
+			if len(tapes) == 0 {
+				return fmt.Errorf("no tapes found in %s", args[0])
+			}
+			if batchOut != "" {
+				if err := os.MkdirAll(batchOut, 0o750); err != nil {
+					return fmt.Errorf("failed to create output directory: %w", err)
+				}
+			}
+
+			jobs := make([]batchJob, len(tapes))
+			for i, tape := range tapes {
+				jobs[i] = batchJob{Tape: tape}
+				if batchOut != "" {
+					jobs[i].Options = []EvaluatorOption{withOutputDir(batchOut)}
+				}
+			}
+			results := renderBatch(cmd.Context(), jobs, batchParallel)
+			printBatchSummary(cmd.OutOrStdout(), results)
+
+			failed := 0
+			for _, r := range results {
+				if r.Err != nil {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d tapes failed to render", failed, len(results))
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	batchCmd.Flags().StringVarP(&batchOut, "out", "o", "", "directory to write the outputs to instead of the paths in the tapes")
+	batchCmd.Flags().IntVarP(&batchParallel, "parallel", "p", defaultBatchParallel, "number of tapes rendered at once")
+	batchCmd.Flags().StringVar(&backendFlag, "backend", browserBackend, "capture backend: browser or native")
+}
+
+// batchJob is a tape to render in a batch.
+type batchJob struct {
+	Tape string
+	// Prelude is prepended to the tape, e.g. settings it may override.
+	Prelude string
+	Options []EvaluatorOption
+}
+
+// batchResult is the outcome of rendering a tape of a batch.
+type batchResult struct {
+	Tape     string
+	Outputs  []string
+	Duration time.Duration
+	Size     int64
+	Err      error
+}
+
+// findTapes returns the tapes in the directory and its subdirectories.
+func findTapes(dir string) ([]string, error) {
+	var tapes []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".tape" {
+			tapes = append(tapes, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tapes: %w", err)
+	}
+	return tapes, nil
+}
+
+// renderBatch renders the tapes with up to parallel at once, returning the
+// results in the order of the jobs.
+func renderBatch(ctx context.Context, batch []batchJob, parallel int) []batchResult {
+	results := make([]batchResult, len(batch))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range max(1, min(parallel, len(batch))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var browser *rod.Browser
+			defer func() {
+				if browser != nil {
+					_ = browser.Close()
+				}
+			}()
+
+			for i := range jobs {
+				opts := []EvaluatorOption{WithBackend(backendFlag), WithOffline(offlineFlag || offlineFromEnv()), WithTimeout(timeoutFlag)}
+				if backendFlag != nativeBackend {
+					if browser == nil {
+						b, err := launchBrowser(offlineFlag || offlineFromEnv())
+						if err != nil {
+							results[i] = batchResult{Tape: batch[i].Tape, Err: err}
+							continue
+						}
+						browser = b
+					}
+					opts = append(opts, WithBrowser(browser))
+				}
+				results[i] = renderBatchJob(ctx, batch[i], opts...)
+			}
+		}()
+	}
+
+	for i := range batch {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// renderBatchJob renders the tape of a job.
+func renderBatchJob(ctx context.Context, job batchJob, opts ...EvaluatorOption) (result batchResult) {
+	result.Tape = job.Tape
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	b, err := os.ReadFile(job.Tape)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read tape: %w", err)
+		return result
+	}
+
+	var outputs *VideoOutputs
+	opts = append(append(opts, job.Options...), func(v *VHS) {
+		outputs = &v.Options.Video.Output
+	})
+	if errs := Evaluate(ctx, job.Prelude+string(b), io.Discard, opts...); len(errs) > 0 {
+		result.Err = errors.Join(errs...)
+		return result
+	}
+
+	paths := []string{outputs.GIF, outputs.MP4, outputs.WebM}
+	for _, o := range outputs.renderedOutputs() {
+		paths = append(paths, o.Path)
+	}
+	for _, o := range paths {
+		if o == "" {
+			continue
+		}
+		result.Outputs = append(result.Outputs, o)
+		if info, err := os.Stat(o); err == nil {
+			result.Size += info.Size()
+		}
+	}
+	return result
+}
+
+// withOutputDir returns an EvaluatorOption moving the outputs into the
+// directory, keeping their names.
+func withOutputDir(dir string) EvaluatorOption {
+	return func(v *VHS) {
+		outputs := &v.Options.Video.Output
+		for _, o := range []*string{&outputs.GIF, &outputs.MP4, &outputs.WebM, &outputs.SVG, &outputs.Transcript, &outputs.PDF, &outputs.Lottie, &outputs.Frames} {
+			if *o != "" {
+				*o = filepath.Join(dir, filepath.Base(*o))
+			}
+		}
+		for ext, o := range outputs.Rendered {
+			outputs.Rendered[ext] = filepath.Join(dir, filepath.Base(o))
+		}
+	}
+}
+
+// printBatchSummary prints a table of the outputs, durations and sizes of the
+// rendered tapes.
+func printBatchSummary(w io.Writer, results []batchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+	_, _ = fmt.Fprintln(tw, "TAPE\tOUTPUTS\tDURATION\tSIZE")
+	var total time.Duration
+	var size int64
+	for _, r := range results {
+		total += r.Duration
+		size += r.Size
+		outputs, s := strings.Join(r.Outputs, ", "), formatFileSize(r.Size)
+		if r.Err != nil {
+			outputs, s = "failed: "+strings.ReplaceAll(r.Err.Error(), "\n", "; "), "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Tape, outputs, r.Duration.Round(time.Millisecond), s)
+	}
+	_, _ = fmt.Fprintf(tw, "%d tapes\t\t%s\t%s\n", len(results), total.Round(time.Millisecond), formatFileSize(size))
+	_ = tw.Flush()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindTapes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.tape", "a.tape", "notes.md", "nested/c.tape"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tapes, err := findTapes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(dir, "a.tape"),
+		filepath.Join(dir, "b.tape"),
+		filepath.Join(dir, "nested", "c.tape"),
+	}
+	if !reflect.DeepEqual(tapes, expected) {
+		t.Errorf("expected %v, got %v", expected, tapes)
+	}
+}
+
+func TestWithOutputDir(t *testing.T) {
+	v := &VHS{Options: &Options{Video: VideoOptions{Output: VideoOutputs{GIF: "demo/out.gif", SVG: "/tmp/out.svg"}}}}
+	// Evaluator options are applied before and after the tape.
+	withOutputDir("assets")(v)
+	withOutputDir("assets")(v)
+	if outputs := v.Options.Video.Output; outputs.GIF != filepath.Join("assets", "out.gif") || outputs.SVG != filepath.Join("assets", "out.svg") || outputs.MP4 != "" {
+		t.Errorf("unexpected outputs %+v", outputs)
+	}
+}
+
+func TestPrintBatchSummary(t *testing.T) {
+	var buf bytes.Buffer
+	printBatchSummary(&buf, []batchResult{
+		{Tape: "a.tape", Outputs: []string{"a.gif", "a.svg"}, Duration: 2 * time.Second, Size: 2048},
+		{Tape: "b.tape", Duration: time.Second, Err: errors.New("boom")},
+	})
+
+	out := buf.String()
+	for _, want := range []string{"TAPE", "a.gif, a.svg", "2.0KB", "failed: boom", "2 tapes", "3s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderBatchNative(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir, out := t.TempDir(), t.TempDir()
+	for _, name := range []string{"one", "two"} {
+		tape := "Output " + name + ".svg\nSet Shell bash\nType \"echo " + name + "\"\nEnter"
+		if err := os.WriteFile(filepath.Join(dir, name+".tape"), []byte(tape), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tapes, _ := findTapes(dir)
+	jobs := []batchJob{
+		{Tape: tapes[0], Options: []EvaluatorOption{withOutputDir(out)}},
+		{Tape: tapes[1], Options: []EvaluatorOption{withOutputDir(out)}},
+	}
+
+	backend := backendFlag
+	backendFlag = nativeBackend
+	defer func() { backendFlag = backend }()
+
+	results := renderBatch(context.Background(), jobs, 2)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("failed to render %s: %v", r.Tape, r.Err)
+		}
+		if r.Tape != tapes[i] || len(r.Outputs) != 1 || r.Size == 0 {
+			t.Errorf("unexpected result %+v", r)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "two.svg")); err != nil {
+		t.Errorf("expected the output in the out directory: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Package vhs camera.go animates a virtual camera over the terminal to focus
+// attention on a region, e.g. a widget of a dense TUI dashboard.
+//
+// Zoom magnifies the terminal by a factor around a cell (or the current
+// center of the camera), Pan moves the center of the camera to a cell while
+// keeping the zoom factor. Rows and columns are 1-based. The camera moves
+// over the given duration, without blocking the following commands, and stays
+// in place until the next move. Zoom 1x shows the whole terminal again.
+//
+// Videos are rendered with the zoompan filter of ffmpeg, SVG outputs animate
+// the viewBox of the terminal.
+//
+// Zoom <factor>x [<row>,<col>] [<time>]
+// Pan <row>,<col> [<time>]
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// defaultCameraDuration is how long the camera takes to move by default.
+const defaultCameraDuration = 500 * time.Millisecond
+
+// CameraKeyframe is the position of the virtual camera at a frame. The
+// camera moves linearly between keyframes.
+type CameraKeyframe struct {
+	Frame int
+	// Zoom is the magnification factor, 1 shows the whole terminal.
+	Zoom float64
+	// X and Y are the center of the camera as a fraction of the terminal
+	// width and height.
+	X, Y float64
+}
+
+// defaultCamera shows the whole terminal.
+var defaultCamera = CameraKeyframe{Zoom: 1, X: 0.5, Y: 0.5}
+
+// cameraAt returns the position of the camera at the given frame.
+func cameraAt(keyframes []CameraKeyframe, frame int) CameraKeyframe {
+	camera := defaultCamera
+	for i, k := range keyframes {
+		if k.Frame > frame {
+			if i == 0 {
+				break
+			}
+			prev := keyframes[i-1]
+			t := float64(frame-prev.Frame) / float64(k.Frame-prev.Frame)
+			return CameraKeyframe{
+				Frame: frame,
+				Zoom:  lerp(prev.Zoom, k.Zoom, t),
+				X:     lerp(prev.X, k.X, t),
+				Y:     lerp(prev.Y, k.Y, t),
+			}
+		}
+		camera = k
+	}
+	camera.Frame = frame
+	return camera
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// clamp keeps the view of the camera within the terminal.
+func (k CameraKeyframe) clamp() CameraKeyframe {
+	half := 0.5 / k.Zoom
+	k.X = math.Max(half, math.Min(1-half, k.X))
+	k.Y = math.Max(half, math.Min(1-half, k.Y))
+	return k
+}
+
+// cameraKeyframes converts the keyframes of the camera into 0-based offsets
+// into the rendered frame sequence (which starts at startingFrame). It
+// returns nil if the camera never moves.
+func cameraKeyframes(camera []CameraKeyframe, startingFrame int) []CameraKeyframe {
+	moved := false
+	keyframes := make([]CameraKeyframe, 0, len(camera))
+	for _, k := range camera {
+		// The camera cannot pan without zooming in
+		moved = moved || k.Zoom != 1
+		k.Frame -= startingFrame
+		keyframes = append(keyframes, k)
+	}
+	if !moved {
+		return nil
+	}
+	return keyframes
+}
+
+// cameraFilter returns the ffmpeg zoompan filter which moves the camera over
+// the input stream, scaled to the given dimensions.
+func cameraFilter(keyframes []CameraKeyframe, width, height, framerate int) string {
+	zoom := cameraExpr(keyframes, func(k CameraKeyframe) float64 { return k.Zoom })
+	x := cameraExpr(keyframes, func(k CameraKeyframe) float64 { return k.X })
+	y := cameraExpr(keyframes, func(k CameraKeyframe) float64 { return k.Y })
+	return fmt.Sprintf("zoompan=z='%s':x='iw*(%s)-iw/zoom/2':y='ih*(%s)-ih/zoom/2':d=1:s=%dx%d:fps=%d",
+		zoom, x, y, width, height, framerate)
+}
+
+// cameraExpr returns an ffmpeg expression which linearly interpolates the
+// value between the keyframes (of which there is at least one), by input
+// frame number.
+func cameraExpr(keyframes []CameraKeyframe, value func(CameraKeyframe) float64) string {
+	format := func(k CameraKeyframe) string {
+		return strconv.FormatFloat(value(k), 'g', 6, 64)
+	}
+
+	expr := format(keyframes[len(keyframes)-1])
+	for i := len(keyframes) - 2; i >= 0; i-- {
+		k, next := keyframes[i], keyframes[i+1]
+		expr = fmt.Sprintf("if(lt(in,%d),%s+(%s-%s)*(in-%d)/%d,%s)",
+			next.Frame, format(k), format(next), format(k), k.Frame, next.Frame-k.Frame, expr)
+	}
+	return fmt.Sprintf("if(lt(in,%d),%s,%s)", keyframes[0].Frame, format(defaultCamera), expr)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCameraAt(t *testing.T) {
+	keyframes := []CameraKeyframe{
+		{Frame: 10, Zoom: 1, X: 0.5, Y: 0.5},
+		{Frame: 20, Zoom: 2, X: 0.25, Y: 0.75},
+	}
+
+	tests := []struct {
+		frame int
+		want  CameraKeyframe
+	}{
+		{0, CameraKeyframe{Frame: 0, Zoom: 1, X: 0.5, Y: 0.5}},
+		{15, CameraKeyframe{Frame: 15, Zoom: 1.5, X: 0.375, Y: 0.625}},
+		{30, CameraKeyframe{Frame: 30, Zoom: 2, X: 0.25, Y: 0.75}},
+	}
+	for _, tc := range tests {
+		if got := cameraAt(keyframes, tc.frame); got != tc.want {
+			t.Errorf("cameraAt(%d) = %+v, want %+v", tc.frame, got, tc.want)
+		}
+	}
+}
+
+func TestCameraClamp(t *testing.T) {
+	k := CameraKeyframe{Zoom: 2, X: 0, Y: 0.9}.clamp()
+	if k.X != 0.25 || k.Y != 0.75 {
+		t.Errorf("expected the view to stay within the terminal, got %+v", k)
+	}
+}
+
+func TestCameraKeyframes(t *testing.T) {
+	if got := cameraKeyframes([]CameraKeyframe{defaultCamera, defaultCamera}, 1); got != nil {
+		t.Errorf("expected no keyframes without zoom, got %+v", got)
+	}
+
+	got := cameraKeyframes([]CameraKeyframe{{Frame: 5, Zoom: 1, X: 0.5, Y: 0.5}, {Frame: 10, Zoom: 2, X: 0.5, Y: 0.5}}, 3)
+	if len(got) != 2 || got[0].Frame != 2 || got[1].Frame != 7 {
+		t.Errorf("expected keyframes offset by the starting frame, got %+v", got)
+	}
+}
+
+func TestCameraFilter(t *testing.T) {
+	keyframes := []CameraKeyframe{
+		{Frame: 10, Zoom: 1, X: 0.5, Y: 0.5},
+		{Frame: 20, Zoom: 2, X: 0.25, Y: 0.5},
+	}
+	got := cameraFilter(keyframes, 800, 600, 50)
+
+	for _, want := range []string{
+		"z='if(lt(in,10),1,if(lt(in,20),1+(2-1)*(in-10)/10,2))'",
+		"x='iw*(if(lt(in,10),0.5,if(lt(in,20),0.5+(0.25-0.5)*(in-10)/10,0.25)))-iw/zoom/2'",
+		"d=1:s=800x600:fps=50",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
+
+func TestVideoFilterBuilder_Camera(t *testing.T) {
+	opts := DefaultVideoOptions()
+	opts.Style = DefaultStyleOptions()
+	opts.Camera = []CameraKeyframe{{Frame: 0, Zoom: 1, X: 0.5, Y: 0.5}, {Frame: 25, Zoom: 2, X: 0.5, Y: 0.5}}
+
+	filter := NewVideoFilterBuilder(&opts).filterComplex.String()
+	if !strings.Contains(filter, "[boxed]zoompan=") || !strings.Contains(filter, "[camera];") {
+		t.Errorf("expected the camera to be rendered with zoompan, got:\n%s", filter)
+	}
+	if !strings.Contains(filter, "[camera]fps=") {
+		t.Errorf("expected the camera stream to be sped up, got:\n%s", filter)
+	}
+}
@@ -0,0 +1,106 @@
+//go:build !js
+
+// Package vhs capturerate.go sets how often the terminal is captured.
+//
+// Frames are captured at the framerate of the outputs by default. Set
+// CaptureRate captures the terminal less often, writing every capture as as
+// many frames as the framerate needs, which trades smooth output for cheaper
+// recordings. Set CaptureRate adaptive captures the terminal only once it
+// renders something new, and writes the last capture again otherwise, so
+// mostly idle demos capture a few frames instead of one every tick.
+//
+// Set CaptureRate 10
+// Set CaptureRate adaptive
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// adaptiveCapture is the capture rate which captures the terminal on change.
+const adaptiveCapture = "adaptive"
+
+// terminalRenderedJS reports whether the terminal rendered since it was
+// last called, listening for renders on the first call.
+const terminalRenderedJS = `() => {
+	if (!window.vhsRendered) {
+		window.vhsRendered = { rendered: true };
+		term.onRender(() => { window.vhsRendered.rendered = true; });
+	}
+	const rendered = window.vhsRendered.rendered;
+	window.vhsRendered.rendered = false;
+	return rendered;
+}`
+
+// lastCapture is the last capture of the terminal, written again while the
+// terminal is unchanged in adaptive captures.
+type lastCapture struct {
+	text []byte
+	svg  *SVGFrame
+	// changes is the number of changes of the native screen.
+	changes uint64
+	frame   *SVGFrame
+}
+
+// ExecuteSetCaptureRate sets how often the terminal is captured.
+func ExecuteSetCaptureRate(c parser.Command, v *VHS) error {
+	if c.Args == adaptiveCapture {
+		v.Options.Video.AdaptiveCapture = true
+		return nil
+	}
+	rate, err := strconv.Atoi(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse capture rate: %w", err)
+	}
+	v.Options.Video.CaptureRate = rate
+	v.Options.Video.AdaptiveCapture = false
+	return nil
+}
+
+// captureRate returns the number of captures per second, no more than the
+// framerate.
+func (opts VideoOptions) captureRate() int {
+	if opts.CaptureRate <= 0 {
+		return opts.Framerate
+	}
+	return min(opts.CaptureRate, opts.Framerate)
+}
+
+// captureInterval returns the time between captures.
+func (opts VideoOptions) captureInterval() time.Duration {
+	return time.Second / time.Duration(max(opts.captureRate(), 1))
+}
+
+// capturedFrames returns the number of frames written by the nth capture,
+// counting from 1, so that the captures keep the framerate.
+func (opts VideoOptions) capturedFrames(n int) int {
+	rate := max(opts.captureRate(), 1)
+	return n*opts.Framerate/rate - (n-1)*opts.Framerate/rate
+}
+
+// terminalRendered returns whether the terminal must be captured again, as it
+// rendered since the last capture. Captures are never skipped unless
+// adaptive.
+func (vhs *VHS) terminalRendered() bool {
+	if !vhs.Options.Video.AdaptiveCapture || vhs.lastCapture.text == nil {
+		return true
+	}
+	res, err := vhs.Page.Eval(terminalRenderedJS)
+	return err != nil || res.Value.Bool()
+}
+
+// nativeFrame returns the screen of the native backend, or its last capture
+// while it is unchanged in adaptive captures.
+func (vhs *VHS) nativeFrame() SVGFrame {
+	changes := vhs.native.screen.Changes()
+	if vhs.Options.Video.AdaptiveCapture && vhs.lastCapture.frame != nil && changes == vhs.lastCapture.changes {
+		return *vhs.lastCapture.frame
+	}
+	frame := vhs.native.frame()
+	vhs.lastCapture.frame, vhs.lastCapture.changes = &frame, changes
+	return frame
+}
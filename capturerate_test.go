@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestCapturedFrames(t *testing.T) {
+	opts := VideoOptions{Framerate: 50, CaptureRate: 20}
+	if got := opts.captureInterval(); got != 50*time.Millisecond {
+		t.Errorf("expected a capture every 50ms, got %v", got)
+	}
+	var frames []int
+	total := 0
+	for n := 1; n <= 20; n++ {
+		frames = append(frames, opts.capturedFrames(n))
+		total += opts.capturedFrames(n)
+	}
+	if total != 50 || frames[0] != 2 || frames[1] != 3 {
+		t.Errorf("expected 50 frames per second of captures, got %v", frames)
+	}
+
+	// Captures are no more frequent than frames.
+	opts.CaptureRate = 100
+	if got := opts.capturedFrames(1); got != 1 {
+		t.Errorf("expected a frame per capture, got %d", got)
+	}
+}
+
+func TestExecuteSetCaptureRate(t *testing.T) {
+	v := New()
+	if err := ExecuteSetCaptureRate(parser.Command{Args: adaptiveCapture}, &v); err != nil || !v.Options.Video.AdaptiveCapture {
+		t.Errorf("expected adaptive captures, got %v", err)
+	}
+	if err := ExecuteSetCaptureRate(parser.Command{Args: "10"}, &v); err != nil || v.Options.Video.CaptureRate != 10 || v.Options.Video.AdaptiveCapture {
+		t.Errorf("expected 10 captures per second, got %+v (%v)", v.Options.Video, err)
+	}
+	if err := ExecuteSetCaptureRate(parser.Command{Args: "often"}, &v); err == nil {
+		t.Error("expected an error for an invalid capture rate")
+	}
+}
+
+func TestNativeFrameAdaptive(t *testing.T) {
+	v := New()
+	v.Options.Video.AdaptiveCapture = true
+	v.native = &nativeTerminal{screen: newEmulator(10, 2, DefaultTheme)}
+
+	_, _ = v.native.screen.Write([]byte("a"))
+	first := v.nativeFrame()
+	first.Lines[0] = "reused"
+	if got := v.nativeFrame(); got.Lines[0] != "reused" {
+		t.Errorf("expected the last capture of the unchanged screen, got %q", got.Lines)
+	}
+	_, _ = v.native.screen.Write([]byte("b"))
+	if got := v.nativeFrame(); got.Lines[0] != "ab" {
+		t.Errorf("expected the screen to be captured once changed, got %q", got.Lines)
+	}
+}
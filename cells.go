@@ -0,0 +1,100 @@
+// Package vhs cells.go measures terminal text in cells.
+//
+// Terminals lay text out on a grid: CJK characters and most emoji occupy two
+// cells, while combining marks and zero width joiners attach to the preceding
+// character. Byte or rune offsets therefore do not match terminal columns, so
+// layout code splits text into grapheme clusters and measures their width
+// instead.
+package main
+
+import (
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// textCell is a grapheme cluster placed on the terminal grid.
+type textCell struct {
+	Text  string
+	Col   int
+	Width int
+}
+
+// splitCells splits a line into grapheme clusters and places them on the
+// terminal grid. Widths captured from the terminal take precedence over the
+// computed ones, since they reflect the Unicode version xterm.js was
+// configured with.
+func splitCells(line string, styles []CharStyle) []textCell {
+	var cells []textCell
+	col := 0
+	state := -1
+	for line != "" {
+		var cluster string
+		var width int
+		cluster, line, width, state = uniseg.FirstGraphemeClusterInString(line, state)
+		if col < len(styles) && styles[col].Width > 0 {
+			width = styles[col].Width
+		}
+		cells = append(cells, textCell{Text: cluster, Col: col, Width: width})
+		col += width
+	}
+	return cells
+}
+
+// isSingleCell returns whether the cell holds a single character which occupies
+// one column.
+func isSingleCell(c textCell) bool {
+	return c.Width == 1 && utf8.RuneCountInString(c.Text) == 1
+}
+
+// cellWidth returns the number of terminal cells occupied by s.
+func cellWidth(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// graphemeCount returns the number of user-perceived characters in s.
+func graphemeCount(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// isCellAligned returns whether every character of the line occupies exactly
+// one cell, in which case rune offsets are terminal columns.
+func isCellAligned(line string) bool {
+	for _, r := range line {
+		if r < 0x20 || r > 0x7e {
+			n := len([]rune(line))
+			return cellWidth(line) == n && graphemeCount(line) == n
+		}
+	}
+	return true
+}
+
+// textRun is a run of characters of the same style which occupy one cell
+// each, or a single wide character.
+type textRun struct {
+	Text  string
+	Col   int
+	Width int
+	Style CharStyle
+}
+
+// splitRuns splits a line into runs, so that every run can be drawn at once
+// by renderers which do not support styles within a text element.
+func splitRuns(line string, styles []CharStyle) []textRun {
+	var runs []textRun
+	for _, cell := range splitCells(line, styles) {
+		var style CharStyle
+		if cell.Col < len(styles) {
+			style = styles[cell.Col]
+		}
+		style.Width = 0
+		if n := len(runs); n > 0 && isSingleCell(cell) && runs[n-1].Style == style &&
+			runs[n-1].Col+runs[n-1].Width == cell.Col && runs[n-1].Width == len([]rune(runs[n-1].Text)) {
+			runs[n-1].Text += cell.Text
+			runs[n-1].Width++
+			continue
+		}
+		runs = append(runs, textRun{Text: cell.Text, Col: cell.Col, Width: max(cell.Width, 1), Style: style})
+	}
+	return runs
+}
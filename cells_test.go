@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCells(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		styles []CharStyle
+		want   []textCell
+	}{
+		{"ascii", "ab", nil, []textCell{{"a", 0, 1}, {"b", 1, 1}}},
+		{"cjk", "日a", nil, []textCell{{"日", 0, 2}, {"a", 2, 1}}},
+		{"combining", "e\u0301x", nil, []textCell{{"e\u0301", 0, 1}, {"x", 1, 1}}},
+		{"emoji zwj", "👩‍💻x", nil, []textCell{{"👩‍💻", 0, 2}, {"x", 2, 1}}},
+		{"captured width", "★a", []CharStyle{{Width: 2}}, []textCell{{"★", 0, 2}, {"a", 2, 1}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := splitCells(tc.line, tc.styles); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("splitCells(%q) = %v, want %v", tc.line, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsCellAligned(t *testing.T) {
+	for line, want := range map[string]bool{
+		"$ echo hi":  true,
+		"─ ❯ \u00e9": true,
+		"e\u0301":    false,
+		"日本":         false,
+		"👍":          false,
+	} {
+		if got := isCellAligned(line); got != want {
+			t.Errorf("isCellAligned(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
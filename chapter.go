@@ -0,0 +1,186 @@
+//go:build !js
+
+// Package vhs chapter.go embeds chapter markers in the video outputs.
+//
+// Every Scene starts a chapter, and the Chapter command marks additional
+// positions without a transition or terminal reset.
+//
+// Chapter "Configuration"
+//
+// Chapters are embedded as metadata in MP4 outputs and written to a WebVTT
+// sidecar file next to WebM outputs.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+const (
+	chaptersMetadataFile = "chapters.txt"
+	chaptersSidecarExt   = ".chapters.vtt"
+)
+
+// chapterMark is a chapter title recorded at a captured frame (1-based).
+type chapterMark struct {
+	Title string
+	Frame int
+}
+
+// Chapter is a named section of the rendered video.
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// ExecuteChapter is a CommandFunc that marks the start of a new chapter.
+func ExecuteChapter(c parser.Command, v *VHS) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.chapters = append(v.chapters, chapterMark{
+		Title: c.Args,
+		Frame: v.totalFrames + 1,
+	})
+	return nil
+}
+
+// buildChapters maps the chapter marks onto the timeline of the rendered
+// video, taking the playback speed and the overlap of scene transitions into
+// account.
+func buildChapters(marks []chapterMark, opts VideoOptions, totalFrames int) []Chapter {
+	frames := totalFrames - opts.StartingFrame + 1
+	if len(marks) == 0 || frames <= 0 || opts.Framerate <= 0 {
+		return nil
+	}
+
+	speed := opts.PlaybackSpeed
+	if speed <= 0 {
+		speed = defaultPlaybackSpeed
+	}
+	overlap := sceneTransitionDuration(opts.Scenes, opts.Framerate, opts.Transition)
+
+	at := func(frame int) time.Duration {
+		seconds := float64(frame) / float64(opts.Framerate)
+		for _, b := range opts.Scenes {
+			if b <= frame {
+				seconds -= overlap
+			}
+		}
+		return time.Duration(seconds / speed * float64(time.Second)).Round(time.Millisecond)
+	}
+
+	sorted := make([]chapterMark, len(marks))
+	copy(sorted, marks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Frame < sorted[j].Frame })
+
+	var chapters []Chapter
+	for _, m := range sorted {
+		frame := m.Frame - opts.StartingFrame
+		if frame < 0 {
+			frame = 0
+		}
+		if frame >= frames {
+			continue
+		}
+		start := at(frame)
+		// Markers at the same position collapse into the last one.
+		if n := len(chapters); n > 0 && chapters[n-1].Start == start {
+			chapters[n-1].Title = m.Title
+			continue
+		}
+		chapters = append(chapters, Chapter{Title: m.Title, Start: start})
+	}
+
+	end := at(frames)
+	for i := range chapters {
+		chapters[i].End = end
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		}
+	}
+
+	return chapters
+}
+
+// ffmetadataEscaper escapes the special characters of the FFMETADATA format.
+var ffmetadataEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"=", `\=`,
+	";", `\;`,
+	"#", `\#`,
+	"\n", "\\\n",
+)
+
+// chaptersMetadata returns the chapters in ffmpeg's FFMETADATA format.
+func chaptersMetadata(chapters []Chapter) string {
+	var sb strings.Builder
+	sb.WriteString(";FFMETADATA1\n")
+	for _, c := range chapters {
+		_, _ = fmt.Fprintf(&sb, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			c.Start.Milliseconds(), c.End.Milliseconds(), ffmetadataEscaper.Replace(c.Title))
+	}
+	return sb.String()
+}
+
+// chaptersWebVTT returns the chapters as a WebVTT chapters track.
+func chaptersWebVTT(chapters []Chapter) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n")
+	for i, c := range chapters {
+		_, _ = fmt.Fprintf(&sb, "\n%d\n%s --> %s\n%s\n",
+			i+1, formatVTTTime(c.Start), formatVTTTime(c.End), c.Title)
+	}
+	return sb.String()
+}
+
+// formatVTTTime formats a duration as a WebVTT timestamp (hh:mm:ss.ttt).
+func formatVTTTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// MakeChapterSidecar writes the chapters of a WebM output to a WebVTT file
+// next to it, since WebM players generally read chapters from a separate
+// track.
+func MakeChapterSidecar(opts VideoOptions) error {
+	if opts.Output.WebM == "" || len(opts.Chapters) == 0 {
+		return nil
+	}
+
+	path := strings.TrimSuffix(opts.Output.WebM, filepath.Ext(opts.Output.WebM)) + chaptersSidecarExt
+	ensureDir(path)
+	if err := os.WriteFile(path, []byte(chaptersWebVTT(opts.Chapters)), 0o600); err != nil {
+		return fmt.Errorf("failed to write chapters file: %w", err)
+	}
+	return nil
+}
+
+// WithChapters adds a metadata stream holding the chapters and maps them
+// onto the output.
+func (sb *StreamBuilder) WithChapters(chapters []Chapter) *StreamBuilder {
+	if len(chapters) == 0 {
+		return sb
+	}
+
+	path := filepath.Join(sb.input, chaptersMetadataFile)
+	if err := os.WriteFile(path, []byte(chaptersMetadata(chapters)), 0o600); err != nil {
+		fmt.Println(ErrorStyle.Render("Unable to write chapters: "), err)
+		return sb
+	}
+
+	sb.args = append(sb.args,
+		"-f", "ffmetadata",
+		"-i", path,
+		"-map_chapters", fmt.Sprint(sb.counter),
+	)
+	sb.counter++
+
+	return sb
+}
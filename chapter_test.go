@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildChapters(t *testing.T) {
+	opts := VideoOptions{Framerate: 10, PlaybackSpeed: 1, StartingFrame: 1}
+	marks := []chapterMark{
+		{Title: "Intro", Frame: 1},
+		{Title: "Install", Frame: 51},
+		{Title: "Usage", Frame: 101},
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		chapters := buildChapters(marks, opts, 200)
+		expected := []Chapter{
+			{Title: "Intro", Start: 0, End: 5 * time.Second},
+			{Title: "Install", Start: 5 * time.Second, End: 10 * time.Second},
+			{Title: "Usage", Start: 10 * time.Second, End: 20 * time.Second},
+		}
+		if len(chapters) != len(expected) {
+			t.Fatalf("expected %d chapters, got %d", len(expected), len(chapters))
+		}
+		for i := range expected {
+			if chapters[i] != expected[i] {
+				t.Errorf("expected chapter %d to be %+v, got %+v", i, expected[i], chapters[i])
+			}
+		}
+	})
+
+	t.Run("playback speed and transitions", func(t *testing.T) {
+		opts := opts
+		opts.PlaybackSpeed = 2
+		opts.Scenes = []int{50, 100}
+		opts.Transition = Transition{Type: transitionFade, Duration: time.Second}
+
+		chapters := buildChapters(marks, opts, 200)
+		if len(chapters) != 3 {
+			t.Fatalf("expected 3 chapters, got %d", len(chapters))
+		}
+		if chapters[1].Start != 2*time.Second {
+			t.Errorf("expected second chapter to start at 2s, got %s", chapters[1].Start)
+		}
+		if chapters[2].Start != 4*time.Second {
+			t.Errorf("expected third chapter to start at 4s, got %s", chapters[2].Start)
+		}
+		if chapters[2].End != 9*time.Second {
+			t.Errorf("expected last chapter to end at 9s, got %s", chapters[2].End)
+		}
+	})
+}
+
+func TestChaptersMetadata(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Start: 0, End: 1500 * time.Millisecond},
+		{Title: "a=b; #c", Start: 1500 * time.Millisecond, End: time.Hour},
+	}
+
+	metadata := chaptersMetadata(chapters)
+	for _, expected := range []string{
+		";FFMETADATA1\n",
+		"START=0\nEND=1500\ntitle=Intro\n",
+		`title=a\=b\; \#c`,
+	} {
+		if !strings.Contains(metadata, expected) {
+			t.Errorf("expected metadata to contain %q, got:\n%s", expected, metadata)
+		}
+	}
+
+	vtt := chaptersWebVTT(chapters)
+	if !strings.Contains(vtt, "2\n00:00:01.500 --> 01:00:00.000\na=b; #c\n") {
+		t.Errorf("unexpected WebVTT chapters:\n%s", vtt)
+	}
+}
@@ -0,0 +1,170 @@
+//go:build !js
+
+// Package vhs colormode.go emulates terminals with fewer colors.
+//
+// Set ColorMode reports the capabilities of a terminal with fewer colors to
+// the programs of the tape, through TERM, COLORTERM and NO_COLOR, so that
+// demos show how a program degrades on limited terminals. Colors which
+// programs print regardless are limited too: the extended colors of xterm.js
+// are mapped onto the colors the mode has, and monochrome terminals show every
+// color in the foreground color. The native backend also maps true colors,
+// which xterm.js always shows.
+//
+// Set ColorMode 16
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Color modes.
+const (
+	colorModeMono      = "mono"
+	colorMode16        = "16"
+	colorMode256       = "256"
+	colorModeTruecolor = "truecolor"
+)
+
+// xtermColors is the number of colors of the xterm palette.
+const xtermColors = 256
+
+// colorModeEnv returns the environment reporting the capabilities of the
+// color mode to the shell.
+func colorModeEnv(mode string) []string {
+	switch mode {
+	case colorModeMono:
+		return []string{"TERM=xterm-mono", "COLORTERM=", "NO_COLOR=1"}
+	case colorMode16:
+		return []string{"TERM=xterm-16color", "COLORTERM="}
+	case colorMode256:
+		return []string{"TERM=xterm-256color", "COLORTERM="}
+	case colorModeTruecolor:
+		return []string{"TERM=xterm-256color", "COLORTERM=truecolor"}
+	default:
+		return nil
+	}
+}
+
+// colorModeSandbox returns the sandbox with the environment of the color
+// mode.
+func colorModeSandbox(sandbox ShellSandbox, mode string) ShellSandbox {
+	sandbox.Env = append(slices.Clone(sandbox.Env), colorModeEnv(mode)...)
+	return sandbox
+}
+
+// sandboxTerm returns the TERM of the sandbox, if it sets one.
+func sandboxTerm(sandbox ShellSandbox) string {
+	var term string
+	for _, env := range sandbox.Env {
+		if v, ok := strings.CutPrefix(env, "TERM="); ok {
+			term = v
+		}
+	}
+	return term
+}
+
+// monochromeTheme returns the theme with every color of the palette in the
+// foreground color.
+func monochromeTheme(t Theme) Theme {
+	for _, c := range themeColors(&t) {
+		switch c.Name {
+		case "background", "foreground", "selection", "cursor", "cursorAccent":
+		default:
+			*c.Color = t.Foreground
+		}
+	}
+	return t
+}
+
+// xtermTheme is the theme of xterm.js, with the extended colors of the
+// palette when the color mode limits them.
+type xtermTheme struct {
+	Theme
+	ExtendedAnsi []string `json:"extendedAnsi,omitempty"`
+}
+
+// newXtermTheme returns the theme of xterm.js in the color mode. The
+// extended colors of terminals with 16 colors, or monochrome ones whose theme
+// has the foreground color only, are the closest of the 16 colors.
+func newXtermTheme(t Theme, mode string) xtermTheme {
+	theme := xtermTheme{Theme: t}
+	if mode != colorModeMono && mode != colorMode16 {
+		return theme
+	}
+	for n := 16; n < xtermColors; n++ {
+		theme.ExtendedAnsi = append(theme.ExtendedAnsi, closestColor(xtermColor(t, n), t, 16)) //nolint:mnd
+	}
+	return theme
+}
+
+// limitColor returns the closest color of the color mode, or "" for the
+// default color of monochrome terminals.
+func limitColor(c string, t Theme, mode string) string {
+	if c == "" {
+		return c
+	}
+	switch mode {
+	case colorModeMono:
+		return ""
+	case colorMode16:
+		return closestColor(c, t, 16) //nolint:mnd
+	case colorMode256:
+		return closestColor(c, t, xtermColors)
+	default:
+		return c
+	}
+}
+
+// closestColor returns the color of the first n colors of the xterm palette
+// closest to c.
+func closestColor(c string, t Theme, n int) string {
+	target, err := parseHexColor(c)
+	if err != nil {
+		return c
+	}
+	closest, best := c, math.Inf(1)
+	for i := range n {
+		candidate := xtermColor(t, i)
+		rgb, err := parseHexColor(candidate)
+		if err != nil {
+			continue
+		}
+		dr, dg, db := float64(rgb.R)-float64(target.R), float64(rgb.G)-float64(target.G), float64(rgb.B)-float64(target.B)
+		if d := dr*dr + dg*dg + db*db; d < best {
+			closest, best = candidate, d
+		}
+	}
+	return closest
+}
+
+// xtermColor returns the color of the xterm 256 color palette, where the
+// first 16 colors come from the theme.
+func xtermColor(t Theme, n int) string {
+	ansiColors := [16]string{
+		t.Black, t.Red, t.Green, t.Yellow, t.Blue, t.Magenta, t.Cyan, t.White,
+		t.BrightBlack, t.BrightRed, t.BrightGreen, t.BrightYellow,
+		t.BrightBlue, t.BrightMagenta, t.BrightCyan, t.BrightWhite,
+	}
+	switch {
+	case n < 0 || n >= xtermColors:
+		return ""
+	case n < 16: //nolint:mnd
+		return ansiColors[n]
+	case n < 232: //nolint:mnd
+		// 6x6x6 color cube
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40 //nolint:mnd
+		}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		gray := 8 + (n-232)*10 //nolint:mnd
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestColorModeSandbox(t *testing.T) {
+	tests := []struct {
+		mode string
+		term string
+		env  string
+	}{
+		{colorModeMono, "xterm-mono", "NO_COLOR=1"},
+		{colorMode16, "xterm-16color", "COLORTERM="},
+		{colorMode256, "xterm-256color", "COLORTERM="},
+		{colorModeTruecolor, "xterm-256color", "COLORTERM=truecolor"},
+	}
+	for _, tt := range tests {
+		sandbox := colorModeSandbox(ShellSandbox{Env: []string{"TERM=dumb"}}, tt.mode)
+		if got := sandboxTerm(sandbox); got != tt.term {
+			t.Errorf("%s: expected TERM=%s, got %s", tt.mode, tt.term, got)
+		}
+		if !slices.Contains(sandbox.Env, tt.env) {
+			t.Errorf("%s: expected %s in %v", tt.mode, tt.env, sandbox.Env)
+		}
+	}
+
+	if cmd := buildTtyCmd(7681, Shells[bash], colorModeSandbox(ShellSandbox{}, colorMode16)); !slices.Contains(cmd.Args, "xterm-16color") {
+		t.Errorf("expected ttyd to set the TERM of the color mode, got %v", cmd.Args)
+	}
+}
+
+func TestLimitColor(t *testing.T) {
+	theme := DefaultTheme
+	tests := []struct {
+		color string
+		mode  string
+		want  string
+	}{
+		{"#123456", colorModeTruecolor, "#123456"},
+		{"#123456", "", "#123456"},
+		{"#123456", colorModeMono, ""},
+		{"#010101", colorMode256, "#000000"},
+		{"#fefefe", colorMode256, "#ffffff"},
+		{theme.Red, colorMode16, theme.Red},
+		{"", colorMode16, ""},
+	}
+	for _, tt := range tests {
+		if got := limitColor(tt.color, theme, tt.mode); got != tt.want {
+			t.Errorf("limitColor(%q, %s): expected %q, got %q", tt.color, tt.mode, tt.want, got)
+		}
+	}
+}
+
+func TestNewXtermTheme(t *testing.T) {
+	if theme := newXtermTheme(DefaultTheme, colorMode256); theme.ExtendedAnsi != nil {
+		t.Errorf("expected the extended colors of xterm.js, got %v", theme.ExtendedAnsi)
+	}
+
+	theme := newXtermTheme(DefaultTheme, colorMode16)
+	if len(theme.ExtendedAnsi) != xtermColors-16 {
+		t.Fatalf("expected %d extended colors, got %d", xtermColors-16, len(theme.ExtendedAnsi))
+	}
+	for _, c := range theme.ExtendedAnsi {
+		if closestColor(c, DefaultTheme, 16) != c {
+			t.Errorf("expected one of the 16 colors of the theme, got %s", c)
+		}
+	}
+}
+
+func TestEmulatorColorMode(t *testing.T) {
+	theme := DefaultTheme
+	e := newEmulator(4, 1, theme)
+	e.colorMode = colorMode16
+	_, _ = e.Write([]byte("\x1b[38;2;1;1;1mA\x1b[38;5;196mB"))
+
+	styles := e.Styles()[0]
+	if styles[0].FgColor != theme.Black {
+		t.Errorf("expected the true color in black, got %s", styles[0].FgColor)
+	}
+	if got := closestColor(xtermColor(theme, 196), theme, 16); styles[1].FgColor != got {
+		t.Errorf("expected the 256 color in %s, got %s", got, styles[1].FgColor)
+	}
+
+	e = newEmulator(4, 1, theme)
+	e.colorMode = colorModeMono
+	_, _ = e.Write([]byte("\x1b[31;44mA"))
+	if styles := e.Styles()[0]; styles[0].FgColor != "" || styles[0].BgColor != "" {
+		t.Errorf("expected the default colors, got %+v", styles[0])
+	}
+}
+
+func TestExecuteSetColorMode(t *testing.T) {
+	v := &VHS{Options: &Options{Theme: DefaultTheme, Video: VideoOptions{Style: DefaultStyleOptions()}}}
+	if err := ExecuteSetColorMode(parser.Command{Args: colorModeMono}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.Theme.Red != DefaultTheme.Foreground {
+		t.Errorf("expected a monochrome theme, got red %s", v.Options.Theme.Red)
+	}
+
+	if err := ExecuteSetColorMode(parser.Command{Args: colorMode16}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.Theme != DefaultTheme {
+		t.Errorf("expected the theme to be restored, got %+v", v.Options.Theme)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build !js
+
+// Package vhs colorscheme.go records tapes in a light and a dark theme.
+//
+// With Set ThemeDark, SVG outputs follow the color scheme of the viewer (see
+// darkmode.go), but raster outputs cannot: the tape is recorded a second
+// time in the dark theme, and the raster outputs are suffixed with -light
+// and -dark.
+//
+// Set Theme "Catppuccin Latte"
+// Set ThemeDark "Catppuccin Mocha"
+// Output demo.gif # demo-light.gif and demo-dark.gif
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+)
+
+// Color schemes of the passes of a recording with a dark theme.
+const (
+	colorSchemeLight = "light"
+	colorSchemeDark  = "dark"
+)
+
+// withColorScheme returns an EvaluatorOption recording the pass of the color
+// scheme.
+func withColorScheme(scheme string) EvaluatorOption {
+	return func(v *VHS) {
+		v.Options.colorScheme = scheme
+	}
+}
+
+// needsDarkPass returns whether the tape sets a dark theme and has raster
+// outputs, which must be recorded again in the dark theme.
+func needsDarkPass(cmds []parser.Command) bool {
+	var dark, raster bool
+	for _, cmd := range cmds {
+		switch cmd.Type {
+		case token.SET:
+			dark = dark || cmd.Options == "ThemeDark"
+		case token.OUTPUT:
+			ext, _, _ := strings.Cut(cmd.Options, " ")
+			raster = raster || isRasterOutput(ext)
+		}
+	}
+	return dark && raster
+}
+
+// isRasterOutput returns whether the output of the extension is rendered
+// from images of the terminal rather than its text.
+func isRasterOutput(ext string) bool {
+	if _, ok := renderers[ext]; ok {
+		return false
+	}
+	switch ext {
+	case ".txt", ".test", ".ascii":
+		return false
+	default:
+		return true
+	}
+}
+
+// colorSchemePath suffixes the path of an output, or of a directory of
+// frames, with the color scheme.
+func colorSchemePath(path, scheme string) string {
+	if dir, ok := strings.CutSuffix(path, "/"); ok {
+		return dir + "-" + scheme + "/"
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + scheme + ext
+}
+
+// darkTheme returns the dark theme with the theme filter and the color mode
+// applied, if any.
+func (o *Options) darkTheme() *Theme {
+	if o.ThemeDark == nil || !o.themeOverridden() {
+		return o.ThemeDark
+	}
+	theme := *o.ThemeDark
+	if o.ThemeFilter != "" {
+		var err error
+		if theme, err = filterTheme(theme, o.ThemeFilter); err != nil {
+			return o.ThemeDark
+		}
+	}
+	if o.ColorMode == colorModeMono {
+		theme = monochromeTheme(theme)
+	}
+	theme = cursorTheme(theme, o.CursorColor, o.CursorAccentColor)
+	return &theme
+}
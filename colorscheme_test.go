@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestNeedsDarkPass(t *testing.T) {
+	tests := []struct {
+		tape string
+		want bool
+	}{
+		{"Output demo.gif\nSet ThemeDark \"Dracula\"", true},
+		{"Output frames/\nSet ThemeDark \"Dracula\"", true},
+		{"Output demo.svg\nSet ThemeDark \"Dracula\"", false},
+		{"Output demo.gif\nSet Theme \"Dracula\"", false},
+	}
+	for _, tt := range tests {
+		cmds := parser.New(lexer.New(tt.tape)).Parse()
+		if got := needsDarkPass(cmds); got != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.tape, tt.want, got)
+		}
+	}
+}
+
+func TestExecuteOutputColorScheme(t *testing.T) {
+	v := &VHS{Options: &Options{}}
+	withColorScheme(colorSchemeDark)(v)
+	for _, output := range []parser.Command{
+		{Options: ".gif", Args: "out/demo.gif"},
+		{Options: ".png", Args: "frames/"},
+		{Options: ".svg", Args: "demo.svg"},
+	} {
+		if err := ExecuteOutput(output, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs := v.Options.Video.Output
+	if outputs.GIF != "out/demo-dark.gif" || outputs.Frames != "frames-dark/" || outputs.SVG != "" {
+		t.Errorf("unexpected outputs of the dark pass %+v", outputs)
+	}
+}
+
+func TestExecuteSetThemeDark(t *testing.T) {
+	v := &VHS{Options: &Options{Video: VideoOptions{Style: DefaultStyleOptions()}}}
+	withColorScheme(colorSchemeDark)(v)
+	if err := ExecuteSetThemeDark(parser.Command{Args: "Dracula"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExecuteSetTheme(parser.Command{Args: "Catppuccin Latte"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.Theme.Background != v.Options.ThemeDark.Background {
+		t.Errorf("expected the dark theme in the dark pass, got %+v", v.Options.Theme)
+	}
+
+	withColorScheme(colorSchemeLight)(v)
+	if err := ExecuteSetTheme(parser.Command{Args: "Catppuccin Latte"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.Theme.Background == v.Options.ThemeDark.Background {
+		t.Error("expected the theme in the light pass")
+	}
+}
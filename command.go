@@ -1,8 +1,11 @@
+//go:build !js
+
 package main
 
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"regexp"
@@ -18,7 +21,22 @@ import (
 
 // Execute executes a command on a running instance of vhs.
 func Execute(c parser.Command, v *VHS) error {
-	err := CommandFuncs[c.Type](c, v)
+	fn := CommandFuncs[c.Type]
+	if native, ok := nativeCommandFuncs[c.Type]; ok && v.native != nil {
+		fn = native
+	}
+	if fn == nil {
+		return fmt.Errorf("unknown command %s", c.Type)
+	}
+	if c.Type == token.ENTER || c.Type == token.SCRIPT {
+		if err := v.markLaunch(); err != nil {
+			return fmt.Errorf("failed to execute command: %w", err)
+		}
+	}
+	v.diagnostics.reached(c)
+	span := v.traceCommand(c)
+	err := fn(c, v)
+	endSpan(span, err)
 	if err != nil {
 		return fmt.Errorf("failed to execute command: %w", err)
 	}
@@ -39,35 +57,52 @@ type CommandFunc func(c parser.Command, v *VHS) error
 
 // CommandFuncs maps command types to their executable functions.
 var CommandFuncs = map[parser.CommandType]CommandFunc{
-	token.BACKSPACE:  ExecuteKey(input.Backspace),
-	token.DELETE:     ExecuteKey(input.Delete),
-	token.INSERT:     ExecuteKey(input.Insert),
-	token.DOWN:       ExecuteKey(input.ArrowDown),
-	token.ENTER:      ExecuteKey(input.Enter),
-	token.LEFT:       ExecuteKey(input.ArrowLeft),
-	token.RIGHT:      ExecuteKey(input.ArrowRight),
-	token.SPACE:      ExecuteKey(input.Space),
-	token.UP:         ExecuteKey(input.ArrowUp),
-	token.TAB:        ExecuteKey(input.Tab),
-	token.ESCAPE:     ExecuteKey(input.Escape),
-	token.PAGE_UP:    ExecuteKey(input.PageUp),
-	token.PAGE_DOWN:  ExecuteKey(input.PageDown),
-	token.HIDE:       ExecuteHide,
-	token.REQUIRE:    ExecuteRequire,
-	token.SHOW:       ExecuteShow,
-	token.SET:        ExecuteSet,
-	token.OUTPUT:     ExecuteOutput,
-	token.SLEEP:      ExecuteSleep,
-	token.TYPE:       ExecuteType,
-	token.CTRL:       ExecuteCtrl,
-	token.ALT:        ExecuteAlt,
-	token.SHIFT:      ExecuteShift,
-	token.ILLEGAL:    ExecuteNoop,
-	token.SCREENSHOT: ExecuteScreenshot,
-	token.COPY:       ExecuteCopy,
-	token.PASTE:      ExecutePaste,
-	token.ENV:        ExecuteEnv,
-	token.WAIT:       ExecuteWait,
+	token.BACKSPACE:   ExecuteKey(input.Backspace),
+	token.DELETE:      ExecuteKey(input.Delete),
+	token.INSERT:      ExecuteKey(input.Insert),
+	token.DOWN:        ExecuteKey(input.ArrowDown),
+	token.ENTER:       ExecuteKey(input.Enter),
+	token.LEFT:        ExecuteKey(input.ArrowLeft),
+	token.RIGHT:       ExecuteKey(input.ArrowRight),
+	token.SPACE:       ExecuteKey(input.Space),
+	token.UP:          ExecuteKey(input.ArrowUp),
+	token.TAB:         ExecuteKey(input.Tab),
+	token.ESCAPE:      ExecuteKey(input.Escape),
+	token.PAGE_UP:     ExecuteKey(input.PageUp),
+	token.PAGE_DOWN:   ExecuteKey(input.PageDown),
+	token.HOME:        ExecuteKey(input.Home),
+	token.END:         ExecuteKey(input.End),
+	token.KEY:         ExecuteKeySequence,
+	token.SCROLL_UP:   ExecuteScroll(-1),
+	token.SCROLL_DOWN: ExecuteScroll(1),
+	token.HIDE:        ExecuteHide,
+	token.REQUIRE:     ExecuteRequire,
+	token.SHOW:        ExecuteShow,
+	token.SET:         ExecuteSet,
+	token.OUTPUT:      ExecuteOutput,
+	token.SLEEP:       ExecuteSleep,
+	token.TYPE:        ExecuteType,
+	token.CTRL:        ExecuteCtrl,
+	token.ALT:         ExecuteAlt,
+	token.SHIFT:       ExecuteShift,
+	token.ILLEGAL:     ExecuteNoop,
+	token.SCREENSHOT:  ExecuteScreenshot,
+	token.COPY:        ExecuteCopy,
+	token.PASTE:       ExecutePaste,
+	token.ENV:         ExecuteEnv,
+	token.WAIT:        ExecuteWait,
+	token.SCENE:       ExecuteScene,
+	token.CHAPTER:     ExecuteChapter,
+	token.RESIZE:      ExecuteResize,
+	token.HIGHLIGHT:   ExecuteHighlight,
+	token.ZOOM:        ExecuteZoom,
+	token.PAN:         ExecutePan,
+	token.ANNOTATE:    ExecuteAnnotate,
+	token.SCRIPT:      ExecuteScript,
+	token.SIGNAL:      ExecuteSignal,
+	token.WAIT_EXIT:   ExecuteWaitExit,
+	token.TYPE_FILE:   ExecuteTypeFile,
+	token.TITLE_CARD:  ExecuteTitleCard,
 }
 
 // ExecuteNoop is a no-op command that does nothing.
@@ -96,13 +131,51 @@ func ExecuteKey(k input.Key) CommandFunc {
 			if err != nil {
 				return fmt.Errorf("failed to type key %c: %w", k, err)
 			}
-			time.Sleep(typingSpeed)
+			if err := v.sleep(typingSpeed); err != nil {
+				return err
+			}
 		}
 
 		return nil
 	}
 }
 
+// ExecuteKeySequence is a CommandFunc that sends the sequence of a function
+// key, or an escape sequence, to the terminal, repeated and delayed like
+// ExecuteKey.
+func ExecuteKeySequence(c parser.Command, v *VHS) error {
+	typingSpeed, err := time.ParseDuration(c.Options)
+	if err != nil {
+		typingSpeed = v.Options.TypingSpeed
+	}
+	key, count := keyArgs(c.Args)
+	seq, err := keySequence(key)
+	if err != nil {
+		return err
+	}
+	repeat, err := strconv.Atoi(count)
+	if err != nil {
+		repeat = 1
+	}
+	for i := 0; i < repeat; i++ {
+		if err := v.typeSequence(seq); err != nil {
+			return fmt.Errorf("failed to type key %s: %w", key, err)
+		}
+		if err := v.sleep(typingSpeed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// typeSequence sends the sequence to the terminal as if it was typed, for
+// the keys which the browser cannot press, e.g. F13.
+func (vhs *VHS) typeSequence(seq string) error {
+	_, err := vhs.Page.Eval(`(seq) => term.input ? term.input(seq, true) : term._core.coreService.triggerDataEvent(seq, true)`, seq)
+	return err //nolint:wrapcheck
+}
+
 // WaitTick is the amount of time to wait between checking for a match.
 const WaitTick = 10 * time.Millisecond
 
@@ -162,6 +235,8 @@ func ExecuteWait(c parser.Command, v *VHS) error {
 		select {
 		case <-checkT.C:
 			continue
+		case <-v.limits.Done():
+			return v.limits.Err()
 		case <-timeoutT.C:
 			return fmt.Errorf("timeout waiting for %q to match %s; last value was: %s", c.Args, rx.String(), last)
 		}
@@ -189,6 +264,7 @@ func ExecuteCtrl(c parser.Command, v *VHS) error {
 			inputKey = &input.Space
 		case "Backspace":
 			inputKey = &input.Backspace
+		case "":
 		default:
 			r := rune(key[0])
 			if k, ok := keymap[r]; ok {
@@ -218,6 +294,13 @@ func ExecuteCtrl(c parser.Command, v *VHS) error {
 // ExecuteAlt is a CommandFunc that presses the argument key with the alt key
 // held down on the running instance of vhs.
 func ExecuteAlt(c parser.Command, v *VHS) error {
+	if seq, ok := navigationSequence(c.Args, modifierAlt); ok {
+		if err := v.typeSequence(seq); err != nil {
+			return fmt.Errorf("failed to type Alt+%s: %w", c.Args, err)
+		}
+		return nil
+	}
+
 	err := v.Page.Keyboard.Press(input.AltLeft)
 	if err != nil {
 		return fmt.Errorf("failed to press Alt key: %w", err)
@@ -257,6 +340,13 @@ func ExecuteAlt(c parser.Command, v *VHS) error {
 // ExecuteShift is a CommandFunc that presses the argument key with the shift
 // key held down on the running instance of vhs.
 func ExecuteShift(c parser.Command, v *VHS) error {
+	if seq, ok := navigationSequence(c.Args, modifierShift); ok {
+		if err := v.typeSequence(seq); err != nil {
+			return fmt.Errorf("failed to type Shift+%s: %w", c.Args, err)
+		}
+		return nil
+	}
+
 	err := v.Page.Keyboard.Press(input.ShiftLeft)
 	if err != nil {
 		return fmt.Errorf("failed to press Shift key: %w", err)
@@ -315,13 +405,12 @@ func ExecuteShow(_ parser.Command, v *VHS) error {
 
 // ExecuteSleep sleeps for the desired time specified through the argument of
 // the Sleep command.
-func ExecuteSleep(c parser.Command, _ *VHS) error {
+func ExecuteSleep(c parser.Command, v *VHS) error {
 	dur, err := time.ParseDuration(c.Args)
 	if err != nil {
 		return fmt.Errorf("failed to parse duration: %w", err)
 	}
-	time.Sleep(dur)
-	return nil
+	return v.sleep(dur)
 }
 
 // ExecuteType types the argument string on the running instance of vhs.
@@ -334,9 +423,15 @@ func ExecuteType(c parser.Command, v *VHS) error {
 			return fmt.Errorf("failed to parse typing speed: %w", err)
 		}
 	}
+	strokes := keyboardStrokes(v.Options.KeyboardLayout)
 	for _, r := range c.Args {
+		s, layoutKey := strokes[r]
 		k, ok := keymap[r]
-		if ok {
+		if layoutKey {
+			if err := v.typeStrokes(s); err != nil {
+				return fmt.Errorf("failed to type key %c: %w", r, err)
+			}
+		} else if ok {
 			err := v.Page.Keyboard.Type(k)
 			if err != nil {
 				return fmt.Errorf("failed to type key %c: %w", r, err)
@@ -349,7 +444,9 @@ func ExecuteType(c parser.Command, v *VHS) error {
 
 			v.Page.MustWaitIdle()
 		}
-		time.Sleep(typingSpeed)
+		if err := v.sleep(typingSpeed); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -357,19 +454,40 @@ func ExecuteType(c parser.Command, v *VHS) error {
 
 // ExecuteOutput applies the output on the vhs videos.
 func ExecuteOutput(c parser.Command, v *VHS) error {
-	switch c.Options {
+	ext, rawLoops, _ := strings.Cut(c.Options, " loops=")
+	if rawLoops != "" {
+		loops, err := strconv.Atoi(rawLoops)
+		if err != nil {
+			return fmt.Errorf("failed to parse loops: %w", err)
+		}
+		v.Options.Video.Output.GIFLoops = loops
+	}
+
+	path := c.Args
+	switch {
+	case v.Options.colorScheme == "":
+	case isRasterOutput(ext):
+		path = colorSchemePath(path, v.Options.colorScheme)
+	case v.Options.colorScheme == colorSchemeDark:
+		// Text outputs are rendered once, in the light pass.
+		return nil
+	}
+
+	switch ext {
 	case ".mp4":
-		v.Options.Video.Output.MP4 = c.Args
+		v.Options.Video.Output.MP4 = path
 	case ".test", ".ascii", ".txt":
-		v.Options.Test.Output = c.Args
+		v.Options.Test.Output = path
 	case ".png":
-		v.Options.Video.Output.Frames = c.Args
+		v.Options.Video.Output.Frames = path
 	case ".webm":
-		v.Options.Video.Output.WebM = c.Args
-	case ".svg":
-		v.Options.Video.Output.SVG = c.Args
+		v.Options.Video.Output.WebM = path
 	default:
-		v.Options.Video.Output.GIF = c.Args
+		if ext, ok := rendererExt(c.Args); ok {
+			v.Options.Video.Output.setRendered(ext, path)
+			return nil
+		}
+		v.Options.Video.Output.GIF = path
 	}
 
 	return nil
@@ -385,6 +503,23 @@ func ExecuteEnv(c parser.Command, _ *VHS) error {
 	return os.Setenv(c.Options, c.Args) //nolint:wrapcheck
 }
 
+// ExecuteResize resizes the terminal to the given columns and rows. xterm.js
+// reports the new size to ttyd, which resizes the PTY so the program receives
+// SIGWINCH.
+func ExecuteResize(c parser.Command, v *VHS) error {
+	var cols, rows int
+	if _, err := fmt.Sscanf(c.Args, "%d %d", &cols, &rows); err != nil {
+		return fmt.Errorf("failed to parse terminal size: %w", err)
+	}
+
+	_, err := v.Page.Eval(fmt.Sprintf("() => term.resize(%d, %d)", cols, rows))
+	if err != nil {
+		return fmt.Errorf("failed to resize terminal: %w", err)
+	}
+
+	return nil
+}
+
 // ExecutePaste pastes text from the clipboard.
 func ExecutePaste(_ parser.Command, v *VHS) error {
 	clip, err := clipboard.ReadAll()
@@ -410,32 +545,89 @@ func ExecutePaste(_ parser.Command, v *VHS) error {
 	return nil
 }
 
+// ExecuteScript sends the lines of the script to the shell at once, instead
+// of typing them, and waits for the prompt once they ran.
+func ExecuteScript(c parser.Command, v *VHS) error {
+	for _, line := range strings.Split(c.Args, "\n") {
+		if line != "" {
+			if err := v.Page.MustElement("textarea").Input(line); err != nil {
+				return fmt.Errorf("failed to input script: %w", err)
+			}
+		}
+		if err := v.Page.Keyboard.Type(input.Enter); err != nil {
+			return fmt.Errorf("failed to run script: %w", err)
+		}
+	}
+	v.Page.MustWaitIdle()
+
+	return waitForPrompt(v)
+}
+
+// waitForPrompt waits until the current line matches the wait pattern.
+func waitForPrompt(v *VHS) error {
+	return ExecuteWait(parser.Command{Type: token.WAIT, Args: "Line"}, v)
+}
+
 // Settings maps the Set commands to their respective functions.
 var Settings = map[string]CommandFunc{
-	"FontFamily":          ExecuteSetFontFamily,
-	"FontSize":            ExecuteSetFontSize,
-	"Framerate":           ExecuteSetFramerate,
-	"Height":              ExecuteSetHeight,
-	"LetterSpacing":       ExecuteSetLetterSpacing,
-	"LineHeight":          ExecuteSetLineHeight,
-	"PlaybackSpeed":       ExecuteSetPlaybackSpeed,
-	"Padding":             ExecuteSetPadding,
-	"Theme":               ExecuteSetTheme,
-	"TypingSpeed":         ExecuteSetTypingSpeed,
-	"Width":               ExecuteSetWidth,
-	"Shell":               ExecuteSetShell,
-	"LoopOffset":          ExecuteLoopOffset,
-	"MarginFill":          ExecuteSetMarginFill,
-	"Margin":              ExecuteSetMargin,
-	"WindowBar":           ExecuteSetWindowBar,
-	"WindowBarSize":       ExecuteSetWindowBarSize,
-	"WindowBarTitle":      ExecuteSetWindowBarTitle,
-	"WindowBarFontFamily": ExecuteSetWindowBarFontFamily,
-	"WindowBarFontSize":   ExecuteSetWindowBarFontSize,
-	"BorderRadius":        ExecuteSetBorderRadius,
-	"WaitPattern":         ExecuteSetWaitPattern,
-	"WaitTimeout":         ExecuteSetWaitTimeout,
-	"CursorBlink":         ExecuteSetCursorBlink,
+	"FontFamily":           ExecuteSetFontFamily,
+	"EmojiFont":            ExecuteSetEmojiFont,
+	"FontSize":             ExecuteSetFontSize,
+	"Framerate":            ExecuteSetFramerate,
+	"Height":               ExecuteSetHeight,
+	"LetterSpacing":        ExecuteSetLetterSpacing,
+	"LineHeight":           ExecuteSetLineHeight,
+	"PlaybackSpeed":        ExecuteSetPlaybackSpeed,
+	"Padding":              ExecuteSetPadding,
+	"Theme":                ExecuteSetTheme,
+	"TypingSpeed":          ExecuteSetTypingSpeed,
+	"Width":                ExecuteSetWidth,
+	"Shell":                ExecuteSetShell,
+	"LoopOffset":           ExecuteLoopOffset,
+	"MarginFill":           ExecuteSetMarginFill,
+	"Margin":               ExecuteSetMargin,
+	"WindowBar":            ExecuteSetWindowBar,
+	"WindowBarSize":        ExecuteSetWindowBarSize,
+	"WindowBarTitle":       ExecuteSetWindowBarTitle,
+	"WindowBarFontFamily":  ExecuteSetWindowBarFontFamily,
+	"WindowBarFontSize":    ExecuteSetWindowBarFontSize,
+	"WindowBarTitleColor":  ExecuteSetWindowBarTitleColor,
+	"BorderRadius":         ExecuteSetBorderRadius,
+	"WaitPattern":          ExecuteSetWaitPattern,
+	"WaitTimeout":          ExecuteSetWaitTimeout,
+	"CursorBlink":          ExecuteSetCursorBlink,
+	"Transition":           ExecuteSetTransition,
+	"GIFColors":            ExecuteSetGIFColors,
+	"GIFDither":            ExecuteSetGIFDither,
+	"GIFStatsMode":         ExecuteSetGIFStatsMode,
+	"Quality":              ExecuteSetQuality,
+	"MaxFileSize":          ExecuteSetMaxFileSize,
+	"PixelRatio":           ExecuteSetPixelRatio,
+	"UnderlineLinks":       ExecuteSetUnderlineLinks,
+	"FontLigatures":        ExecuteSetFontLigatures,
+	"Scrollback":           ExecuteSetScrollback,
+	"Watermark":            ExecuteSetWatermark,
+	"VideoFilter":          ExecuteSetVideoFilter,
+	"FrameCommand":         ExecuteSetFrameCommand,
+	"Timeout":              ExecuteSetTimeout,
+	"MaxFrames":            ExecuteSetMaxFrames,
+	"MaxDiskUsage":         ExecuteSetMaxDiskUsage,
+	"LoopDelay":            ExecuteSetLoopDelay,
+	"Description":          ExecuteSetDescription,
+	"AccessibleTranscript": ExecuteSetAccessibleTranscript,
+	"ThemeFilter":          ExecuteSetThemeFilter,
+	"ThemeDark":            ExecuteSetThemeDark,
+	"ColorMode":            ExecuteSetColorMode,
+	"CursorColor":          ExecuteSetCursorColor,
+	"CursorAccentColor":    ExecuteSetCursorAccentColor,
+	"MaxIdle":              ExecuteSetMaxIdle,
+	"SVGOptimizeLevel":     ExecuteSetSVGOptimizeLevel,
+	"CursorTween":          ExecuteSetCursorTween,
+	"WorkingDirectory":     ExecuteSetWorkingDirectory,
+	"Sandbox":              ExecuteSetSandbox,
+	"CaptureRate":          ExecuteSetCaptureRate,
+	"KeyboardLayout":       ExecuteSetKeyboardLayout,
+	"SyntaxHighlight":      ExecuteSetSyntaxHighlight,
 }
 
 // ExecuteSet applies the settings on the running vhs specified by the
@@ -451,6 +643,10 @@ func ExecuteSetFontSize(c parser.Command, v *VHS) error {
 		return fmt.Errorf("failed to parse font size: %w", err)
 	}
 	v.Options.FontSize = fontSize
+	// Without a browser (e.g. the native backend) only the option is stored.
+	if v.Page == nil {
+		return nil
+	}
 	_, err = v.Page.Eval(fmt.Sprintf("() => term.options.fontSize = %d", fontSize))
 	if err != nil {
 		return fmt.Errorf("failed to set font size: %w", err)
@@ -471,7 +667,23 @@ func ExecuteSetFontSize(c parser.Command, v *VHS) error {
 // ExecuteSetFontFamily applies the font family on the vhs.
 func ExecuteSetFontFamily(c parser.Command, v *VHS) error {
 	v.Options.FontFamily = c.Args
-	_, err := v.Page.Eval(fmt.Sprintf("() => term.options.fontFamily = '%s'", withSymbolsFallback(c.Args)))
+	return applyFontFamily(v)
+}
+
+// ExecuteSetEmojiFont sets the font of emoji, which takes precedence over the
+// emoji fonts of the fallback.
+func ExecuteSetEmojiFont(c parser.Command, v *VHS) error {
+	v.Options.EmojiFont = c.Args
+	return applyFontFamily(v)
+}
+
+// applyFontFamily applies the font family, with the emoji font, to the
+// terminal.
+func applyFontFamily(v *VHS) error {
+	if v.Page == nil {
+		return nil
+	}
+	_, err := v.Page.Eval(fmt.Sprintf("() => term.options.fontFamily = '%s'", fontStack(v.terminalFontFamily())))
 	if err != nil {
 		return fmt.Errorf("failed to set font family: %w", err)
 	}
@@ -526,6 +738,9 @@ func ExecuteSetLetterSpacing(c parser.Command, v *VHS) error {
 	}
 
 	v.Options.LetterSpacing = letterSpacing
+	if v.Page == nil {
+		return nil
+	}
 	_, err = v.Page.Eval(fmt.Sprintf("() => term.options.letterSpacing = %f", letterSpacing))
 	if err != nil {
 		return fmt.Errorf("failed to set letter spacing: %w", err)
@@ -542,6 +757,9 @@ func ExecuteSetLineHeight(c parser.Command, v *VHS) error {
 	}
 
 	v.Options.LineHeight = lineHeight
+	if v.Page == nil {
+		return nil
+	}
 	_, err = v.Page.Eval(fmt.Sprintf("() => term.options.lineHeight = %f", lineHeight))
 	if err != nil {
 		return fmt.Errorf("failed to set line height: %w", err)
@@ -552,13 +770,122 @@ func ExecuteSetLineHeight(c parser.Command, v *VHS) error {
 
 // ExecuteSetTheme applies the theme on the vhs.
 func ExecuteSetTheme(c parser.Command, v *VHS) error {
-	var err error
-	v.Options.Theme, err = getTheme(c.Args)
+	theme, err := getTheme(c.Args)
 	if err != nil {
 		return err
 	}
+	// The dark theme takes precedence in the dark pass.
+	if v.Options.colorScheme == colorSchemeDark && v.Options.ThemeDark != nil {
+		return nil
+	}
+	v.Options.unfilteredTheme = theme
+	return applyTheme(v)
+}
 
-	bts, err := json.Marshal(v.Options.Theme)
+// ExecuteSetThemeDark sets the theme for viewers who prefer a dark color
+// scheme, which is the theme of the terminal in the dark pass.
+func ExecuteSetThemeDark(c parser.Command, v *VHS) error {
+	theme, err := getTheme(c.Args)
+	if err != nil {
+		return err
+	}
+	v.Options.ThemeDark = &theme
+	if v.Options.colorScheme != colorSchemeDark {
+		return nil
+	}
+	v.Options.unfilteredTheme = theme
+	return applyTheme(v)
+}
+
+// ExecuteSetThemeFilter applies a color transform to the theme, before or
+// after it is set.
+func ExecuteSetThemeFilter(c parser.Command, v *VHS) error {
+	if !v.Options.themeOverridden() {
+		v.Options.unfilteredTheme = v.Options.Theme
+	}
+	v.Options.ThemeFilter = c.Args
+	return applyTheme(v)
+}
+
+// ExecuteSetColorMode sets the number of colors of the emulated terminal.
+// The shell is started with the capabilities of the color mode, so that it is
+// set before the other commands run.
+func ExecuteSetColorMode(c parser.Command, v *VHS) error {
+	if !v.Options.themeOverridden() {
+		v.Options.unfilteredTheme = v.Options.Theme
+	}
+	v.Options.ColorMode = c.Args
+	return applyTheme(v)
+}
+
+// ExecuteSetCursorColor sets the color of the cursor over the theme, or
+// inverts the cell under it if reverse.
+func ExecuteSetCursorColor(c parser.Command, v *VHS) error {
+	if !v.Options.themeOverridden() {
+		v.Options.unfilteredTheme = v.Options.Theme
+	}
+	v.Options.CursorColor = c.Args
+	return applyTheme(v)
+}
+
+// ExecuteSetCursorAccentColor sets the color of the text under the cursor
+// over the theme.
+func ExecuteSetCursorAccentColor(c parser.Command, v *VHS) error {
+	if !v.Options.themeOverridden() {
+		v.Options.unfilteredTheme = v.Options.Theme
+	}
+	v.Options.CursorAccentColor = c.Args
+	return applyTheme(v)
+}
+
+// themeOverridden reports whether the theme is changed by a filter, the color
+// mode or the cursor colors, and so applied from the unfiltered theme.
+func (o *Options) themeOverridden() bool {
+	return o.ThemeFilter != "" || o.ColorMode != "" || o.CursorColor != "" || o.CursorAccentColor != ""
+}
+
+// cursorTheme returns the theme with the cursor colors, if any. The terminal
+// approximates a reverse cursor with the colors of the text.
+func cursorTheme(theme Theme, cursor, accent string) Theme {
+	switch cursor {
+	case "":
+	case cursorReverse:
+		theme.Cursor, theme.CursorAccent = theme.Foreground, theme.Background
+	default:
+		theme.Cursor = cursor
+	}
+	if accent != "" {
+		theme.CursorAccent = accent
+	}
+	return theme
+}
+
+// applyTheme applies the theme filter, the color mode and the cursor colors,
+// if any, to the theme and the theme to the terminal.
+func applyTheme(v *VHS) error {
+	v.Options.Theme = v.Options.unfilteredTheme
+	if v.Options.ThemeFilter != "" {
+		var err error
+		v.Options.Theme, err = filterTheme(v.Options.unfilteredTheme, v.Options.ThemeFilter)
+		if err != nil {
+			return err
+		}
+		for _, warning := range contrastWarnings(v.Options.Theme) {
+			log.Println(ErrorStyle.Render("WARN: " + warning))
+		}
+	}
+	if v.Options.ColorMode == colorModeMono {
+		v.Options.Theme = monochromeTheme(v.Options.Theme)
+	}
+	v.Options.Theme = cursorTheme(v.Options.Theme, v.Options.CursorColor, v.Options.CursorAccentColor)
+
+	v.Options.Video.Style.BackgroundColor = v.Options.Theme.Background
+	v.Options.Video.Style.WindowBarColor = v.Options.Theme.Background
+	if v.Page == nil {
+		return nil
+	}
+
+	bts, err := json.Marshal(newXtermTheme(v.Options.Theme, v.Options.ColorMode))
 	if err != nil {
 		return fmt.Errorf("failed to marshal theme: %w", err)
 	}
@@ -568,9 +895,6 @@ func ExecuteSetTheme(c parser.Command, v *VHS) error {
 		return fmt.Errorf("failed to set theme: %w", err)
 	}
 
-	v.Options.Video.Style.BackgroundColor = v.Options.Theme.Background
-	v.Options.Video.Style.WindowBarColor = v.Options.Theme.Background
-
 	return nil
 }
 
@@ -585,6 +909,23 @@ func ExecuteSetTypingSpeed(c parser.Command, v *VHS) error {
 	return nil
 }
 
+// ExecuteSetDescription sets the accessible description of the SVG output.
+func ExecuteSetDescription(c parser.Command, v *VHS) error {
+	v.Options.SVG.Description = c.Args
+	return nil
+}
+
+// ExecuteSetAccessibleTranscript sets whether the text of the terminal is
+// embedded in the SVG output for screen readers.
+func ExecuteSetAccessibleTranscript(c parser.Command, v *VHS) error {
+	var err error
+	v.Options.SVG.AccessibleTranscript, err = strconv.ParseBool(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse accessible transcript: %w", err)
+	}
+	return nil
+}
+
 // ExecuteSetWaitTimeout applies the default wait timeout on the vhs.
 func ExecuteSetWaitTimeout(c parser.Command, v *VHS) error {
 	waitTimeout, err := time.ParseDuration(c.Args)
@@ -649,6 +990,18 @@ func ExecuteLoopOffset(c parser.Command, v *VHS) error {
 	return nil
 }
 
+// ExecuteSetLoopDelay sets how long GIF outputs pause on the last frame
+// before looping.
+func ExecuteSetLoopDelay(c parser.Command, v *VHS) error {
+	loopDelay, err := time.ParseDuration(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse loop delay: %w", err)
+	}
+
+	v.Options.Video.LoopDelay = loopDelay
+	return nil
+}
+
 // ExecuteSetMarginFill sets vhs margin fill.
 func ExecuteSetMarginFill(c parser.Command, v *VHS) error {
 	v.Options.Video.Style.MarginFill = c.Args
@@ -705,6 +1058,36 @@ func ExecuteSetWindowBarFontSize(c parser.Command, v *VHS) error {
 	return nil
 }
 
+// ExecuteSetWindowBarTitleColor sets window bar title color.
+func ExecuteSetWindowBarTitleColor(c parser.Command, v *VHS) error {
+	v.Options.Video.Style.WindowBarTitleColor = c.Args
+	return nil
+}
+
+// ExecuteSetGIFColors sets the maximum number of colors in the GIF palette.
+func ExecuteSetGIFColors(c parser.Command, v *VHS) error {
+	colors, err := strconv.Atoi(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse gif colors: %w", err)
+	}
+
+	v.Options.Video.MaxColors = colors
+	return nil
+}
+
+// ExecuteSetGIFDither sets the dithering algorithm used for the GIF palette.
+func ExecuteSetGIFDither(c parser.Command, v *VHS) error {
+	v.Options.Video.GIFDither = c.Args
+	return nil
+}
+
+// ExecuteSetGIFStatsMode sets how the GIF palette is generated from the
+// frames.
+func ExecuteSetGIFStatsMode(c parser.Command, v *VHS) error {
+	v.Options.Video.GIFStatsMode = c.Args
+	return nil
+}
+
 // ExecuteSetBorderRadius sets corner radius.
 func ExecuteSetBorderRadius(c parser.Command, v *VHS) error {
 	borderRadius, err := strconv.Atoi(c.Args)
@@ -727,28 +1110,237 @@ func ExecuteSetCursorBlink(c parser.Command, v *VHS) error {
 	return nil
 }
 
+// ExecuteSetUnderlineLinks sets whether hyperlinks are underlined in SVG
+// outputs.
+func ExecuteSetUnderlineLinks(c parser.Command, v *VHS) error {
+	var err error
+	v.Options.SVG.UnderlineLinks, err = strconv.ParseBool(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse underline links: %w", err)
+	}
+
+	return nil
+}
+
+// ExecuteSetSyntaxHighlight sets the language the lines without colors of
+// SVG outputs are highlighted as, or auto to detect it.
+func ExecuteSetSyntaxHighlight(c parser.Command, v *VHS) error {
+	if c.Args != autoSyntax && syntaxLexer(c.Args) == nil {
+		return fmt.Errorf("unknown language %q", c.Args)
+	}
+	v.Options.SVG.SyntaxHighlight = c.Args
+	return nil
+}
+
+// ExecuteSetCursorTween sets whether the cursor of SVG outputs slides
+// between its positions.
+func ExecuteSetCursorTween(c parser.Command, v *VHS) error {
+	var err error
+	v.Options.SVG.CursorTween, err = strconv.ParseBool(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse cursor tween: %w", err)
+	}
+
+	return nil
+}
+
+// ExecuteSetSVGOptimizeLevel sets how hard SVG outputs are optimized, 0 not
+// at all.
+func ExecuteSetSVGOptimizeLevel(c parser.Command, v *VHS) error {
+	level, err := strconv.Atoi(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse SVG optimize level: %w", err)
+	}
+
+	v.Options.SVG.OptimizeSize = level > svgOptimizeNone
+	v.Options.SVG.OptimizeLevel = level
+	return nil
+}
+
+// ExecuteSetFontLigatures sets whether SVG outputs let the font shape
+// ligatures or position every character on the terminal grid.
+func ExecuteSetFontLigatures(c parser.Command, v *VHS) error {
+	var err error
+	v.Options.SVG.FontLigatures, err = strconv.ParseBool(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse font ligatures: %w", err)
+	}
+
+	return nil
+}
+
 // ExecuteScreenshot is a CommandFunc that indicates a new screenshot must be taken.
 func ExecuteScreenshot(c parser.Command, v *VHS) error {
+	// Golden tests compare the screen instead of writing images.
+	if v.Options.Test.Golden != "" {
+		return v.takeSnapshot(c.Args)
+	}
 	v.ScreenshotNextFrame(c.Args)
 	return nil
 }
 
-func getTheme(s string) (Theme, error) {
-	if strings.TrimSpace(s) == "" {
-		return DefaultTheme, nil
+// ExecuteZoom moves the camera to the zoom factor around the position.
+func ExecuteZoom(c parser.Command, v *VHS) error {
+	rawZoom, position, _ := strings.Cut(c.Args, " ")
+	zoom, err := strconv.ParseFloat(rawZoom, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse zoom factor: %w", err)
 	}
-	switch s[0] {
-	case '{':
-		return getJSONTheme(s)
-	default:
-		return findTheme(s)
+	return v.moveCamera(c.Options, position, zoom)
+}
+
+// ExecutePan moves the center of the camera to the position.
+func ExecutePan(c parser.Command, v *VHS) error {
+	return v.moveCamera(c.Options, c.Args, 0)
+}
+
+// ExecuteHighlight selects the given span of the terminal for the duration.
+func ExecuteHighlight(c parser.Command, v *VHS) error {
+	s, err := v.startHighlight(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = v.Page.Eval(fmt.Sprintf(`() => {
+		if (%[1]q) term.options.theme = { ...term.options.theme, selectionBackground: %[1]q };
+		term.select(%[2]d, term.buffer.active.viewportY + %[3]d, %[4]d * term.cols + %[5]d);
+	}`, v.Options.Theme.Selection, s.StartCol, s.StartRow, s.EndRow-s.StartRow, s.EndCol-s.StartCol+1))
+	if err != nil {
+		return fmt.Errorf("failed to highlight text: %w", err)
 	}
+	return nil
 }
 
-func getJSONTheme(s string) (Theme, error) {
-	var t Theme
-	if err := json.Unmarshal([]byte(s), &t); err != nil {
-		return DefaultTheme, fmt.Errorf("invalid `Set Theme %q: %w`", s, err)
+// ExecuteAnnotate adds a callout to the recording.
+func ExecuteAnnotate(c parser.Command, v *VHS) error {
+	a := Annotation{
+		Text:       c.Args,
+		Row:        -1,
+		Foreground: v.Options.Theme.Foreground,
+		Background: v.Options.Theme.Background,
+	}
+
+	var err error
+	a.Cols, a.Rows, err = v.terminalSize()
+	if err != nil {
+		return err
 	}
-	return t, nil
+
+	v.mutex.Lock()
+	a.Start = v.totalFrames + 1
+	v.mutex.Unlock()
+	duration := defaultAnnotationDuration
+
+	for _, option := range strings.Fields(c.Options) {
+		name, value, _ := strings.Cut(option, "=")
+		switch name {
+		case "position":
+			if _, err := fmt.Sscanf(value, "%d,%d", &a.Row, &a.Col); err != nil {
+				return fmt.Errorf("failed to parse annotation position: %w", err)
+			}
+			a.Row--
+			a.Col--
+		case "arrow":
+			if _, err := fmt.Sscanf(value, "%d,%d", &a.ArrowRow, &a.ArrowCol); err != nil {
+				return fmt.Errorf("failed to parse annotation arrow: %w", err)
+			}
+			a.Arrow = true
+			a.ArrowRow--
+			a.ArrowCol--
+		case "at":
+			at, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse annotation time: %w", err)
+			}
+			a.Start = 1 + v.durationFrames(at)
+		case "for":
+			duration, err = time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse annotation duration: %w", err)
+			}
+		}
+	}
+	a.End = a.Start + max(1, v.durationFrames(duration))
+
+	if a.Row < 0 {
+		a.placeCallout()
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.annotations = append(v.annotations, a)
+	return nil
+}
+
+// ExecuteScene is a CommandFunc that marks the start of a new scene, clearing
+// the terminal first if requested.
+func ExecuteScene(c parser.Command, v *VHS) error {
+	// The previous scene ends here.
+	if err := v.captureTranscript(); err != nil {
+		return err
+	}
+
+	reset := c.Options == "Reset"
+	if reset && v.Page != nil {
+		// term.clear keeps the prompt line, so the shell does not need to be
+		// told about the reset.
+		if _, err := v.Page.Eval("() => term.clear()"); err != nil {
+			return fmt.Errorf("failed to reset terminal: %w", err)
+		}
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.scenes = append(v.scenes, Scene{
+		Name:  c.Args,
+		Frame: v.totalFrames + 1,
+		Reset: reset,
+	})
+	v.chapters = append(v.chapters, chapterMark{
+		Title: c.Args,
+		Frame: v.totalFrames + 1,
+	})
+
+	return nil
+}
+
+// ExecuteSetTransition sets the transition rendered between scenes.
+func ExecuteSetTransition(c parser.Command, v *VHS) error {
+	kind, rawDuration, _ := strings.Cut(c.Args, " ")
+	transition := Transition{Type: kind, Duration: defaultTransitionDuration}
+	if rawDuration != "" {
+		d, err := time.ParseDuration(rawDuration)
+		if err != nil {
+			return fmt.Errorf("failed to parse transition duration: %w", err)
+		}
+		transition.Duration = d
+	}
+
+	v.Options.Video.Transition = transition
+	return nil
+}
+
+// ExecuteSetWatermark sets the watermark of the outputs.
+func ExecuteSetWatermark(c parser.Command, v *VHS) error {
+	args := strings.SplitN(c.Args, " ", 3)
+	if len(args) != 3 {
+		return fmt.Errorf("failed to parse watermark: %s", c.Args)
+	}
+	opacity, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse watermark opacity: %w", err)
+	}
+
+	w := &Watermark{Position: args[0], Opacity: opacity}
+	if isWatermarkImage(args[2]) {
+		if _, err := os.Stat(args[2]); err != nil {
+			return fmt.Errorf("failed to read watermark image: %w", err)
+		}
+		w.Image = args[2]
+	} else {
+		w.Text = args[2]
+	}
+
+	v.Options.Video.Watermark = w
+	return nil
 }
@@ -8,12 +8,12 @@ import (
 )
 
 func TestCommand(t *testing.T) {
-	const numberOfCommands = 29
+	const numberOfCommands = 46
 	if len(parser.CommandTypes) != numberOfCommands {
 		t.Errorf("Expected %d commands, got %d", numberOfCommands, len(parser.CommandTypes))
 	}
 
-	const numberOfCommandFuncs = 29
+	const numberOfCommandFuncs = 46
 	if len(CommandFuncs) != numberOfCommandFuncs {
 		t.Errorf("Expected %d commands, got %d", numberOfCommandFuncs, len(CommandFuncs))
 	}
@@ -55,6 +55,21 @@ func TestExecuteSetTheme(t *testing.T) {
 	})
 }
 
+func TestExecuteSetCursorColor(t *testing.T) {
+	v := &VHS{Options: &Options{Theme: DefaultTheme, Video: VideoOptions{Style: DefaultStyleOptions()}}}
+	requireNoErr(t, ExecuteSetCursorColor(parser.Command{Args: "#ff00ff"}, v))
+	requireNoErr(t, ExecuteSetCursorAccentColor(parser.Command{Args: "#000000"}, v))
+	requireNoErr(t, ExecuteSetTheme(parser.Command{Args: "Andromeda"}, v))
+	if v.Options.Theme.Cursor != "#ff00ff" || v.Options.Theme.CursorAccent != "#000000" {
+		t.Errorf("expected the cursor colors to outlast the theme, got %q and %q", v.Options.Theme.Cursor, v.Options.Theme.CursorAccent)
+	}
+
+	requireNoErr(t, ExecuteSetCursorColor(parser.Command{Args: cursorReverse}, v))
+	if v.Options.Theme.Cursor != v.Options.Theme.Foreground {
+		t.Errorf("expected a reverse cursor in the foreground color, got %q", v.Options.Theme.Cursor)
+	}
+}
+
 func requireErr(tb testing.TB, err error) {
 	tb.Helper()
 	if err == nil {
@@ -0,0 +1,280 @@
+//go:build !js
+
+// Package vhs compare.go renders recordings side by side.
+//
+// vhs compare records two or more tapes, or reads their JSON recordings, and
+// lays their terminals out in one output, side by side or stacked, each under
+// its label, e.g. to show a program before and after a change. The recordings
+// play on a shared timeline: every terminal shows its frame at the same time,
+// and holds its last frame once its recording ends.
+//
+// vhs compare before.tape after.tape -o compare.gif --labels before,after
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"github.com/spf13/cobra"
+)
+
+// compareGutter separates the terminals laid out side by side.
+const compareGutter = " │ "
+
+var (
+	compareOutputs []string
+	compareLabels  []string
+	compareStacked bool
+	compareTape    string
+	compareBackend string
+
+	compareCmd = &cobra.Command{
+		Use:   "compare <file> <file>...",
+		Short: "Render tapes or recordings side by side, e.g. before and after a change",
+		Args:  cobra.MinimumNArgs(2), //nolint:mnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(compareOutputs) == 0 {
+				return errors.New("no outputs, e.g. -o compare.gif")
+			}
+			if len(compareLabels) > 0 && len(compareLabels) != len(args) {
+				return fmt.Errorf("expected %d labels, got %d", len(args), len(compareLabels))
+			}
+			var settings []byte
+			if compareTape != "" {
+				var err error
+				settings, err = readTape(cmd, compareTape)
+				if err != nil {
+					return err
+				}
+			}
+
+			panes := make([]comparePane, len(args))
+			var cfg SVGConfig
+			for i, path := range args {
+				log.Println(GrayStyle.Render("Recording " + path + "..."))
+				recording, err := readComparison(cmd.Context(), path, compareBackend)
+				if err != nil {
+					return err
+				}
+				if i == 0 {
+					cfg = recording
+				}
+				label := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), svgRecordingExt), extension)
+				if len(compareLabels) > 0 {
+					label = compareLabels[i]
+				}
+				panes[i] = newComparePane(label, recording.Frames)
+			}
+
+			// The outputs take the style of the first recording.
+			cfg.Frames, cfg.Duration = compareFrames(panes, compareStacked, cfg.Theme)
+			return convertFrames(cmd.Context(), cfg, string(settings), compareOutputs)
+		},
+	}
+)
+
+func init() {
+	compareCmd.Flags().StringSliceVarP(&compareOutputs, "output", "o", nil, "file name(s) of the outputs, e.g. compare.gif or compare.svg")
+	compareCmd.Flags().StringSliceVarP(&compareLabels, "labels", "l", nil, "labels of the terminals, the file names by default, e.g. before,after")
+	compareCmd.Flags().BoolVar(&compareStacked, "stacked", false, "stack the terminals instead of laying them side by side")
+	compareCmd.Flags().StringVarP(&compareTape, "tape", "t", "", "tape whose Set commands style the output, e.g. the theme and window bar")
+	compareCmd.Flags().StringVar(&compareBackend, "backend", browserBackend, "capture backend of the tapes: browser, or native")
+}
+
+// readComparison returns the JSON recording of the file, recording it first
+// if it is a tape. The outputs of the tape are not written.
+func readComparison(ctx context.Context, path, backend string) (SVGConfig, error) {
+	var data []byte
+	if strings.HasSuffix(path, svgRecordingExt) {
+		var err error
+		if data, err = os.ReadFile(path); err != nil { //nolint:gosec
+			return SVGConfig{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return ParseSVGRecording(data)
+	}
+
+	tape, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return SVGConfig{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	dir, err := os.MkdirTemp("", "vhs-compare-*")
+	if err != nil {
+		return SVGConfig{}, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	output := filepath.Join(dir, "recording"+svgRecordingExt)
+	if errs := Evaluate(ctx, withoutOutputs(string(tape)), io.Discard, WithBackend(backend), withOutputs([]string{output})); len(errs) > 0 {
+		return SVGConfig{}, fmt.Errorf("failed to record %s: %w", path, errors.Join(errs...))
+	}
+	if data, err = os.ReadFile(output); err != nil { //nolint:gosec
+		return SVGConfig{}, fmt.Errorf("failed to read the recording of %s: %w", path, err)
+	}
+	return ParseSVGRecording(data)
+}
+
+// comparePane is a terminal laid out in a comparison.
+type comparePane struct {
+	label      string
+	frames     []SVGFrame
+	cols, rows int
+	// x and y are the cell of the top left corner of the terminal.
+	x, y int
+}
+
+// newComparePane returns the pane of the frames, sized to fit them all and
+// the label.
+func newComparePane(label string, frames []SVGFrame) comparePane {
+	p := comparePane{label: label, frames: frames, cols: uniseg.StringWidth(label)}
+	for _, frame := range frames {
+		cols, rows := framesSize([]SVGFrame{frame})
+		p.cols, p.rows = max(p.cols, cols), max(p.rows, rows)
+	}
+	return p
+}
+
+// frameAt returns the index of the frame shown at the time, in seconds.
+func (p comparePane) frameAt(t float64) int {
+	i := 0
+	for i+1 < len(p.frames) && p.frames[i+1].Timestamp <= t {
+		i++
+	}
+	return i
+}
+
+// compareFrames lays out the frames of the panes on their shared timeline,
+// at the framerate of the first, and returns the frames and their duration.
+// The cursor is the one of the terminal which changed last.
+func compareFrames(panes []comparePane, stacked bool, theme Theme) ([]SVGFrame, float64) {
+	cols, rows := layoutPanes(panes, stacked)
+
+	var duration float64
+	for _, p := range panes {
+		duration = max(duration, p.frames[len(p.frames)-1].Timestamp)
+	}
+	first := panes[0].frames
+	interval := first[len(first)-1].Timestamp / float64(len(first))
+	if interval <= 0 {
+		interval = 1 / float64(defaultFramerate)
+	}
+	count := max(int(math.Round(duration/interval)), 1)
+
+	frames := make([]SVGFrame, 0, count)
+	shown := make([]int, len(panes))
+	active := 0
+	for n := 1; n <= count; n++ {
+		t := float64(n) * interval
+		e := newEmulator(cols, rows, theme)
+		for i, p := range panes {
+			if j := p.frameAt(t); j != shown[i] {
+				if !slices.Equal(p.frames[j].Lines, p.frames[shown[i]].Lines) || p.frames[j].CursorX != p.frames[shown[i]].CursorX {
+					active = i
+				}
+				shown[i] = j
+			}
+			drawPane(e, p, p.frames[shown[i]])
+		}
+
+		p, cursor := panes[active], panes[active].frames[shown[active]]
+		frame := e.Frame()
+		frame.CursorX, frame.CursorY = p.x+cursor.CursorX, p.y+cursor.CursorY
+		frame.CursorChar = cursor.CursorChar
+		frame.CharWidth, frame.CharHeight = cursor.CharWidth, cursor.CharHeight
+		frame.Timestamp = t
+		frames = append(frames, frame)
+	}
+	return frames, float64(count) * interval
+}
+
+// layoutPanes places the panes under their labels, side by side or stacked,
+// and returns the size of the terminal holding them.
+func layoutPanes(panes []comparePane, stacked bool) (cols, rows int) {
+	for i := range panes {
+		p := &panes[i]
+		if stacked {
+			if i > 0 {
+				rows++ // The separator.
+			}
+			p.x, p.y = 0, rows+1
+			cols, rows = max(cols, p.cols), p.y+p.rows
+			continue
+		}
+		if i > 0 {
+			cols += uniseg.StringWidth(compareGutter)
+		}
+		p.x, p.y = cols, 1
+		cols, rows = p.x+p.cols, max(rows, p.y+p.rows)
+	}
+	return cols, rows
+}
+
+// drawPane draws the frame of the pane, its label and the separator before
+// it into the screen of the emulator.
+func drawPane(e *emulator, p comparePane, frame SVGFrame) {
+	separator := CharStyle{FgColor: e.theme.BrightBlack, Width: 1}
+	switch {
+	case p.y > 1:
+		drawCells(e, 0, p.y-2, strings.Repeat("─", len(e.screen[0])), separator, len(e.screen[0]))
+	case p.x > 0:
+		for y := range e.screen {
+			drawCells(e, p.x-uniseg.StringWidth(compareGutter), y, compareGutter, separator, p.x)
+		}
+	}
+	drawCells(e, p.x, p.y-1, p.label, CharStyle{Bold: true, Width: 1}, p.x+p.cols)
+
+	for y, line := range frame.Lines {
+		if y >= p.rows {
+			break
+		}
+		var styles []CharStyle
+		if y < len(frame.LineColors) {
+			styles = frame.LineColors[y]
+		}
+		row := e.screen[p.y+y]
+		// Backgrounds span the cells past the end of the text.
+		for x, style := range styles {
+			if x < p.cols && style.Width > 0 {
+				row[p.x+x] = emuCell{Width: 1, Style: style}
+			}
+		}
+		x := 0
+		graphemes := uniseg.NewGraphemes(line)
+		for graphemes.Next() && x < p.cols {
+			style := CharStyle{Width: graphemes.Width()}
+			if x < len(styles) {
+				style = styles[x]
+			}
+			width := max(style.Width, 1)
+			if x+width > p.cols {
+				break
+			}
+			row[p.x+x] = emuCell{Text: graphemes.Str(), Width: width, Style: style}
+			for i := 1; i < width; i++ {
+				row[p.x+x+i] = emuCell{Style: style}
+			}
+			x += width
+		}
+	}
+}
+
+// drawCells writes the text of single width characters in the style into the
+// row of the screen, from the column up to the end.
+func drawCells(e *emulator, x, y int, text string, style CharStyle, end int) {
+	row := e.screen[y]
+	for _, r := range text {
+		if x >= end || x >= len(row) {
+			return
+		}
+		row[x] = emuCell{Text: string(r), Width: 1, Style: style}
+		x++
+	}
+}
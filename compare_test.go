@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCompareFrames(t *testing.T) {
+	before := newComparePane("before", []SVGFrame{
+		{Lines: []string{"$ ls"}, CursorX: 4, Timestamp: 0.5, Cols: 6, Rows: 2},
+		{Lines: []string{"$ ls", "a"}, CursorX: 1, CursorY: 1, Timestamp: 1, Cols: 6, Rows: 2},
+	})
+	after := newComparePane("after", []SVGFrame{
+		{Lines: []string{"$ ls"}, Timestamp: 0.5, Cols: 4, Rows: 3},
+		{Lines: []string{"$ ls"}, Timestamp: 1, Cols: 4, Rows: 3},
+		{Lines: []string{"$ ls", "a b", "漢x"}, CursorX: 3, CursorY: 2, Timestamp: 1.5, Cols: 4, Rows: 3},
+	})
+
+	frames, duration := compareFrames([]comparePane{before, after}, false, DefaultTheme)
+	if len(frames) != 3 || duration != 1.5 {
+		t.Fatalf("expected 3 frames over 1.5s, got %d over %vs", len(frames), duration)
+	}
+	last := frames[2]
+	want := []string{"before │ after", "$ ls   │ $ ls", "a      │ a b", "       │ 漢x"}
+	if len(last.Lines) != len(want) {
+		t.Fatalf("expected %q, got %q", want, last.Lines)
+	}
+	for i := range want {
+		if last.Lines[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], last.Lines[i])
+		}
+	}
+	if !last.LineColors[0][0].Bold || last.LineColors[1][7].FgColor != DefaultTheme.BrightBlack {
+		t.Errorf("expected a bold label and a dim separator, got %+v and %+v", last.LineColors[0][0], last.LineColors[1][7])
+	}
+	// The cursor is in the terminal which changed last.
+	if frames[1].CursorX != 1 || frames[1].CursorY != 2 || last.CursorX != 12 || last.CursorY != 3 {
+		t.Errorf("expected the cursor to follow the changes, got %d,%d and %d,%d", frames[1].CursorX, frames[1].CursorY, last.CursorX, last.CursorY)
+	}
+
+	frames, _ = compareFrames([]comparePane{before, after}, true, DefaultTheme)
+	want = []string{"before", "$ ls", "a", "──────", "after", "$ ls", "a b", "漢x"}
+	if got := frames[2].Lines; len(got) != len(want) || got[3] != want[3] || got[4] != want[4] || got[7] != want[7] {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,394 @@
+//go:build !js
+
+// Package vhs convert.go renders existing terminal recordings.
+//
+// vhs convert replays a recording, an asciicast, a ttyrec file or a typescript
+// of script(1), through the emulator of the native backend and renders the
+// frames into the outputs of VHS, with the theme, window chrome and fonts of
+// the Set commands of a tape. SVGs, transcripts and the other outputs of
+// renderers are rendered from the frames, and GIFs and videos encoded by ffmpeg
+// from the frames drawn as images. Plain text outputs, e.g. .txt, are not
+// supported.
+//
+// The frames of GIFs and videos cannot be read back as text, so those are
+// converted from what they were made from instead: the JSON recording kept
+// next to them, e.g. demo.vhs.json for demo.gif, or else their tape, which is
+// recorded again.
+//
+// vhs convert session.cast -o demo.gif -o demo.svg --tape style.tape
+// vhs convert demo.gif -o demo.svg
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+	"github.com/rivo/uniseg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertOutputs []string
+	convertTape    string
+	convertTiming  string
+	convertCols    int
+	convertRows    int
+
+	convertCmd = &cobra.Command{
+		Use:   "convert <file>",
+		Short: "Render an asciicast, ttyrec or script recording into GIFs, SVGs and other outputs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(convertOutputs) == 0 {
+				return errors.New("no outputs, e.g. -o demo.gif")
+			}
+			if err := checkOutputs(convertOutputs); err != nil {
+				return err
+			}
+			var settings []byte
+			if convertTape != "" {
+				var err error
+				settings, err = readTape(cmd, convertTape)
+				if err != nil {
+					return err
+				}
+			}
+
+			source, err := convertSource(args[0])
+			if err != nil {
+				return err
+			}
+			switch {
+			case strings.HasSuffix(source, svgRecordingExt):
+				log.Println(GrayStyle.Render("Converting " + source + "..."))
+				data, err := os.ReadFile(source) //nolint:gosec
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", source, err)
+				}
+				cfg, err := ParseSVGRecording(data)
+				if err != nil {
+					return err
+				}
+				return convertFrames(cmd.Context(), cfg, string(settings), convertOutputs)
+			case filepath.Ext(source) == extension:
+				log.Println(GrayStyle.Render("Recording " + source + " again..."))
+				tape, err := os.ReadFile(source) //nolint:gosec
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", source, err)
+				}
+				return rerecordTape(cmd.Context(), string(settings)+"\n"+string(tape), convertOutputs)
+			}
+
+			rec, err := readRecording(args[0], convertTiming)
+			if err != nil {
+				return err
+			}
+			if rec.Cols <= 0 || rec.Rows <= 0 {
+				rec.Cols, rec.Rows = convertCols, convertRows
+			}
+			log.Println(GrayStyle.Render("Converting " + args[0] + "..."))
+			return convertRecording(cmd.Context(), rec, string(settings), convertOutputs)
+		},
+	}
+)
+
+func init() {
+	convertCmd.Flags().StringSliceVarP(&convertOutputs, "output", "o", nil, "file name(s) of the outputs, e.g. demo.gif or demo.svg")
+	convertCmd.Flags().StringVarP(&convertTape, "tape", "t", "", "tape whose Set commands style the outputs, e.g. the theme and window bar")
+	convertCmd.Flags().StringVar(&convertTiming, "timing", "", "timing file of a typescript of script(1)")
+	convertCmd.Flags().IntVar(&convertCols, "cols", 80, "columns of the terminal of recordings which do not record its size") //nolint:mnd
+	convertCmd.Flags().IntVar(&convertRows, "rows", 24, "rows of the terminal of recordings which do not record its size")    //nolint:mnd
+}
+
+// convertSource returns what the file is converted from: JSON recordings are
+// converted as is, and GIFs and videos from the JSON recording or else the
+// tape of the same name. Other recordings are replayed, so the file itself is
+// returned.
+func convertSource(path string) (string, error) {
+	ext := filepath.Ext(path)
+	if ext != gif && ext != mp4 && ext != webm {
+		return path, nil
+	}
+	base := strings.TrimSuffix(path, ext)
+	for _, source := range []string{base + svgRecordingExt, base + extension} {
+		if _, err := os.Stat(source); err == nil {
+			return source, nil
+		}
+	}
+	return "", fmt.Errorf("cannot convert %s without its recording or tape, record it with an Output %s or keep %s next to it", path, base+svgRecordingExt, base+extension)
+}
+
+// readRecording reads a terminal recording by its extension, or as a
+// typescript with its timing file.
+func readRecording(path, timing string) (termRecording, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return termRecording{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	switch {
+	case timing != "":
+		t, err := os.Open(timing) //nolint:gosec
+		if err != nil {
+			return termRecording{}, fmt.Errorf("failed to open %s: %w", timing, err)
+		}
+		defer t.Close() //nolint:errcheck
+		return parseTypescript(f, t)
+	case filepath.Ext(path) == castExt:
+		return parseAsciicast(f)
+	case filepath.Ext(path) == ttyrecExt:
+		return parseTtyrec(f)
+	default:
+		return termRecording{}, fmt.Errorf("unsupported recording %s, convert reads asciicasts (%s), ttyrec files (%s) and typescripts with --timing", path, castExt, ttyrecExt)
+	}
+}
+
+// convertRecording renders the recording into the outputs, styled by the Set
+// commands of the tape.
+func convertRecording(ctx context.Context, rec termRecording, tape string, outputs []string) error {
+	v := New()
+	v.trace = ctx
+	defer func() { _ = v.Cleanup() }()
+
+	// The theme of the recording applies unless the tape sets one.
+	if rec.Theme != nil {
+		v.Options.Theme = *rec.Theme
+	}
+	if err := applyConvertSettings(&v, tape, outputs); err != nil {
+		return err
+	}
+
+	// Set MaxIdle caps the pauses of the recording like its idle time limit.
+	if maxIdle := v.Options.Video.MaxIdle.Seconds(); maxIdle > 0 && (rec.IdleTimeLimit <= 0 || maxIdle < rec.IdleTimeLimit) {
+		rec.IdleTimeLimit = maxIdle
+	}
+
+	charWidth, charHeight := nativeCellSize(v.Options)
+	v.svgFrames = replayRecording(rec, v.Options.Theme, v.Options.Video.Framerate, charWidth, charHeight)
+	v.totalFrames = len(v.svgFrames)
+	return v.renderConverted(rec.Cols, rec.Rows, charWidth, charHeight)
+}
+
+// convertFrames renders the frames of a JSON recording into the outputs, in
+// the style of the recording unless the Set commands of the tape change it.
+func convertFrames(ctx context.Context, cfg SVGConfig, tape string, outputs []string) error {
+	v := New()
+	v.trace = ctx
+	defer func() { _ = v.Cleanup() }()
+
+	v.Options.Theme = cfg.Theme
+	v.Options.FontSize = cfg.FontSize
+	v.Options.FontFamily = cfg.FontFamily
+	v.Options.LineHeight = cfg.LineHeight
+	v.Options.LetterSpacing = cfg.LetterSpacing
+	v.Options.CursorBlink = cfg.CursorBlink
+	v.Options.SVG.Description = cfg.Description
+	v.Options.Video.PlaybackSpeed = cfg.PlaybackSpeed
+	if cfg.Style != nil {
+		v.Options.Video.Style = cfg.Style
+	}
+	// The frames keep their timing at the framerate of the outputs.
+	if cfg.Duration > 0 {
+		v.Options.Video.Framerate = max(int(math.Round(float64(len(cfg.Frames))/cfg.Duration)), 1)
+	}
+	if err := applyConvertSettings(&v, tape, outputs); err != nil {
+		return err
+	}
+
+	charWidth, charHeight := nativeCellSize(v.Options)
+	if first := cfg.Frames[0]; first.CharWidth > 0 && first.CharHeight > 0 {
+		charWidth, charHeight = first.CharWidth, first.CharHeight
+	}
+	cols, rows := framesSize(cfg.Frames)
+	v.svgFrames = make([]SVGFrame, len(cfg.Frames))
+	for i, frame := range cfg.Frames {
+		if frame.Cols <= 0 || frame.Rows <= 0 {
+			frame.Cols, frame.Rows = cols, rows
+		}
+		v.svgFrames[i] = frame
+	}
+	v.totalFrames = len(v.svgFrames)
+	return v.renderConverted(cols, rows, charWidth, charHeight)
+}
+
+// framesSize returns the size of the terminal of the frames, as recorded by
+// the last frame, or else the size of their text.
+func framesSize(frames []SVGFrame) (cols, rows int) {
+	if last := frames[len(frames)-1]; last.Cols > 0 && last.Rows > 0 {
+		return last.Cols, last.Rows
+	}
+	for _, frame := range frames {
+		rows = max(rows, len(frame.Lines))
+		for _, line := range frame.Lines {
+			cols = max(cols, uniseg.StringWidth(line))
+		}
+	}
+	return max(cols, 1), max(rows, 1)
+}
+
+// rerecordTape records the tape again into the outputs instead of its own.
+func rerecordTape(ctx context.Context, tape string, outputs []string) error {
+	errs := Evaluate(ctx, withoutOutputs(tape), io.Discard, withOutputs(outputs))
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// withoutOutputs removes the Output commands of the tape, so that recording
+// it again does not overwrite its outputs.
+func withoutOutputs(tape string) string {
+	l := lexer.New(tape)
+	outputLines := map[int]bool{}
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.OUTPUT {
+			outputLines[tok.Line] = true
+		}
+	}
+	lines := strings.Split(tape, "\n")
+	kept := lines[:0]
+	for i, line := range lines {
+		if !outputLines[i+1] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// applyConvertSettings applies the Set commands of the tape, and the outputs.
+func applyConvertSettings(v *VHS, tape string, outputs []string) error {
+	v.Options.unfilteredTheme = v.Options.Theme
+	if err := applyTheme(v); err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(tape))
+	cmds := p.Parse()
+	if errs := p.Errors(); len(errs) != 0 {
+		return InvalidSyntaxError{errs}
+	}
+	for _, cmd := range cmds {
+		if cmd.Type != token.SET {
+			continue
+		}
+		if err := Execute(cmd, v); err != nil {
+			return err
+		}
+	}
+	if len(v.Errors) > 0 {
+		return errors.Join(v.Errors...)
+	}
+	withOutputs(outputs)(v)
+	return nil
+}
+
+// renderConverted renders the converted frames of a terminal of cols by rows
+// into the outputs.
+func (vhs *VHS) renderConverted(cols, rows int, charWidth, charHeight float64) error {
+	// The terminal is sized by the recording, the window around it by the tape.
+	r := newFrameRasterizer(vhs.Options.Theme, vhs.Options.FontFamily, vhs.Options.FontSize, charWidth, charHeight)
+	style := vhs.Options.Video.Style
+	style.Width, style.Height = r.Size(cols, rows)
+	style.Width += double(style.Padding)
+	style.Height += double(style.Padding)
+	if style.MarginFill != "" {
+		style.Width += double(style.Margin)
+		style.Height += double(style.Margin)
+	}
+	if style.WindowBar != "" {
+		style.Height += style.WindowBarSize
+	}
+
+	video := vhs.Options.Video.Output
+	if video.GIF == "" && video.MP4 == "" && video.WebM == "" {
+		return vhs.renderNative()
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return errors.New("ffmpeg is not installed. Install it from: http://ffmpeg.org")
+	}
+	if err := writeRasterFrames(vhs.Options.Video.Input, vhs.svgFrames, r); err != nil {
+		return err
+	}
+	return vhs.Render()
+}
+
+// replayRecording replays the events of the recording in the emulator, and
+// captures its screen at the framerate until the last event. Pauses are no
+// longer than the idle time limit of the recording.
+func replayRecording(rec termRecording, theme Theme, framerate int, charWidth, charHeight float64) []SVGFrame {
+	framerate = max(framerate, 1)
+	e := newEmulator(rec.Cols, rec.Rows, theme)
+
+	events := make([]termEvent, len(rec.Events))
+	var last, shift float64
+	for i, event := range rec.Events {
+		if gap := event.Time - last; rec.IdleTimeLimit > 0 && gap > rec.IdleTimeLimit {
+			shift += gap - rec.IdleTimeLimit
+		}
+		last = event.Time
+		events[i] = event
+		events[i].Time -= shift
+	}
+	var duration float64
+	if len(events) > 0 {
+		duration = events[len(events)-1].Time
+	}
+
+	var frames []SVGFrame
+	next := 0
+	for counter := 1; counter <= int(math.Ceil(duration*float64(framerate)))+1; counter++ {
+		t := float64(counter-1) / float64(framerate)
+		for ; next < len(events) && events[next].Time <= t; next++ {
+			if events[next].Cols > 0 {
+				e.Resize(events[next].Cols, events[next].Rows)
+			} else {
+				_, _ = e.Write([]byte(events[next].Data))
+			}
+		}
+		frame := e.Frame()
+		frame.CharWidth = charWidth
+		frame.CharHeight = charHeight
+		frame.Timestamp = float64(counter) / float64(framerate)
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// writeRasterFrames draws the frames into the text and cursor frames of the
+// directory, numbered from 1 as the frames of a recording.
+func writeRasterFrames(dir string, frames []SVGFrame, r *frameRasterizer) error {
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	write := func(format string, n int, img image.Image) error {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf(format, n)))
+		if err != nil {
+			return fmt.Errorf("failed to write frame: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+
+		return encoder.Encode(f, img) //nolint:wrapcheck
+	}
+	for i, frame := range frames {
+		text, cursor := r.Draw(frame)
+		if err := write(textFrameFormat, i+1, text); err != nil {
+			return err
+		}
+		if err := write(cursorFrameFormat, i+1, cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
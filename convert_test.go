@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayRecording(t *testing.T) {
+	rec := termRecording{
+		Cols: 10, Rows: 2, IdleTimeLimit: 1,
+		Events: []termEvent{{Time: 0, Data: "a"}, {Time: 5, Data: "b"}, {Time: 5.5, Cols: 12, Rows: 3}},
+	}
+	frames := replayRecording(rec, DefaultTheme, 10, 9, 18)
+
+	// The pause of 5s is limited to 1s, so the recording lasts 1.5s.
+	if len(frames) != 16 {
+		t.Fatalf("expected 16 frames, got %d", len(frames))
+	}
+	if frames[0].Lines[0] != "a" || frames[9].Lines[0] != "a" || frames[10].Lines[0] != "ab" {
+		t.Errorf("expected b after the limited pause, got %q, %q and %q", frames[0].Lines[0], frames[9].Lines[0], frames[10].Lines[0])
+	}
+	if last := frames[len(frames)-1]; last.Cols != 12 || last.Rows != 3 || last.Timestamp != 1.6 {
+		t.Errorf("expected the resized last frame at 1.6s, got %dx%d at %v", last.Cols, last.Rows, last.Timestamp)
+	}
+}
+
+func TestFrameRasterizer(t *testing.T) {
+	theme := DefaultTheme
+	r := newFrameRasterizer(theme, defaultFontFamily, defaultFontSize, 10, 20)
+	frame := SVGFrame{
+		Lines:      []string{"ab", ""},
+		LineColors: [][]CharStyle{{{BgColor: "#ff0000", Width: 1}, {Width: 1}}, {}},
+		CursorX:    1, CursorY: 1, Cols: 3, Rows: 2,
+	}
+	text, cursor := r.Draw(frame)
+	if b := text.Bounds(); b.Dx() != 30 || b.Dy() != 40 {
+		t.Fatalf("expected a 30x40 frame, got %v", b)
+	}
+	if got := text.RGBAAt(1, 1); got != parseHexColorOr("#ff0000", "") {
+		t.Errorf("expected the background of the cell, got %v", got)
+	}
+	if got := text.RGBAAt(25, 35); got != parseHexColorOr(theme.Background, "") {
+		t.Errorf("expected the background of the theme, got %v", got)
+	}
+	if got := cursor.RGBAAt(15, 25); got != parseHexColorOr(theme.Cursor, "") {
+		t.Errorf("expected the cursor, got %v", got)
+	}
+	if got := cursor.RGBAAt(5, 5); got.A != 0 {
+		t.Errorf("expected a transparent cursor layer, got %v", got)
+	}
+}
+
+func TestConvertRecording(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "demo"+svgRecordingExt)
+	theme := DefaultTheme
+	theme.Background = "#123456"
+	rec := termRecording{Cols: 20, Rows: 4, Theme: &theme, Events: []termEvent{{Time: 0.1, Data: "hello"}}}
+
+	if err := convertRecording(context.Background(), rec, "Set FontSize 20\nType \"ignored\"", []string{output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ParseSVGRecording(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.FontSize != 20 || cfg.Theme.Background != "#123456" {
+		t.Errorf("expected the font size of the tape and the theme of the recording, got %d and %s", cfg.FontSize, cfg.Theme.Background)
+	}
+	if len(cfg.Frames) == 0 || cfg.Frames[len(cfg.Frames)-1].Lines[0] != "hello" {
+		t.Errorf("expected the replayed output, got %v", cfg.Frames)
+	}
+
+	// The theme of the tape takes precedence.
+	if err := convertRecording(context.Background(), rec, `Set Theme "Dracula"`, []string{output}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(output)
+	dracula, _ := getTheme("Dracula")
+	if cfg, err := ParseSVGRecording(data); err != nil || cfg.Theme.Background != dracula.Background {
+		t.Errorf("expected the theme of the tape, got %+v (%v)", cfg.Theme, err)
+	}
+}
+
+func TestConvertSource(t *testing.T) {
+	dir := t.TempDir()
+	gif := filepath.Join(dir, "demo.gif")
+	if _, err := convertSource(gif); err == nil || !strings.Contains(err.Error(), "demo.tape") {
+		t.Errorf("expected an error naming the tape, got %v", err)
+	}
+
+	tape := filepath.Join(dir, "demo.tape")
+	if err := os.WriteFile(tape, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := convertSource(gif); err != nil || got != tape {
+		t.Errorf("expected the tape, got %q (%v)", got, err)
+	}
+
+	// The recording is converted rather than recording the tape again.
+	recording := filepath.Join(dir, "demo"+svgRecordingExt)
+	if err := os.WriteFile(recording, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := convertSource(filepath.Join(dir, "demo.mp4")); err != nil || got != recording {
+		t.Errorf("expected the recording, got %q (%v)", got, err)
+	}
+	if got, err := convertSource("session.cast"); err != nil || got != "session.cast" {
+		t.Errorf("expected the asciicast itself, got %q (%v)", got, err)
+	}
+}
+
+func TestConvertUnsupportedOutput(t *testing.T) {
+	defer func(outputs []string) { convertOutputs = outputs }(convertOutputs)
+
+	out := filepath.Join(t.TempDir(), "session.txt")
+	convertOutputs = []string{out}
+	if err := convertCmd.RunE(convertCmd, []string{"session.cast"}); err == nil || !strings.Contains(err.Error(), "unsupported output") {
+		t.Errorf("expected the output to be rejected, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("expected no output to be written, got %v", err)
+	}
+}
+
+func TestWithoutOutputs(t *testing.T) {
+	tape := "Output demo.gif\nSet FontSize 20\nOutput \"demo.mp4\"\nType \"Output\"\n"
+	if got, want := withoutOutputs(tape), "Set FontSize 20\nType \"Output\"\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestConvertFrames(t *testing.T) {
+	cfg, err := ParseSVGRecording([]byte(`{"theme": "Dracula", "fontSize": 18, "frames": [
+		{"lines": ["$"], "timestamp": 0.5},
+		{"lines": ["$ ls", "demo.gif"], "timestamp": 1}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "demo"+svgRecordingExt)
+	if err := convertFrames(context.Background(), cfg, "Set FontSize 20", []string{output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseSVGRecording(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FontSize != 20 || got.Theme.Background != cfg.Theme.Background {
+		t.Errorf("expected the font size of the tape and the theme of the recording, got %d and %s", got.FontSize, got.Theme.Background)
+	}
+	if len(got.Frames) != 2 || got.Frames[1].Cols != 8 || got.Frames[1].Rows != 2 || got.Duration != 1 {
+		t.Errorf("expected both frames sized by their text over 1s, got %+v over %vs", got.Frames, got.Duration)
+	}
+}
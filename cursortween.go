@@ -0,0 +1,116 @@
+// Package vhs cursortween.go slides the cursor of SVG outputs.
+//
+// The states of SVG outputs switch instantly, and their cursor jumps along
+// with them. With Set CursorTween, the cursor is drawn once above the states
+// instead, and moves between their positions with short linear transitions
+// ending when the next state shows, which makes typing look smoother. The
+// text still switches with the states. The tweened cursor does not blink.
+//
+// Set CursorTween true
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// cursorTweenDuration is the duration of the move of the cursor between two
+// states, in seconds.
+const cursorTweenDuration = 0.08
+
+// tweenCursor is the position of the cursor of a state.
+type tweenCursor struct {
+	X, Y    float64
+	Visible bool
+}
+
+// takeTweenCursors records the cursor of every state, and removes it from
+// the states so that it is drawn above them.
+func (g *SVGGenerator) takeTweenCursors() {
+	if !g.options.CursorTween {
+		return
+	}
+	g.tweenCursors = make([]tweenCursor, len(g.states))
+	for i := range g.states {
+		state := &g.states[i]
+		scale := g.stateScale(state)
+		g.tweenCursors[i] = tweenCursor{
+			X:       float64(state.CursorX) * g.charWidth * scale,
+			Y:       float64(state.CursorY) * g.charHeight * scale,
+			Visible: state.CursorChar != "" && state.CursorY >= 0 && state.CursorY < len(state.Lines),
+		}
+		state.CursorChar = ""
+	}
+}
+
+// generateCursorTweenCSS writes the keyframes moving the cursor along the
+// timeline. Every position is held until the move to the next one, which
+// ends when the next state shows.
+func (g *SVGGenerator) generateCursorTweenCSS(sb *strings.Builder, duration, delay float64) {
+	if len(g.tweenCursors) == 0 || len(g.timeline) == 0 {
+		return
+	}
+	// Stops rounded to the same percentage would only repeat themselves.
+	var last string
+	keyframe := func(percentage float64, c tweenCursor) {
+		p := formatPercentage(percentage, 2*len(g.timeline))
+		if p == last {
+			return
+		}
+		last = p
+		opacity := 0
+		if c.Visible {
+			opacity = 1
+		}
+		fmt.Fprintf(sb, "  %s%% { transform: translate(%spx, %spx); opacity: %d; }",
+			p, formatCoord(c.X), formatCoord(c.Y), opacity)
+		g.writeNewline(sb)
+	}
+
+	tween := 0.0
+	if duration > 0 {
+		tween = cursorTweenDuration / duration * 100
+	}
+	sb.WriteString("@keyframes cursor-tween {")
+	g.writeNewline(sb)
+	for i, stop := range g.timeline {
+		if i > 0 {
+			prev := g.timeline[i-1]
+			hold := max(stop.Percentage-tween, prev.Percentage)
+			if formatPercentage(hold, 2*len(g.timeline)) != formatPercentage(stop.Percentage, 2*len(g.timeline)) {
+				keyframe(hold, g.tweenCursors[prev.StateIndex])
+			}
+		}
+		keyframe(stop.Percentage, g.tweenCursors[stop.StateIndex])
+	}
+	sb.WriteString("}")
+	g.writeNewline(sb)
+
+	fmt.Fprintf(sb, ".%s { animation: cursor-tween %ss linear %ss infinite; }",
+		g.cursorTweenClass(), formatDuration(duration), formatDuration(delay))
+	g.writeNewline(sb)
+}
+
+// generateTweenCursor writes the cursor moved by the keyframes.
+func (g *SVGGenerator) generateTweenCursor(sb *strings.Builder) {
+	if len(g.tweenCursors) == 0 || len(g.timeline) == 0 {
+		return
+	}
+	color := g.options.CursorColor
+	if color == cursorReverse {
+		color = ""
+	}
+	fmt.Fprintf(sb, `<rect class="%s" width="%s" height="%s" fill="%s"/>`,
+		g.cursorTweenClass(), formatCoord(g.charWidth), formatCoord(g.charHeight),
+		cmp.Or(color, g.options.Theme.Foreground, defaultCursorColor))
+	g.writeNewline(sb)
+}
+
+// cursorTweenClass returns the class of the tweened cursor.
+func (g *SVGGenerator) cursorTweenClass() string {
+	if g.options.OptimizeSize {
+		return "ct"
+	}
+	return "cursor-tween"
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestSVGGenerator_CursorTween(t *testing.T) {
+	frames := []SVGFrame{
+		{Lines: []string{"a"}, CursorX: 1, CursorChar: "█", CharWidth: 10, CharHeight: 20},
+		{Lines: []string{"ab"}, CursorX: 2, CursorChar: "█", CharWidth: 10, CharHeight: 20},
+	}
+	opts := createTestSVGConfig()
+	opts.Frames = frames
+	opts.CursorTween = true
+	opts.CursorColor = "#ff00ff"
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	if strings.Contains(svg, "█") {
+		t.Error("expected the cursor to be removed from the states")
+	}
+	assertContains(t, svg, `<rect class="cursor-tween" width="10" height="20" fill="#ff00ff"/>`, "The cursor is drawn once")
+	assertContains(t, svg, `@keyframes cursor-tween`, "The cursor has its own keyframes")
+	assertContains(t, svg, `transform: translate(10px, 0px); opacity: 1;`, "The cursor starts after the first character")
+	assertContains(t, svg, `transform: translate(20px, 0px); opacity: 1;`, "The cursor moves after the second character")
+	assertContains(t, svg, `animation: cursor-tween `, "The cursor is animated")
+	if strings.Count(svg, "translate(10px, 0px)") != 2 {
+		t.Errorf("expected the first position to be held until the move, got:\n%s", svg)
+	}
+
+	opts.CursorTween = false
+	if svg := NewSVGGenerator(opts).Generate(); strings.Contains(svg, "cursor-tween") {
+		t.Error("expected no tweened cursor by default")
+	}
+}
+
+func TestExecuteSetCursorTween(t *testing.T) {
+	v := &VHS{Options: &Options{}}
+	if err := ExecuteSetCursorTween(parser.Command{Args: "true"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Options.SVG.CursorTween {
+		t.Error("expected the cursor tween to be enabled")
+	}
+	if err := ExecuteSetCursorTween(parser.Command{Args: "maybe"}, v); err == nil {
+		t.Error("expected an error for an invalid boolean")
+	}
+}
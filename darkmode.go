@@ -0,0 +1,68 @@
+// Package vhs darkmode.go renders SVG outputs which follow the color scheme
+// of the viewer.
+//
+// With a dark theme, the SVG is recorded in the theme and switches to the
+// dark theme with a prefers-color-scheme media query: every color of the
+// theme is replaced by the color of the same name in the dark theme, so
+// that a single file fits both the light and dark modes of GitHub READMEs.
+// Colors outside of the themes, e.g. from 256-color palettes, are kept.
+//
+// Set Theme "Catppuccin Latte"
+// Set ThemeDark "Catppuccin Mocha"
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generateDarkThemeCSS writes the media query replacing the colors of the
+// theme with the colors of the dark theme.
+func (g *SVGGenerator) generateDarkThemeCSS(sb *strings.Builder) {
+	if g.options.DarkTheme == nil {
+		return
+	}
+	light, dark := g.options.Theme, *g.options.DarkTheme
+
+	sb.WriteString("@media (prefers-color-scheme: dark) {")
+	g.writeNewline(sb)
+
+	// The text and color classes.
+	fmt.Fprintf(sb, ".%s { fill: %s; }", g.textClass, dark.Foreground)
+	g.writeNewline(sb)
+	classes := [][2]string{
+		{"black", dark.Black}, {"red", dark.Red}, {"green", dark.Green}, {"yellow", dark.Yellow},
+		{"blue", dark.Blue}, {"magenta", dark.Magenta}, {"cyan", dark.Cyan}, {"white", dark.White},
+	}
+	if g.options.OptimizeSize {
+		classes = [][2]string{
+			{"k", dark.Black}, {"r", dark.Red}, {"g", dark.Green}, {"y", dark.Yellow},
+			{"l", dark.Blue}, {"m", dark.Magenta}, {"c", dark.Cyan}, {"w", dark.White},
+			{"p", dark.BrightBlue},
+		}
+	}
+	for _, class := range classes {
+		fmt.Fprintf(sb, ".%s { fill: %s; }", class[0], class[1])
+		g.writeNewline(sb)
+	}
+
+	// The colors of the elements, in fill attributes and inline styles,
+	// which only important declarations override. A color shared by several
+	// entries of the theme takes the dark color of the first.
+	seen := map[string]bool{}
+	darkColors := themeColors(&dark)
+	for i, c := range themeColors(&light) {
+		from, to := strings.ToLower(*c.Color), *darkColors[i].Color
+		if from == "" || to == "" || seen[from] {
+			continue
+		}
+		seen[from] = true
+		fmt.Fprintf(sb, `[fill="%s" i] { fill: %s; }`, from, to)
+		g.writeNewline(sb)
+		fmt.Fprintf(sb, `[style*="fill:%s" i] { fill: %s !important; }`, from, to)
+		g.writeNewline(sb)
+	}
+
+	sb.WriteString("}")
+	g.writeNewline(sb)
+}
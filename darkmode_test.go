@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSVGGenerator_DarkTheme(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Theme = Theme{Background: "#EFF1F5", Foreground: "#4c4f69", Black: "#eff1f5", Red: "#d20f39"}
+	opts.DarkTheme = &Theme{Background: "#1e1e2e", Foreground: "#cdd6f4", Black: "#45475a", Red: "#f38ba8"}
+	opts.Frames[0].LineColors = [][]CharStyle{{{FgColor: "#d20f39"}}}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, "@media (prefers-color-scheme: dark) {", "Media query")
+	assertContains(t, svg, ".f { fill: #cdd6f4; }", "Dark foreground")
+	assertContains(t, svg, ".red { fill: #f38ba8; }", "Dark color class")
+	assertContains(t, svg, `[fill="#eff1f5" i] { fill: #1e1e2e; }`, "Dark background")
+	assertContains(t, svg, `[style*="fill:#d20f39" i] { fill: #f38ba8 !important; }`, "Dark inline colors")
+	if strings.Contains(svg, `[fill="#eff1f5" i] { fill: #45475a; }`) {
+		t.Error("expected black, the same color as the background, to take the dark background")
+	}
+
+	opts.DarkTheme = nil
+	assertNotContains(t, NewSVGGenerator(opts).Generate(), "prefers-color-scheme", "No media query without a dark theme")
+}
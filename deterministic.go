@@ -0,0 +1,82 @@
+//go:build !js
+
+// Package vhs deterministic.go makes recordings reproducible.
+//
+// Frames are normally captured on a wall clock ticker, so the number of
+// frames showing each step varies between runs. In deterministic mode frames
+// are captured on a virtual clock instead: each delay of a command (typing
+// speed, key repeats and sleeps) is waited for, then the settled terminal is
+// written as exactly as many frames as the delay lasts. The shell runs with a
+// fixed time zone and, if libfaketime is installed, a fixed clock, and ffmpeg
+// encodes without timestamps or version metadata. Two runs of the same tape
+// then produce byte-identical outputs, which makes them usable as golden
+// files.
+//
+// vhs --deterministic demo.tape
+package main
+
+import (
+	"log"
+	"os/exec"
+	"time"
+)
+
+// deterministicTime is the time the clock of the shell starts at in
+// deterministic mode.
+const deterministicTime = "2000-01-01 00:00:00"
+
+// deterministicEnv is added to the environment of the shell in deterministic
+// mode.
+var deterministicEnv = []string{
+	"TZ=UTC",
+	"SOURCE_DATE_EPOCH=946684800",
+}
+
+// deterministicFFopts strip the encoder version and timestamps from the
+// outputs and avoid the frame order of multithreaded encoding.
+var deterministicFFopts = []string{
+	"-fflags", "+bitexact",
+	"-flags:v", "+bitexact",
+	"-map_metadata", "-1",
+	"-threads", "1",
+}
+
+// WithDeterministic returns an EvaluatorOption that records on a virtual
+// clock so that the outputs are reproducible.
+func WithDeterministic(deterministic bool) EvaluatorOption {
+	return func(v *VHS) {
+		v.Options.Video.Deterministic = deterministic
+	}
+}
+
+// deterministicSandbox returns the sandbox with a fixed time zone and, if
+// faketime is installed, a fixed clock.
+func deterministicSandbox(sandbox ShellSandbox) ShellSandbox {
+	sandbox.Env = append(append([]string{}, sandbox.Env...), deterministicEnv...)
+	if _, err := exec.LookPath("faketime"); err != nil {
+		log.Println(ErrorStyle.Render("faketime is not installed, the clock of the shell is not fixed"))
+		return sandbox
+	}
+	sandbox.Command = append([]string{"faketime", deterministicTime}, sandbox.Command...)
+	return sandbox
+}
+
+// sleep pauses the commands for d. In deterministic mode, the frames covered
+// by d are captured at the end of the pause from the settled terminal.
+func (vhs *VHS) sleep(d time.Duration) error {
+	select {
+	case <-time.After(d):
+	case <-vhs.limits.Done():
+		return vhs.limits.Err()
+	}
+	if !vhs.Options.Video.Deterministic || !vhs.recording || vhs.Page == nil && vhs.native == nil {
+		return nil
+	}
+
+	vhs.clock += d
+	frames := int(vhs.clock * time.Duration(vhs.Options.Video.Framerate) / time.Second)
+	if n := frames - vhs.totalFrames - vhs.idle.skipped; n > 0 {
+		return vhs.captureFrames(n)
+	}
+	return nil
+}
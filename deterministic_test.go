@@ -0,0 +1,54 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildFFoptsDeterministic(t *testing.T) {
+	opts := DefaultVideoOptions()
+	opts.Style = DefaultStyleOptions()
+	opts.Deterministic = true
+	args := buildFFopts(opts, "out.mp4")
+
+	if args[len(args)-1] != "out.mp4" {
+		t.Fatalf("expected output last, got %v", args)
+	}
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"-fflags +bitexact", "-flags:v +bitexact", "-map_metadata -1", "-threads 1"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in %s", want, joined)
+		}
+	}
+
+	opts.Deterministic = false
+	if slices.Contains(buildFFopts(opts, "out.mp4"), "+bitexact") {
+		t.Error("expected bitexact only in deterministic mode")
+	}
+}
+
+func TestBuildTtyCmdEnv(t *testing.T) {
+	cmd := buildTtyCmd(7681, Shells[bash], ShellSandbox{Env: []string{"TZ=UTC"}})
+	if cmd.Env[len(cmd.Env)-1] != "TZ=UTC" {
+		t.Errorf("expected sandbox environment to take precedence, got %v", cmd.Env)
+	}
+}
+
+func TestSVGGenerator_Reproducible(t *testing.T) {
+	for _, optimize := range []bool{false, true} {
+		opts := createTestSVGConfig()
+		opts.OptimizeSize = optimize
+		opts.Frames = []SVGFrame{
+			{Lines: []string{"$ ls"}, CursorX: 4, CharWidth: 10, CharHeight: 20},
+			{Lines: []string{"$ ls", "demo.tape"}, CursorY: 1, Timestamp: 0.5, CharWidth: 10, CharHeight: 20},
+		}
+
+		first := NewSVGGenerator(opts).Generate()
+		for i := 0; i < 5; i++ {
+			if NewSVGGenerator(opts).Generate() != first {
+				t.Fatalf("expected identical SVGs (optimize: %t)", optimize)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+//go:build !js
+
+// Package vhs diagnostics.go writes a diagnostics bundle when a recording
+// fails.
+//
+// Recordings fail for reasons which are hard to reproduce, e.g. a shell which
+// crashed, a browser which disconnected or an output which failed to encode,
+// mostly on CI. Once the terminal is started, a failed recording writes a
+// vhs-crash-<time> directory with what it had reached: the last captured
+// frame, the text of the terminal, the line of the tape and the errors, and
+// the last lines logged while recording.
+//
+// vhs demo.tape --diagnostics ./artifacts
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// diagnosticsLogLines is the number of lines of the log kept.
+	diagnosticsLogLines = 200
+	// diagnosticsTimeout bounds reading the terminal of a browser which may
+	// be gone.
+	diagnosticsTimeout = 5 * time.Second
+)
+
+// diagnostics keeps what a recording reached, for its diagnostics bundle.
+type diagnostics struct {
+	// dir is the directory the bundle is written in.
+	dir string
+	// command is the last command executed.
+	command parser.Command
+	written bool
+
+	mu  sync.Mutex
+	log []string
+}
+
+// WithDiagnostics returns an EvaluatorOption which writes a diagnostics
+// bundle in the directory if the recording fails. Options are applied again
+// after the tape, which keeps what the recording reached.
+func WithDiagnostics(dir string) EvaluatorOption {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return func(v *VHS) {
+		if v.diagnostics == nil {
+			v.diagnostics = &diagnostics{dir: dir}
+		}
+	}
+}
+
+// Write keeps the last lines of the log, without their styles.
+func (d *diagnostics) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		d.log = append(d.log, ansi.Strip(line))
+	}
+	if len(d.log) > diagnosticsLogLines {
+		d.log = d.log[len(d.log)-diagnosticsLogLines:]
+	}
+	return len(p), nil
+}
+
+// reached records the command being executed.
+func (d *diagnostics) reached(c parser.Command) {
+	if d != nil {
+		d.command = c
+	}
+}
+
+// diagnose writes the diagnostics bundle of the recording if it failed once
+// its terminal was started. Interrupted recordings did not fail.
+func (vhs *VHS) diagnose(errs []error) {
+	d := vhs.diagnostics
+	if d == nil || d.written || len(errs) == 0 || (vhs.Page == nil && vhs.native == nil) {
+		return
+	}
+	err := errors.Join(errs...)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	d.written = true
+
+	dir := filepath.Join(d.dir, "vhs-crash-"+time.Now().Format("20060102-150405"))
+	if err := vhs.writeDiagnostics(dir, err); err != nil {
+		log.Println(ErrorStyle.Render("Failed to write diagnostics: " + err.Error()))
+		return
+	}
+	log.Println(GrayStyle.Render("Diagnostics written to " + dir))
+}
+
+// writeDiagnostics writes the bundle of the failed recording in the
+// directory.
+func (vhs *VHS) writeDiagnostics(dir string, failure error) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	d := vhs.diagnostics
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "VHS %s, %s backend, %s\n\n", cmp.Or(Version, "dev"), cmp.Or(vhs.Options.Backend, browserBackend), time.Now().Format(time.RFC3339))
+	if d.command.Type != "" {
+		fmt.Fprintf(&report, "Reached line %d: %s\n\n", d.command.Line, ansi.Strip(Highlight(d.command, false)))
+	}
+	fmt.Fprintf(&report, "%s\n", ansi.Strip(failure.Error()))
+
+	d.mu.Lock()
+	logs := strings.Join(d.log, "\n") + "\n"
+	d.mu.Unlock()
+
+	files := map[string][]byte{
+		"report.txt": []byte(report.String()),
+		"log.txt":    []byte(logs),
+	}
+	if lines, err := vhs.diagnosticsBuffer(); err == nil {
+		files["terminal.txt"] = []byte(strings.Join(lines, "\n") + "\n")
+	} else {
+		files["terminal.txt"] = []byte("failed to read the terminal: " + err.Error() + "\n")
+	}
+	if frame := vhs.diagnosticsFrame(); frame != nil {
+		files["frame.png"] = frame
+	}
+
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// diagnosticsBuffer returns the text of the terminal, waiting no longer than
+// diagnosticsTimeout for the browser.
+func (vhs *VHS) diagnosticsBuffer() ([]string, error) {
+	if vhs.native == nil {
+		page := vhs.Page
+		vhs.Page = page.Timeout(diagnosticsTimeout)
+		defer func() { vhs.Page = page }()
+	}
+	return vhs.Buffer()
+}
+
+// diagnosticsFrame returns the last captured frame as a PNG image, if any.
+func (vhs *VHS) diagnosticsFrame() []byte {
+	if vhs.native == nil {
+		return vhs.lastCapture.text
+	}
+
+	frame := vhs.native.frame()
+	r := newFrameRasterizer(vhs.Options.Theme, vhs.Options.FontFamily, vhs.Options.FontSize, frame.CharWidth, frame.CharHeight)
+	text, cursor := r.Draw(frame)
+	draw.Draw(text, text.Bounds(), cursor, image.Point{}, draw.Over)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, text); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
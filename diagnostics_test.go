@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+)
+
+func TestDiagnose(t *testing.T) {
+	dir := t.TempDir()
+	v := New()
+	WithDiagnostics(dir)(&v)
+	v.native = &nativeTerminal{screen: newEmulator(20, 3, DefaultTheme), charWidth: 9, charHeight: 18}
+	_, _ = v.native.screen.Write([]byte("$ make\r\nSegmentation fault"))
+	_, _ = v.diagnostics.Write([]byte("\x1b[1mType\x1b[0m make\nEnter 1\n"))
+	v.diagnostics.reached(parser.Command{Type: token.WAIT, Options: "Screen", Args: "done", Line: 7})
+	// Options are applied again before rendering, which can fail too.
+	WithDiagnostics(dir)(&v)
+
+	v.diagnose([]error{errors.New("timeout waiting for done")})
+	bundles, _ := filepath.Glob(filepath.Join(dir, "vhs-crash-*"))
+	if len(bundles) != 1 {
+		t.Fatalf("expected a diagnostics bundle, got %v", bundles)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(bundles[0], name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if report := read("report.txt"); !strings.Contains(report, "Reached line 7: Wait Screen done") || !strings.Contains(report, "timeout waiting for done") {
+		t.Errorf("expected the line reached and the error, got %q", report)
+	}
+	if terminal := read("terminal.txt"); !strings.Contains(terminal, "Segmentation fault") {
+		t.Errorf("expected the text of the terminal, got %q", terminal)
+	}
+	if logs := read("log.txt"); logs != "Type make\nEnter 1\n" {
+		t.Errorf("expected the log without styles, got %q", logs)
+	}
+	if frame := read("frame.png"); !strings.HasPrefix(frame, "\x89PNG") {
+		t.Error("expected the last frame as a PNG image")
+	}
+
+	// The bundle is written once.
+	v.diagnose([]error{errors.New("failed again")})
+	if bundles, _ := filepath.Glob(filepath.Join(dir, "vhs-crash-*")); len(bundles) != 1 {
+		t.Errorf("expected a single bundle, got %v", bundles)
+	}
+}
+
+func TestDiagnoseSkipped(t *testing.T) {
+	dir := t.TempDir()
+	v := New()
+	WithDiagnostics(dir)(&v)
+	// The terminal was never started.
+	v.diagnose([]error{errors.New("invalid tape")})
+
+	v.native = &nativeTerminal{screen: newEmulator(20, 3, DefaultTheme), charWidth: 9, charHeight: 18}
+	v.diagnose([]error{context.Canceled})
+	v.diagnose(nil)
+	if bundles, _ := filepath.Glob(filepath.Join(dir, "vhs-crash-*")); len(bundles) != 0 {
+		t.Errorf("expected no bundle, got %v", bundles)
+	}
+}
@@ -0,0 +1,116 @@
+//go:build !js
+
+// Package vhs doctor.go checks the external dependencies of VHS.
+//
+// vhs doctor verifies that every program VHS runs is installed and recent
+// enough, and prints how to fix the ones which are not.
+//
+// vhs doctor
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+)
+
+// check is a dependency verified by vhs doctor.
+type check struct {
+	Name string
+	// Required checks fail the doctor command, optional ones only warn.
+	Required bool
+	// Run returns a description of the dependency, e.g. its path, or an
+	// error explaining how to install it.
+	Run func() (string, error)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the programs VHS needs are installed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		out := cmd.OutOrStdout()
+		if failed := runChecks(out, doctorChecks(offlineFlag || offlineFromEnv())); failed > 0 {
+			_, _ = fmt.Fprintln(out, FaintStyle.Render("\nSVG and text outputs can be recorded without ffmpeg, ttyd and Chromium with --backend native."))
+			return fmt.Errorf("%d required dependencies are missing", failed)
+		}
+		return nil
+	},
+}
+
+// doctorChecks returns the dependencies of VHS.
+func doctorChecks(offline bool) []check {
+	return []check{
+		{Name: "ffmpeg", Required: true, Run: func() (string, error) {
+			return lookPath("ffmpeg", "Install it from: https://ffmpeg.org/download.html")
+		}},
+		{Name: "ttyd", Required: true, Run: checkTtyd},
+		{Name: "chromium", Required: true, Run: func() (string, error) {
+			path, err := browserPath(offline)
+			if err != nil {
+				return "", err
+			}
+			if path == "" {
+				return "not installed, it will be downloaded on the first recording", nil
+			}
+			return path, nil
+		}},
+		{Name: defaultShell, Required: true, Run: func() (string, error) {
+			return lookPath(defaultShell, "Install it with your package manager")
+		}},
+		{Name: "fonts", Run: func() (string, error) {
+			if _, err := getFontLoader().LoadFont(defaultFontFamily, defaultFontSize); err != nil {
+				return "", errors.New("none of the default fonts is installed, install JetBrains Mono or DejaVu Sans Mono, " +
+					"or use --offline to fall back to the bundled " + bundledFontFamily)
+			}
+			return "installed", nil
+		}},
+		{Name: "faketime", Run: func() (string, error) {
+			return lookPath("faketime", "Install libfaketime to fix the clock of the shell with --deterministic")
+		}},
+	}
+}
+
+// runChecks prints the result of every check and returns the number of
+// failed required checks.
+func runChecks(out io.Writer, checks []check) int {
+	failed := 0
+	for _, c := range checks {
+		detail, err := c.Run()
+		switch {
+		case err == nil:
+			_, _ = fmt.Fprintf(out, "%s %-10s %s\n", StringStyle.Render("✓"), c.Name, detail)
+		case c.Required:
+			failed++
+			_, _ = fmt.Fprintf(out, "%s %-10s %s\n", ErrorStyle.Render("✗"), c.Name, err)
+		default:
+			_, _ = fmt.Fprintf(out, "%s %-10s %s\n", FaintStyle.Render("-"), c.Name, err)
+		}
+	}
+	return failed
+}
+
+// lookPath returns the path of the program, or an error with the hint.
+func lookPath(program, hint string) (string, error) {
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return "", fmt.Errorf("not installed. %s", hint)
+	}
+	return path, nil
+}
+
+func checkTtyd() (string, error) {
+	path, err := lookPath("ttyd", "Install it from: https://github.com/tsl0922/ttyd")
+	if err != nil {
+		return "", err
+	}
+	v := getVersion("ttyd")
+	if v == nil || v.LessThan(ttydMinVersion) {
+		return "", fmt.Errorf("version %s is out of date, VHS requires %s. Install the latest version from: https://github.com/tsl0922/ttyd",
+			v, ttydMinVersion)
+	}
+	return fmt.Sprintf("%s (%s)", path, v), nil
+}
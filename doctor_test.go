@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunChecks(t *testing.T) {
+	var buf bytes.Buffer
+	failed := runChecks(&buf, []check{
+		{Name: "found", Required: true, Run: func() (string, error) { return "/usr/bin/found", nil }},
+		{Name: "missing", Required: true, Run: func() (string, error) { return "", errors.New("install missing") }},
+		{Name: "optional", Run: func() (string, error) { return "", errors.New("install optional") }},
+	})
+	if failed != 1 {
+		t.Errorf("expected 1 failed check, got %d", failed)
+	}
+	for _, want := range []string{"/usr/bin/found", "install missing", "install optional"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
@@ -233,14 +233,25 @@ const (
 	barToDotBorderRatio = 5
 )
 
+// defaultWindowBarTitleColor is the color of window bar titles.
+const defaultWindowBarTitleColor = "#cccccc"
+
+// windowControlMetrics returns the radius of the window controls, the space
+// between the first control and the edge of the bar, and the space between
+// the centers of the controls, which all scale with the size of the bar.
+func windowControlMetrics(barSize int, rings bool) (radius, gap, spacing int) {
+	radius = barSize / barToDotRatio
+	if rings {
+		radius = barSize / barToDotBorderRatio
+	}
+	gap = half(barSize - double(radius))
+	spacing = double(radius) + barSize/barToDotRatio
+	return radius, gap, spacing
+}
+
 func makeColorfulBar(termWidth int, termHeight int, isRight bool, opts StyleOptions, targetpng string) error {
-	// Radius of dots
-	dotRad := opts.WindowBarSize / barToDotRatio
-	dotDia := double(dotRad)
-	// Space between dots and edge
-	dotGap := half(opts.WindowBarSize - dotDia)
-	// Space between dot centers
-	dotSpace := dotDia + opts.WindowBarSize/barToDotRatio
+	// Radius of dots, space between dots and edge, and between dot centers
+	dotRad, dotGap, dotSpace := windowControlMetrics(opts.WindowBarSize, false)
 
 	// Dimensions of bar image
 	width := termWidth
@@ -318,14 +329,9 @@ func makeColorfulBar(termWidth int, termHeight int, isRight bool, opts StyleOpti
 }
 
 func makeRingBar(termWidth int, termHeight int, isRight bool, opts StyleOptions, targetpng string) error {
-	// Radius of dots
-	outerRad := opts.WindowBarSize / barToDotBorderRatio
-	outerDia := double(outerRad)
-	innerRad := double(outerDia) / barToDotBorderRatio
-	// Space between dots and edge
-	ringGap := half(opts.WindowBarSize - outerDia)
-	// Space between dot centers
-	ringSpace := outerDia + opts.WindowBarSize/barToDotRatio
+	// Radius of dots, space between dots and edge, and between dot centers
+	outerRad, ringGap, ringSpace := windowControlMetrics(opts.WindowBarSize, true)
+	innerRad := double(double(outerRad)) / barToDotBorderRatio
 
 	// Dimensions of bar image
 	width := termWidth
@@ -420,6 +426,16 @@ func parseHexColor(s string) (c color.RGBA, err error) {
 	return
 }
 
+// parseHexColorOr parses a color, falling back to another one if it is
+// invalid.
+func parseHexColorOr(s, fallback string) color.RGBA {
+	c, err := parseHexColor(s)
+	if err != nil {
+		c, _ = parseHexColor(fallback)
+	}
+	return c
+}
+
 // drawWindowBarTitle draws the window bar title with proper font selection and positioning.
 func drawWindowBarTitle(img draw.Image, opts StyleOptions) {
 	if opts.WindowBarTitle == "" {
@@ -439,7 +455,7 @@ func drawWindowBarTitle(img draw.Image, opts StyleOptions) {
 	}
 
 	font := getWindowBarFont(fontFamily, fontSize)
-	textColor := &image.Uniform{color.RGBA{0xCC, 0xCC, 0xCC, 0xFF}}
+	textColor := &image.Uniform{parseHexColorOr(opts.WindowBarTitleColor, defaultWindowBarTitleColor)}
 	y := getTextYPositionForFont(opts.WindowBarSize, font, int(fontSize))
 	drawCenteredText(img, font, opts.WindowBarTitle, y, textColor)
 }
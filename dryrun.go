@@ -0,0 +1,232 @@
+//go:build !js
+
+// Package vhs dryrun.go estimates the timeline of a tape without recording.
+//
+// vhs --dry-run walks the commands of a tape and sums their delays (typing
+// speed, key repeats and sleeps) to estimate how long the recording and the
+// resulting video will be, which outputs will be written and which programs
+// are needed to render them. Nothing is launched, so long renders can be
+// tuned quickly.
+//
+// vhs --dry-run demo.tape
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// repeatableKeys are the commands which sleep for the typing speed after
+// each repeat.
+var repeatableKeys = map[parser.CommandType]bool{
+	token.BACKSPACE:   true,
+	token.DELETE:      true,
+	token.INSERT:      true,
+	token.DOWN:        true,
+	token.ENTER:       true,
+	token.ESCAPE:      true,
+	token.LEFT:        true,
+	token.PAGE_UP:     true,
+	token.PAGE_DOWN:   true,
+	token.RIGHT:       true,
+	token.SPACE:       true,
+	token.TAB:         true,
+	token.UP:          true,
+	token.SCROLL_UP:   true,
+	token.SCROLL_DOWN: true,
+}
+
+// timelineEntry is the estimated duration of a command.
+type timelineEntry struct {
+	Command  parser.Command
+	Duration time.Duration
+	// MaxWait is the timeout of Wait commands, whose duration depends on
+	// the output of the terminal.
+	MaxWait time.Duration
+	Hidden  bool
+}
+
+// dependency is a program needed to render the tape.
+type dependency struct {
+	Name string
+	Path string
+}
+
+// dryRunPlan is the estimated timeline and outputs of a tape.
+type dryRunPlan struct {
+	Entries       []timelineEntry
+	Recorded      time.Duration
+	Hidden        time.Duration
+	MaxWait       time.Duration
+	PlaybackSpeed float64
+	Outputs       []string
+	Dependencies  []dependency
+}
+
+// planTape estimates the timeline of the commands, applying the settings
+// which change it as they appear.
+func planTape(cmds []parser.Command) (dryRunPlan, error) {
+	v := New()
+	var plan dryRunPlan
+	var requires []string
+	hidden := false
+
+	for _, cmd := range cmds {
+		entry := timelineEntry{Command: cmd, Hidden: hidden}
+		var err error
+
+		switch {
+		case cmd.Type == token.SET:
+			switch cmd.Options {
+			case "TypingSpeed", "WaitTimeout", "PlaybackSpeed", "Shell":
+				err = Execute(cmd, &v)
+			}
+		case cmd.Type == token.OUTPUT:
+			err = ExecuteOutput(cmd, &v)
+			plan.Outputs = append(plan.Outputs, cmd.Args)
+		case cmd.Type == token.SCREENSHOT:
+			plan.Outputs = append(plan.Outputs, cmd.Args)
+		case cmd.Type == token.REQUIRE:
+			requires = append(requires, cmd.Args)
+		case cmd.Type == token.HIDE:
+			hidden = true
+		case cmd.Type == token.SHOW:
+			hidden = false
+			entry.Hidden = false
+		case cmd.Type == token.SLEEP:
+			entry.Duration, err = time.ParseDuration(cmd.Args)
+		case cmd.Type == token.TYPE:
+			entry.Duration = typingDelay(cmd, v.Options.TypingSpeed) * time.Duration(len([]rune(cmd.Args)))
+		case cmd.Type == token.WAIT:
+			entry.MaxWait = v.Options.WaitTimeout
+			if cmd.Options != "" {
+				entry.MaxWait, err = time.ParseDuration(cmd.Options)
+			}
+		case cmd.Type == token.WAIT_EXIT:
+			entry.MaxWait = v.Options.WaitTimeout
+			if cmd.Args != "" {
+				entry.MaxWait, err = time.ParseDuration(cmd.Args)
+			}
+		case repeatableKeys[cmd.Type]:
+			repeat, convErr := strconv.Atoi(cmd.Args)
+			if convErr != nil {
+				repeat = 1
+			}
+			entry.Duration = typingDelay(cmd, v.Options.TypingSpeed) * time.Duration(repeat)
+		}
+		if err != nil {
+			return plan, fmt.Errorf("failed to estimate %s: %w", cmd.Type, err)
+		}
+
+		if entry.Hidden {
+			plan.Hidden += entry.Duration
+		} else {
+			plan.Recorded += entry.Duration
+		}
+		plan.MaxWait += entry.MaxWait
+		plan.Entries = append(plan.Entries, entry)
+	}
+
+	plan.PlaybackSpeed = v.Options.Video.PlaybackSpeed
+	if plan.PlaybackSpeed <= 0 {
+		plan.PlaybackSpeed = defaultPlaybackSpeed
+	}
+
+	programs := []string{"ffmpeg", "ttyd"}
+	if command := v.Options.Shell.Command(); len(command) > 0 {
+		programs = append(programs, command[0])
+	}
+	for _, name := range append(programs, requires...) {
+		path, _ := exec.LookPath(name)
+		plan.Dependencies = append(plan.Dependencies, dependency{Name: name, Path: path})
+	}
+	browser, _ := launcher.LookPath()
+	plan.Dependencies = append(plan.Dependencies, dependency{Name: "chromium", Path: browser})
+
+	return plan, nil
+}
+
+// dryRun prints the estimated timeline of the tape.
+func dryRun(out io.Writer, tape string) error {
+	p := parser.New(lexer.New(tape))
+	cmds := p.Parse()
+	if errs := p.Errors(); len(errs) > 0 {
+		printErrors(os.Stderr, tape, []error{InvalidSyntaxError{errs}})
+		return errors.New("invalid tape")
+	}
+
+	plan, err := planTape(cmds)
+	if err != nil {
+		return err
+	}
+	plan.Print(out)
+	return nil
+}
+
+// typingDelay returns the delay after each key of the command.
+func typingDelay(cmd parser.Command, typingSpeed time.Duration) time.Duration {
+	if d, err := time.ParseDuration(cmd.Options); err == nil {
+		return d
+	}
+	return typingSpeed
+}
+
+// VideoLength returns the estimated length of the rendered video.
+func (p dryRunPlan) VideoLength() time.Duration {
+	return time.Duration(float64(p.Recorded) / p.PlaybackSpeed)
+}
+
+// Print writes the plan as a human readable report.
+func (p dryRunPlan) Print(out io.Writer) {
+	_, _ = fmt.Fprintln(out, "Timeline:")
+	for _, e := range p.Entries {
+		if e.Duration == 0 && e.MaxWait == 0 {
+			continue
+		}
+		d := e.Duration.String()
+		if e.MaxWait > 0 {
+			d = "≤ " + e.MaxWait.String()
+		}
+		_, _ = fmt.Fprintf(out, "  %-10s %s\n", d, Highlight(e.Command, e.Hidden))
+	}
+
+	_, _ = fmt.Fprintf(out, "\nEstimated recording: %s (%s hidden)\n", p.Recorded+p.Hidden, p.Hidden)
+	_, _ = fmt.Fprintf(out, "Estimated video length: %s", p.VideoLength())
+	if p.PlaybackSpeed != defaultPlaybackSpeed {
+		_, _ = fmt.Fprintf(out, " at %gx playback speed", p.PlaybackSpeed)
+	}
+	_, _ = fmt.Fprintln(out)
+	if p.MaxWait > 0 {
+		_, _ = fmt.Fprintf(out, "Wait commands may add up to %s.\n", p.MaxWait)
+	}
+
+	_, _ = fmt.Fprintln(out, "\nOutputs:")
+	if len(p.Outputs) == 0 {
+		_, _ = fmt.Fprintln(out, "  none")
+	}
+	for _, o := range p.Outputs {
+		_, _ = fmt.Fprintf(out, "  %s\n", o)
+	}
+
+	_, _ = fmt.Fprintln(out, "\nDependencies:")
+	for _, d := range p.Dependencies {
+		path := d.Path
+		if path == "" {
+			path = ErrorStyle.Render("not found")
+			if d.Name == "chromium" {
+				path = "not found, it will be downloaded"
+			}
+		}
+		_, _ = fmt.Fprintf(out, "  %-10s %s\n", d.Name, path)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestPlanTape(t *testing.T) {
+	tape := `Output demo.gif
+Set TypingSpeed 100ms
+Set PlaybackSpeed 2
+Hide
+Type "cd"
+Enter
+Show
+Type@10ms "ls"
+Backspace 3
+Wait@5s /\$/
+Wait /\$/
+Sleep 1s
+Screenshot demo.png
+`
+	p := parser.New(lexer.New(tape))
+	cmds := p.Parse()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", p.Errors())
+	}
+
+	plan, err := planTape(cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 300 * time.Millisecond; plan.Hidden != want {
+		t.Errorf("expected %s hidden, got %s", want, plan.Hidden)
+	}
+	if want := 1320 * time.Millisecond; plan.Recorded != want {
+		t.Errorf("expected %s recorded, got %s", want, plan.Recorded)
+	}
+	if want := 660 * time.Millisecond; plan.VideoLength() != want {
+		t.Errorf("expected video length %s, got %s", want, plan.VideoLength())
+	}
+	if want := 5*time.Second + defaultWaitTimeout; plan.MaxWait != want {
+		t.Errorf("expected max wait %s, got %s", want, plan.MaxWait)
+	}
+	if len(plan.Outputs) != 2 || plan.Outputs[0] != "demo.gif" || plan.Outputs[1] != "demo.png" {
+		t.Errorf("expected gif and screenshot outputs, got %v", plan.Outputs)
+	}
+	if plan.Dependencies[0].Name != "ffmpeg" || plan.Dependencies[1].Name != "ttyd" {
+		t.Errorf("expected ffmpeg and ttyd dependencies, got %v", plan.Dependencies)
+	}
+}
@@ -0,0 +1,624 @@
+//go:build !js
+
+// Package vhs emulator.go emulates a terminal screen in Go.
+//
+// The native backend feeds the output of the shell into this emulator instead
+// of xterm.js. It supports the xterm sequences used by shells and common
+// command line programs: cursor movement, erasing, scroll regions, the
+// alternate screen, and SGR colors and attributes. Other sequences are
+// ignored.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rivo/uniseg"
+)
+
+// tabWidth is the distance between tab stops.
+const tabWidth = 8
+
+// emuCell is a cell of the emulated screen. Width is 0 for the second half of
+// a wide character, and Text is empty for blank cells.
+type emuCell struct {
+	Text  string
+	Width int
+	Style CharStyle
+}
+
+// emuPen holds the SGR attributes applied to printed characters.
+type emuPen struct {
+	fg, bg                  string
+	bold, italic, underline bool
+	strikethrough, dim      bool
+	inverse                 bool
+}
+
+// emulator is a terminal screen driven by the output of a program.
+type emulator struct {
+	mu     sync.Mutex
+	parser *ansi.Parser
+	theme  Theme
+
+	cols, rows int
+	screen     [][]emuCell
+	// main holds the main screen while the alternate screen is active.
+	main [][]emuCell
+
+	x, y           int
+	wrapNext       bool
+	savedX, savedY int
+	top, bottom    int
+	pen            emuPen
+	noAutowrap     bool
+	cursorHidden   bool
+	// colorMode limits the colors of the pen (see Set ColorMode).
+	colorMode string
+	// shell holds the semantic prompts marked by the shell (see promptMarker).
+	shell ShellState
+	// changes counts the writes and resizes, see Changes.
+	changes uint64
+}
+
+// newEmulator returns an empty screen of the given size.
+func newEmulator(cols, rows int, theme Theme) *emulator {
+	e := &emulator{theme: theme}
+	e.resize(cols, rows)
+	e.parser = ansi.NewParser()
+	e.parser.SetHandler(ansi.Handler{
+		Print:     e.print,
+		Execute:   e.execute,
+		HandleCsi: e.handleCsi,
+		HandleEsc: e.handleEsc,
+		HandleOsc: e.handleOsc,
+	})
+	return e
+}
+
+// Write feeds the output of the program into the emulator.
+func (e *emulator) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.parser.Parse(p)
+	e.changes++
+	return len(p), nil
+}
+
+// Changes returns the number of writes and resizes of the screen.
+func (e *emulator) Changes() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.changes
+}
+
+// Resize changes the size of the screen, keeping its top left content.
+func (e *emulator) Resize(cols, rows int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.resize(cols, rows)
+	e.changes++
+}
+
+func (e *emulator) resize(cols, rows int) {
+	cols, rows = max(cols, 1), max(rows, 1)
+	resized := make([][]emuCell, rows)
+	for y := range resized {
+		resized[y] = e.blankLine(cols)
+		if y < len(e.screen) {
+			copy(resized[y], e.screen[y])
+		}
+	}
+	e.screen, e.cols, e.rows = resized, cols, rows
+	e.top, e.bottom = 0, rows-1
+	e.x, e.y = min(e.x, cols-1), min(e.y, rows-1)
+	e.wrapNext = false
+}
+
+// Lines returns the text of every row, without trailing spaces.
+func (e *emulator) Lines() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.lines()
+}
+
+func (e *emulator) lines() []string {
+	lines := make([]string, len(e.screen))
+	for y, row := range e.screen {
+		var sb strings.Builder
+		for _, c := range row {
+			switch {
+			case c.Width == 0:
+			case c.Text == "":
+				sb.WriteByte(' ')
+			default:
+				sb.WriteString(c.Text)
+			}
+		}
+		lines[y] = strings.TrimRight(sb.String(), " ")
+	}
+	return lines
+}
+
+// Styles returns the style of every cell, as captured from xterm.js.
+func (e *emulator) Styles() [][]CharStyle {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.styles()
+}
+
+func (e *emulator) styles() [][]CharStyle {
+	styles := make([][]CharStyle, len(e.screen))
+	for y, row := range e.screen {
+		styles[y] = make([]CharStyle, len(row))
+		for x, c := range row {
+			styles[y][x] = c.Style
+			styles[y][x].Width = c.Width
+		}
+	}
+	return styles
+}
+
+// Frame returns the screen as an SVG frame, with the text, styles, cursor and
+// size read at the same time.
+func (e *emulator) Frame() SVGFrame {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return SVGFrame{
+		Lines:      e.lines(),
+		LineColors: e.styles(),
+		CursorX:    e.x,
+		CursorY:    e.y,
+		CursorChar: e.cursorChar(),
+		Cols:       e.cols,
+		Rows:       e.rows,
+	}
+}
+
+// cursorChar returns the character of the cursor, none while it is hidden.
+func (e *emulator) cursorChar() string {
+	if e.cursorHidden {
+		return ""
+	}
+	return "█"
+}
+
+// Cursor returns the position of the cursor.
+func (e *emulator) Cursor() (int, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.x, e.y
+}
+
+// Size returns the number of columns and rows.
+func (e *emulator) Size() (int, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.cols, e.rows
+}
+
+// ShellState returns what the shell has marked with semantic prompts.
+func (e *emulator) ShellState() ShellState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.shell
+}
+
+func (e *emulator) blankLine(cols int) []emuCell {
+	line := make([]emuCell, cols)
+	for x := range line {
+		line[x] = emuCell{Width: 1, Style: CharStyle{BgColor: e.pen.bg}}
+	}
+	return line
+}
+
+// style returns the cell style of the pen.
+func (e *emulator) style() CharStyle {
+	fg, bg := e.pen.fg, e.pen.bg
+	if e.pen.inverse {
+		if fg == "" {
+			fg = e.theme.Foreground
+		}
+		if bg == "" {
+			bg = e.theme.Background
+		}
+		fg, bg = bg, fg
+	}
+	return CharStyle{
+		FgColor:       fg,
+		BgColor:       bg,
+		Bold:          e.pen.bold,
+		Italic:        e.pen.italic,
+		Underline:     e.pen.underline,
+		Strikethrough: e.pen.strikethrough,
+		Dim:           e.pen.dim,
+	}
+}
+
+func (e *emulator) print(r rune) {
+	s := string(r)
+	width := uniseg.StringWidth(s)
+	if width == 0 {
+		// Combining marks and joiners attach to the previous character.
+		x, y := e.x-1, e.y
+		if e.wrapNext {
+			x = e.x
+		}
+		for x > 0 && e.screen[y][x].Width == 0 {
+			x--
+		}
+		if x >= 0 && e.screen[y][x].Text != "" {
+			e.screen[y][x].Text += s
+		}
+		return
+	}
+
+	if e.wrapNext || (width > 1 && e.x+width > e.cols) {
+		if e.noAutowrap {
+			e.x = e.cols - width
+		} else {
+			e.x = 0
+			e.lineFeed()
+		}
+		e.wrapNext = false
+	}
+
+	style := e.style()
+	e.screen[e.y][e.x] = emuCell{Text: s, Width: width, Style: style}
+	for i := 1; i < width && e.x+i < e.cols; i++ {
+		e.screen[e.y][e.x+i] = emuCell{Width: 0, Style: style}
+	}
+
+	e.x += width
+	if e.x >= e.cols {
+		e.x = e.cols - 1
+		e.wrapNext = true
+	}
+}
+
+func (e *emulator) execute(b byte) {
+	switch b {
+	case ansi.BS:
+		if e.x > 0 {
+			e.x--
+		}
+		e.wrapNext = false
+	case ansi.HT:
+		e.x = min(e.cols-1, (e.x/tabWidth+1)*tabWidth)
+	case ansi.LF, ansi.VT, ansi.FF:
+		e.lineFeed()
+	case ansi.CR:
+		e.x = 0
+		e.wrapNext = false
+	}
+}
+
+// lineFeed moves the cursor down, scrolling at the bottom of the scroll
+// region.
+func (e *emulator) lineFeed() {
+	e.wrapNext = false
+	switch {
+	case e.y == e.bottom:
+		e.scrollUp(1)
+	case e.y < e.rows-1:
+		e.y++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling at the top of the scroll
+// region.
+func (e *emulator) reverseIndex() {
+	e.wrapNext = false
+	switch {
+	case e.y == e.top:
+		e.scrollDown(1)
+	case e.y > 0:
+		e.y--
+	}
+}
+
+// scrollUp scrolls the scroll region up by n lines.
+func (e *emulator) scrollUp(n int) {
+	e.deleteLines(e.top, n)
+}
+
+// scrollDown scrolls the scroll region down by n lines.
+func (e *emulator) scrollDown(n int) {
+	e.insertLines(e.top, n)
+}
+
+// insertLines inserts n blank lines at row y, pushing the lines below out of
+// the scroll region.
+func (e *emulator) insertLines(y, n int) {
+	if y < e.top || y > e.bottom {
+		return
+	}
+	n = min(n, e.bottom-y+1)
+	copy(e.screen[y+n:e.bottom+1], e.screen[y:e.bottom+1-n])
+	for i := y; i < y+n; i++ {
+		e.screen[i] = e.blankLine(e.cols)
+	}
+}
+
+// deleteLines deletes n lines at row y, pulling up the lines below within
+// the scroll region.
+func (e *emulator) deleteLines(y, n int) {
+	if y < e.top || y > e.bottom {
+		return
+	}
+	n = min(n, e.bottom-y+1)
+	copy(e.screen[y:e.bottom+1-n], e.screen[y+n:e.bottom+1])
+	for i := e.bottom + 1 - n; i <= e.bottom; i++ {
+		e.screen[i] = e.blankLine(e.cols)
+	}
+}
+
+// erase blanks the cells [from, to) of row y.
+func (e *emulator) erase(y, from, to int) {
+	blank := emuCell{Width: 1, Style: CharStyle{BgColor: e.pen.bg}}
+	for x := max(from, 0); x < min(to, e.cols); x++ {
+		e.screen[y][x] = blank
+	}
+}
+
+func (e *emulator) moveTo(x, y int) {
+	e.x = min(max(x, 0), e.cols-1)
+	e.y = min(max(y, 0), e.rows-1)
+	e.wrapNext = false
+}
+
+func (e *emulator) handleCsi(cmd ansi.Cmd, params ansi.Params) {
+	// param returns the parameter at i, where 0 means the default.
+	param := func(i, def int) int {
+		n, _, _ := params.Param(i, def)
+		if n == 0 {
+			return def
+		}
+		return n
+	}
+
+	if cmd.Prefix() == '?' {
+		switch cmd.Final() {
+		case 'h', 'l':
+			e.setMode(params, cmd.Final() == 'h')
+		}
+		return
+	}
+	if cmd.Prefix() != 0 || cmd.Intermediate() != 0 {
+		return
+	}
+
+	switch cmd.Final() {
+	case 'A':
+		e.moveTo(e.x, e.y-param(0, 1))
+	case 'B':
+		e.moveTo(e.x, e.y+param(0, 1))
+	case 'C':
+		e.moveTo(e.x+param(0, 1), e.y)
+	case 'D':
+		e.moveTo(e.x-param(0, 1), e.y)
+	case 'E':
+		e.moveTo(0, e.y+param(0, 1))
+	case 'F':
+		e.moveTo(0, e.y-param(0, 1))
+	case 'G', '`':
+		e.moveTo(param(0, 1)-1, e.y)
+	case 'd':
+		e.moveTo(e.x, param(0, 1)-1)
+	case 'H', 'f':
+		e.moveTo(param(1, 1)-1, param(0, 1)-1)
+	case 'J':
+		switch n, _, _ := params.Param(0, 0); n {
+		case 0:
+			e.erase(e.y, e.x, e.cols)
+			for y := e.y + 1; y < e.rows; y++ {
+				e.erase(y, 0, e.cols)
+			}
+		case 1:
+			e.erase(e.y, 0, e.x+1)
+			for y := 0; y < e.y; y++ {
+				e.erase(y, 0, e.cols)
+			}
+		case 2, 3:
+			for y := 0; y < e.rows; y++ {
+				e.erase(y, 0, e.cols)
+			}
+		}
+	case 'K':
+		switch n, _, _ := params.Param(0, 0); n {
+		case 0:
+			e.erase(e.y, e.x, e.cols)
+		case 1:
+			e.erase(e.y, 0, e.x+1)
+		case 2:
+			e.erase(e.y, 0, e.cols)
+		}
+	case 'X':
+		e.erase(e.y, e.x, e.x+param(0, 1))
+	case 'P':
+		n := min(param(0, 1), e.cols-e.x)
+		row := e.screen[e.y]
+		copy(row[e.x:], row[e.x+n:])
+		e.erase(e.y, e.cols-n, e.cols)
+	case '@':
+		n := min(param(0, 1), e.cols-e.x)
+		row := e.screen[e.y]
+		copy(row[e.x+n:], row[e.x:e.cols-n])
+		e.erase(e.y, e.x, e.x+n)
+	case 'L':
+		e.insertLines(e.y, param(0, 1))
+	case 'M':
+		e.deleteLines(e.y, param(0, 1))
+	case 'S':
+		e.scrollUp(param(0, 1))
+	case 'T':
+		e.scrollDown(param(0, 1))
+	case 'r':
+		top, bottom := param(0, 1)-1, param(1, e.rows)-1
+		if top < bottom && bottom < e.rows {
+			e.top, e.bottom = top, bottom
+			e.moveTo(0, 0)
+		}
+	case 's':
+		e.savedX, e.savedY = e.x, e.y
+	case 'u':
+		e.moveTo(e.savedX, e.savedY)
+	case 'm':
+		e.setGraphics(params)
+	}
+}
+
+// setMode handles the DEC private modes the screen depends on.
+func (e *emulator) setMode(params ansi.Params, set bool) {
+	params.ForEach(0, func(_, mode int, _ bool) {
+		switch mode {
+		case 7:
+			e.noAutowrap = !set
+		case 25:
+			e.cursorHidden = !set
+		case 47, 1047, 1049:
+			if set == (e.main != nil) {
+				return
+			}
+			if set {
+				e.savedX, e.savedY = e.x, e.y
+				e.main = e.screen
+				e.screen = make([][]emuCell, e.rows)
+				for y := range e.screen {
+					e.screen[y] = e.blankLine(e.cols)
+				}
+			} else {
+				e.screen, e.main = e.main, nil
+				e.moveTo(e.savedX, e.savedY)
+			}
+		}
+	})
+}
+
+// setGraphics applies an SGR sequence to the pen.
+func (e *emulator) setGraphics(params ansi.Params) {
+	if len(params) == 0 {
+		e.pen = emuPen{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		n := params[i].Param(0)
+		switch {
+		case n == 0:
+			e.pen = emuPen{}
+		case n == 1:
+			e.pen.bold = true
+		case n == 2:
+			e.pen.dim = true
+		case n == 3:
+			e.pen.italic = true
+		case n == 4:
+			e.pen.underline = true
+		case n == 7:
+			e.pen.inverse = true
+		case n == 9:
+			e.pen.strikethrough = true
+		case n == 22:
+			e.pen.bold = false
+			e.pen.dim = false
+		case n == 23:
+			e.pen.italic = false
+		case n == 24:
+			e.pen.underline = false
+		case n == 27:
+			e.pen.inverse = false
+		case n == 29:
+			e.pen.strikethrough = false
+		case n >= 30 && n <= 37:
+			e.pen.fg = e.paletteColor(n - 30)
+		case n == 39:
+			e.pen.fg = ""
+		case n >= 40 && n <= 47:
+			e.pen.bg = e.paletteColor(n - 40)
+		case n == 49:
+			e.pen.bg = ""
+		case n >= 90 && n <= 97:
+			e.pen.fg = e.paletteColor(n - 90 + 8)
+		case n >= 100 && n <= 107:
+			e.pen.bg = e.paletteColor(n - 100 + 8)
+		case n == 38 || n == 48:
+			var color string
+			color, i = e.extendedColor(params, i)
+			if n == 38 {
+				e.pen.fg = color
+			} else {
+				e.pen.bg = color
+			}
+		}
+	}
+	e.pen.fg = limitColor(e.pen.fg, e.theme, e.colorMode)
+	e.pen.bg = limitColor(e.pen.bg, e.theme, e.colorMode)
+}
+
+// extendedColor parses a 256 color (5;n) or true color (2;r;g;b) starting
+// after index i, returning the color and the index of its last parameter.
+func (e *emulator) extendedColor(params ansi.Params, i int) (string, int) {
+	arg := func(j int) int {
+		if j < len(params) {
+			return params[j].Param(0)
+		}
+		return 0
+	}
+	switch arg(i + 1) {
+	case 5:
+		return e.paletteColor(arg(i + 2)), i + 2
+	case 2:
+		return fmt.Sprintf("#%02x%02x%02x", arg(i+2)&0xff, arg(i+3)&0xff, arg(i+4)&0xff), i + 4
+	}
+	return "", i + 1
+}
+
+// paletteColor returns the color of the xterm 256 color palette, where the
+// first 16 colors come from the theme.
+func (e *emulator) paletteColor(n int) string {
+	return xtermColor(e.theme, n)
+}
+
+func (e *emulator) handleOsc(cmd int, data []byte) {
+	// Only semantic prompts are tracked, titles and the like are ignored.
+	if cmd == 133 {
+		e.shell.mark(strings.TrimPrefix(string(data), "133;"))
+	}
+}
+
+func (e *emulator) handleEsc(cmd ansi.Cmd) {
+	if cmd.Intermediate() != 0 {
+		// Character set designations are not emulated.
+		return
+	}
+	switch cmd.Final() {
+	case '7':
+		e.savedX, e.savedY = e.x, e.y
+	case '8':
+		e.moveTo(e.savedX, e.savedY)
+	case 'D':
+		e.lineFeed()
+	case 'E':
+		e.x = 0
+		e.lineFeed()
+	case 'M':
+		e.reverseIndex()
+	case 'c':
+		e.pen = emuPen{}
+		e.main = nil
+		e.screen = nil
+		e.resize(e.cols, e.rows)
+		e.moveTo(0, 0)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmulator(t *testing.T) {
+	theme := DefaultTheme
+	tests := []struct {
+		name   string
+		input  string
+		lines  []string
+		cursor [2]int
+	}{
+		{"text", "hello\r\nworld", []string{"hello", "world", "", ""}, [2]int{5, 1}},
+		{"wrap", "abcdefghij", []string{"abcdefgh", "ij", "", ""}, [2]int{2, 1}},
+		{"scroll", "1\r\n2\r\n3\r\n4\r\n5", []string{"2", "3", "4", "5"}, [2]int{1, 3}},
+		{"backspace", "abc\b\bX", []string{"aXc", "", "", ""}, [2]int{2, 0}},
+		{"erase line", "hello\x1b[3D\x1b[K", []string{"he", "", "", ""}, [2]int{2, 0}},
+		{"erase screen", "a\r\nb\x1b[2J\x1b[H", []string{"", "", "", ""}, [2]int{0, 0}},
+		{"cursor position", "\x1b[2;3Hx", []string{"", "  x", "", ""}, [2]int{3, 1}},
+		{"tab", "a\tb", []string{"a      b", "", "", ""}, [2]int{7, 0}},
+		{"wide", "日本", []string{"日本", "", "", ""}, [2]int{4, 0}},
+		{"combining", "e\u0301!", []string{"e\u0301!", "", "", ""}, [2]int{2, 0}},
+		{"alternate screen", "main\x1b[?1049hvim\x1b[?1049l", []string{"main", "", "", ""}, [2]int{4, 0}},
+		{"insert line", "a\r\nb\x1b[1;1H\x1b[L", []string{"", "a", "b", ""}, [2]int{0, 0}},
+		{"delete chars", "abcdef\x1b[1;2H\x1b[2P", []string{"adef", "", "", ""}, [2]int{1, 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newEmulator(8, 4, theme)
+			_, _ = e.Write([]byte(tc.input))
+			if got := e.Lines(); !reflect.DeepEqual(got, tc.lines) {
+				t.Errorf("expected lines %q, got %q", tc.lines, got)
+			}
+			if x, y := e.Cursor(); x != tc.cursor[0] || y != tc.cursor[1] {
+				t.Errorf("expected cursor at %v, got %d,%d", tc.cursor, x, y)
+			}
+		})
+	}
+}
+
+func TestEmulatorStyles(t *testing.T) {
+	theme := DefaultTheme
+	e := newEmulator(10, 2, theme)
+	_, _ = e.Write([]byte("\x1b[1;31mA\x1b[0;38;5;196mB\x1b[48;2;1;2;3mC\x1b[0;7mD\x1b[m日"))
+
+	styles := e.Styles()[0]
+	if !styles[0].Bold || styles[0].FgColor != theme.Red {
+		t.Errorf("expected bold red, got %+v", styles[0])
+	}
+	if styles[1].Bold || styles[1].FgColor != "#ff0000" {
+		t.Errorf("expected 256 color red, got %+v", styles[1])
+	}
+	if styles[2].BgColor != "#010203" {
+		t.Errorf("expected true color background, got %+v", styles[2])
+	}
+	if styles[3].FgColor != theme.Background || styles[3].BgColor != theme.Foreground {
+		t.Errorf("expected inverse colors, got %+v", styles[3])
+	}
+	if styles[4].Width != 2 || styles[5].Width != 0 || styles[6].Width != 1 {
+		t.Errorf("expected wide character widths, got %+v", styles[4:7])
+	}
+}
+
+func TestEmulatorTextAttributes(t *testing.T) {
+	e := newEmulator(10, 1, DefaultTheme)
+	_, _ = e.Write([]byte("\x1b[2;9mA\x1b[22mB\x1b[29mC"))
+
+	styles := e.Styles()[0]
+	if !styles[0].Dim || !styles[0].Strikethrough {
+		t.Errorf("expected dim strikethrough, got %+v", styles[0])
+	}
+	if styles[1].Dim || !styles[1].Strikethrough {
+		t.Errorf("expected strikethrough only, got %+v", styles[1])
+	}
+	if styles[2].Strikethrough {
+		t.Errorf("expected no attributes, got %+v", styles[2])
+	}
+}
+
+func TestEmulatorPrompts(t *testing.T) {
+	e := newEmulator(10, 1, DefaultTheme)
+	_, _ = e.Write([]byte(promptMarker + "> ls\r\n\x1b]0;title\x07\x1b]133;D;2\x07" + promptMarker + "> "))
+
+	if got := e.ShellState(); got != (ShellState{Prompts: 2, Exits: 1, Status: 2}) {
+		t.Errorf("expected 2 prompts and an exit status of 2, got %+v", got)
+	}
+	if got := e.Lines()[0]; got != ">" {
+		t.Errorf("expected the markers to be invisible, got %q", got)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -6,9 +8,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"slices"
 
-	"github.com/agentstation/vhs/lexer"
-	"github.com/agentstation/vhs/parser"
 	"github.com/agentstation/vhs/token"
 	"github.com/go-rod/rod"
 )
@@ -23,6 +24,14 @@ func WithSVGOptimization(optimize bool) EvaluatorOption {
 	}
 }
 
+// WithBrowser returns an EvaluatorOption that records in a browser shared with
+// other recordings instead of launching one. The browser is left open.
+func WithBrowser(browser *rod.Browser) EvaluatorOption {
+	return func(v *VHS) {
+		v.browser = browser
+	}
+}
+
 // WithDebugConsole returns an EvaluatorOption that enables browser console logging.
 func WithDebugConsole(debug bool) EvaluatorOption {
 	return func(v *VHS) {
@@ -33,25 +42,34 @@ func WithDebugConsole(debug bool) EvaluatorOption {
 // Evaluate takes as input a tape string, an output writer, and an output file
 // and evaluates all the commands within the tape string and produces a GIF.
 func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...EvaluatorOption) []error {
-	l := lexer.New(tape)
-	p := parser.New(l)
+	ctx, span := startSpan(ctx, "vhs.evaluate")
+	errs := evaluate(ctx, tape, out, opts...)
+	endSpanErrors(span, errs)
+	return errs
+}
 
-	cmds := p.Parse()
-	errs := p.Errors()
-	if len(errs) != 0 || len(cmds) == 0 {
-		return []error{InvalidSyntaxError{errs}}
+// evaluate evaluates the tape within the span of the context.
+func evaluate(ctx context.Context, tape string, out io.Writer, opts ...EvaluatorOption) (errs []error) {
+	cmds, err := parseTape(tape)
+	if err != nil {
+		return []error{err}
 	}
 
 	v := New()
+	v.trace = ctx
 
 	// Apply evaluator options early (before Start) for options like DebugConsole
 	// that need to be set before the browser is initialized
 	for _, opt := range opts {
 		opt(&v)
 	}
+	cmds = append(slices.Clone(v.defaults), cmds...)
+	if v.diagnostics != nil {
+		out = io.MultiWriter(out, v.diagnostics)
+	}
 
 	for _, cmd := range cmds {
-		if cmd.Type == token.SET && cmd.Options == "Shell" || cmd.Type == token.ENV {
+		if cmd.Type == token.SET && (cmd.Options == "Shell" || cmd.Options == "ColorMode" || isLimit(cmd.Options) || isWorkspaceSetting(cmd.Options)) || cmd.Type == token.ENV {
 			err := Execute(cmd, &v)
 			if err != nil {
 				return []error{err}
@@ -59,17 +77,62 @@ func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...Evaluator
 		}
 	}
 
+	if v.Options.colorScheme == "" && needsDarkPass(cmds) {
+		// The raster outputs are recorded again in the dark theme.
+		darkOpts := append(slices.Clone(opts), withColorScheme(colorSchemeDark))
+		if errs := evaluate(ctx, tape, out, darkOpts...); len(errs) > 0 {
+			return errs
+		}
+		withColorScheme(colorSchemeLight)(&v)
+	}
+
+	cleanup, err := v.setupWorkspace()
+	if err != nil {
+		return []error{err}
+	}
+	defer cleanup()
+
+	// Once a limit is exceeded, the command which failed as a result reports
+	// the limit instead.
+	v.limits = newLimiter(&v)
+	defer v.limits.stop()
+	// An interrupt aborts the command being executed, as a limit does.
+	defer context.AfterFunc(ctx, func() {
+		v.limits.abort(&v, fmt.Errorf("recording interrupted: %w", context.Cause(ctx)))
+	})()
+	defer func() {
+		if err := v.limits.Err(); err != nil && len(errs) > 0 {
+			errs = []error{err}
+		}
+	}()
+
+	// The diagnostics are written before the browser is closed, or after the
+	// native terminal is.
+	defer func() { v.diagnose(errs) }()
+
+	switch v.Options.Backend {
+	case nativeBackend:
+		return evaluateNative(ctx, cmds, &v, out, opts)
+	case "", browserBackend:
+	default:
+		return []error{fmt.Errorf("unknown backend %q, use %s or %s", v.Options.Backend, browserBackend, nativeBackend)}
+	}
+
 	// Start things up
+	_, setup := startSpan(ctx, "vhs.setup")
 	if err := v.Start(); err != nil {
+		endSpan(setup, err)
 		return []error{err}
 	}
 	defer func() { _ = v.close() }()
+	defer func() { v.diagnose(errs) }()
 
 	// Let's wait until we can access the window.term variable.
 	//
 	// This is necessary because some SET commands modify the terminal.
-	err := v.Page.Wait(rod.Eval("() => window.term != undefined"))
+	err = v.Page.Wait(rod.Eval("() => window.term != undefined"))
 	if err != nil {
+		endSpan(setup, err)
 		return []error{err}
 	}
 
@@ -113,6 +176,11 @@ func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...Evaluator
 
 	// Setup the terminal session so we can start executing commands.
 	v.Setup()
+	err = v.waitForShell(v.Options.WaitTimeout)
+	endSpan(setup, err)
+	if err != nil {
+		return []error{err}
+	}
 
 	// If the first command (after Settings and Outputs) is a Hide command, we can
 	// begin executing the commands before we start recording to avoid capturing
@@ -146,6 +214,7 @@ func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...Evaluator
 	}()
 
 	teardown := func() {
+		v.limits.stop()
 		// Stop recording frames.
 		cancel()
 		// Read from channel to ensure recorder is done.
@@ -156,14 +225,23 @@ func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...Evaluator
 	go func() {
 		for err := range ch {
 			log.Print(err.Error())
+			if v.diagnostics != nil {
+				_, _ = fmt.Fprintln(v.diagnostics, err)
+			}
 		}
 	}()
 
-	for _, cmd := range cmds[offset:] {
+	for i, cmd := range cmds[offset:] {
 		if ctx.Err() != nil {
 			teardown()
+			v.renderTruncated(v.Render)
 			return []error{ctx.Err()}
 		}
+		if err := v.limits.Err(); err != nil {
+			teardown()
+			v.renderTruncated(v.Render)
+			return []error{err}
+		}
 
 		// When changing the FontFamily, FontSize, LineHeight, Padding
 		// The xterm.js canvas changes dimensions and causes FFMPEG to not work
@@ -176,18 +254,21 @@ func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...Evaluator
 		isSetting := cmd.Type == token.SET && cmd.Options != "TypingSpeed"
 
 		if isSetting {
-			fmt.Println(ErrorStyle.Render(fmt.Sprintf("WARN: 'Set %s %s' has been ignored. Move the directive to the top of the file.\nLearn more: https://github.com/agentstation/vhs#settings", cmd.Options, cmd.Args)))
+			log.Println(ErrorStyle.Render(fmt.Sprintf("WARN: 'Set %s %s' has been ignored. Move the directive to the top of the file.\nLearn more: https://github.com/agentstation/vhs#settings", cmd.Options, cmd.Args)))
 		}
 		if isSetting || cmd.Type == token.REQUIRE {
 			_, _ = fmt.Fprintln(out, Highlight(cmd, true))
 			continue
 		}
 		_, _ = fmt.Fprintln(out, Highlight(cmd, !v.recording || cmd.Type == token.SHOW || cmd.Type == token.HIDE || isSetting))
+		v.events.Publish(Event{Type: EventCommand, Command: cmd.String(), Line: cmd.Line})
 		err := Execute(cmd, &v)
 		if err != nil {
 			teardown()
+			v.renderTruncated(v.Render)
 			return []error{err}
 		}
+		v.publishProgress(cmd, offset+i+1, len(cmds))
 	}
 
 	// If running as an SSH server, the output file is a temporary file
@@ -202,6 +283,28 @@ func Evaluate(ctx context.Context, tape string, out io.Writer, opts ...Evaluator
 		opt(&v)
 	}
 
+	// Capture the transcript before the browser is closed.
+	if (v.Options.SVG.Scrollback || v.Options.SVG.AccessibleTranscript) && v.Options.Video.Output.SVG != "" {
+		if err := v.CaptureScrollback(); err != nil {
+			log.Println(err)
+		}
+	}
+
+	if err := v.captureTranscript(); err != nil {
+		log.Println(err)
+	}
+
+	if err := v.WarnMissingGlyphs(); err != nil {
+		log.Println(err)
+	}
+
+	if v.Options.Test.Golden != "" {
+		if err := v.takeSnapshot(finalSnapshot); err != nil {
+			teardown()
+			return []error{err}
+		}
+	}
+
 	teardown()
 	if err := v.Render(); err != nil {
 		return []error{err}
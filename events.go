@@ -0,0 +1,181 @@
+//go:build !js
+
+// Package vhs events.go publishes the progress of a recording.
+//
+// Integrators subscribe to an EventBus to follow a recording as it happens,
+// e.g. to show a live preview of the frames or the command being executed.
+// Progress events report the phase of the recording (record, encode, then
+// optimize) and how far along it is. With --log-format json, vhs prints the
+// events to stdout and its log messages to stderr as JSON lines.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Event types published while recording.
+const (
+	EventCommand  = "command"
+	EventFrame    = "frame"
+	EventProgress = "progress"
+	EventDone     = "done"
+	EventError    = "error"
+)
+
+// Phases of a recording reported by progress events.
+const (
+	PhaseRecord   = "record"
+	PhaseEncode   = "encode"
+	PhaseOptimize = "optimize"
+)
+
+// Event is a step of a recording.
+type Event struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	// Line is the line of the command in the tape.
+	Line  int `json:"line,omitempty"`
+	Frame int `json:"frame,omitempty"`
+	// Image is the captured frame as a PNG.
+	Image []byte `json:"image,omitempty"`
+	// Phase is the phase of progress events, and Percent how much of it is
+	// complete. They are published as steps of the phase complete.
+	Phase   string  `json:"phase,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// progressEvent returns the progress event of a phase with done of total
+// steps complete, as a percentage rounded to a tenth.
+func progressEvent(phase string, done, total int) Event {
+	percent := 100.0
+	if total > 0 {
+		percent = math.Round(float64(done)/float64(total)*1000) / 10 //nolint:mnd
+	}
+	return Event{Type: EventProgress, Phase: phase, Percent: percent}
+}
+
+// EventBus fans out events to its subscribers. Publishing never blocks the
+// recording: events are dropped for subscribers which fall behind.
+type EventBus struct {
+	mu     sync.Mutex
+	subs   []chan Event
+	closed bool
+}
+
+// NewEventBus returns an event bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe returns a channel receiving the events published from now on. It
+// buffers up to size events and is closed with the bus.
+func (b *EventBus) Subscribe(size int) <-chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, size)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subs = append(b.subs, ch)
+	return ch
+}
+
+// Publish sends the event to every subscriber with room for it. Publishing to
+// a nil bus does nothing, so callers do not need to check for one.
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Close closes the channels of every subscriber.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subs {
+		close(ch)
+	}
+}
+
+// publishProgress publishes the progress of the recording once the command
+// has executed.
+func (vhs *VHS) publishProgress(cmd parser.Command, done, total int) {
+	e := progressEvent(PhaseRecord, done, total)
+	e.Command, e.Line = cmd.String(), cmd.Line
+	vhs.events.Publish(e)
+}
+
+// writeEvents writes the events of the bus as JSON lines until it is closed.
+// Frames are skipped, since their images would flood the output.
+func writeEvents(w io.Writer, events <-chan Event) {
+	enc := json.NewEncoder(w)
+	for e := range events {
+		if e.Type == EventFrame {
+			continue
+		}
+		_ = enc.Encode(e)
+	}
+}
+
+// jsonLogWriter writes log messages as JSON lines, for --log-format json.
+// The log package writes each message with a single call.
+type jsonLogWriter struct {
+	w io.Writer
+}
+
+// Write implements io.Writer.
+func (l jsonLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(ansi.Strip(string(p)))
+	if msg == "" {
+		return len(p), nil
+	}
+	b, err := json.Marshal(struct {
+		Time  string `json:"time"`
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}{time.Now().UTC().Format(time.RFC3339), "info", msg})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode log message: %w", err)
+	}
+	if _, err := l.w.Write(append(b, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to write log message: %w", err)
+	}
+	return len(p), nil
+}
+
+// WithEvents returns an EvaluatorOption publishing the progress of the
+// recording to the bus.
+func WithEvents(bus *EventBus) EvaluatorOption {
+	return func(v *VHS) {
+		v.events = bus
+	}
+}
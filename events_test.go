@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	a := bus.Subscribe(2)
+	b := bus.Subscribe(1)
+
+	bus.Publish(Event{Type: EventCommand, Command: "Type"})
+	bus.Publish(Event{Type: EventFrame, Frame: 1})
+	bus.Close()
+	bus.Publish(Event{Type: EventFrame, Frame: 2})
+
+	var got []Event
+	for e := range a {
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].Command != "Type" || got[1].Frame != 1 {
+		t.Errorf("expected both events, got %+v", got)
+	}
+
+	// Slow subscribers miss events instead of blocking the recording.
+	got = nil
+	for e := range b {
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0].Type != EventCommand {
+		t.Errorf("expected only the first event, got %+v", got)
+	}
+
+	if _, ok := <-bus.Subscribe(1); ok {
+		t.Error("expected subscription to a closed bus to be closed")
+	}
+
+	var nilBus *EventBus
+	nilBus.Publish(Event{Type: EventDone})
+}
+
+func TestProgressEvent(t *testing.T) {
+	tests := []struct {
+		done, total int
+		expected    float64
+	}{
+		{1, 3, 33.3},
+		{3, 3, 100},
+		{0, 0, 100},
+	}
+	for _, tc := range tests {
+		e := progressEvent(PhaseRecord, tc.done, tc.total)
+		if e.Type != EventProgress || e.Phase != PhaseRecord || e.Percent != tc.expected {
+			t.Errorf("expected %v%% for %d of %d, got %+v", tc.expected, tc.done, tc.total, e)
+		}
+	}
+}
+
+func TestWriteEvents(t *testing.T) {
+	events := make(chan Event, 3)
+	events <- Event{Type: EventProgress, Phase: PhaseRecord, Percent: 50, Line: 2, Command: "Enter"}
+	events <- Event{Type: EventFrame, Frame: 1, Image: []byte{1}}
+	events <- Event{Type: EventDone}
+	close(events)
+
+	var buf bytes.Buffer
+	writeEvents(&buf, events)
+
+	expected := `{"type":"progress","command":"Enter","line":2,"phase":"record","percent":50}
+{"type":"done"}
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestJSONLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := jsonLogWriter{&buf}
+	if _, err := w.Write([]byte(ErrorStyle.Render("WARN: late setting") + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("\n"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %q", buf.String())
+	}
+	var msg struct{ Level, Msg string }
+	if err := json.Unmarshal([]byte(lines[0]), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Level != "info" || msg.Msg != "WARN: late setting" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+}
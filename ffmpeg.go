@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -25,14 +27,58 @@ func NewVideoFilterBuilder(videoOpts *VideoOptions) *FilterComplexBuilder {
 		fmt.Sprintf(`
 		[0][1]overlay[merged];
 		[merged]scale=%d:%d:force_original_aspect_ratio=1[scaled];
-		[scaled]fps=%d,setpts=PTS/%f[speed];
-		[speed]pad=%d:%d:(ow-iw)/2:(oh-ih)/2:%s[padded];
-		[padded]fillborders=left=%d:right=%d:top=%d:bottom=%d:mode=fixed:color=%s[padded]
 		`,
 			termWidth-double(videoOpts.Style.Padding),
 			termHeight-double(videoOpts.Style.Padding),
+		),
+	)
+
+	// The overlays are positioned on the terminal letterboxed to its
+	// dimensions, which also lets zoompan keep its aspect ratio.
+	scaled := "scaled"
+	width := termWidth - double(videoOpts.Style.Padding)
+	height := termHeight - double(videoOpts.Style.Padding)
+	if len(videoOpts.Camera) > 0 || len(videoOpts.Annotations) > 0 {
+		_, _ = fmt.Fprintf(&filterCode, "[%s]pad=%d:%d:(ow-iw)/2:(oh-ih)/2:%s[boxed];\n",
+			scaled, width, height, videoOpts.Style.BackgroundColor)
+		scaled = "boxed"
+	}
+
+	// Overlay the callouts (from the inputs following the frames) while they
+	// are shown, below the camera so that they zoom with the terminal.
+	for i, a := range videoOpts.Annotations {
+		label := fmt.Sprintf("annotated%d", i)
+		_, _ = fmt.Fprintf(&filterCode, "[%s][%d]overlay=enable='between(n,%d,%d)'[%s];\n",
+			scaled, annotationStream+i, a.Start-videoOpts.StartingFrame, a.End-videoOpts.StartingFrame-1, label)
+		scaled = label
+	}
+
+	// Move the camera over the terminal.
+	if len(videoOpts.Camera) > 0 {
+		_, _ = fmt.Fprintf(&filterCode, "[%s]%s[camera];\n",
+			scaled, cameraFilter(videoOpts.Camera, width, height, videoOpts.Framerate))
+		scaled = "camera"
+	}
+
+	// Split the stream at scene boundaries and join it back with transitions.
+	if transitions := sceneTransitionFilter(scaled, "scenes", videoOpts.Scenes, videoOpts.Framerate, videoOpts.Transition); transitions != "" {
+		filterCode.WriteString(transitions + ";\n")
+		scaled = "scenes"
+	}
+
+	framerate := videoOpts.Framerate
+	if videoOpts.OutputFramerate > 0 {
+		framerate = videoOpts.OutputFramerate
+	}
 
-			videoOpts.Framerate,
+	filterCode.WriteString(
+		fmt.Sprintf(`
+		[%s]fps=%d,setpts=PTS/%f[speed];
+		[speed]pad=%d:%d:(ow-iw)/2:(oh-ih)/2:%s[padded];
+		[padded]fillborders=left=%d:right=%d:top=%d:bottom=%d:mode=fixed:color=%s[padded]
+		`,
+			scaled,
+			framerate,
 			videoOpts.PlaybackSpeed,
 
 			termWidth,
@@ -172,16 +218,120 @@ func (fb *FilterComplexBuilder) WithMarginFill(marginStream int) *FilterComplexB
 	return fb
 }
 
+// WithWatermark overlays the watermark on the output.
+func (fb *FilterComplexBuilder) WithWatermark(watermarkStream int, watermark *Watermark) *FilterComplexBuilder {
+	if watermark == nil {
+		return fb
+	}
+
+	fb.filterComplex.WriteString(";")
+	_, _ = fmt.Fprintf(
+		fb.filterComplex,
+		`
+		[%d]format=rgba,colorchannelmixer=aa=%g[watermark];
+		[%s][watermark]overlay=%s:shortest=1[watermarked]
+		`,
+		watermarkStream,
+		watermark.Opacity,
+		fb.prevStageName,
+		watermark.overlayPosition(),
+	)
+	fb.prevStageName = "watermarked"
+
+	return fb
+}
+
+// WithScale scales the output by the given factor, keeping the dimensions
+// even for the video encoders.
+func (fb *FilterComplexBuilder) WithScale(scale float64) *FilterComplexBuilder {
+	if scale <= 0 || scale == 1 {
+		return fb
+	}
+
+	fb.filterComplex.WriteString(";")
+	_, _ = fmt.Fprintf(
+		fb.filterComplex,
+		`
+		[%s]scale=trunc(iw*%[2]g/2)*2:trunc(ih*%[2]g/2)*2[resized]
+		`,
+		fb.prevStageName,
+		scale,
+	)
+	fb.prevStageName = "resized"
+
+	return fb
+}
+
+// WithFilter applies the filter chain of Set VideoFilter to the composed
+// frames.
+func (fb *FilterComplexBuilder) WithFilter(filter string) *FilterComplexBuilder {
+	if filter == "" {
+		return fb
+	}
+
+	fb.filterComplex.WriteString(";")
+	_, _ = fmt.Fprintf(
+		fb.filterComplex,
+		`
+		[%s]%s[filtered]
+		`,
+		fb.prevStageName,
+		filter,
+	)
+	fb.prevStageName = "filtered"
+
+	return fb
+}
+
 // WithGIF adds gif options to ffmepg filter_complex.
-func (fb *FilterComplexBuilder) WithGIF() *FilterComplexBuilder {
+func (fb *FilterComplexBuilder) WithGIF(opts VideoOptions) *FilterComplexBuilder {
+	maxColors := opts.MaxColors
+	if maxColors <= 0 {
+		maxColors = defaultMaxColors
+	}
+
+	palettegen := fmt.Sprintf("palettegen=max_colors=%d", maxColors)
+	paletteuse := "paletteuse"
+	var paletteuseOpts []string
+	if opts.GIFStatsMode != "" {
+		palettegen += ":stats_mode=" + opts.GIFStatsMode
+		// A palette is generated for every frame in single mode, so paletteuse
+		// has to pick up each new palette.
+		if opts.GIFStatsMode == "single" {
+			paletteuseOpts = append(paletteuseOpts, "new=1")
+		}
+	}
+	if opts.GIFDither != "" {
+		paletteuseOpts = append(paletteuseOpts, "dither="+opts.GIFDither)
+	}
+	if len(paletteuseOpts) > 0 {
+		paletteuse += "=" + strings.Join(paletteuseOpts, ":")
+	}
+
+	// Hold the last frame before the GIF loops.
+	if opts.LoopDelay > 0 {
+		fb.filterComplex.WriteString(";")
+		_, _ = fmt.Fprintf(
+			fb.filterComplex,
+			`
+			[%s]tpad=stop_mode=clone:stop_duration=%g[delayed]
+			`,
+			fb.prevStageName,
+			opts.LoopDelay.Seconds(),
+		)
+		fb.prevStageName = "delayed"
+	}
+
 	fb.filterComplex.WriteString(";")
 	_, _ = fmt.Fprintf(
 		fb.filterComplex,
 		`
 		[%s]split[plt_a][plt_b];
-		[plt_a]palettegen=max_colors=256[plt];
-		[plt_b][plt]paletteuse[palette]`,
+		[plt_a]%s[plt];
+		[plt_b][plt]%s[palette]`,
 		fb.prevStageName,
+		palettegen,
+		paletteuse,
 	)
 	fb.prevStageName = "palette"
 
@@ -198,15 +348,16 @@ func (fb *FilterComplexBuilder) Build() []string {
 
 // StreamBuilder generates streams used by ffmepg.
 type StreamBuilder struct {
-	args         []string
-	counter      int
-	style        *StyleOptions
-	termWidth    int
-	termHeight   int
-	input        string
-	barStream    int
-	cornerStream int
-	marginStream int
+	args            []string
+	counter         int
+	style           *StyleOptions
+	termWidth       int
+	termHeight      int
+	input           string
+	barStream       int
+	cornerStream    int
+	marginStream    int
+	watermarkStream int
 }
 
 // NewStreamBuilder returns instance of StreamBuilder.
@@ -223,6 +374,24 @@ func NewStreamBuilder(streamCounter int, input string, style *StyleOptions) *Str
 	}
 }
 
+// WithAnnotations adds a stream with the image of every callout, which must
+// be the first streams after the frames.
+func (sb *StreamBuilder) WithAnnotations(annotations []Annotation) *StreamBuilder {
+	width := sb.termWidth - double(sb.style.Padding)
+	height := sb.termHeight - double(sb.style.Padding)
+	for i, a := range annotations {
+		path := filepath.Join(sb.input, fmt.Sprintf("annotation-%d.png", i))
+		if err := MakeAnnotationImage(a, width, height, sb.style.FontFamily, float64(sb.style.FontSize), path); err != nil {
+			fmt.Println(ErrorStyle.Render("Couldn't draw annotation: "), err)
+		}
+
+		sb.args = append(sb.args, "-i", path)
+		sb.counter++
+	}
+
+	return sb
+}
+
 // WithMargin adds margin stream.
 func (sb *StreamBuilder) WithMargin() *StreamBuilder {
 	if sb.style.MarginFill != "" {
@@ -299,24 +468,86 @@ func (sb *StreamBuilder) WithCorner() *StreamBuilder {
 	return sb
 }
 
+// WithWatermark adds watermark stream, drawing the image of the text if
+// there is no logo.
+func (sb *StreamBuilder) WithWatermark(watermark *Watermark) *StreamBuilder {
+	if watermark == nil {
+		return sb
+	}
+
+	path := watermark.Image
+	if path == "" {
+		path = filepath.Join(sb.input, "watermark.png")
+		if err := MakeWatermarkImage(*watermark, sb.style.FontFamily, float64(sb.style.FontSize), path); err != nil {
+			fmt.Println(ErrorStyle.Render("Couldn't draw watermark: "), err)
+		}
+	}
+
+	sb.args = append(sb.args,
+		"-loop", "1",
+		"-i", path,
+	)
+	sb.watermarkStream = sb.counter
+	sb.counter++
+
+	return sb
+}
+
+// WithLoops sets the number of times the GIF plays, looping forever if 0.
+func (sb *StreamBuilder) WithLoops(loops int) *StreamBuilder {
+	if loops <= 0 {
+		return sb
+	}
+
+	// ffmpeg counts the repeats after the first play, with -1 for none.
+	repeats := loops - 1
+	if repeats == 0 {
+		repeats = -1
+	}
+	sb.args = append(sb.args, "-loop", fmt.Sprint(repeats))
+
+	return sb
+}
+
 // WithMP4 adds mp4 stream with required config.
-func (sb *StreamBuilder) WithMP4() *StreamBuilder {
+func (sb *StreamBuilder) WithMP4(quality string) *StreamBuilder {
+	preset := qualityPresetFor(quality)
+	if preset.Lossless {
+		sb.args = append(sb.args,
+			"-vcodec", "libx264",
+			"-pix_fmt", "yuv444p",
+			"-an",
+			"-qp", "0",
+		)
+		return sb
+	}
+
 	sb.args = append(sb.args,
 		"-vcodec", "libx264",
 		"-pix_fmt", "yuv420p",
 		"-an",
-		"-crf", "20",
+		"-crf", fmt.Sprint(preset.MP4CRF),
 	)
 
 	return sb
 }
 
 // WithWebm adds webm stream with required config.
-func (sb *StreamBuilder) WithWebm() *StreamBuilder {
+func (sb *StreamBuilder) WithWebm(quality string) *StreamBuilder {
+	preset := qualityPresetFor(quality)
+	if preset.Lossless {
+		sb.args = append(sb.args,
+			"-pix_fmt", "yuv444p",
+			"-an",
+			"-lossless", "1",
+		)
+		return sb
+	}
+
 	sb.args = append(sb.args,
 		"-pix_fmt", "yuv420p",
 		"-an",
-		"-crf", "30",
+		"-crf", fmt.Sprint(preset.WebMCRF),
 		"-b:v", "0",
 	)
 	return sb
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterComplexBuilder_WithGIF(t *testing.T) {
+	build := func(opts VideoOptions) string {
+		fb := &FilterComplexBuilder{filterComplex: &strings.Builder{}, prevStageName: "padded"}
+		return fb.WithGIF(opts).filterComplex.String()
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		filter := build(VideoOptions{})
+		if !strings.Contains(filter, "[plt_a]palettegen=max_colors=256[plt]") {
+			t.Errorf("expected default palettegen, got:\n%s", filter)
+		}
+		if !strings.Contains(filter, "[plt_b][plt]paletteuse[palette]") {
+			t.Errorf("expected default paletteuse, got:\n%s", filter)
+		}
+	})
+
+	t.Run("custom palette", func(t *testing.T) {
+		filter := build(VideoOptions{MaxColors: 128, GIFDither: "bayer", GIFStatsMode: "single"})
+		if !strings.Contains(filter, "palettegen=max_colors=128:stats_mode=single[plt]") {
+			t.Errorf("expected custom palettegen, got:\n%s", filter)
+		}
+		if !strings.Contains(filter, "paletteuse=new=1:dither=bayer[palette]") {
+			t.Errorf("expected custom paletteuse, got:\n%s", filter)
+		}
+	})
+
+	t.Run("loop delay", func(t *testing.T) {
+		filter := build(VideoOptions{LoopDelay: 3 * time.Second})
+		if !strings.Contains(filter, "[padded]tpad=stop_mode=clone:stop_duration=3[delayed]") ||
+			!strings.Contains(filter, "[delayed]split") {
+			t.Errorf("expected the last frame to be held, got:\n%s", filter)
+		}
+	})
+}
+
+func TestFilterComplexBuilder_WithFilter(t *testing.T) {
+	fb := &FilterComplexBuilder{filterComplex: &strings.Builder{}, prevStageName: "padded"}
+	filter := fb.WithFilter("eq=saturation=1.2,unsharp").WithGIF(VideoOptions{}).filterComplex.String()
+	if !strings.Contains(filter, "[padded]eq=saturation=1.2,unsharp[filtered]") || !strings.Contains(filter, "[filtered]split") {
+		t.Errorf("expected the filter to apply before the palette, got:\n%s", filter)
+	}
+
+	fb = &FilterComplexBuilder{filterComplex: &strings.Builder{}, prevStageName: "padded"}
+	if fb.WithFilter("").prevStageName != "padded" {
+		t.Error("expected no filter to leave the filter graph unchanged")
+	}
+}
+
+func TestStreamBuilder_WithLoops(t *testing.T) {
+	tests := []struct {
+		loops    int
+		expected string
+	}{
+		{0, ""},
+		{1, "-loop -1"},
+		{3, "-loop 2"},
+	}
+
+	for _, tc := range tests {
+		args := strings.Join((&StreamBuilder{}).WithLoops(tc.loops).Build(), " ")
+		if args != tc.expected {
+			t.Errorf("expected %d loops to add %q, got %q", tc.loops, tc.expected, args)
+		}
+	}
+}
+
+func TestStreamBuilder_Quality(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(sb *StreamBuilder) *StreamBuilder
+		expected string
+	}{
+		{"mp4 default", func(sb *StreamBuilder) *StreamBuilder { return sb.WithMP4("") }, "-crf 20"},
+		{"mp4 low", func(sb *StreamBuilder) *StreamBuilder { return sb.WithMP4("low") }, "-crf 30"},
+		{"mp4 lossless", func(sb *StreamBuilder) *StreamBuilder { return sb.WithMP4("lossless") }, "-qp 0"},
+		{"webm default", func(sb *StreamBuilder) *StreamBuilder { return sb.WithWebm("") }, "-crf 30"},
+		{"webm medium", func(sb *StreamBuilder) *StreamBuilder { return sb.WithWebm("medium") }, "-crf 38"},
+		{"webm lossless", func(sb *StreamBuilder) *StreamBuilder { return sb.WithWebm("lossless") }, "-lossless 1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			args := strings.Join(tc.build(&StreamBuilder{}).Build(), " ")
+			if !strings.Contains(args, tc.expected) {
+				t.Errorf("expected args to contain %q, got %q", tc.expected, args)
+			}
+		})
+	}
+}
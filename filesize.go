@@ -0,0 +1,203 @@
+//go:build !js
+
+// Package vhs filesize.go keeps the video outputs within a size budget.
+//
+// If an output is larger than the budget after rendering, it is re-encoded
+// with a progressively lower framerate, scale and palette until it fits.
+//
+// Set MaxFileSize 10MB
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// fileSizeUnits maps the supported size suffixes to their number of bytes.
+var fileSizeUnits = map[string]int64{
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+}
+
+// parseFileSize parses a size such as 10MB, 512KB or 1.5GB into bytes.
+// Sizes without a unit are in bytes.
+func parseFileSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := s, "B"
+	if i >= 0 {
+		number, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+
+	multiplier, ok := fileSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid file size unit %q", unit)
+	}
+	n, err := strconv.ParseFloat(number, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file size %q: %w", s, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("file size must be positive: %s", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatFileSize formats a number of bytes for humans.
+func formatFileSize(n int64) string {
+	switch {
+	case n >= fileSizeUnits["GB"]:
+		return fmt.Sprintf("%.1fGB", float64(n)/float64(fileSizeUnits["GB"]))
+	case n >= fileSizeUnits["MB"]:
+		return fmt.Sprintf("%.1fMB", float64(n)/float64(fileSizeUnits["MB"]))
+	case n >= fileSizeUnits["KB"]:
+		return fmt.Sprintf("%.1fKB", float64(n)/float64(fileSizeUnits["KB"]))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// ExecuteSetMaxFileSize sets the size budget of the video outputs.
+func ExecuteSetMaxFileSize(c parser.Command, v *VHS) error {
+	size, err := parseFileSize(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse max file size: %w", err)
+	}
+
+	v.Options.Video.MaxFileSize = size
+	return nil
+}
+
+// sizeReduction is a single step of degrading an output to reduce its size.
+type sizeReduction struct {
+	Framerate int
+	Scale     float64
+	MaxColors int
+}
+
+func (r sizeReduction) String() string {
+	return fmt.Sprintf("%dfps, %.0f%% scale, %d colors", r.Framerate, r.Scale*100, r.MaxColors)
+}
+
+// sizeReductions are the steps tried, in order, to fit an output into the
+// size budget. Each step never exceeds the settings of the original render.
+var sizeReductions = []sizeReduction{
+	{Framerate: 30, Scale: 1, MaxColors: 256},
+	{Framerate: 20, Scale: 0.85, MaxColors: 128},
+	{Framerate: 15, Scale: 0.75, MaxColors: 64},
+	{Framerate: 10, Scale: 0.6, MaxColors: 32},
+	{Framerate: 10, Scale: 0.5, MaxColors: 16},
+}
+
+// apply returns the video options for the reduction step.
+func (r sizeReduction) apply(opts VideoOptions) VideoOptions {
+	framerate := opts.Framerate
+	if opts.OutputFramerate > 0 {
+		framerate = opts.OutputFramerate
+	}
+	opts.OutputFramerate = min(framerate, r.Framerate)
+
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	opts.Scale = scale * r.Scale
+
+	maxColors := opts.MaxColors
+	if maxColors <= 0 {
+		maxColors = defaultMaxColors
+	}
+	opts.MaxColors = min(maxColors, r.MaxColors)
+
+	return opts
+}
+
+// FileSizeError is returned when an output does not fit into the size budget
+// after every reduction was tried.
+type FileSizeError struct {
+	Output   string
+	Size     int64
+	Max      int64
+	Attempts []string
+}
+
+func (e FileSizeError) Error() string {
+	return fmt.Sprintf("%s is %s, which exceeds MaxFileSize %s after %d attempts:\n  %s",
+		e.Output, formatFileSize(e.Size), formatFileSize(e.Max),
+		len(e.Attempts), strings.Join(e.Attempts, "\n  "))
+}
+
+// fileSize returns the size of a file in bytes.
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return info.Size(), nil
+}
+
+// FitFileSize re-encodes every video output which exceeds the size budget
+// until it fits.
+func FitFileSize(opts VideoOptions) error {
+	if opts.MaxFileSize <= 0 {
+		return nil
+	}
+
+	for _, target := range []string{opts.Output.GIF, opts.Output.MP4, opts.Output.WebM} {
+		if target == "" {
+			continue
+		}
+		if err := fitFileSize(opts, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fitFileSize(opts VideoOptions, target string) error {
+	size, err := fileSize(target)
+	if err != nil {
+		return err
+	}
+
+	attempts := []string{fmt.Sprintf("original: %s", formatFileSize(size))}
+	for _, reduction := range sizeReductions {
+		if size <= opts.MaxFileSize {
+			return nil
+		}
+
+		log.Println(GrayStyle.Render(fmt.Sprintf("%s is %s, re-encoding at %s...", target, formatFileSize(size), reduction)))
+		out, err := makeMedia(reduction.apply(opts), target).CombinedOutput()
+		if err != nil {
+			log.Println(string(out))
+			return fmt.Errorf("failed to re-encode %s: %w", target, err)
+		}
+
+		size, err = fileSize(target)
+		if err != nil {
+			return err
+		}
+		attempts = append(attempts, fmt.Sprintf("%s: %s", reduction, formatFileSize(size)))
+	}
+
+	if size <= opts.MaxFileSize {
+		return nil
+	}
+
+	return FileSizeError{
+		Output:   target,
+		Size:     size,
+		Max:      opts.MaxFileSize,
+		Attempts: attempts,
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		err      bool
+	}{
+		{"10MB", 10 << 20, false},
+		{"512KB", 512 << 10, false},
+		{"1.5GB", 3 << 29, false},
+		{"2048", 2048, false},
+		{"10mb", 10 << 20, false},
+		{"10TB", 0, true},
+		{"MB", 0, true},
+		{"0MB", 0, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			size, err := parseFileSize(tc.input)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got %d", size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, size)
+			}
+		})
+	}
+}
+
+func TestSizeReductionApply(t *testing.T) {
+	opts := VideoOptions{Framerate: 24, MaxColors: 128}
+
+	reduced := sizeReductions[0].apply(opts)
+	if reduced.OutputFramerate != 24 || reduced.Scale != 1 || reduced.MaxColors != 128 {
+		t.Errorf("expected reduction to keep lower settings, got %+v", reduced)
+	}
+
+	reduced = sizeReductions[len(sizeReductions)-1].apply(opts)
+	if reduced.OutputFramerate != 10 || reduced.Scale != 0.5 || reduced.MaxColors != 16 {
+		t.Errorf("expected last reduction to apply, got %+v", reduced)
+	}
+}
+
+func TestFitFileSize(t *testing.T) {
+	t.Run("within budget", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "out.gif")
+		if err := os.WriteFile(output, make([]byte, 100), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		opts := VideoOptions{MaxFileSize: 1 << 10, Output: VideoOutputs{GIF: output}}
+		if err := FitFileSize(opts); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("error report", func(t *testing.T) {
+		err := error(FileSizeError{
+			Output:   "out.gif",
+			Size:     15 << 20,
+			Max:      10 << 20,
+			Attempts: []string{"original: 20.0MB", "30fps, 100% scale, 256 colors: 15.0MB"},
+		})
+
+		var sizeErr FileSizeError
+		if !errors.As(err, &sizeErr) {
+			t.Fatal("expected a FileSizeError")
+		}
+		if !strings.Contains(err.Error(), "out.gif is 15.0MB, which exceeds MaxFileSize 10.0MB after 2 attempts") {
+			t.Errorf("unexpected error message: %s", err)
+		}
+	})
+}
@@ -12,6 +12,7 @@ import (
 	xdraw "golang.org/x/image/draw"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gomono"
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
 )
@@ -29,6 +30,15 @@ const (
 	windowControlPadding = 100 // Horizontal padding for window controls
 )
 
+const (
+	defaultFontSize      = 22
+	defaultLineHeight    = 1.0
+	defaultLetterSpacing = 1.0
+)
+
+// bundledFontFamily is the name of the font embedded in VHS.
+const bundledFontFamily = "Go Mono"
+
 var (
 	fontLoader     *FontLoader
 	fontLoaderOnce sync.Once
@@ -253,6 +263,10 @@ func (fl *FontLoader) loadFontFromFamily(fontFamily string, fontSize float64) (f
 // loadSingleFont attempts to load a single font by name.
 // It searches common font directories across different platforms.
 func (fl *FontLoader) loadSingleFont(fontName string, fontSize float64) (font.Face, error) {
+	if fontName == bundledFontFamily {
+		return bundledFontFace(fontSize)
+	}
+
 	// Map of font names to potential file paths
 	fontPaths := fl.getFontPaths(fontName)
 
@@ -344,6 +358,27 @@ func (fl *FontLoader) getFontPaths(fontName string) []string {
 // It supports both TrueType (.ttf) and OpenType (.otf) fonts,
 // as well as TrueType Collection (.ttc) files.
 func (fl *FontLoader) loadFontFromFile(path string, fontSize float64) (font.Face, error) {
+	tt, err := parseFontFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create font face
+	face, err := opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    fontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create font face: %w", err)
+	}
+
+	return face, nil
+}
+
+// parseFontFile parses a TrueType, OpenType or TrueType Collection file,
+// returning the first font of collections.
+func parseFontFile(path string) (*opentype.Font, error) {
 	// Check if file exists
 	if _, err := os.Stat(path); err != nil {
 		return nil, fmt.Errorf("stat font file: %w", err)
@@ -374,17 +409,7 @@ func (fl *FontLoader) loadFontFromFile(path string, fontSize float64) (font.Face
 		}
 	}
 
-	// Create font face
-	face, err := opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    fontSize,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("create font face: %w", err)
-	}
-
-	return face, nil
+	return tt, nil
 }
 
 // GetFallbackFont returns a fallback font face.
@@ -401,6 +426,37 @@ func (fl *FontLoader) GetFallbackFont(fontSize float64) font.Face {
 		}
 	}
 
+	// Then the font bundled with VHS
+	if face, err := bundledFontFace(fontSize); err == nil {
+		return face
+	}
+
 	// If all else fails, return the basic font
 	return getDefaultFont()
 }
+
+// bundledFont returns the parsed bundled font.
+func bundledFont() (*opentype.Font, error) {
+	f, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundled font: %w", err)
+	}
+	return f, nil
+}
+
+// bundledFontFace returns a face of the bundled font.
+func bundledFontFace(fontSize float64) (font.Face, error) {
+	f, err := bundledFont()
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    fontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create font face: %w", err)
+	}
+	return face, nil
+}
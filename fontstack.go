@@ -0,0 +1,187 @@
+// Package vhs fontstack.go handles font fallback chains.
+//
+// FontFamily accepts a comma separated list of fonts, which is passed to
+// xterm.js and the SVG outputs as a CSS font stack so that glyphs missing from
+// the first font (e.g. Powerline or Nerd Font symbols) are drawn by the next
+// font which has them. The stack ends with the color emoji fonts of the
+// common platforms, after the one of EmojiFont if any. After recording, VHS
+// warns about characters none of the configured fonts can draw.
+//
+// Set FontFamily "JetBrains Mono, Symbols Nerd Font"
+// Set EmojiFont "Twemoji Mozilla"
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// maxMissingGlyphs is the number of missing glyphs listed in the warning.
+const maxMissingGlyphs = 10
+
+const fontsSeparator = ","
+
+var defaultFontFamily = withSymbolsFallback(strings.Join([]string{
+	"JetBrains Mono",
+	"DejaVu Sans Mono",
+	"Menlo",
+	"Bitstream Vera Sans Mono",
+	"Inconsolata",
+	"Roboto Mono",
+	"Hack",
+	"Consolas",
+	"ui-monospace",
+	"monospace",
+}, fontsSeparator))
+
+var symbolsFallback = []string{
+	"Apple Symbols",
+}
+
+// emojiFallback are the color emoji fonts of macOS, Windows and Linux.
+var emojiFallback = []string{
+	"Apple Color Emoji",
+	"Segoe UI Emoji",
+	"Noto Color Emoji",
+}
+
+func withSymbolsFallback(font string) string {
+	return font + fontsSeparator + strings.Join(symbolsFallback, fontsSeparator)
+}
+
+// withEmojiFont returns the font family list with the emoji font, if any,
+// before the emoji fonts of the fallback.
+func withEmojiFont(fontFamily, emojiFont string) string {
+	if emojiFont == "" {
+		return fontFamily
+	}
+	return fontFamily + fontsSeparator + emojiFont
+}
+
+// fallbackFonts are the fonts added to every font family list.
+func fallbackFonts() []string {
+	return append(slices.Clone(symbolsFallback), emojiFallback...)
+}
+
+// genericFontFamilies are the CSS generic families, which are never quoted
+// and always resolve to an installed font.
+var genericFontFamilies = map[string]bool{
+	"monospace":    true,
+	"ui-monospace": true,
+	"sans-serif":   true,
+	"serif":        true,
+}
+
+// fontStack returns the CSS font-family stack for a comma separated list of
+// fonts. The symbol and emoji fonts are added before the generic families,
+// which always match and would hide every font after them.
+func fontStack(fontFamily string) string {
+	seen := map[string]bool{}
+	var fonts, generics []string
+	for _, name := range append(parseFontFamily(fontFamily), fallbackFonts()...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if genericFontFamilies[name] {
+			generics = append(generics, name)
+		} else {
+			fonts = append(fonts, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(generics) == 0 {
+		generics = []string{monospaceFont}
+	}
+	return strings.Join(append(fonts, generics...), ", ")
+}
+
+// loadFontChain loads the fonts of a font family list from the system font
+// directories. It returns whether every configured font was found, since
+// glyph coverage can only be judged if all of them are known.
+func (fl *FontLoader) loadFontChain(fontFamily string) ([]*opentype.Font, bool) {
+	var fonts []*opentype.Font
+	complete := true
+	for _, name := range append(parseFontFamily(fontFamily), fallbackFonts()...) {
+		if genericFontFamilies[name] {
+			continue
+		}
+		f := fl.findFont(name)
+		if f != nil {
+			fonts = append(fonts, f)
+			continue
+		}
+		// The fallback fonts are optional, each is only installed on one
+		// platform.
+		if !slices.Contains(fallbackFonts(), name) {
+			complete = false
+		}
+	}
+	return fonts, complete
+}
+
+// findFont returns the first font file matching the name, or nil.
+func (fl *FontLoader) findFont(name string) *opentype.Font {
+	if name == bundledFontFamily {
+		f, _ := bundledFont()
+		return f
+	}
+	for _, path := range fl.getFontPaths(name) {
+		if f, err := parseFontFile(path); err == nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// missingGlyphs returns the characters of the text which none of the fonts
+// has a glyph for, in order of appearance.
+func missingGlyphs(fonts []*opentype.Font, text []string) []rune {
+	var buf sfnt.Buffer
+	seen := map[rune]bool{}
+	var missing []rune
+	for _, line := range text {
+		for _, r := range line {
+			// Every monospace font covers ASCII, and marks and format
+			// characters are never drawn on their own. Private use
+			// characters are checked, since Nerd Font icons live there.
+			if r < utf8.RuneSelf || seen[r] || unicode.IsSpace(r) || unicode.IsControl(r) ||
+				unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+				continue
+			}
+			seen[r] = true
+			if !hasGlyph(fonts, &buf, r) {
+				missing = append(missing, r)
+			}
+		}
+	}
+	return missing
+}
+
+// hasGlyph returns whether any of the fonts has a glyph for r.
+func hasGlyph(fonts []*opentype.Font, buf *sfnt.Buffer, r rune) bool {
+	for _, f := range fonts {
+		if i, err := f.GlyphIndex(buf, r); err == nil && i != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// formatGlyphs lists the characters with their code points.
+func formatGlyphs(glyphs []rune) string {
+	parts := make([]string, 0, min(len(glyphs), maxMissingGlyphs)+1)
+	for i, r := range glyphs {
+		if i == maxMissingGlyphs {
+			parts = append(parts, fmt.Sprintf("and %d more", len(glyphs)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%c (U+%04X)", r, r))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestFontStack(t *testing.T) {
+	const emoji = `"Apple Color Emoji", "Segoe UI Emoji", "Noto Color Emoji"`
+	tests := []struct {
+		family string
+		want   string
+	}{
+		{"", `"Apple Symbols", ` + emoji + `, monospace`},
+		{"JetBrains Mono", `"JetBrains Mono", "Apple Symbols", ` + emoji + `, monospace`},
+		{"JetBrains Mono, Symbols Nerd Font", `"JetBrains Mono", "Symbols Nerd Font", "Apple Symbols", ` + emoji + `, monospace`},
+		{"'Fira Code', monospace, Symbols Nerd Font", `"Fira Code", "Symbols Nerd Font", "Apple Symbols", ` + emoji + `, monospace`},
+		{defaultFontFamily, `"JetBrains Mono", "DejaVu Sans Mono", "Menlo", "Bitstream Vera Sans Mono", "Inconsolata", "Roboto Mono", "Hack", "Consolas", "Apple Symbols", ` + emoji + `, ui-monospace, monospace`},
+		{withEmojiFont("JetBrains Mono", "Twemoji Mozilla"), `"JetBrains Mono", "Twemoji Mozilla", "Apple Symbols", ` + emoji + `, monospace`},
+		{withEmojiFont("JetBrains Mono", "Noto Color Emoji"), `"JetBrains Mono", "Noto Color Emoji", "Apple Symbols", "Apple Color Emoji", "Segoe UI Emoji", monospace`},
+	}
+
+	for _, tc := range tests {
+		if got := fontStack(tc.family); got != tc.want {
+			t.Errorf("fontStack(%q) = %s, want %s", tc.family, got, tc.want)
+		}
+	}
+}
+
+func TestMissingGlyphs(t *testing.T) {
+	f, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := []string{"~/src \ue0b0 \ue0a0 main ✗", "café → λ", "\ue0a0 main"}
+	missing := missingGlyphs([]*opentype.Font{f}, text)
+
+	want := []rune{'\ue0b0', '\ue0a0', '✗'}
+	if string(missing) != string(want) {
+		t.Errorf("expected missing glyphs %q, got %q", string(want), string(missing))
+	}
+
+	if got := formatGlyphs(missing[:1]); got != "\ue0b0 (U+E0B0)" {
+		t.Errorf("unexpected formatted glyphs %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !js
+
+// Package vhs framefilter.go post-processes the frames before encoding.
+//
+// Set VideoFilter applies an ffmpeg filter chain to the frames of the video
+// outputs once the window, margin and watermark are composed, e.g. for color
+// grading or sharpening. The chain is spliced into the filter graph of ffmpeg,
+// so it cannot have labels or chains of its own, nor filters which read files
+// or generate frames of their own. Set FrameCommand runs a shell command in the
+// directory of the captured frames (frame-text-00001.png, ... for the
+// terminal and frame-cursor-00001.png, ... for the cursor), which may edit
+// them in place before they are encoded.
+//
+// Set VideoFilter "eq=saturation=1.2,unsharp"
+// Set FrameCommand "mogrify -sharpen 0x1 frame-text-*.png"
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// errSandboxedFrameCommand is returned by FrameCommand in sandboxed
+// recordings, where it would run outside of the sandbox.
+var errSandboxedFrameCommand = errors.New("FrameCommand is not allowed in sandboxed recordings")
+
+// forbiddenVideoFilters are the ffmpeg filters which read files, load
+// libraries or generate frames instead of filtering the frames of the video.
+var forbiddenVideoFilters = []string{
+	"movie", "amovie", "subtitles", "ass", "sendcmd", "asendcmd", "zmq", "azmq",
+	"lut1d", "lut3d", "haldclutsrc", "frei0r", "frei0r_src", "ladspa", "lv2",
+	"ocv", "dnn_processing", "dnn_classify", "dnn_detect", "sr", "derain",
+	"buffer", "abuffer", "color", "nullsrc", "testsrc", "testsrc2", "rgbtestsrc",
+	"yuvtestsrc", "smptebars", "smptehdbars", "allrgb", "allyuv", "cellauto",
+	"life", "mandelbrot", "gradients", "sierpinski", "sine", "anullsrc",
+}
+
+// positionalFileFilters are the filters whose unnamed options include files.
+var positionalFileFilters = []string{"drawtext", "psnr", "ssim", "vmaf", "libvmaf"}
+
+// validateVideoFilter returns an error if the filter chain has labels or
+// chains of its own, or filters or options which read or write files.
+func validateVideoFilter(filter string) error {
+	if strings.ContainsAny(filter, "[];") {
+		return errors.New("video filters cannot have labels or several chains")
+	}
+	for _, f := range strings.Split(filter, ",") {
+		name, options, _ := strings.Cut(strings.TrimSpace(f), "=")
+		if slices.Contains(forbiddenVideoFilters, name) {
+			return fmt.Errorf("video filter %s is not allowed", name)
+		}
+		if options == "" {
+			continue
+		}
+		for _, option := range strings.Split(options, ":") {
+			key, _, named := strings.Cut(option, "=")
+			if !named && slices.Contains(positionalFileFilters, name) {
+				return fmt.Errorf("options of video filter %s must be named", name)
+			}
+			if named && (key == "f" || strings.Contains(strings.ToLower(key), "file") || strings.Contains(key, "path") || key == "model") {
+				return fmt.Errorf("option %s of video filter %s is not allowed", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ExecuteSetVideoFilter sets the ffmpeg filter chain applied to the frames.
+func ExecuteSetVideoFilter(c parser.Command, v *VHS) error {
+	if err := validateVideoFilter(c.Args); err != nil {
+		return err
+	}
+	v.Options.Video.Filter = c.Args
+	return nil
+}
+
+// ExecuteSetFrameCommand sets the shell command run over the frames.
+func ExecuteSetFrameCommand(c parser.Command, v *VHS) error {
+	if v.Options.Sandbox.Dir != "" || len(v.Options.Sandbox.Command) > 0 {
+		return errSandboxedFrameCommand
+	}
+	v.Options.Video.FrameCommand = c.Args
+	return nil
+}
+
+// runFrameCommand runs the FrameCommand in the directory of the frames, with
+// the directory and framerate in $VHS_FRAMES and $VHS_FRAMERATE.
+func runFrameCommand(opts VideoOptions) error {
+	if opts.FrameCommand == "" {
+		return nil
+	}
+
+	cmd := exec.Command(hookShell[0], append(hookShell[1:], opts.FrameCommand)...) //nolint:gosec,noctx
+	cmd.Dir = opts.Input
+	cmd.Env = append(os.Environ(),
+		"VHS_FRAMES="+opts.Input,
+		"VHS_FRAMERATE="+strconv.Itoa(opts.Framerate),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run frame command %q: %w\n%s", opts.FrameCommand, err, out)
+	}
+	return nil
+}
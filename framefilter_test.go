@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+)
+
+func TestRunFrameCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command is written for sh")
+	}
+
+	dir := t.TempDir()
+	opts := VideoOptions{Input: dir, Framerate: 30, FrameCommand: `printf '%s %s' "$VHS_FRAMERATE" "$(basename "$VHS_FRAMES")" > info.txt`}
+	if err := runFrameCommand(opts); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "info.txt"))
+	if err != nil {
+		t.Fatalf("expected the command to run in the frames directory: %v", err)
+	}
+	if expected := "30 " + filepath.Base(dir); string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+
+	opts.FrameCommand = "echo broken >&2; exit 3"
+	if err := runFrameCommand(opts); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected the output of the failed command, got %v", err)
+	}
+}
+
+func TestSetFrameCommandSandboxed(t *testing.T) {
+	v := New()
+	v.Options.Sandbox = ShellSandbox{Dir: t.TempDir()}
+	err := ExecuteSetFrameCommand(parser.Command{Type: token.SET, Options: "FrameCommand", Args: "rm -rf /"}, &v)
+	if err != errSandboxedFrameCommand || v.Options.Video.FrameCommand != "" {
+		t.Errorf("expected FrameCommand to be refused, got %v", err)
+	}
+}
+
+func TestValidateVideoFilter(t *testing.T) {
+	for _, filter := range []string{
+		"eq=saturation=1.2,unsharp",
+		"drawtext=text=vhs:fontsize=24:x=10:y=10",
+		"hue=s=0, vignette",
+	} {
+		if err := validateVideoFilter(filter); err != nil {
+			t.Errorf("expected %q to be allowed, got %v", filter, err)
+		}
+	}
+
+	for _, filter := range []string{
+		"drawtext=textfile=/etc/passwd",
+		"drawtext=/usr/share/fonts/a.ttf:x:/etc/passwd",
+		"eq,movie=/home/u/secret.png",
+		"subtitles=/etc/passwd",
+		"unsharp;[0]null",
+		"[in]eq[out]",
+		"sendcmd=f=cmds.txt",
+		"psnr=stats_file=/tmp/x",
+	} {
+		if err := validateVideoFilter(filter); err == nil {
+			t.Errorf("expected %q to be rejected", filter)
+		}
+	}
+
+	v := New()
+	err := ExecuteSetVideoFilter(parser.Command{Type: token.SET, Options: "VideoFilter", Args: "drawtext=textfile=/etc/passwd"}, &v)
+	if err == nil || v.Options.Video.Filter != "" {
+		t.Errorf("expected the filter to be refused, got %v", err)
+	}
+}
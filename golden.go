@@ -0,0 +1,194 @@
+//go:build !js
+
+// Package vhs golden.go compares recordings against golden files.
+//
+// vhs test records tapes without writing their outputs and compares the
+// terminal screens against golden text snapshots: the final screen, and the
+// screen at every Screenshot command, named after its path. Mismatches are
+// printed as unified diffs, and --update rewrites the golden files.
+//
+// vhs test demo.tape --golden golden/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/spf13/cobra"
+)
+
+// goldenExtension is the extension of golden files.
+const goldenExtension = ".golden"
+
+// finalSnapshot names the snapshot of the screen at the end of the tape.
+const finalSnapshot = "final"
+
+// snapshot is the terminal screen at a point of the tape.
+type snapshot struct {
+	Name  string
+	Lines []string
+}
+
+var (
+	goldenDir    string
+	updateGolden bool
+
+	testCmd = &cobra.Command{
+		Use:   "test <file>...",
+		Short: "Record tapes and compare their terminal screens against golden files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if backendFlag != nativeBackend {
+				if err := ensureDependencies(); err != nil {
+					return err
+				}
+			}
+
+			failed := 0
+			for _, file := range args {
+				ok, err := testTape(cmd, file)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d tapes differ from their golden files", failed, len(args))
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	testCmd.Flags().StringVar(&goldenDir, "golden", "golden", "directory of the golden files")
+	testCmd.Flags().StringVar(&backendFlag, "backend", browserBackend, "capture backend: browser or native")
+	testCmd.Flags().BoolVarP(&updateGolden, "update", "u", false, "write the golden files instead of comparing them")
+}
+
+// testTape records the tape and compares its snapshots against its golden
+// file, returning whether they match.
+func testTape(cmd *cobra.Command, file string) (bool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read tape: %w", err)
+	}
+
+	var vhs *VHS
+	errs := Evaluate(cmd.Context(), string(b), io.Discard, func(v *VHS) {
+		v.Options.Test.Golden = goldenPath(file)
+		v.Options.Test.Output = ""
+		v.Options.Video.Output = VideoOutputs{}
+		vhs = v
+	}, WithBackend(backendFlag), WithOffline(offlineFlag || offlineFromEnv()))
+	if len(errs) > 0 {
+		printErrors(os.Stderr, string(b), errs)
+		return false, fmt.Errorf("failed to record %s", file)
+	}
+
+	path := vhs.Options.Test.Golden
+	got := formatSnapshots(vhs.snapshots)
+	if updateGolden {
+		if err := writeGolden(path, got); err != nil {
+			return false, err
+		}
+		log.Println(StringStyle.Render("UPDATED") + " " + file)
+		return true, nil
+	}
+
+	diff, err := compareGolden(path, got)
+	if err != nil {
+		return false, err
+	}
+	if diff != "" {
+		log.Println(ErrorStyle.Render("FAIL") + " " + file)
+		cmd.Print(colorDiff(diff))
+		return false, nil
+	}
+	log.Println(StringStyle.Render("PASS") + " " + file)
+	return true, nil
+}
+
+// goldenPath returns the path of the golden file of a tape.
+func goldenPath(tape string) string {
+	name := strings.TrimSuffix(filepath.Base(tape), extension)
+	return filepath.Join(goldenDir, name+goldenExtension)
+}
+
+// takeSnapshot stores the current screen under the given name.
+func (v *VHS) takeSnapshot(name string) error {
+	lines, err := v.Buffer()
+	if err != nil {
+		return err
+	}
+	v.snapshots = append(v.snapshots, snapshot{Name: name, Lines: lines})
+	return nil
+}
+
+// formatSnapshots returns the golden file contents of the snapshots. Every
+// snapshot starts with a header holding its name, and trailing blank lines
+// of the screen are dropped.
+func formatSnapshots(snapshots []snapshot) string {
+	var sb strings.Builder
+	for _, s := range snapshots {
+		fmt.Fprintf(&sb, "── %s ──\n", s.Name)
+		lines := s.Lines
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// compareGolden returns a unified diff between the golden file and got, or
+// an empty string if they match.
+func compareGolden(path, got string) (string, error) {
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("golden file %s does not exist, create it with --update", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read golden file: %w", err)
+	}
+	if string(want) == got {
+		return "", nil
+	}
+	return udiff.Unified(path, "recording", string(want), got), nil
+}
+
+func writeGolden(path, contents string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create golden directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		return fmt.Errorf("failed to write golden file: %w", err)
+	}
+	return nil
+}
+
+// colorDiff highlights the added and removed lines of a unified diff.
+func colorDiff(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			lines[i] = FaintStyle.Render(strings.TrimSuffix(line, "\n")) + "\n"
+		case strings.HasPrefix(line, "-"):
+			lines[i] = ErrorStyle.Render(strings.TrimSuffix(line, "\n")) + "\n"
+		case strings.HasPrefix(line, "+"):
+			lines[i] = StringStyle.Render(strings.TrimSuffix(line, "\n")) + "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
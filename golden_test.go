@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatSnapshots(t *testing.T) {
+	got := formatSnapshots([]snapshot{
+		{Name: "list.png", Lines: []string{"> ls", "demo.tape", "", ""}},
+		{Name: finalSnapshot, Lines: []string{"> exit", ""}},
+	})
+	want := "── list.png ──\n> ls\ndemo.tape\n── final ──\n> exit\n"
+	if got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestCompareGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden", "demo.golden")
+
+	if _, err := compareGolden(path, "> ls\n"); err == nil || !strings.Contains(err.Error(), "--update") {
+		t.Errorf("expected missing golden file to suggest --update, got %v", err)
+	}
+
+	if err := writeGolden(path, "> ls\ndemo.tape\n"); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := compareGolden(path, "> ls\ndemo.tape\n")
+	if err != nil || diff != "" {
+		t.Errorf("expected no diff, got %q (%v)", diff, err)
+	}
+
+	diff, err = compareGolden(path, "> ls\nother.tape\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-demo.tape") || !strings.Contains(diff, "+other.tape") {
+		t.Errorf("expected unified diff, got:\n%s", diff)
+	}
+}
+
+func TestGoldenPath(t *testing.T) {
+	goldenDir = "testdata"
+	defer func() { goldenDir = "golden" }()
+
+	if got, want := goldenPath("examples/demo.tape"), filepath.Join("testdata", "demo.golden"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,64 @@
+// Package vhs highlight.go draws attention to a span of the terminal.
+//
+// Highlight selects the text from the start to the end position (rows and
+// columns are 1-based and inclusive) for a duration, without blocking the
+// following commands. Videos show the selection of xterm.js, SVG outputs draw
+// the selection color behind the text.
+//
+// Highlight[@<time>] <row>,<col> <row>,<col>
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultHighlightDuration is how long a Highlight is shown by default.
+const defaultHighlightDuration = time.Second
+
+// Selection is a span of terminal cells in reading order, from the start to
+// the end cell (inclusive). Rows and columns are 0-based.
+type Selection struct {
+	StartRow int `json:"startRow"`
+	StartCol int `json:"startCol"`
+	EndRow   int `json:"endRow"`
+	EndCol   int `json:"endCol"`
+}
+
+// Columns returns the range of columns [start, end) selected on the row of a
+// terminal with the given number of columns.
+func (s Selection) Columns(row, cols int) (start, end int, ok bool) {
+	if row < s.StartRow || row > s.EndRow {
+		return 0, 0, false
+	}
+	start, end = 0, cols
+	if row == s.StartRow {
+		start = s.StartCol
+	}
+	if row == s.EndRow {
+		end = min(s.EndCol+1, cols)
+	}
+	return start, end, start < end
+}
+
+// highlight is the active Highlight, shown until the given frame.
+type highlight struct {
+	Selection
+	until int
+}
+
+// parseSelection parses the <row>,<col> <row>,<col> arguments of Highlight.
+func parseSelection(args string) (Selection, error) {
+	var s Selection
+	if _, err := fmt.Sscanf(args, "%d,%d %d,%d", &s.StartRow, &s.StartCol, &s.EndRow, &s.EndCol); err != nil {
+		return s, fmt.Errorf("failed to parse highlight: %w", err)
+	}
+	s.StartRow--
+	s.StartCol--
+	s.EndRow--
+	s.EndCol--
+	if s.EndRow < s.StartRow || s.EndRow == s.StartRow && s.EndCol < s.StartCol {
+		return s, fmt.Errorf("highlight ends before it starts: %s", args)
+	}
+	return s, nil
+}
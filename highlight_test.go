@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestParseSelection(t *testing.T) {
+	s, err := parseSelection("2,5 3,20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Selection{StartRow: 1, StartCol: 4, EndRow: 2, EndCol: 19}); s != want {
+		t.Errorf("parseSelection() = %+v, want %+v", s, want)
+	}
+
+	if _, err := parseSelection("2,20 2,5"); err == nil {
+		t.Error("expected an error for a highlight ending before it starts")
+	}
+}
+
+func TestSelectionColumns(t *testing.T) {
+	s := Selection{StartRow: 1, StartCol: 4, EndRow: 3, EndCol: 2}
+	tests := []struct {
+		row        int
+		start, end int
+		ok         bool
+	}{
+		{0, 0, 0, false},
+		{1, 4, 10, true},
+		{2, 0, 10, true},
+		{3, 0, 3, true},
+		{4, 0, 0, false},
+	}
+	for _, tc := range tests {
+		start, end, ok := s.Columns(tc.row, 10)
+		if start != tc.start || end != tc.end || ok != tc.ok {
+			t.Errorf("Columns(%d) = %d, %d, %v, want %d, %d, %v", tc.row, start, end, ok, tc.start, tc.end, tc.ok)
+		}
+	}
+}
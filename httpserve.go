@@ -0,0 +1,657 @@
+//go:build !js
+
+// Package vhs httpserve.go exposes tape rendering over HTTP.
+//
+// vhs serve --http :8080 accepts tapes with POST /render and responds with
+// the rendered output. Renders are limited in number and duration, and every
+// recording runs in a temporary directory, optionally wrapped in a sandbox
+// command. Commands which access the files, clipboard or environment of the
+// server are rejected. Since tapes run shell commands, clients always need a
+// token, generated at startup if none is configured, requests from web pages
+// and for other host names are rejected, and the server refuses to listen on
+// other hosts than localhost unless the token is configured or the shell is
+// wrapped in a sandbox.
+//
+// GET /stream upgrades to a WebSocket which receives a tape and pushes the
+// progress of the recording as it happens.
+//
+// curl --data-binary @demo.tape 'localhost:8080/render?format=gif' > demo.gif
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// defaultStreamFPS is the default rate of frames sent to stream clients.
+	defaultStreamFPS = 10
+	// streamBufferSize is the number of events buffered for stream clients
+	// before frames are dropped.
+	streamBufferSize = 64
+)
+
+// httpConfig configures the HTTP server, read from VHS_ prefixed environment
+// variables.
+type httpConfig struct {
+	MaxConcurrent int           `env:"HTTP_MAX_CONCURRENT" envDefault:"2"`
+	Timeout       time.Duration `env:"HTTP_TIMEOUT" envDefault:"5m"`
+	MaxTapeSize   int64         `env:"HTTP_MAX_TAPE_SIZE" envDefault:"65536"`
+	Sandbox       string        `env:"HTTP_SANDBOX"`
+	Token         string        `env:"HTTP_TOKEN"`
+	Origins       []string      `env:"HTTP_ORIGINS"`
+	Hosts         []string      `env:"HTTP_HOSTS"`
+}
+
+// forbiddenServeCommands access the files, clipboard or environment of the
+// server. Env sets the environment of VHS itself, which concurrent renders and
+// the programs they start share.
+var forbiddenServeCommands = map[token.Type]bool{
+	token.SOURCE:     true,
+	token.TYPE_FILE:  true,
+	token.SCREENSHOT: true,
+	token.COPY:       true,
+	token.PASTE:      true,
+	token.ENV:        true,
+}
+
+// serveEnv are the variables of the environment of the server which shells
+// of remote tapes inherit. Others, e.g. VHS_HTTP_TOKEN or credentials, are
+// not passed on.
+var serveEnv = []string{"PATH", "LANG", "LC_ALL", "LC_CTYPE", "TZ", "USER", "LOGNAME", "SHELL"}
+
+// serveEnviron returns the variables of serveEnv of the environment.
+func serveEnviron() []string {
+	env := []string{}
+	for _, name := range serveEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// serveFormats maps the formats which can be requested to their extension.
+var serveFormats = map[string]string{
+	"gif":  gif,
+	"mp4":  mp4,
+	"webm": webm,
+	"svg":  svg,
+}
+
+// serveContentTypes maps the output extensions to their content type.
+var serveContentTypes = map[string]string{
+	gif:  "image/gif",
+	mp4:  "video/mp4",
+	webm: "video/webm",
+	svg:  "image/svg+xml",
+}
+
+// withServeOutput returns an EvaluatorOption replacing the outputs of the
+// tape with a single file at base, using the given extension or the first
+// output of the tape (MP4, WebM, SVG, then GIF). The path of the output is
+// stored in out.
+func withServeOutput(base, ext string, out *string) EvaluatorOption {
+	return func(v *VHS) {
+		// Evaluator options are applied before and after the tape, so ext
+		// must not be overwritten.
+		e := ext
+		if e == "" {
+			switch {
+			case v.Options.Video.Output.MP4 != "":
+				e = mp4
+			case v.Options.Video.Output.WebM != "":
+				e = webm
+			case v.Options.Video.Output.SVG != "":
+				e = svg
+			default:
+				e = gif
+			}
+		}
+		*out = base + e
+
+		v.Options.Test.Output = ""
+		v.Options.Video.Output = VideoOutputs{}
+		switch e {
+		case mp4:
+			v.Options.Video.Output.MP4 = *out
+		case webm:
+			v.Options.Video.Output.WebM = *out
+		case svg:
+			v.Options.Video.Output.SVG = *out
+		default:
+			v.Options.Video.Output.GIF = *out
+		}
+	}
+}
+
+// validateServeTape returns an error if the tape uses commands which are not
+// allowed when rendering for remote clients. Tapes in the tape language are
+// checked before they are parsed too, since parsing reads the files of Source
+// and TypeFile.
+func validateServeTape(tape string) error {
+	if !isJSONTape(tape) {
+		var errs []parser.Error
+		l := lexer.New(tape)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			if forbiddenServeCommands[tok.Type] {
+				errs = append(errs, parser.NewError(tok, tok.Literal+" is not allowed when rendering over HTTP"))
+			}
+		}
+		if len(errs) > 0 {
+			return InvalidSyntaxError{errs}
+		}
+	}
+
+	cmds, err := parseTape(tape)
+	if err != nil {
+		// Syntax errors are reported when the tape is evaluated.
+		return nil
+	}
+	return validateServeCommands(cmds)
+}
+
+// validateServeCommands returns an error if the commands access the files or
+// clipboard of the server, or write text outputs, which are written while
+// recording.
+func validateServeCommands(cmds []parser.Command) error {
+	var errs []error
+	for _, c := range cmds {
+		switch {
+		case forbiddenServeCommands[token.Type(c.Type)]:
+			errs = append(errs, serveCommandError(c, string(c.Type)+" is not allowed when rendering over HTTP"))
+		case c.Type == token.OUTPUT && isTextOutput(c):
+			errs = append(errs, serveCommandError(c, "text outputs are not allowed when rendering over HTTP"))
+		case c.Type == token.TITLE_CARD:
+			if _, _, logo := titleCardArgs(c.Args); logo != "" {
+				errs = append(errs, serveCommandError(c, "TitleCard logos are not allowed when rendering over HTTP"))
+			}
+		case c.Type == token.SET && c.Options == "Watermark":
+			if args := strings.SplitN(c.Args, " ", 3); len(args) == 3 && isWatermarkImage(args[2]) {
+				errs = append(errs, serveCommandError(c, "Watermark images are not allowed when rendering over HTTP"))
+			}
+		case c.Type == token.SET && c.Options == "VideoFilter":
+			errs = append(errs, serveCommandError(c, "VideoFilter is not allowed when rendering over HTTP"))
+		case c.Type == token.SET && c.Options == "MarginFill":
+			if c.Args != "" && !marginFillIsColor(c.Args) {
+				errs = append(errs, serveCommandError(c, "MarginFill images are not allowed when rendering over HTTP"))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isTextOutput returns whether the Output command writes the text of the
+// terminal, as ExecuteOutput does.
+func isTextOutput(c parser.Command) bool {
+	ext, _, _ := strings.Cut(c.Options, " loops=")
+	switch ext {
+	case ".test", ".ascii", ".txt":
+		return true
+	default:
+		return false
+	}
+}
+
+// serveCommandError returns the error of a command rejected by the server, with
+// its line if it has one.
+func serveCommandError(c parser.Command, msg string) error {
+	if c.Line > 0 {
+		return fmt.Errorf("line %d: %s", c.Line, msg)
+	}
+	return errors.New(msg)
+}
+
+// loopbackHosts are the host names of the server when it listens on localhost.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// httpServer renders tapes sent over HTTP.
+type httpServer struct {
+	cfg   httpConfig
+	slots chan struct{}
+	// hosts are the host names requests can be sent to, any if nil.
+	hosts []string
+}
+
+// newHTTPServer returns the server listening on addr. A random token is
+// generated if the configuration has none.
+func newHTTPServer(addr string, cfg httpConfig) (*httpServer, error) {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.Token == "" {
+		b := make([]byte, 16) //nolint:mnd
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate token: %w", err)
+		}
+		cfg.Token = hex.EncodeToString(b)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	return &httpServer{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxConcurrent),
+		hosts: serveHosts(host, cfg.Hosts),
+	}, nil
+}
+
+// serveHosts returns the host names requests can be sent to when listening on
+// host: the loopback names, host and the configured ones. Requests for other
+// names, e.g. of a DNS rebinding attack, are rejected. Servers listening on
+// every interface accept any name unless some are configured.
+func serveHosts(host string, configured []string) []string {
+	ip := net.ParseIP(host)
+	if (host == "" || ip != nil && ip.IsUnspecified()) && len(configured) == 0 {
+		return nil
+	}
+	hosts := slices.Clone(loopbackHosts)
+	if host != "" && (ip == nil || !ip.IsUnspecified()) {
+		hosts = append(hosts, host)
+	}
+	return append(hosts, configured...)
+}
+
+// allowedHost returns whether the request was sent to a host name of the
+// server.
+func (s *httpServer) allowedHost(r *http.Request) bool {
+	if s.hosts == nil {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	return slices.ContainsFunc(s.hosts, func(h string) bool { return strings.EqualFold(h, host) })
+}
+
+// allowedOrigin returns whether a request with the Origin header can be
+// served. Clients which are not browsers send none, and web pages must be of
+// the origins of VHS_HTTP_ORIGINS.
+func (s *httpServer) allowedOrigin(origin string) bool {
+	return origin == "" || slices.Contains(s.cfg.Origins, origin)
+}
+
+// checkRequest returns a handler which rejects requests for other host names
+// than the ones of the server, and requests of web pages of other origins
+// than the allowed ones, which browsers send without preflight.
+func (s *httpServer) checkRequest(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r) {
+			http.Error(w, fmt.Sprintf("host %q is not allowed", r.Host), http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); !s.allowedOrigin(origin) {
+			http.Error(w, fmt.Sprintf("origin %q is not allowed", origin), http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *httpServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /render", s.checkRequest(s.authorize(s.render)))
+	// Browsers let any page open WebSockets, so the handshake only accepts
+	// pages of the allowed origins, and clients without an Origin header,
+	// which are not browsers, connecting to a host name of the server.
+	stream := websocket.Server{Handler: s.stream, Handshake: s.handshake}
+	mux.HandleFunc("GET /stream", s.authorize(stream.ServeHTTP))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	return mux
+}
+
+// authorize returns a handler which rejects requests without the token of the
+// server, as a bearer token or the token query parameter.
+func (s *httpServer) authorize(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// authorized returns whether the request carries the token of the server.
+func (s *httpServer) authorized(r *http.Request) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		got = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.cfg.Token)) == 1
+}
+
+func (s *httpServer) render(w http.ResponseWriter, r *http.Request) {
+	ext := ""
+	if format := r.URL.Query().Get("format"); format != "" {
+		var ok bool
+		if ext, ok = serveFormats[format]; !ok {
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+			return
+		}
+	}
+
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxTapeSize))
+	if err != nil {
+		http.Error(w, "tape is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	tape := string(b)
+	if strings.TrimSpace(tape) == "" {
+		http.Error(w, "no tape provided", http.StatusBadRequest)
+		return
+	}
+	if err := validateServeTape(tape); err != nil {
+		var buf bytes.Buffer
+		printErrors(&buf, tape, []error{err})
+		http.Error(w, buf.String(), http.StatusBadRequest)
+		return
+	}
+
+	if !s.acquire() {
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, "too many renders in progress", http.StatusTooManyRequests)
+		return
+	}
+	defer s.release()
+
+	ctx := r.Context()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	dir, err := os.MkdirTemp("", "vhs-http-")
+	if err != nil {
+		http.Error(w, "failed to create working directory", http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	var output string
+	errs := s.evaluate(ctx, tape, dir, ext, &output)
+	if len(errs) > 0 {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		var buf bytes.Buffer
+		printErrors(&buf, tape, errs)
+		http.Error(w, buf.String(), status)
+		return
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		http.Error(w, "no output was rendered", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close() //nolint:errcheck
+
+	w.Header().Set("Content-Type", serveContentTypes[filepath.Ext(output)])
+	if info, err := f.Stat(); err == nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		log.Printf("failed to send output: %v", err)
+	}
+}
+
+// acquire reserves a render slot, returning false if all are in use.
+func (s *httpServer) acquire() bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *httpServer) release() {
+	<-s.slots
+}
+
+// evaluate renders the tape in dir, storing the path of the output in out.
+func (s *httpServer) evaluate(ctx context.Context, tape, dir, ext string, out *string, opts ...EvaluatorOption) []error {
+	var logs bytes.Buffer
+	opts = append([]EvaluatorOption{
+		func(v *VHS) {
+			v.Options.Sandbox = ShellSandbox{Dir: dir, Command: strings.Fields(s.cfg.Sandbox), Environ: serveEnviron()}
+		},
+		withServeOutput(filepath.Join(dir, "output"), ext, out),
+	}, opts...)
+	return Evaluate(ctx, tape, &logs, opts...)
+}
+
+// handshake rejects WebSocket connections for other host names than the ones
+// of the server, e.g. of a DNS rebinding attack whose pages have the origin of
+// the host, and connections opened by pages of other origins than the ones of
+// VHS_HTTP_ORIGINS.
+func (s *httpServer) handshake(config *websocket.Config, r *http.Request) error {
+	if !s.allowedHost(r) {
+		return fmt.Errorf("host %q is not allowed", r.Host)
+	}
+	origin := r.Header.Get("Origin")
+	if !s.allowedOrigin(origin) {
+		return fmt.Errorf("origin %q is not allowed", origin)
+	}
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	config.Origin = u
+	return nil
+}
+
+// stream renders a tape sent as the first message of the WebSocket
+// connection. The progress of the recording is pushed as JSON events, with
+// frames limited to the fps query parameter, and the output is sent as a
+// binary message after the done event. Sending "abort" or closing the
+// connection stops the recording.
+func (s *httpServer) stream(ws *websocket.Conn) {
+	defer ws.Close() //nolint:errcheck
+
+	sendError := func(msg string) {
+		_ = websocket.JSON.Send(ws, Event{Type: EventError, Error: msg})
+	}
+
+	ext := ""
+	if format := ws.Request().URL.Query().Get("format"); format != "" {
+		var ok bool
+		if ext, ok = serveFormats[format]; !ok {
+			sendError(fmt.Sprintf("unsupported format %q", format))
+			return
+		}
+	}
+	fps := defaultStreamFPS
+	if v := ws.Request().URL.Query().Get("fps"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			sendError(fmt.Sprintf("invalid fps %q", v))
+			return
+		}
+		fps = n
+	}
+
+	ws.MaxPayloadBytes = int(s.cfg.MaxTapeSize)
+	var tape string
+	if err := websocket.Message.Receive(ws, &tape); err != nil {
+		sendError("failed to read tape: " + err.Error())
+		return
+	}
+	if strings.TrimSpace(tape) == "" {
+		sendError("no tape provided")
+		return
+	}
+	if err := validateServeTape(tape); err != nil {
+		var buf bytes.Buffer
+		printErrors(&buf, tape, []error{err})
+		sendError(buf.String())
+		return
+	}
+
+	if !s.acquire() {
+		sendError("too many renders in progress")
+		return
+	}
+	defer s.release()
+
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+	if s.cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	// An "abort" message or a closed connection stops the recording.
+	go func() {
+		for {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil || strings.TrimSpace(msg) == "abort" {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	dir, err := os.MkdirTemp("", "vhs-http-")
+	if err != nil {
+		sendError("failed to create working directory")
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	bus := NewEventBus()
+	events := bus.Subscribe(streamBufferSize)
+	var output string
+	done := make(chan []error, 1)
+	go func() {
+		done <- s.evaluate(ctx, tape, dir, ext, &output, WithEvents(bus))
+		bus.Close()
+	}()
+
+	interval := time.Second / time.Duration(fps)
+	var lastFrame time.Time
+	for e := range events {
+		if e.Type == EventFrame {
+			if time.Since(lastFrame) < interval {
+				continue
+			}
+			lastFrame = time.Now()
+		}
+		if err := websocket.JSON.Send(ws, e); err != nil {
+			cancel()
+		}
+	}
+
+	if errs := <-done; len(errs) > 0 {
+		var buf bytes.Buffer
+		printErrors(&buf, tape, errs)
+		sendError(buf.String())
+		return
+	}
+	b, err := os.ReadFile(output)
+	if err != nil {
+		sendError("no output was rendered")
+		return
+	}
+	if err := websocket.JSON.Send(ws, Event{Type: EventDone}); err != nil {
+		return
+	}
+	if err := websocket.Message.Send(ws, b); err != nil {
+		log.Printf("failed to send output: %v", err)
+	}
+}
+
+// errOpenHTTPServer is returned when the HTTP API would let anyone on the
+// network run shell commands on the server.
+var errOpenHTTPServer = errors.New("refusing to serve the HTTP API beyond localhost without VHS_HTTP_TOKEN or VHS_HTTP_SANDBOX, since tapes run shell commands on this host")
+
+// checkHTTPAccess returns an error if the API would be reachable from other
+// hosts without a configured token or a sandbox.
+func checkHTTPAccess(addr string, cfg httpConfig) error {
+	if cfg.Token != "" || cfg.Sandbox != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return errOpenHTTPServer
+}
+
+// serveHTTP runs the HTTP API on addr until the context is done.
+func serveHTTP(ctx context.Context, addr string, cfg httpConfig) error {
+	if err := checkHTTPAccess(addr, cfg); err != nil {
+		return err
+	}
+	s, err := newHTTPServer(addr, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Token == "" {
+		log.Printf("Clients must send the token %s, set VHS_HTTP_TOKEN to choose it", s.cfg.Token)
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: timeout,
+	}
+
+	sch := make(chan error, 1)
+	go func() {
+		log.Printf("Starting HTTP server on %s", addr)
+		sch <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-sch:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Stopping HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestValidateServeTape(t *testing.T) {
+	if err := validateServeTape("Output demo.gif\nType \"Source Screenshot\"\nEnter\n"); err != nil {
+		t.Errorf("expected tape to be allowed, got %v", err)
+	}
+
+	err := validateServeTape("Source /etc/passwd.tape\nType ls\nPaste\n")
+	if err == nil {
+		t.Fatal("expected Source and Paste to be rejected")
+	}
+	syntaxErr, ok := err.(InvalidSyntaxError)
+	if !ok || len(syntaxErr.Errors) != 2 {
+		t.Errorf("expected two errors, got %v", err)
+	}
+
+	if err := validateServeTape("Output \"/tmp/demo.txt\"\nType ls\n"); err == nil {
+		t.Error("expected text output to be rejected")
+	}
+	if err := validateServeTape("TypeFile \"/etc/passwd\"\n"); err == nil {
+		t.Error("expected TypeFile to be rejected")
+	}
+
+	if err := validateServeTape(`[{"type":"TYPE","args":"ls"},{"type":"ENTER"}]`); err != nil {
+		t.Errorf("expected JSON tape to be allowed, got %v", err)
+	}
+	err = validateServeTape(`[{"type":"SCREENSHOT","args":"/home/u/.bashrc.png"},{"type":"PASTE"},{"type":"OUTPUT","options":".txt","args":"/tmp/demo"}]`)
+	if err == nil || !strings.Contains(err.Error(), "SCREENSHOT is not allowed") || !strings.Contains(err.Error(), "PASTE is not allowed") || !strings.Contains(err.Error(), "text outputs") {
+		t.Errorf("expected JSON tape commands to be rejected, got %v", err)
+	}
+
+	if err := validateServeTape("TitleCard \"Demo\" --subtitle \"vhs\"\nSet Watermark \"vhs\"\n"); err != nil {
+		t.Errorf("expected title card and text watermark to be allowed, got %v", err)
+	}
+	err = validateServeTape("TitleCard \"Demo\" --logo \"/home/u/logo.png\"\nSet Watermark \"/home/u/logo.png\"\n")
+	if err == nil || !strings.Contains(err.Error(), "line 1: TitleCard logos") || !strings.Contains(err.Error(), "line 2: Watermark images") {
+		t.Errorf("expected logo and watermark image to be rejected, got %v", err)
+	}
+
+	if err := validateServeTape("Set MarginFill \"#6B50FF\"\n"); err != nil {
+		t.Errorf("expected margin fill color to be allowed, got %v", err)
+	}
+	if err := validateServeTape("Set MarginFill \"/home/u/photo.png\"\n"); err == nil || !strings.Contains(err.Error(), "MarginFill images") {
+		t.Errorf("expected margin fill image to be rejected, got %v", err)
+	}
+	if err := validateServeTape("Set VideoFilter \"eq=saturation=1.2\"\n"); err == nil {
+		t.Error("expected VideoFilter to be rejected")
+	}
+	if err := validateServeTape("Env LD_PRELOAD \"/tmp/evil.so\"\n"); err == nil {
+		t.Error("expected Env to be rejected")
+	}
+}
+
+func TestServeEnviron(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("VHS_HTTP_TOKEN", "secret")
+	env := shellEnv(Shells[bash], ShellSandbox{Dir: "/tmp/vhs", Environ: serveEnviron()})
+	if !slices.Contains(env, "PATH=/usr/bin") || !slices.Contains(env, "HOME=/tmp/vhs") {
+		t.Errorf("expected PATH and HOME, got %v", env)
+	}
+	for _, v := range env {
+		if strings.HasPrefix(v, "VHS_HTTP_TOKEN=") {
+			t.Errorf("expected the token not to be passed on, got %v", env)
+		}
+	}
+}
+
+// newTestHTTPServer returns a server listening on localhost with the token
+// secret.
+func newTestHTTPServer(t *testing.T, cfg httpConfig) (*httpServer, *httptest.Server) {
+	t.Helper()
+	cfg.Token = "secret"
+	s, err := newHTTPServer("localhost:8080", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func TestWithServeOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		outputs VideoOutputs
+		want    string
+	}{
+		{"default", "", VideoOutputs{}, "/tmp/out.gif"},
+		{"tape output", "", VideoOutputs{GIF: "demo.gif", MP4: "demo.mp4"}, "/tmp/out.mp4"},
+		{"requested format", svg, VideoOutputs{MP4: "demo.mp4"}, "/tmp/out.svg"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out string
+			opt := withServeOutput("/tmp/out", tc.ext, &out)
+
+			// Options are applied before and after evaluating the tape.
+			v := New()
+			opt(&v)
+			v.Options.Video.Output = tc.outputs
+			v.Options.Test.Output = "/tmp/demo.txt"
+			opt(&v)
+
+			if out != tc.want {
+				t.Errorf("expected output %s, got %s", tc.want, out)
+			}
+			o := v.Options.Video.Output
+			if got := o.GIF + o.MP4 + o.WebM + o.SVG; got != tc.want {
+				t.Errorf("expected only %s to be rendered, got %+v", tc.want, o)
+			}
+			if v.Options.Test.Output != "" {
+				t.Errorf("expected no text output, got %s", v.Options.Test.Output)
+			}
+		})
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	s, server := newTestHTTPServer(t, httpConfig{MaxConcurrent: 1, MaxTapeSize: 64})
+
+	post := func(query, tape string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/render"+query, strings.NewReader(tape))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	if resp := post("?format=avi", "Type hi"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected unsupported format to fail, got %s", resp.Status)
+	}
+	if resp := post("", " "); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected empty tape to fail, got %s", resp.Status)
+	}
+	if resp := post("", "Source other.tape"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected Source to be rejected, got %s", resp.Status)
+	}
+	if resp := post("", strings.Repeat("Type hi\n", 10)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected large tape to be rejected, got %s", resp.Status)
+	}
+
+	s.slots <- struct{}{}
+	if resp := post("", "Type hi"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected concurrency limit, got %s", resp.Status)
+	}
+	<-s.slots
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected health check to succeed, got %s", resp.Status)
+	}
+}
+
+func TestHTTPServerToken(t *testing.T) {
+	_, server := newTestHTTPServer(t, httpConfig{MaxConcurrent: 1, MaxTapeSize: 64, Origins: []string{"https://docs.example.com"}})
+
+	post := func(query, auth string, header ...string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/render"+query, strings.NewReader("Source other.tape"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			if header[i] == "Host" {
+				req.Host = header[i+1]
+			} else {
+				req.Header.Set(header[i], header[i+1])
+			}
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := post("", ""); status != http.StatusUnauthorized {
+		t.Errorf("expected request without token to be rejected, got %d", status)
+	}
+	if status := post("", "Bearer wrong"); status != http.StatusUnauthorized {
+		t.Errorf("expected wrong token to be rejected, got %d", status)
+	}
+	// Authorized requests reach the validation of the tape.
+	if status := post("", "Bearer secret"); status != http.StatusBadRequest {
+		t.Errorf("expected bearer token to be accepted, got %d", status)
+	}
+	if status := post("?token=secret", ""); status != http.StatusBadRequest {
+		t.Errorf("expected token parameter to be accepted, got %d", status)
+	}
+
+	// Web pages can send requests without preflight, and DNS rebinding
+	// sends them for the name of the attacker.
+	if status := post("", "Bearer secret", "Origin", "https://evil.example.com"); status != http.StatusForbidden {
+		t.Errorf("expected other origins to be rejected, got %d", status)
+	}
+	if status := post("", "Bearer secret", "Origin", "https://docs.example.com"); status != http.StatusBadRequest {
+		t.Errorf("expected allowed origin to be accepted, got %d", status)
+	}
+	if status := post("", "Bearer secret", "Host", "rebind.example.com:8080"); status != http.StatusForbidden {
+		t.Errorf("expected other hosts to be rejected, got %d", status)
+	}
+
+	s, err := newHTTPServer(":8080", httpConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.cfg.Token) != 32 {
+		t.Errorf("expected a generated token, got %q", s.cfg.Token)
+	}
+}
+
+func TestServeHosts(t *testing.T) {
+	tests := []struct {
+		host       string
+		configured []string
+		want       []string
+	}{
+		{"", nil, nil},
+		{"0.0.0.0", nil, nil},
+		{"", []string{"vhs.example.com"}, []string{"localhost", "127.0.0.1", "::1", "vhs.example.com"}},
+		{"localhost", nil, []string{"localhost", "127.0.0.1", "::1", "localhost"}},
+		{"10.0.0.2", nil, []string{"localhost", "127.0.0.1", "::1", "10.0.0.2"}},
+	}
+
+	for _, tc := range tests {
+		if got := serveHosts(tc.host, tc.configured); !slices.Equal(got, tc.want) {
+			t.Errorf("%q %v: expected %v, got %v", tc.host, tc.configured, tc.want, got)
+		}
+	}
+}
+
+func TestCheckHTTPAccess(t *testing.T) {
+	tests := []struct {
+		addr    string
+		cfg     httpConfig
+		allowed bool
+	}{
+		{":8080", httpConfig{}, false},
+		{"0.0.0.0:8080", httpConfig{}, false},
+		{"localhost:8080", httpConfig{}, true},
+		{"127.0.0.1:8080", httpConfig{}, true},
+		{"[::1]:8080", httpConfig{}, true},
+		{":8080", httpConfig{Token: "secret"}, true},
+		{":8080", httpConfig{Sandbox: "firejail --quiet"}, true},
+	}
+
+	for _, tc := range tests {
+		err := checkHTTPAccess(tc.addr, tc.cfg)
+		if tc.allowed != (err == nil) {
+			t.Errorf("%s %+v: expected allowed %v, got %v", tc.addr, tc.cfg, tc.allowed, err)
+		}
+	}
+}
+
+func TestHTTPServerStream(t *testing.T) {
+	s, server := newTestHTTPServer(t, httpConfig{MaxConcurrent: 1, MaxTapeSize: 1024, Origins: []string{"https://docs.example.com"}})
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream?token=secret"
+	stream := func(query, tape string) Event {
+		t.Helper()
+		ws, err := websocket.Dial(url+query, "", "https://docs.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ws.Close() //nolint:errcheck
+		if err := websocket.Message.Send(ws, tape); err != nil {
+			t.Fatal(err)
+		}
+		var e Event
+		if err := websocket.JSON.Receive(ws, &e); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	if e := stream("&fps=0", "Type hi"); e.Type != EventError || !strings.Contains(e.Error, "invalid fps") {
+		t.Errorf("expected invalid fps to fail, got %+v", e)
+	}
+	if e := stream("", "Paste"); e.Type != EventError || !strings.Contains(e.Error, "not allowed") {
+		t.Errorf("expected Paste to be rejected, got %+v", e)
+	}
+
+	s.slots <- struct{}{}
+	if e := stream("", "Type hi"); e.Type != EventError || !strings.Contains(e.Error, "too many renders") {
+		t.Errorf("expected concurrency limit, got %+v", e)
+	}
+	<-s.slots
+
+	if _, err := websocket.Dial(url, "", "https://evil.example.com"); err == nil {
+		t.Error("expected other origins to be rejected")
+	}
+	if _, err := websocket.Dial(url, "", server.URL); err == nil {
+		t.Error("expected the origin of the server to be rejected")
+	}
+
+	// Pages of a DNS rebinding attack have the origin of the host.
+	req := httptest.NewRequest(http.MethodGet, "http://rebind.example.com:8080/stream", nil)
+	req.Header.Set("Origin", "http://rebind.example.com:8080")
+	if err := s.handshake(&websocket.Config{}, req); err == nil {
+		t.Error("expected other hosts to be rejected")
+	}
+	req.Header.Del("Origin")
+	if err := s.handshake(&websocket.Config{}, req); err == nil || !strings.Contains(err.Error(), "host") {
+		t.Errorf("expected other hosts to be rejected without an origin, got %v", err)
+	}
+	req.Host = "localhost:8080"
+	if err := s.handshake(&websocket.Config{}, req); err != nil {
+		t.Errorf("expected clients without an origin to connect, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+//go:build !js
+
+// Package vhs idle.go compresses long stretches of inactivity.
+//
+// Set MaxIdle caps every stretch of identical frames, e.g. a forgotten long
+// Sleep or a slow command, to the given duration. The frames past it are not
+// captured, so that the outputs and the frames of their scenes, chapters and
+// callouts keep in step. A blinking cursor does not end a stretch.
+//
+// Set MaxIdle 2s
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// idleTracker follows the stretch of identical frames being captured.
+type idleTracker struct {
+	screen [sha256.Size]byte
+	// cursors are the images of the cursor in the stretch: the cursor and,
+	// when it blinks, the cursor blinked off.
+	cursors [][sha256.Size]byte
+	frames  int
+	// skipped is the number of frames left out of the outputs.
+	skipped int
+}
+
+// idle records the next frame, and reports whether it extends a stretch of
+// identical frames past the limit, in frames, and is left out.
+func (t *idleTracker) idle(screen, cursor []byte, limit int) bool {
+	s, c := sha256.Sum256(screen), sha256.Sum256(cursor)
+	switch {
+	case t.frames == 0 || s != t.screen:
+		t.screen, t.cursors, t.frames = s, [][sha256.Size]byte{c}, 1
+	case slices.Contains(t.cursors, c):
+		t.frames++
+	case len(t.cursors) < 2: //nolint:mnd
+		t.cursors = append(t.cursors, c)
+		t.frames++
+	default:
+		// The cursor moved.
+		t.cursors, t.frames = [][sha256.Size]byte{c}, 1
+	}
+
+	if limit <= 0 || t.frames <= limit {
+		return false
+	}
+	t.skipped++
+	return true
+}
+
+// skipIdleFrame reports whether the next frame of the screen and cursor is
+// left out of an idle stretch. A screenshot of it is taken from the last
+// frame, which is identical.
+func (vhs *VHS) skipIdleFrame(screen, cursor []byte) bool {
+	limit := vhs.Options.Video.maxIdleFrames()
+	if limit == 0 || !vhs.idle.idle(screen, cursor, limit) {
+		return false
+	}
+	if vhs.Options.Screenshot.frameCapture {
+		vhs.Options.Screenshot.makeScreenshot(vhs.totalFrames)
+	}
+	return true
+}
+
+// maxIdleFrames returns the longest stretch of identical frames, or 0 if
+// idle stretches are not compressed.
+func (opts VideoOptions) maxIdleFrames() int {
+	if opts.MaxIdle <= 0 {
+		return 0
+	}
+	return max(int(opts.MaxIdle.Seconds()*float64(opts.Framerate)), 1)
+}
+
+// ExecuteSetMaxIdle sets the longest stretch of identical frames of the
+// outputs.
+func ExecuteSetMaxIdle(c parser.Command, v *VHS) error {
+	maxIdle, err := time.ParseDuration(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse max idle: %w", err)
+	}
+
+	v.Options.Video.MaxIdle = maxIdle
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdleTracker(t *testing.T) {
+	var tracker idleTracker
+	frames := []struct {
+		screen, cursor string
+		idle           bool
+	}{
+		{"a", "on", false},
+		{"a", "off", false},
+		{"a", "on", false},
+		{"a", "off", true}, // the blinking cursor does not end the stretch
+		{"a", "on", true},
+		{"a", "moved", false},
+		{"b", "moved", false},
+		{"b", "moved", false},
+		{"b", "moved", false},
+		{"b", "moved", true},
+	}
+	for i, f := range frames {
+		if got := tracker.idle([]byte(f.screen), []byte(f.cursor), 3); got != f.idle {
+			t.Errorf("frame %d: expected idle %v, got %v", i, f.idle, got)
+		}
+	}
+	if tracker.skipped != 3 {
+		t.Errorf("expected 3 frames to be skipped, got %d", tracker.skipped)
+	}
+}
+
+func TestMaxIdleFrames(t *testing.T) {
+	tests := []struct {
+		maxIdle time.Duration
+		want    int
+	}{
+		{0, 0},
+		{2 * time.Second, 100},
+		{time.Millisecond, 1},
+	}
+	for _, tt := range tests {
+		opts := VideoOptions{Framerate: 50, MaxIdle: tt.maxIdle}
+		if got := opts.maxIdleFrames(); got != tt.want {
+			t.Errorf("%s: expected %d frames, got %d", tt.maxIdle, tt.want, got)
+		}
+	}
+}
+
+func TestEvaluateMaxIdle(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	tape := `Set Shell bash
+Set Framerate 10
+Set MaxIdle 300ms
+Set TypingSpeed 0
+Type "echo hi"
+Sleep 1500ms`
+
+	var v *VHS
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+		v = vhs
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if v.idle.skipped == 0 {
+		t.Fatal("expected idle frames to be skipped")
+	}
+	if n := len(v.svgFrames); n != v.totalFrames || n >= 15 {
+		t.Errorf("expected fewer frames than the Sleep, got %d of %d", n, v.totalFrames)
+	}
+	for i, frame := range v.svgFrames {
+		if want := float64(i+1) / 10; frame.Timestamp != want {
+			t.Fatalf("frame %d: expected the timestamp %v, got %v", i, want, frame.Timestamp)
+		}
+	}
+}
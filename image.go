@@ -0,0 +1,67 @@
+//go:build !js
+
+// Package vhs image.go captures inline images drawn by programs using the
+// Sixel or iTerm2 inline image protocols (e.g. chafa, timg, viu).
+//
+// xterm.js draws these images on a separate canvas layer, which is composited
+// onto the text layer of every captured frame and embedded as an <image> in
+// SVG outputs. The Kitty graphics protocol is not supported by xterm.js.
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	imageLayerSelector     = "canvas.xterm-image-layer"
+	selectionLayerSelector = "canvas.xterm-selection-layer"
+)
+
+// compositeTextLayerJS draws the selection (see Highlight) and image layers on
+// top of the text layer and returns the result as a PNG data URL, or an empty
+// string if there is nothing to draw. xterm.js only adds the image layer while
+// images are on screen.
+var compositeTextLayerJS = fmt.Sprintf(`() => {
+	const images = document.querySelector('%s');
+	const selection = term.hasSelection() ? document.querySelector('%s') : null;
+	if (!images && !selection) return '';
+	const text = document.querySelector('canvas.xterm-text-layer');
+	const canvas = document.createElement('canvas');
+	canvas.width = text.width;
+	canvas.height = text.height;
+	const ctx = canvas.getContext('2d');
+	ctx.drawImage(text, 0, 0);
+	if (selection) ctx.drawImage(selection, 0, 0, text.width, text.height);
+	if (images) ctx.drawImage(images, 0, 0, text.width, text.height);
+	return canvas.toDataURL('image/png');
+}`, imageLayerSelector, selectionLayerSelector)
+
+// captureTextLayer returns the text layer of the terminal as a PNG, including
+// any inline images.
+func (vhs *VHS) captureTextLayer() ([]byte, error) {
+	res, err := vhs.Page.Eval(compositeTextLayerJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to composite image layer: %w", err)
+	}
+	if dataURL := res.Value.Str(); dataURL != "" {
+		return decodeDataURL(dataURL)
+	}
+
+	//nolint:wrapcheck
+	return vhs.TextCanvas.CanvasToImage("image/png", quality)
+}
+
+// decodeDataURL returns the bytes of a base64 encoded data URL.
+func decodeDataURL(dataURL string) ([]byte, error) {
+	_, data, ok := strings.Cut(dataURL, ";base64,")
+	if !ok {
+		return nil, fmt.Errorf("invalid data url")
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data url: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeDataURL(t *testing.T) {
+	b, err := decodeDataURL("data:image/png;base64,iVBORw0KGgo=")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(b, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Errorf("unexpected data: %q", b)
+	}
+
+	if _, err := decodeDataURL("image/png"); err == nil {
+		t.Error("expected error for invalid data url")
+	}
+}
@@ -0,0 +1,212 @@
+//go:build !js
+
+// Package vhs keyboardlayout.go types characters as the keys of a keyboard
+// layout.
+//
+// Set KeyboardLayout types the characters of Type with the keys which produce
+// them on a German, French or Dvorak keyboard instead of a US one: the key
+// events carry the physical key, Shift and AltGr of the layout, and accented
+// characters without a key of their own are typed with a dead key followed by
+// the letter, as their users type them. Programs which read the key events,
+// e.g. to handle keybindings, then see what they would on those keyboards.
+// The native backend writes characters to the terminal, which is unaffected.
+//
+// Set KeyboardLayout de
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// keyboardRows are the codes of the keys of the rows of a keyboard, from the
+// top, as in KeyboardEvent.code.
+var keyboardRows = [4][]string{
+	{"Backquote", "Digit1", "Digit2", "Digit3", "Digit4", "Digit5", "Digit6", "Digit7", "Digit8", "Digit9", "Digit0", "Minus", "Equal"},
+	{"KeyQ", "KeyW", "KeyE", "KeyR", "KeyT", "KeyY", "KeyU", "KeyI", "KeyO", "KeyP", "BracketLeft", "BracketRight", "Backslash"},
+	{"KeyA", "KeyS", "KeyD", "KeyF", "KeyG", "KeyH", "KeyJ", "KeyK", "KeyL", "Semicolon", "Quote"},
+	{"IntlBackslash", "KeyZ", "KeyX", "KeyC", "KeyV", "KeyB", "KeyN", "KeyM", "Comma", "Period", "Slash"},
+}
+
+// keyboardLayout holds the characters of the keys of a layout, by row, as in
+// keyboardRows, without modifier, with Shift and with AltGr. Spaces are keys
+// without a character. The dead characters of the keys without modifier or
+// with Shift are dead keys.
+type keyboardLayout struct {
+	normal, shift, altGr [4]string
+	dead                 string
+}
+
+// keyboardLayouts are the layouts of Set KeyboardLayout.
+var keyboardLayouts = map[string]keyboardLayout{
+	"us": {
+		normal: [4]string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", " zxcvbnm,./"},
+		shift:  [4]string{"~!@#$%^&*()_+", "QWERTYUIOP{}|", "ASDFGHJKL:\"", " ZXCVBNM<>?"},
+	},
+	"de": {
+		normal: [4]string{"^1234567890ß´", "qwertzuiopü+#", "asdfghjklöä", "<yxcvbnm,.-"},
+		shift:  [4]string{"°!\"§$%&/()=?`", "QWERTZUIOPÜ*'", "ASDFGHJKLÖÄ", ">YXCVBNM;:_"},
+		altGr:  [4]string{"  ²³   {[]}\\ ", "@ €        ~ ", "", "|      µ   "},
+		dead:   "^´`",
+	},
+	"fr": {
+		normal: [4]string{"²&é\"'(-è_çà)=", "azertyuiop^$*", "qsdfghjklmù", "<wxcvbn,;:!"},
+		shift:  [4]string{" 1234567890°+", "AZERTYUIOP¨£µ", "QSDFGHJKLM%", ">WXCVBN?./§"},
+		altGr:  [4]string{"  ~#{[|`\\^@]}", "  €", "", ""},
+		dead:   "^¨",
+	},
+	"dvorak": {
+		normal: [4]string{"`1234567890[]", "',.pyfgcrl/=\\", "aoeuidhtns-", " ;qjkxbmwvz"},
+		shift:  [4]string{"~!@#$%^&*(){}", "\"<>PYFGCRL?+|", "AOEUIDHTNS_", " :QJKXBMWVZ"},
+	},
+}
+
+// deadKeyCompositions are the characters composed by dead keys with the
+// letters typed after them.
+var deadKeyCompositions = map[rune][2]string{
+	'^': {"aeiouAEIOU", "âêîôûÂÊÎÔÛ"},
+	'´': {"aeiouyAEIOUY", "áéíóúýÁÉÍÓÚÝ"},
+	'`': {"aeiouAEIOU", "àèìòùÀÈÌÒÙ"},
+	'¨': {"aeiouyAEIOU", "äëïöüÿÄËÏÖÜ"},
+}
+
+// keyStroke is a key pressed to type a character.
+type keyStroke struct {
+	code         string
+	key          string
+	shift, altGr bool
+	dead         bool
+}
+
+// keyboardStrokes returns the keys pressed to type the characters of the
+// layout, nil for US keyboards, which Type uses by default.
+func keyboardStrokes(name string) map[rune][]keyStroke {
+	layout, ok := keyboardLayouts[name]
+	if !ok || name == "us" {
+		return nil
+	}
+
+	strokes := map[rune][]keyStroke{}
+	deadKeys := map[rune]keyStroke{}
+	add := func(rows [4]string, shift, altGr bool) {
+		for row, chars := range rows {
+			for i, r := range []rune(chars) {
+				if r == ' ' || i >= len(keyboardRows[row]) {
+					continue
+				}
+				stroke := keyStroke{code: keyboardRows[row][i], key: string(r), shift: shift, altGr: altGr}
+				if !altGr && strings.ContainsRune(layout.dead, r) {
+					stroke.dead = true
+					deadKeys[r] = stroke
+					continue
+				}
+				if _, ok := strokes[r]; !ok {
+					strokes[r] = []keyStroke{stroke}
+				}
+			}
+		}
+	}
+	add(layout.normal, false, false)
+	add(layout.shift, true, false)
+	add(layout.altGr, false, true)
+
+	for r, dead := range deadKeys {
+		// The character of a dead key is typed with a space after it.
+		if _, ok := strokes[r]; !ok {
+			strokes[r] = []keyStroke{dead, {code: "Space", key: string(r)}}
+		}
+		composition := deadKeyCompositions[r]
+		composed := []rune(composition[1])
+		for i, base := range []rune(composition[0]) {
+			if _, ok := strokes[composed[i]]; ok || len(strokes[base]) != 1 {
+				continue
+			}
+			letter := strokes[base][0]
+			letter.key = string(composed[i])
+			strokes[composed[i]] = []keyStroke{dead, letter}
+		}
+	}
+	return strokes
+}
+
+// keyCode returns the key code of the stroke, which follows the layout for
+// letters and the US key of the code otherwise.
+func (s keyStroke) keyCode() int {
+	if r := []rune(s.key); len(r) == 1 && r[0] < unicode.MaxASCII && unicode.IsLetter(r[0]) {
+		return int(unicode.ToUpper(r[0]))
+	}
+	if s.code == "Space" {
+		return input.Space.Info().KeyCode
+	}
+	us := keyboardLayouts["us"].normal
+	for row, codes := range keyboardRows {
+		for i, code := range codes {
+			if code == s.code && us[row][i] != ' ' {
+				return input.Key(us[row][i]).Info().KeyCode
+			}
+		}
+	}
+	return 226 //nolint:mnd // The key left of Z on ISO keyboards.
+}
+
+// events returns the key events of the stroke. Dead keys type nothing, and
+// AltGr is pressed around the key, as browsers report it.
+func (s keyStroke) events() []*proto.InputDispatchKeyEvent {
+	var modifiers int
+	if s.shift {
+		modifiers = input.ModifierShift
+	}
+	down := &proto.InputDispatchKeyEvent{
+		Type:                  proto.InputDispatchKeyEventTypeKeyDown,
+		Key:                   s.key,
+		Code:                  s.code,
+		WindowsVirtualKeyCode: s.keyCode(),
+		Text:                  s.key,
+		UnmodifiedText:        s.key,
+		Modifiers:             modifiers,
+	}
+	if s.dead {
+		down.Type, down.Key, down.Text, down.UnmodifiedText = proto.InputDispatchKeyEventTypeRawKeyDown, "Dead", "", ""
+	}
+	up := *down
+	up.Type, up.Text, up.UnmodifiedText = proto.InputDispatchKeyEventTypeKeyUp, "", ""
+
+	if !s.altGr {
+		return []*proto.InputDispatchKeyEvent{down, &up}
+	}
+	altGr := &proto.InputDispatchKeyEvent{
+		Type:                  proto.InputDispatchKeyEventTypeRawKeyDown,
+		Key:                   "AltGraph",
+		Code:                  "AltRight",
+		WindowsVirtualKeyCode: input.AltRight.Info().KeyCode,
+	}
+	altGrUp := *altGr
+	altGrUp.Type = proto.InputDispatchKeyEventTypeKeyUp
+	return []*proto.InputDispatchKeyEvent{altGr, down, &up, &altGrUp}
+}
+
+// typeStrokes presses the keys of the strokes on the terminal.
+func (vhs *VHS) typeStrokes(strokes []keyStroke) error {
+	for _, s := range strokes {
+		for _, e := range s.events() {
+			if err := e.Call(vhs.Page); err != nil {
+				return fmt.Errorf("failed to press %s: %w", s.code, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ExecuteSetKeyboardLayout sets the keyboard layout Type types with.
+func ExecuteSetKeyboardLayout(c parser.Command, v *VHS) error {
+	if _, ok := keyboardLayouts[c.Args]; !ok {
+		return fmt.Errorf("unknown keyboard layout %q", c.Args)
+	}
+	v.Options.KeyboardLayout = c.Args
+	return nil
+}
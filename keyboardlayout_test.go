@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestKeyboardLayouts(t *testing.T) {
+	for name, layout := range keyboardLayouts {
+		for _, rows := range [][4]string{layout.normal, layout.shift, layout.altGr} {
+			for i, row := range rows {
+				if n := len([]rune(row)); n > len(keyboardRows[i]) {
+					t.Errorf("%s: row %d has %d keys, expected at most %d", name, i, n, len(keyboardRows[i]))
+				}
+			}
+		}
+	}
+}
+
+func TestKeyboardStrokes(t *testing.T) {
+	if keyboardStrokes("us") != nil {
+		t.Error("expected US keyboards to type with the default keys")
+	}
+
+	de := keyboardStrokes("de")
+	tests := []struct {
+		r       rune
+		strokes []keyStroke
+	}{
+		{'z', []keyStroke{{code: "KeyY", key: "z"}}},
+		{'Ö', []keyStroke{{code: "Semicolon", key: "Ö", shift: true}}},
+		{'@', []keyStroke{{code: "KeyQ", key: "@", altGr: true}}},
+		{'ê', []keyStroke{{code: "Backquote", key: "^", dead: true}, {code: "KeyE", key: "ê"}}},
+		{'^', []keyStroke{{code: "Backquote", key: "^", dead: true}, {code: "Space", key: "^"}}},
+	}
+	for _, tc := range tests {
+		got := de[tc.r]
+		if len(got) != len(tc.strokes) {
+			t.Errorf("%c: expected %+v, got %+v", tc.r, tc.strokes, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.strokes[i] {
+				t.Errorf("%c: expected %+v, got %+v", tc.r, tc.strokes, got)
+			}
+		}
+	}
+
+	// Direct keys win over dead keys.
+	if s := keyboardStrokes("fr")['é']; len(s) != 1 || s[0].code != "Digit2" {
+		t.Errorf("expected é on its own key, got %+v", s)
+	}
+	if s := keyboardStrokes("dvorak")['s']; len(s) != 1 || s[0].code != "Semicolon" {
+		t.Errorf("expected s on the semicolon key, got %+v", s)
+	}
+}
+
+func TestKeyStrokeEvents(t *testing.T) {
+	events := keyStroke{code: "KeyQ", key: "@", altGr: true}.events()
+	if len(events) != 4 || events[0].Key != "AltGraph" || events[1].Text != "@" || events[1].WindowsVirtualKeyCode != 81 {
+		t.Errorf("expected @ between AltGr key events, got %+v", events)
+	}
+	events = keyStroke{code: "Backquote", key: "^", dead: true}.events()
+	if events[0].Type != proto.InputDispatchKeyEventTypeRawKeyDown || events[0].Key != "Dead" || events[0].Text != "" {
+		t.Errorf("expected a dead key event, got %+v", events[0])
+	}
+	if got := (keyStroke{code: "KeyY", key: "Z", shift: true}).keyCode(); got != 'Z' {
+		t.Errorf("expected the key code of Z, got %d", got)
+	}
+}
+
+func TestExecuteSetKeyboardLayout(t *testing.T) {
+	v := New()
+	if err := ExecuteSetKeyboardLayout(parser.Command{Args: "fr"}, &v); err != nil || v.Options.KeyboardLayout != "fr" {
+		t.Errorf("expected the French layout, got %q (%v)", v.Options.KeyboardLayout, err)
+	}
+	if err := ExecuteSetKeyboardLayout(parser.Command{Args: "colemak"}, &v); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
@@ -12,6 +12,11 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
 	"github.com/go-rod/rod/lib/input"
 )
 
@@ -129,3 +134,90 @@ var keymap = map[rune]input.Key{
 	'→':    input.ArrowRight,
 	'↓':    input.ArrowDown,
 }
+
+// functionKeys are the sequences of the function keys, as sent by xterm,
+// which sends F13-F24 as the shifted F1-F12.
+var functionKeys = map[string]string{
+	"F1":  "\x1bOP",
+	"F2":  "\x1bOQ",
+	"F3":  "\x1bOR",
+	"F4":  "\x1bOS",
+	"F5":  "\x1b[15~",
+	"F6":  "\x1b[17~",
+	"F7":  "\x1b[18~",
+	"F8":  "\x1b[19~",
+	"F9":  "\x1b[20~",
+	"F10": "\x1b[21~",
+	"F11": "\x1b[23~",
+	"F12": "\x1b[24~",
+	"F13": "\x1b[1;2P",
+	"F14": "\x1b[1;2Q",
+	"F15": "\x1b[1;2R",
+	"F16": "\x1b[1;2S",
+	"F17": "\x1b[15;2~",
+	"F18": "\x1b[17;2~",
+	"F19": "\x1b[18;2~",
+	"F20": "\x1b[19;2~",
+	"F21": "\x1b[20;2~",
+	"F22": "\x1b[21;2~",
+	"F23": "\x1b[23;2~",
+	"F24": "\x1b[24;2~",
+}
+
+// navigationKeys are the final characters of the sequences of the
+// navigation keys, which take the modifiers as a parameter, e.g. ESC [1;2A
+// for Shift+Up.
+var navigationKeys = map[token.Type]string{
+	token.UP:        "A",
+	token.DOWN:      "B",
+	token.RIGHT:     "C",
+	token.LEFT:      "D",
+	token.HOME:      "H",
+	token.END:       "F",
+	token.INSERT:    "2~",
+	token.DELETE:    "3~",
+	token.PAGE_UP:   "5~",
+	token.PAGE_DOWN: "6~",
+}
+
+// The xterm modifier parameters of navigation key sequences.
+const (
+	modifierShift = 2
+	modifierAlt   = 3
+)
+
+// navigationSequence returns the sequence of a navigation key, e.g. Up, with
+// the modifier parameter, or false if the key is not a navigation key.
+func navigationSequence(key string, modifier int) (string, bool) {
+	final, ok := navigationKeys[token.Keywords[key]]
+	if !ok {
+		return "", false
+	}
+	if strings.HasSuffix(final, "~") {
+		return fmt.Sprintf("\x1b[%s;%d~", strings.TrimSuffix(final, "~"), modifier), true
+	}
+	return fmt.Sprintf("\x1b[1;%d%s", modifier, final), true
+}
+
+// keySequence returns the bytes sent by the argument of a Key command, either
+// a function key or an escape sequence.
+func keySequence(key string) (string, error) {
+	if seq, ok := functionKeys[key]; ok {
+		return seq, nil
+	}
+	seq, err := parser.KeySequence(key)
+	if err != nil {
+		return "", fmt.Errorf("invalid escape sequence %q: %w", key, err)
+	}
+	return seq, nil
+}
+
+// keyArgs splits the arguments of a Key command into the key and the repeat
+// count.
+func keyArgs(args string) (key, repeat string) {
+	i := strings.LastIndex(args, " ")
+	if i < 0 {
+		return args, "1"
+	}
+	return args[:i], args[i+1:]
+}
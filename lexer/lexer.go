@@ -1,7 +1,11 @@
 // Package lexer provides a lexer for the VHS Tape language.
 package lexer
 
-import "github.com/agentstation/vhs/token"
+import (
+	"strings"
+
+	"github.com/agentstation/vhs/token"
+)
 
 // Lexer is a lexer that tokenizes the input.
 type Lexer struct {
@@ -58,6 +62,9 @@ func (l *Lexer) NextToken() token.Token {
 	case '^':
 		tok = l.newToken(token.CARET, l.ch)
 		l.readChar()
+	case ',':
+		tok = l.newToken(token.COMMA, l.ch)
+		l.readChar()
 	case '\\':
 		tok = l.newToken(token.BACKSLASH, l.ch)
 		l.readChar()
@@ -83,6 +90,18 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Type = token.STRING
 		tok.Literal = l.readString('"')
 		l.readChar()
+	case '<':
+		if l.peekChar() != '<' {
+			tok = l.newToken(token.ILLEGAL, l.ch)
+			l.readChar()
+			break
+		}
+		tok.Type = token.STRING
+		tok.Literal = l.readHeredoc()
+		if tok.Literal == "" {
+			tok.Type = token.ILLEGAL
+			tok.Literal = "<<"
+		}
 	case '/':
 		tok.Type = token.REGEX
 		tok.Literal = l.readRegex('/')
@@ -128,6 +147,43 @@ func (l *Lexer) readComment() string {
 	return l.input[pos:l.pos]
 }
 
+// readHeredoc reads the lines of a heredoc up to the line holding its
+// delimiter, which may be quoted.
+// <<EOF\nFoo\nBar\nEOF => Token(Foo\nBar).
+func (l *Lexer) readHeredoc() string {
+	l.readChar()
+	l.readChar()
+	pos := l.pos
+	for !isNewLine(l.ch) && l.ch != 0 {
+		l.readChar()
+	}
+	delimiter := strings.Trim(strings.TrimSpace(l.input[pos:l.pos]), `'"`)
+	if delimiter == "" {
+		return ""
+	}
+
+	var lines []string
+	for l.ch != 0 {
+		if l.ch == '\r' && l.peekChar() == '\n' {
+			l.readChar()
+		}
+		l.readChar()
+		l.line++
+		l.column = 1
+
+		pos = l.pos
+		for !isNewLine(l.ch) && l.ch != 0 {
+			l.readChar()
+		}
+		line := l.input[pos:l.pos]
+		if strings.TrimSpace(line) == delimiter {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // readString reads a string from the input.
 // "Foo" => Token(Foo).
 func (l *Lexer) readString(endChar byte) string {
@@ -32,7 +32,14 @@ Sleep 2
 Wait+Screen@1m /foobar/
 Wait+Screen@1m /foo\/bar/
 Wait+Screen@1m /foo\\/
-Wait+Screen@1m /foo\\\/bar/`
+Wait+Screen@1m /foo\\\/bar/
+Highlight@2s 2,5 2,20
+Script <<EOF
+cd /tmp
+
+  echo "<<"
+EOF
+Enter`
 
 	tests := []struct {
 		expectedType    token.Type
@@ -119,6 +126,19 @@ Wait+Screen@1m /foo\\\/bar/`
 		{token.NUMBER, "1"},
 		{token.MINUTES, "m"},
 		{token.REGEX, "foo\\\\\\/bar"},
+		{token.HIGHLIGHT, "Highlight"},
+		{token.AT, "@"},
+		{token.NUMBER, "2"},
+		{token.SECONDS, "s"},
+		{token.NUMBER, "2"},
+		{token.COMMA, ","},
+		{token.NUMBER, "5"},
+		{token.NUMBER, "2"},
+		{token.COMMA, ","},
+		{token.NUMBER, "20"},
+		{token.SCRIPT, "Script"},
+		{token.STRING, "cd /tmp\n\n  echo \"<<\""},
+		{token.ENTER, "Enter"},
 	}
 
 	l := New(input)
@@ -136,6 +156,20 @@ Wait+Screen@1m /foo\\\/bar/`
 	}
 }
 
+func TestHeredocPosition(t *testing.T) {
+	l := New("Script <<'END'\r\necho 1\r\nEND\r\nEnter")
+
+	for _, tt := range []token.Token{
+		{Type: token.SCRIPT, Literal: "Script", Line: 1, Column: 1},
+		{Type: token.STRING, Literal: "echo 1", Line: 1, Column: 8},
+		{Type: token.ENTER, Literal: "Enter", Line: 4, Column: 1},
+	} {
+		if tok := l.NextToken(); tok != tt {
+			t.Fatalf("expected %+v, got %+v", tt, tok)
+		}
+	}
+}
+
 func TestLexTapeFile(t *testing.T) {
 	input, err := os.ReadFile("../examples/fixtures/all.tape")
 	if err != nil {
@@ -0,0 +1,180 @@
+//go:build !js
+
+// Package vhs limits.go aborts recordings which exceed their limits.
+//
+// A command which never returns would otherwise hang VHS forever, e.g. in
+// CI. Once the timeout, the number of frames or the disk space used by the
+// frames exceed their limit, the shell is killed and the browser closed so
+// that the command fails, and the recording fails with the limit exceeded.
+//
+// Set Timeout 2m
+// Set MaxFrames 10000
+// Set MaxDiskUsage 2GB
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// LimitOptions are the limits of a recording. Zero values are unlimited.
+type LimitOptions struct {
+	// Timeout bounds the time from launching the terminal until the last
+	// command executed. Encoding the outputs is not limited.
+	Timeout time.Duration
+	// MaxFrames bounds the number of frames captured.
+	MaxFrames int
+	// MaxDiskUsage bounds the bytes of the frames written to disk.
+	MaxDiskUsage int64
+}
+
+// WithTimeout returns an EvaluatorOption limiting the time of the recording.
+// A Set Timeout of the tape can only lower it.
+func WithTimeout(timeout time.Duration) EvaluatorOption {
+	return func(v *VHS) {
+		v.Options.Limits.Timeout = lowerLimit(v.Options.Limits.Timeout, timeout)
+	}
+}
+
+// isLimit returns whether the setting is a limit, which applies from the
+// start of the recording.
+func isLimit(setting string) bool {
+	switch setting {
+	case "Timeout", "MaxFrames", "MaxDiskUsage":
+		return true
+	default:
+		return false
+	}
+}
+
+// lowerLimit returns the lower of two limits, where zero is unlimited.
+func lowerLimit[T int | int64 | time.Duration](a, b T) T {
+	if a == 0 || b != 0 && b < a {
+		return b
+	}
+	return a
+}
+
+// limiter aborts a recording once. Its methods may be called on nil, for
+// recordings without limits.
+type limiter struct {
+	once  sync.Once
+	done  chan struct{}
+	err   error
+	timer *time.Timer
+	disk  int64
+}
+
+// newLimiter returns a limiter aborting the recording after the timeout, if
+// any.
+func newLimiter(vhs *VHS) *limiter {
+	l := &limiter{done: make(chan struct{})}
+	if timeout := vhs.Options.Limits.Timeout; timeout > 0 {
+		l.timer = time.AfterFunc(timeout, func() {
+			l.abort(vhs, fmt.Errorf("recording exceeded the timeout of %s", timeout))
+		})
+	}
+	return l
+}
+
+// abort stops the recording by killing the shell and closing the browser,
+// so that the command waiting on them fails with err.
+func (l *limiter) abort(vhs *VHS, err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.done)
+
+		vhs.mutex.Lock()
+		defer vhs.mutex.Unlock()
+		if vhs.native != nil && vhs.native.cmd.Process != nil {
+			_ = vhs.native.cmd.Process.Kill()
+		}
+		if vhs.tty != nil && vhs.tty.Process != nil {
+			_ = vhs.tty.Process.Kill()
+		}
+		if vhs.close != nil {
+			_ = vhs.close()
+		}
+	})
+}
+
+// stop stops the timeout once the tape has executed.
+func (l *limiter) stop() {
+	if l != nil && l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// Done returns a channel closed once the recording is aborted.
+func (l *limiter) Done() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.done
+}
+
+// Err returns why the recording was aborted, if it was.
+func (l *limiter) Err() error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case <-l.done:
+		return l.err
+	default:
+		return nil
+	}
+}
+
+// checkFrames aborts the recording once its frames exceed the limits, given
+// the number of frames captured and the bytes written for the last one.
+func (vhs *VHS) checkFrames(frames int, written int64) {
+	l := vhs.limits
+	if l == nil {
+		return
+	}
+	limits := vhs.Options.Limits
+	l.disk += written
+	switch {
+	case limits.MaxFrames > 0 && frames > limits.MaxFrames:
+		l.abort(vhs, fmt.Errorf("recording exceeded MaxFrames %d", limits.MaxFrames))
+	case limits.MaxDiskUsage > 0 && l.disk > limits.MaxDiskUsage:
+		l.abort(vhs, fmt.Errorf("recording exceeded MaxDiskUsage %s", formatFileSize(limits.MaxDiskUsage)))
+	}
+}
+
+// ExecuteSetTimeout limits the time of the recording. It can only lower the
+// limit given by --timeout.
+func ExecuteSetTimeout(c parser.Command, v *VHS) error {
+	timeout, err := time.ParseDuration(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse timeout: %w", err)
+	}
+	v.Options.Limits.Timeout = lowerLimit(v.Options.Limits.Timeout, timeout)
+	return nil
+}
+
+// ExecuteSetMaxFrames limits the number of frames of the recording.
+func ExecuteSetMaxFrames(c parser.Command, v *VHS) error {
+	frames, err := strconv.Atoi(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse max frames: %w", err)
+	}
+	v.Options.Limits.MaxFrames = frames
+	return nil
+}
+
+// ExecuteSetMaxDiskUsage limits the disk space used by the frames of the
+// recording.
+func ExecuteSetMaxDiskUsage(c parser.Command, v *VHS) error {
+	size, err := parseFileSize(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse max disk usage: %w", err)
+	}
+	v.Options.Limits.MaxDiskUsage = size
+	return nil
+}
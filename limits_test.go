@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+)
+
+func TestLowerLimit(t *testing.T) {
+	tests := []struct {
+		a, b, expected time.Duration
+	}{
+		{0, time.Minute, time.Minute},
+		{time.Minute, 0, time.Minute},
+		{time.Minute, time.Second, time.Second},
+		{time.Second, time.Minute, time.Second},
+	}
+	for _, tc := range tests {
+		if got := lowerLimit(tc.a, tc.b); got != tc.expected {
+			t.Errorf("expected the lower of %s and %s to be %s, got %s", tc.a, tc.b, tc.expected, got)
+		}
+	}
+
+	// The tape can only lower the limit of --timeout.
+	v := New()
+	WithTimeout(time.Minute)(&v)
+	for _, timeout := range []string{"1h", "10s"} {
+		if err := ExecuteSetTimeout(parser.Command{Type: token.SET, Options: "Timeout", Args: timeout}, &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v.Options.Limits.Timeout != 10*time.Second {
+		t.Errorf("expected a timeout of 10s, got %s", v.Options.Limits.Timeout)
+	}
+}
+
+func TestCheckFrames(t *testing.T) {
+	v := New()
+	v.Options.Limits = LimitOptions{MaxFrames: 3, MaxDiskUsage: 100}
+	v.limits = newLimiter(&v)
+
+	v.checkFrames(1, 40)
+	v.checkFrames(2, 40)
+	if err := v.limits.Err(); err != nil {
+		t.Fatalf("expected the recording to be within its limits, got %v", err)
+	}
+	v.checkFrames(3, 40)
+	if err := v.limits.Err(); err == nil || !strings.Contains(err.Error(), "MaxDiskUsage 100B") {
+		t.Errorf("expected the disk usage to be exceeded, got %v", err)
+	}
+
+	// Recordings without limits are never aborted.
+	var l *limiter
+	if l.Err() != nil || l.Done() != nil {
+		t.Error("expected a nil limiter not to abort")
+	}
+}
+
+func TestEvaluateNativeTimeout(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	tape := "Set Shell bash\nSet Timeout 1s\nType \"sleep 30\"\nEnter\nWaitExit 1m"
+	start := time.Now()
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "exceeded the timeout of 1s") {
+		t.Fatalf("expected the recording to time out, got %v", errs)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the recording to be aborted, took %s", elapsed)
+	}
+}
+
+func TestEvaluateNativeMaxFrames(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	tape := "Set Shell bash\nSet MaxFrames 5\nSleep 5s"
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "exceeded MaxFrames 5") {
+		t.Fatalf("expected the recording to exceed its frames, got %v", errs)
+	}
+}
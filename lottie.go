@@ -0,0 +1,368 @@
+// Package vhs lottie.go renders recordings as Lottie animations.
+//
+// Mobile and web apps using a Lottie player can embed terminal demos
+// natively. The Lottie renderer shares the model of the SVG renderer: every
+// unique terminal state becomes a precomposition of text and rectangle
+// layers, and every stop of the timeline a layer showing the precomposition
+// of its state until the next stop. Text is set in the font family of the
+// recording, which the player must provide; the window bar is left blank.
+//
+// Output demo.lottie.json
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// lottie is the extension of Lottie outputs.
+const lottie = ".lottie.json"
+
+// lottieFramerate is the framerate of Lottie animations, to which the
+// timeline stops are rounded.
+const lottieFramerate = 30
+
+// Fonts of Lottie animations, by name.
+const (
+	lottieFont     = "Terminal"
+	lottieFontBold = "Terminal-Bold"
+)
+
+// Lottie layer types.
+const (
+	lottiePrecomp = 0
+	lottieShapes  = 4
+	lottieText    = 5
+)
+
+// lottieAnimation is the root of a Lottie animation.
+type lottieAnimation struct {
+	Version   string        `json:"v"`
+	Name      string        `json:"nm"`
+	Framerate float64       `json:"fr"`
+	In        float64       `json:"ip"`
+	Out       float64       `json:"op"`
+	Width     int           `json:"w"`
+	Height    int           `json:"h"`
+	ThreeD    int           `json:"ddd"`
+	Assets    []lottieAsset `json:"assets"`
+	Fonts     lottieFonts   `json:"fonts"`
+	Layers    []lottieLayer `json:"layers"`
+}
+
+// lottieAsset is a precomposition, the layers of a terminal state.
+type lottieAsset struct {
+	ID     string        `json:"id"`
+	Layers []lottieLayer `json:"layers"`
+}
+
+// lottieFonts are the fonts of the text layers.
+type lottieFonts struct {
+	List []lottieFontFace `json:"list"`
+}
+
+// lottieFontFace is a font of the text layers.
+type lottieFontFace struct {
+	Name   string  `json:"fName"`
+	Family string  `json:"fFamily"`
+	Style  string  `json:"fStyle"`
+	Ascent float64 `json:"ascent"`
+}
+
+// lottieLayer is a precomposition, shape or text layer.
+type lottieLayer struct {
+	Type      int             `json:"ty"`
+	Index     int             `json:"ind"`
+	Name      string          `json:"nm,omitempty"`
+	RefID     string          `json:"refId,omitempty"`
+	Width     int             `json:"w,omitempty"`
+	Height    int             `json:"h,omitempty"`
+	In        float64         `json:"ip"`
+	Out       float64         `json:"op"`
+	Start     float64         `json:"st"`
+	Transform lottieTransform `json:"ks"`
+	Shapes    []lottieShape   `json:"shapes,omitempty"`
+	Text      *lottieTextData `json:"t,omitempty"`
+}
+
+// lottieValue is a property of a layer, static unless Animated is 1.
+type lottieValue struct {
+	Animated int `json:"a"`
+	Value    any `json:"k"`
+}
+
+// lottieTransform is the transform of a layer.
+type lottieTransform struct {
+	Opacity  lottieValue `json:"o"`
+	Rotation lottieValue `json:"r"`
+	Position lottieValue `json:"p"`
+	Anchor   lottieValue `json:"a"`
+	Scale    lottieValue `json:"s"`
+}
+
+// lottieShape is a group, rectangle, fill or group transform. Only the
+// properties of its type are set.
+type lottieShape struct {
+	Type     string        `json:"ty"`
+	Items    []lottieShape `json:"it,omitempty"`
+	Position *lottieValue  `json:"p,omitempty"`
+	Size     *lottieValue  `json:"s,omitempty"`
+	Radius   *lottieValue  `json:"r,omitempty"`
+	Color    *lottieValue  `json:"c,omitempty"`
+	Opacity  *lottieValue  `json:"o,omitempty"`
+	Anchor   *lottieValue  `json:"a,omitempty"`
+}
+
+// lottieTextData is the text of a text layer.
+type lottieTextData struct {
+	Document  lottieTextDocument `json:"d"`
+	Path      struct{}           `json:"p"`
+	Options   lottieTextOptions  `json:"m"`
+	Animators []struct{}         `json:"a"`
+}
+
+// lottieTextDocument holds the keyframes of a text layer, a single one here.
+type lottieTextDocument struct {
+	Keyframes []lottieTextKeyframe `json:"k"`
+}
+
+// lottieTextKeyframe is the text shown from a time on.
+type lottieTextKeyframe struct {
+	Style lottieTextStyle `json:"s"`
+	Time  float64         `json:"t"`
+}
+
+// lottieTextStyle is the text and style of a text layer.
+type lottieTextStyle struct {
+	Size       float64   `json:"s"`
+	Font       string    `json:"f"`
+	Text       string    `json:"t"`
+	Justify    int       `json:"j"`
+	Tracking   float64   `json:"tr"`
+	LineHeight float64   `json:"lh"`
+	Color      []float64 `json:"fc"`
+}
+
+// lottieTextOptions are the grouping and alignment of a text layer.
+type lottieTextOptions struct {
+	Grouping  int         `json:"g"`
+	Alignment lottieValue `json:"a"`
+}
+
+// LottieGenerator renders the terminal states of the SVG renderer as a
+// Lottie animation.
+type LottieGenerator struct {
+	svg *SVGGenerator
+}
+
+// NewLottieGenerator creates a new Lottie generator.
+func NewLottieGenerator(opts SVGConfig) *LottieGenerator {
+	return &LottieGenerator{svg: NewSVGGenerator(opts)}
+}
+
+// Generate creates the Lottie animation as JSON.
+func (g *LottieGenerator) Generate() ([]byte, error) {
+	s := g.svg
+	s.processFrames()
+	s.fontSize = float64(cmp.Or(s.options.FontSize, defaultFontSize))
+	style := s.options.Style
+	if style == nil {
+		style = DefaultStyleOptions()
+	}
+
+	duration, _ := s.animationTiming()
+	out := max(1, float64(int(duration*lottieFramerate+0.5)))
+
+	family := buildSVGFontFamily(cmp.Or(s.options.FontFamily, defaultFontFamily))
+	anim := lottieAnimation{
+		Version:   "5.7.4",
+		Name:      "VHS",
+		Framerate: lottieFramerate,
+		Out:       out,
+		Width:     style.Width + 2*style.Margin,
+		Height:    style.Height + 2*style.Margin,
+		Fonts: lottieFonts{List: []lottieFontFace{
+			{Name: lottieFont, Family: family, Style: "Regular", Ascent: 75},
+			{Name: lottieFontBold, Family: family, Style: "Bold", Ascent: 75},
+		}},
+	}
+
+	for i := range s.states {
+		anim.Assets = append(anim.Assets, lottieAsset{
+			ID:     lottieStateID(i),
+			Layers: g.stateLayers(&s.states[i], out),
+		})
+	}
+
+	// The terminal is offset by the margin, the window bar and the padding.
+	barHeight := 0
+	if style.WindowBar != "" {
+		barHeight = style.WindowBarSize
+	}
+	x := float64(style.Margin + style.Padding)
+	y := float64(style.Margin + barHeight + style.Padding)
+
+	// Layers are drawn from the last to the first, so the states come first.
+	for i, stop := range s.timeline {
+		in := float64(int(stop.Percentage/100*out + 0.5))
+		end := out
+		if i+1 < len(s.timeline) {
+			end = float64(int(s.timeline[i+1].Percentage/100*out + 0.5))
+		}
+		if end <= in {
+			continue
+		}
+		anim.Layers = append(anim.Layers, lottieLayer{
+			Type:      lottiePrecomp,
+			Index:     len(anim.Layers) + 1,
+			Name:      fmt.Sprintf("Frame %d", i+1),
+			RefID:     lottieStateID(stop.StateIndex),
+			Width:     anim.Width,
+			Height:    anim.Height,
+			In:        in,
+			Out:       end,
+			Transform: lottieTranslate(x, y),
+		})
+	}
+
+	background := lottieShapeLayer("Background", len(anim.Layers)+1, out)
+	terminal := parseHexColorOr(s.options.Theme.Background, defaultMarginColor)
+	background.Shapes = append(background.Shapes, lottieRect(
+		float64(style.Margin), float64(style.Margin), float64(style.Width), float64(style.Height),
+		float64(style.BorderRadius), terminal))
+	if style.Margin > 0 {
+		margin := parseHexColorOr(style.MarginFill, defaultMarginColor)
+		background.Shapes = append(background.Shapes, lottieRect(0, 0, float64(anim.Width), float64(anim.Height), 0, margin))
+	}
+	anim.Layers = append(anim.Layers, background)
+
+	b, err := json.Marshal(anim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Lottie animation: %w", err)
+	}
+	return b, nil
+}
+
+// stateLayers returns the layers of a terminal state: a text layer for every
+// run of characters of the same style, above the cursor and the cell
+// backgrounds.
+func (g *LottieGenerator) stateLayers(state *TerminalState, out float64) []lottieLayer {
+	s := g.svg
+	theme := s.options.Theme
+	foreground := parseHexColorOr(theme.Foreground, "#ffffff")
+
+	var texts []lottieLayer
+	backgrounds := lottieShapeLayer("Backgrounds", 0, out)
+	for row, line := range state.Lines {
+		var styles []CharStyle
+		if row < len(state.LineColors) {
+			styles = state.LineColors[row]
+		}
+		for _, run := range splitRuns(line, styles) {
+			left := float64(run.Col) * s.charWidth
+			if run.Style.BgColor != "" {
+				backgrounds.Shapes = append(backgrounds.Shapes, lottieRect(
+					left, float64(row)*s.charHeight, float64(run.Width)*s.charWidth, s.charHeight, 0,
+					parseHexColorOr(run.Style.BgColor, theme.Background)))
+			}
+			trimmed := strings.TrimLeft(run.Text, " ")
+			if strings.TrimSpace(trimmed) == "" {
+				continue
+			}
+			left += float64(len(run.Text)-len(trimmed)) * s.charWidth
+
+			c := foreground
+			if run.Style.FgColor != "" {
+				c = parseHexColorOr(run.Style.FgColor, theme.Foreground)
+			}
+			font := lottieFont
+			if run.Style.Bold {
+				font = lottieFontBold
+			}
+			texts = append(texts, lottieLayer{
+				Type:      lottieText,
+				In:        0,
+				Out:       out,
+				Transform: lottieTranslate(left, s.baseline(row)),
+				Text: &lottieTextData{
+					Document: lottieTextDocument{Keyframes: []lottieTextKeyframe{{Style: lottieTextStyle{
+						Size:       s.fontSize,
+						Font:       font,
+						Text:       strings.TrimRight(trimmed, " "),
+						LineHeight: s.charHeight,
+						Color:      lottieColor(c)[:3],
+					}}}},
+					Options:   lottieTextOptions{Grouping: 1, Alignment: lottieValue{Value: []float64{0, 0}}},
+					Animators: []struct{}{},
+				},
+			})
+		}
+	}
+
+	layers := texts
+	if state.CursorChar != "" && state.CursorY < len(state.Lines) {
+		cursor := lottieShapeLayer("Cursor", 0, out)
+		cursor.Shapes = []lottieShape{lottieRect(
+			float64(state.CursorX)*s.charWidth, float64(state.CursorY)*s.charHeight, s.charWidth, s.charHeight, 0,
+			parseHexColorOr(theme.Cursor, theme.Foreground))}
+		layers = append(layers, cursor)
+	}
+	if len(backgrounds.Shapes) > 0 {
+		layers = append(layers, backgrounds)
+	}
+	for i := range layers {
+		layers[i].Index = i + 1
+	}
+	return layers
+}
+
+// lottieStateID returns the asset ID of a terminal state.
+func lottieStateID(i int) string {
+	return fmt.Sprintf("state_%d", i)
+}
+
+// lottieShapeLayer returns an empty shape layer shown for the whole
+// animation.
+func lottieShapeLayer(name string, index int, out float64) lottieLayer {
+	return lottieLayer{Type: lottieShapes, Index: index, Name: name, Out: out, Transform: lottieTranslate(0, 0)}
+}
+
+// lottieTranslate returns a transform moving a layer by x and y.
+func lottieTranslate(x, y float64) lottieTransform {
+	return lottieTransform{
+		Opacity:  lottieValue{Value: 100},
+		Rotation: lottieValue{Value: 0},
+		Position: lottieValue{Value: []float64{x, y, 0}},
+		Anchor:   lottieValue{Value: []float64{0, 0, 0}},
+		Scale:    lottieValue{Value: []float64{100, 100, 100}},
+	}
+}
+
+// lottieRect returns a group filling a rectangle with the color.
+func lottieRect(x, y, w, h, radius float64, c color.RGBA) lottieShape {
+	return lottieShape{Type: "gr", Items: []lottieShape{
+		{
+			Type:     "rc",
+			Position: &lottieValue{Value: []float64{x + w/2, y + h/2}},
+			Size:     &lottieValue{Value: []float64{w, h}},
+			Radius:   &lottieValue{Value: radius},
+		},
+		{Type: "fl", Color: &lottieValue{Value: lottieColor(c)}, Opacity: &lottieValue{Value: 100}},
+		{
+			Type:     "tr",
+			Position: &lottieValue{Value: []float64{0, 0}},
+			Anchor:   &lottieValue{Value: []float64{0, 0}},
+			Size:     &lottieValue{Value: []float64{100, 100}},
+			Radius:   &lottieValue{Value: 0},
+			Opacity:  &lottieValue{Value: 100},
+		},
+	}}
+}
+
+// lottieColor returns the color as RGBA components between 0 and 1.
+func lottieColor(c color.RGBA) []float64 {
+	return []float64{float64(c.R) / 0xff, float64(c.G) / 0xff, float64(c.B) / 0xff, 1}
+}
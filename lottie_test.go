@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestLottieGenerator(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{
+		{Lines: []string{"> "}, CursorX: 2, CursorChar: "█", CharWidth: 10, CharHeight: 20, Timestamp: 0},
+		{Lines: []string{"> ls", "a  b"}, CursorX: 4, CharWidth: 10, CharHeight: 20, Timestamp: 0.1, LineColors: [][]CharStyle{
+			{{FgColor: "#ff0000", Bold: true}, {FgColor: "#ff0000", Bold: true}, {}, {}},
+		}},
+		{Lines: []string{"> "}, CursorX: 2, CursorChar: "█", CharWidth: 10, CharHeight: 20, Timestamp: 0.2},
+		{Lines: []string{"> "}, CursorX: 2, CursorChar: "█", CharWidth: 10, CharHeight: 20, Timestamp: 0.3},
+	}
+	opts.Duration = 0.4
+
+	b, err := NewLottieGenerator(opts).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var anim lottieAnimation
+	if err := json.Unmarshal(b, &anim); err != nil {
+		t.Fatal(err)
+	}
+
+	if anim.Out != 0.4*lottieFramerate || anim.Width != opts.Style.Width {
+		t.Errorf("unexpected dimensions %dx%d or duration %v", anim.Width, anim.Height, anim.Out)
+	}
+	// The first state is shown again at the end, from the same asset.
+	if len(anim.Assets) != 2 {
+		t.Fatalf("expected 2 states, got %d", len(anim.Assets))
+	}
+	var refs []string
+	for _, l := range anim.Layers[:len(anim.Layers)-1] {
+		refs = append(refs, l.RefID)
+	}
+	if len(refs) != 3 || refs[0] != refs[2] || anim.Layers[1].In != 3 || anim.Layers[1].Out != 6 {
+		t.Errorf("unexpected timeline %+v", anim.Layers)
+	}
+	if last := anim.Layers[len(anim.Layers)-1]; last.Type != lottieShapes {
+		t.Error("expected the background to be the bottom layer")
+	}
+
+	// "> " and "ls" differ in style, and the blank cells of "a  b" are
+	// skipped.
+	var texts []string
+	var fonts []string
+	for _, l := range anim.Assets[1].Layers {
+		if l.Text != nil {
+			s := l.Text.Document.Keyframes[0].Style
+			texts = append(texts, s.Text)
+			fonts = append(fonts, s.Font)
+		}
+	}
+	if want := "[> ls a  b]"; fmt.Sprint(texts) != want {
+		t.Errorf("expected texts %s, got %s", want, texts)
+	}
+	if fonts[0] != lottieFontBold || fonts[1] != lottieFont {
+		t.Errorf("expected the bold run in the bold font, got %v", fonts)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -16,6 +18,7 @@ import (
 	"runtime/debug"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/agentstation/vhs/lexer"
 	"github.com/agentstation/vhs/parser"
@@ -26,6 +29,16 @@ import (
 
 const extension = ".tape"
 
+// Log formats of --log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// jsonEventsBuffer is the number of events buffered for --log-format json.
+// Frames are published too, so it has room for a burst of them.
+const jsonEventsBuffer = 1024
+
 var (
 	// Version stores the build version of VHS at the time of packaging through -ldflags.
 	Version string
@@ -38,29 +51,63 @@ var (
 	publishFlag bool
 	outputs     *[]string
 
-	quietFlag    bool
-	noSVGOpt     bool
-	debugConsole bool
+	quietFlag         bool
+	noSVGOpt          bool
+	debugConsole      bool
+	dryRunFlag        bool
+	deterministicFlag bool
+	backendFlag       string
+	offlineFlag       bool
+	logFormatFlag     string
+	traceFlag         bool
+	statsFlag         bool
+	timeoutFlag       time.Duration
+	optionsFlag       string
+	diagnosticsFlag   string
+
+	// shutdownTracing flushes the spans before exit when tracing is enabled.
+	shutdownTracing func(context.Context) error
 
 	//nolint:wrapcheck
 	rootCmd = &cobra.Command{
-		Use:           "vhs <file>",
+		Use:           "vhs <file|->",
 		Short:         "Run a given tape file and generates its outputs.",
 		Args:          cobra.MaximumNArgs(1),
 		SilenceUsage:  true,
 		SilenceErrors: true, // we print our own errors
-		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 			log.SetFlags(0)
+			switch logFormatFlag {
+			case logFormatText:
+			case logFormatJSON:
+				log.SetOutput(jsonLogWriter{os.Stderr})
+			default:
+				return fmt.Errorf("invalid log format %q: expected %s or %s", logFormatFlag, logFormatText, logFormatJSON)
+			}
 			if quietFlag {
 				log.SetOutput(io.Discard)
 			}
+			if tracingEnabled(traceFlag) {
+				shutdown, err := setupTracing(cmd.Context())
+				if err != nil {
+					return err
+				}
+				shutdownTracing = shutdown
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := ensureDependencies()
-			if err != nil {
+			if err := checkOutputs(*outputs); err != nil {
 				return err
 			}
 
+			var err error
+			if !dryRunFlag && backendFlag != nativeBackend {
+				if err = ensureDependencies(); err != nil {
+					return err
+				}
+			}
+
 			in := cmd.InOrStdin()
 			// Set the input to the file contents if a file is given
 			// otherwise, use stdin
@@ -70,7 +117,9 @@ var (
 					return err
 				}
 				log.Println(GrayStyle.Render("File: " + args[0]))
-			} else {
+			} else if len(args) == 0 {
+				// An explicit - reads the tape from stdin even when it is a
+				// terminal.
 				stat, _ := os.Stdin.Stat()
 				if (stat.Mode() & os.ModeCharDevice) != 0 {
 					// The user ran vhs without any arguments or stdin.
@@ -86,37 +135,81 @@ var (
 			if string(input) == "" {
 				return errors.New("no input provided")
 			}
+			if dryRunFlag {
+				return dryRun(cmd.OutOrStdout(), string(input))
+			}
+
+			var defaults []parser.Command
+			if optionsFlag != "" {
+				if defaults, err = readOptionsFile(optionsFlag); err != nil {
+					return err
+				}
+			}
 
 			var publishFile string
 			out := cmd.OutOrStdout()
 			if quietFlag {
 				out = io.Discard
 			}
+
+			// With JSON logs, stdout is left to the events of the recording.
+			var bus *EventBus
+			written := make(chan struct{})
+			if logFormatFlag == logFormatJSON {
+				out = io.Discard
+				bus = NewEventBus()
+				events := bus.Subscribe(jsonEventsBuffer)
+				go func() {
+					writeEvents(cmd.OutOrStdout(), events)
+					close(written)
+				}()
+			}
+
+			var stats *statsCollector
+			var recorded *VHS
+			if statsFlag {
+				stats = setupStats()
+			}
+
 			errs := Evaluate(cmd.Context(), string(input), out,
+				WithEvents(bus),
+				WithTimeout(timeoutFlag),
 				WithSVGOptimization(!noSVGOpt),
 				WithDebugConsole(debugConsole),
+				WithDeterministic(deterministicFlag),
+				WithBackend(backendFlag),
+				WithOffline(offlineFlag || offlineFromEnv()),
+				WithDefaults(defaults),
 				func(v *VHS) {
+					if diagnosticsFlag != "" {
+						WithDiagnostics(diagnosticsFlag)(v)
+					}
+				},
+				func(v *VHS) {
+					recorded = v
 					// Output is being overridden, prevent all outputs
 					if len(*outputs) <= 0 {
 						publishFile = v.Options.Video.Output.GIF
 						return
 					}
 
-					for _, output := range *outputs {
-						if strings.HasSuffix(output, gif) {
-							v.Options.Video.Output.GIF = output
-						} else if strings.HasSuffix(output, webm) {
-							v.Options.Video.Output.WebM = output
-						} else if strings.HasSuffix(output, mp4) {
-							v.Options.Video.Output.MP4 = output
-						} else if strings.HasSuffix(output, svg) {
-							v.Options.Video.Output.SVG = output
-						}
-					}
-
+					withOutputs(*outputs)(v)
 					publishFile = v.Options.Video.Output.GIF
 				})
 
+			if bus != nil {
+				if len(errs) > 0 {
+					bus.Publish(Event{Type: EventError, Error: errors.Join(errs...).Error()})
+				} else {
+					bus.Publish(Event{Type: EventDone})
+				}
+				bus.Close()
+				<-written
+			}
+			if stats != nil {
+				printStats(cmd.ErrOrStderr(), recorded, stats)
+			}
+
 			publishEnv, publishEnvSet := os.LookupEnv("VHS_PUBLISH")
 			if !publishEnvSet && !publishFlag && len(errs) == 0 {
 				log.Println(FaintStyle.Render("Host your GIF on vhs.charm.sh: vhs publish <file>.gif"))
@@ -132,7 +225,7 @@ var (
 					log.Printf(GrayStyle.Render("Publishing %s... "), publishFile)
 				}
 
-				url, err := Publish(cmd.Context(), publishFile)
+				url, err := publishWithBackend(cmd.Context(), publishFile)
 				if err != nil {
 					return err
 				}
@@ -142,7 +235,7 @@ var (
 				}
 				if isatty.IsTerminal(os.Stdout.Fd()) {
 					log.Println(StringStyle.Render("Done!"))
-					publishShareInstructions(url)
+					publishShareInstructions(url, publishFile)
 				}
 				log.Println("  " + URLStyle.Render(url))
 				if isatty.IsTerminal(os.Stdout.Fd()) {
@@ -210,14 +303,14 @@ var (
 	}
 
 	validateCmd = &cobra.Command{
-		Use:   "validate <file>...",
+		Use:   "validate <file|->...",
 		Short: "Validate a glob file path and parses all the files to ensure they are valid without running them.",
 		Args:  cobra.MinimumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			valid := true
 
 			for _, file := range args {
-				b, err := os.ReadFile(file)
+				b, err := readTape(cmd, file)
 				if err != nil {
 					continue
 				}
@@ -247,14 +340,31 @@ var (
 	}
 )
 
+// readTape reads the tape file, or stdin if the file is -.
+func readTape(cmd *cobra.Command, file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(cmd.InOrStdin()) //nolint:wrapcheck
+	}
+	return os.ReadFile(file) //nolint:wrapcheck
+}
+
 func main() {
 	ctx, cancel := signal.NotifyContext(
 		context.Background(),
 		os.Interrupt, syscall.SIGTERM,
 	)
 	defer cancel()
-
-	if err := rootCmd.ExecuteContext(ctx); err != nil {
+	// Once interrupted, the truncated outputs are written unless interrupted
+	// again.
+	context.AfterFunc(ctx, cancel)
+
+	err := rootCmd.ExecuteContext(traceParent(ctx))
+	if shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		_ = shutdownTracing(ctx)
+		cancel()
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -263,8 +373,20 @@ func main() {
 func init() {
 	rootCmd.Flags().BoolVarP(&publishFlag, "publish", "p", false, "publish your GIF to vhs.charm.sh and get a shareable URL")
 	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "quiet do not log messages. If publish flag is provided, it will log shareable URL")
+	rootCmd.PersistentFlags().StringVar(&logFormatFlag, "log-format", logFormatText, "format of log messages: text, or json to also print progress events to stdout")
+	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 0, "abort recordings which take longer, e.g. 2m (a Set Timeout of the tape can only lower it)")
+	rootCmd.PersistentFlags().BoolVar(&traceFlag, "trace", false, "export OpenTelemetry spans of the recording over OTLP (also enabled by OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false, "never access the network: use the bundled font as a fallback and do not download Chromium")
+	addPublishFlags(rootCmd.Flags(), "publish-backend")
+	addPublishFlags(publishCmd.Flags(), "backend")
 	rootCmd.Flags().BoolVar(&noSVGOpt, "no-svg-opt", false, "disable SVG output optimization")
 	rootCmd.Flags().BoolVar(&debugConsole, "debug-console", false, "enable browser console logging")
+	rootCmd.Flags().BoolVar(&deterministicFlag, "deterministic", false, "capture frames on a virtual clock and fix the shell clock so that outputs are byte-identical across runs")
+	rootCmd.Flags().StringVar(&backendFlag, "backend", browserBackend, "capture backend: browser, or native to record text outputs without Chromium, ttyd and ffmpeg")
+	rootCmd.Flags().BoolVar(&statsFlag, "stats", false, "report the frames, the time spent in every phase, the weight of the outputs and the peak memory of the recording")
+	rootCmd.Flags().StringVar(&optionsFlag, "options", "", "JSON file of Set defaults, e.g. options.json, which the Set commands of the tape override")
+	rootCmd.Flags().StringVar(&diagnosticsFlag, "diagnostics", ".", "directory of the vhs-crash-<time> bundle written when a recording fails, empty to disable")
+	rootCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "estimate the duration, outputs and dependencies of the tape without recording it")
 
 	outputs = rootCmd.Flags().StringSliceP("output", "o", []string{}, "file name(s) of video output")
 	themesCmd.Flags().BoolVar(&markdown, "markdown", false, "output as markdown")
@@ -273,6 +395,7 @@ func init() {
 	if recordShell == "" {
 		recordShell = defaultShell
 	}
+	serveCmd.Flags().StringVar(&httpAddr, "http", "", "serve the HTTP rendering API on the given address instead of SSH, e.g. localhost:8080")
 	recordCmd.Flags().StringVarP(&shell, "shell", "s", recordShell, "shell for recording")
 	rootCmd.AddCommand(
 		recordCmd,
@@ -282,6 +405,14 @@ func init() {
 		manCmd,
 		serveCmd,
 		publishCmd,
+		testCmd,
+		doctorCmd,
+		batchCmd,
+		buildCmd,
+		previewCmd,
+		convertCmd,
+		compareCmd,
+		schemaCmd,
 	)
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 
@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -24,11 +26,13 @@ A tape file is a script made up of commands describing what actions to perform i
 
 The following is a list of all possible commands in VHS:
 
-* %Output% <path>.(gif|webm|mp4|svg)
+* %Output% <path>.(gif|webm|mp4|svg) [--loops <count>]
 * %Require% <program>
 * %Set% <setting> <value>
 * %Sleep% <time>
 * %Type% "<string>"
+* %TypeFile% <path> [--speed <time>] [--literal]
+* %TitleCard% "<title>" [time] [--subtitle "<subtitle>"] [--logo <image>]
 * %Ctrl% [+Alt][+Shift]+<char>
 * %Backspace% [repeat]
 * %Delete% [repeat]
@@ -41,20 +45,39 @@ The following is a list of all possible commands in VHS:
 * %Up% [repeat]
 * %PageUp% [repeat]
 * %PageDown% [repeat]
+* %Home% [repeat]
+* %End% [repeat]
+* %Key% <F1-F24|"sequence"> [repeat]
 * %Hide%
 * %Show%
 * %Wait%[+Screen][@<timeout>] /<regexp>/
 * %Escape%
 * %Alt%+<key>
+* %Shift%+<key>
 * %Space% [repeat]
 * %Source% <path>.tape
 * %Screenshot% <path>.png
 * %Copy% "<string>"
 * %Paste%
+* %Scene%[+Reset] "<name>"
+* %Chapter% "<name>"
+* %Resize% <cols> <rows>
+* %ScrollUp% [lines]
+* %ScrollDown% [lines]
+* %Highlight%[@<time>] <row>,<col> <row>,<col>
+* %Zoom% <factor>x [<row>,<col>] [<time>]
+* %Pan% <row>,<col> [<time>]
+* %Annotate% "<text>" [--position <row>,<col>] [--arrow <row>,<col>] [--at <time>] [--for <time>]
+* %Script% <<EOF ... EOF
+* %Signal% SIGINT|SIGTERM|SIGTSTP|SIGQUIT|SIGHUP|SIGKILL|SIGCONT
+* %WaitExit% [<time>] [--status <status>]
 `
 
 	manOutput = `The Output command instructs VHS where to save the output of the recording.
 File names with the extension %.gif%, %.webm%, %.mp4%, %.svg% will have the respective file types.
+%.txt% outputs hold the text of the terminal after every command, %.md% outputs the text at the end of every scene.
+%.pdf% outputs are a storyboard of the last frame of every scene, %.lottie.json% outputs a Lottie animation.
+GIF outputs loop forever unless %--loops% sets the number of times they play.
 `
 
 	manSettings = `The Set command allows VHS to adjust settings in the terminal, such as fonts, dimensions, and themes.
@@ -62,19 +85,52 @@ File names with the extension %.gif%, %.webm%, %.mp4%, %.svg% will have the resp
 The following is a list of all possible setting commands in VHS:
 
 * Set %Shell% <string>
+* Set %WorkingDirectory% <path>
+* Set %Sandbox% <boolean>
 * Set %FontSize% <number>
 * Set %FontFamily% <string>
+* Set %EmojiFont% <string>
 * Set %Height% <number>
 * Set %Width% <number>
 * Set %LetterSpacing% <float>
 * Set %LineHeight% <float>
 * Set %TypingSpeed% <time>
 * Set %Theme% <json|string>
+* Set %ThemeFilter% <deuteranopia|protanopia|high-contrast>
+* Set %ThemeDark% <json|string>
+* Set %ColorMode% <16|256|truecolor|mono>
+* Set %CursorColor% <color|reverse>
+* Set %CursorAccentColor% <color>
+* Set %CursorTween% <boolean>
 * Set %Padding% <number>
 * Set %Framerate% <number>
+* Set %CaptureRate% <number|adaptive>
+* Set %KeyboardLayout% <us|de|fr|dvorak>
+* Set %SyntaxHighlight% <language|auto>
 * Set %PlaybackSpeed% <float>
 * Set %WaitTimeout% <time>
 * Set %WaitPattern% <regexp>
+* Set %Transition% <none|fade|slide> [time]
+* Set %GIFColors% <number>
+* Set %GIFDither% <none|bayer|sierra2_4a|...>
+* Set %GIFStatsMode% <full|diff|single>
+* Set %Quality% <low|medium|high|lossless>
+* Set %MaxFileSize% <size>
+* Set %PixelRatio% <float>
+* Set %UnderlineLinks% <boolean>
+* Set %SVGOptimizeLevel% <0|1|2|3>
+* Set %Scrollback% <boolean>
+* Set %FontLigatures% <boolean>
+* Set %LoopDelay% <time>
+* Set %MaxIdle% <time>
+* Set %Timeout% <time>
+* Set %MaxFrames% <number>
+* Set %MaxDiskUsage% <size>
+* Set %VideoFilter% <string>
+* Set %FrameCommand% <string>
+* Set %Description% <string>
+* Set %AccessibleTranscript% <boolean>
+* Set %Watermark% <image|"text"> [--position <position>] [--opacity <opacity>]
 `
 	manBugs = "See GitHub Issues: <https://github.com/agentstation/vhs/issues>"
 
@@ -0,0 +1,277 @@
+//go:build !js
+
+// Package vhs manifest.go renders the tapes of a project from a manifest.
+//
+// A vhs.yaml manifest lists the tapes of a project with their outputs, the
+// settings shared by every tape (which a tape can still override with its
+// own Set commands) and hooks run once every tape has rendered, e.g. to
+// publish the outputs. Paths are relative to the manifest.
+//
+//	settings:
+//	  Theme: Catppuccin Mocha
+//	  FontSize: 22
+//	tapes:
+//	  - tape: tapes/demo.tape
+//	    output: [assets/demo.gif, assets/demo.svg]
+//	hooks:
+//	  - ./scripts/upload.sh
+//
+// vhs build vhs.yaml
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultManifest is the manifest built when none is given.
+const defaultManifest = "vhs.yaml"
+
+var (
+	buildParallel int
+
+	buildCmd = &cobra.Command{
+		Use:   "build [manifest]",
+		Short: "Render the tapes of a vhs.yaml manifest",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := defaultManifest
+			if len(args) > 0 {
+				path = args[0]
+			}
+			m, err := readManifest(path)
+			if err != nil {
+				return err
+			}
+
+			if backendFlag != nativeBackend {
+				if err := ensureDependencies(); err != nil {
+					return err
+				}
+			}
+
+			results := renderBatch(cmd.Context(), m.jobs(filepath.Dir(path)), buildParallel)
+			printBatchSummary(cmd.OutOrStdout(), results)
+
+			var outputs []string
+			failed := 0
+			for _, r := range results {
+				if r.Err != nil {
+					failed++
+				}
+				outputs = append(outputs, r.Outputs...)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d tapes failed to render", failed, len(results))
+			}
+			return m.runHooks(filepath.Dir(path), outputs)
+		},
+	}
+)
+
+func init() {
+	buildCmd.Flags().IntVarP(&buildParallel, "parallel", "p", defaultBatchParallel, "number of tapes rendered at once")
+	buildCmd.Flags().StringVar(&backendFlag, "backend", browserBackend, "capture backend: browser or native")
+}
+
+// Manifest is a vhs.yaml project file.
+type Manifest struct {
+	// Settings are applied to every tape before its own settings.
+	Settings map[string]any `yaml:"settings"`
+	Tapes    []ManifestTape `yaml:"tapes"`
+	// Hooks are shell commands run once every tape has rendered, with the
+	// outputs in $VHS_OUTPUTS (one per line).
+	Hooks []string `yaml:"hooks"`
+}
+
+// ManifestTape is a tape of a manifest.
+type ManifestTape struct {
+	Tape string `yaml:"tape"`
+	// Output replaces the outputs of the tape of the same formats.
+	Output manifestList `yaml:"output"`
+	// Settings are applied after the settings of the manifest.
+	Settings map[string]any `yaml:"settings"`
+}
+
+// manifestList is a list of strings which may be written as a single string.
+type manifestList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *manifestList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = manifestList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err //nolint:wrapcheck
+	}
+	*l = list
+	return nil
+}
+
+// readManifest reads and validates a manifest.
+func readManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if len(m.Tapes) == 0 {
+		return nil, fmt.Errorf("manifest %s has no tapes", path)
+	}
+	for i, t := range m.Tapes {
+		if t.Tape == "" {
+			return nil, fmt.Errorf("tape %d of manifest %s has no path", i+1, path)
+		}
+		if err := checkOutputs(t.Output); err != nil {
+			return nil, fmt.Errorf("tape %d of manifest %s: %w", i+1, path, err)
+		}
+	}
+	return &m, nil
+}
+
+// jobs returns the tapes of the manifest as batch jobs, resolving paths
+// relative to the directory of the manifest.
+func (m *Manifest) jobs(dir string) []batchJob {
+	jobs := make([]batchJob, len(m.Tapes))
+	for i, t := range m.Tapes {
+		jobs[i] = batchJob{
+			Tape:    resolvePath(dir, t.Tape),
+			Prelude: settingsPrelude(m.Settings) + settingsPrelude(t.Settings),
+		}
+		if len(t.Output) > 0 {
+			outputs := make([]string, len(t.Output))
+			for j, o := range t.Output {
+				outputs[j] = resolvePath(dir, o)
+			}
+			jobs[i].Options = []EvaluatorOption{withOutputs(outputs)}
+		}
+	}
+	return jobs
+}
+
+// runHooks runs the hooks of the manifest in its directory.
+func (m *Manifest) runHooks(dir string, outputs []string) error {
+	for _, hook := range m.Hooks {
+		cmd := exec.Command(hookShell[0], append(hookShell[1:], hook)...) //nolint:gosec,noctx
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "VHS_OUTPUTS="+strings.Join(outputs, "\n"))
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run hook %q: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+// resolvePath resolves a path of the manifest relative to its directory.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// bareSettingValue matches the values which can be written without quotes,
+// such as numbers, times and names.
+var bareSettingValue = regexp.MustCompile(`^[A-Za-z0-9._%+/-]+$`)
+
+// settingsPrelude returns the settings as Set commands, sorted by name.
+func settingsPrelude(settings map[string]any) string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "Set %s %s\n", name, settingValue(settings[name]))
+	}
+	return sb.String()
+}
+
+// settingValue formats a value of the manifest as the argument of Set.
+func settingValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		if bareSettingValue.MatchString(v) {
+			return v
+		}
+		// Tapes have no escapes, so the string is quoted with a quote it
+		// does not contain.
+		for _, q := range []string{`"`, `'`, "`"} {
+			if !strings.Contains(v, q) {
+				return q + v + q
+			}
+		}
+		// A string with every quote cannot be written, its double quotes
+		// are dropped.
+		return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+	case map[string]any:
+		// Themes may be given as objects.
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// checkOutputs returns an error for the outputs which withOutputs cannot
+// render, so that they are not silently dropped.
+func checkOutputs(outputs []string) error {
+	for _, output := range outputs {
+		if strings.HasSuffix(output, gif) || strings.HasSuffix(output, webm) || strings.HasSuffix(output, mp4) {
+			continue
+		}
+		if _, ok := rendererExt(output); ok {
+			continue
+		}
+		exts := []string{gif, webm, mp4}
+		for ext := range renderers {
+			exts = append(exts, ext)
+		}
+		slices.Sort(exts)
+		return fmt.Errorf("unsupported output %s: expected one of %s", output, strings.Join(exts, ", "))
+	}
+	return nil
+}
+
+// withOutputs returns an EvaluatorOption replacing the outputs of the tape
+// with the given files, by their extension. The outputs are expected to be
+// checked by checkOutputs.
+func withOutputs(outputs []string) EvaluatorOption {
+	return func(v *VHS) {
+		for _, output := range outputs {
+			switch {
+			case strings.HasSuffix(output, gif):
+				v.Options.Video.Output.GIF = output
+			case strings.HasSuffix(output, webm):
+				v.Options.Video.Output.WebM = output
+			case strings.HasSuffix(output, mp4):
+				v.Options.Video.Output.MP4 = output
+			default:
+				if ext, ok := rendererExt(output); ok {
+					v.Options.Video.Output.setRendered(ext, output)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vhs.yaml")
+	manifest := `settings:
+  Theme: Catppuccin Mocha
+  FontSize: 22
+  MarginFill: "#674EFF"
+  TypingSpeed: 50ms
+tapes:
+  - tape: tapes/demo.tape
+    output: assets/demo.gif
+  - tape: /abs/other.tape
+    output: [assets/other.gif, assets/other.svg]
+    settings:
+      Width: 800
+hooks:
+  - echo done
+`
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := readManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := m.jobs(dir)
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+	if jobs[0].Tape != filepath.Join(dir, "tapes", "demo.tape") || jobs[1].Tape != "/abs/other.tape" {
+		t.Errorf("unexpected tapes %q and %q", jobs[0].Tape, jobs[1].Tape)
+	}
+
+	expected := "Set FontSize 22\nSet MarginFill \"#674EFF\"\nSet Theme \"Catppuccin Mocha\"\nSet TypingSpeed 50ms\n"
+	if jobs[0].Prelude != expected {
+		t.Errorf("expected prelude %q, got %q", expected, jobs[0].Prelude)
+	}
+	if !strings.HasSuffix(jobs[1].Prelude, "Set Width 800\n") {
+		t.Errorf("expected the settings of the tape last, got %q", jobs[1].Prelude)
+	}
+
+	v := &VHS{Options: &Options{}}
+	for _, opt := range jobs[1].Options {
+		opt(v)
+	}
+	if o := v.Options.Video.Output; o.GIF != filepath.Join(dir, "assets", "other.gif") || o.SVG != filepath.Join(dir, "assets", "other.svg") {
+		t.Errorf("unexpected outputs %+v", o)
+	}
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": "tapes:\n  - tape: a.tape\n    outputs: a.gif\n",
+		"no tapes":      "settings:\n  FontSize: 22\n",
+		"no path":       "tapes:\n  - output: a.gif\n",
+		"bad output":    "tapes:\n  - tape: a.tape\n    output: [a.gif, a.mov]\n",
+	}
+	for name, manifest := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vhs.yaml")
+			if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readManifest(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCheckOutputs(t *testing.T) {
+	if err := checkOutputs([]string{"a.gif", "a.webm", "a.mp4", "a.svg", "a.md", "a.vhs.json"}); err != nil {
+		t.Errorf("expected the outputs to be supported, got %v", err)
+	}
+	for _, output := range []string{"a.txt", "a.mov", "a.png", "a.gfi"} {
+		if err := checkOutputs([]string{"a.gif", output}); err == nil || !strings.Contains(err.Error(), output) {
+			t.Errorf("expected %s to be rejected, got %v", output, err)
+		}
+	}
+}
+
+func TestSettingValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{22, "22"},
+		{true, "true"},
+		{"Dracula", "Dracula"},
+		{`say "hi"`, `'say "hi"'`},
+		{map[string]any{"background": "#000000"}, `{"background":"#000000"}`},
+	}
+	for _, tc := range tests {
+		if got := settingValue(tc.value); got != tc.expected {
+			t.Errorf("expected %v to be written as %s, got %s", tc.value, tc.expected, got)
+		}
+	}
+}
+
+func TestBuildManifestNative(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "demo.tape"), []byte("Set Shell bash\nType \"echo hi\"\nEnter"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{
+		Settings: map[string]any{"FontSize": 30},
+		Tapes:    []ManifestTape{{Tape: "demo.tape", Output: manifestList{"out/demo.svg"}}},
+		Hooks:    []string{`printf '%s' "$VHS_OUTPUTS" > outputs.txt`},
+	}
+
+	backend := backendFlag
+	backendFlag = nativeBackend
+	defer func() { backendFlag = backend }()
+
+	results := renderBatch(context.Background(), m.jobs(dir), 1)
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+	if err := m.runHooks(dir, results[0].Outputs); err != nil {
+		t.Fatal(err)
+	}
+
+	svg, err := os.ReadFile(filepath.Join(dir, "out", "demo.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(svg), "font-size: 30px") {
+		t.Error("expected the settings of the manifest to apply")
+	}
+	outputs, _ := os.ReadFile(filepath.Join(dir, "outputs.txt"))
+	if string(outputs) != filepath.Join(dir, "out", "demo.svg") {
+		t.Errorf("expected the hook to get the outputs, got %q", outputs)
+	}
+}
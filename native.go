@@ -0,0 +1,543 @@
+//go:build !js
+
+// Package vhs native.go records tapes without a browser.
+//
+// The native backend runs the shell in a pseudo terminal and feeds its output
+// into the Go terminal emulator of emulator.go instead of ttyd and xterm.js.
+// Keys are written to the pseudo terminal as the bytes a terminal would send.
+// Only text outputs can be rendered this way (SVG, text and golden files),
+// but neither Chromium, ttyd nor ffmpeg are needed, which makes it suitable
+// for minimal CI containers.
+//
+// vhs --backend native demo.tape
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+	"github.com/atotto/clipboard"
+	"github.com/creack/pty"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Capture backends.
+const (
+	browserBackend = "browser"
+	nativeBackend  = "native"
+)
+
+// Font metrics of monospace fonts, used when the font cannot be measured.
+const (
+	monospaceAdvance    = 0.6
+	monospaceLineHeight = 1.2
+)
+
+// errNativeUnsupported is returned by the commands which need a browser.
+var errNativeUnsupported = errors.New("not supported by the native backend")
+
+// WithBackend returns an EvaluatorOption that selects the capture backend.
+func WithBackend(backend string) EvaluatorOption {
+	return func(v *VHS) {
+		v.Options.Backend = backend
+	}
+}
+
+// nativeTerminal is a shell running in a pseudo terminal whose output is
+// emulated in Go.
+type nativeTerminal struct {
+	pty    *os.File
+	cmd    *exec.Cmd
+	screen *emulator
+	// done is closed once the output of the shell has been read.
+	done chan struct{}
+
+	charWidth, charHeight float64
+}
+
+// startNative starts the shell of the tape in a pseudo terminal sized to fit
+// the terminal viewport.
+func startNative(v *VHS) (*nativeTerminal, error) {
+	charWidth, charHeight := nativeCellSize(v.Options)
+	width, height := v.terminalViewport()
+	cols := max(1, int(float64(width)/charWidth))
+	rows := max(1, int(float64(height)/charHeight))
+
+	sandbox := v.Options.Sandbox
+	if v.Options.Video.Deterministic {
+		sandbox = deterministicSandbox(sandbox)
+	}
+	if v.Options.ColorMode != "" {
+		sandbox = colorModeSandbox(sandbox, v.Options.ColorMode)
+	}
+	args := append(append([]string{}, sandbox.Command...), v.Options.Shell.Command()...)
+	if len(args) == 0 {
+		return nil, errors.New("no shell command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec,noctx
+	cmd.Dir = v.shellDir(sandbox)
+	env := shellEnv(v.Options.Shell, sandbox)
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, "TERM="+cmp.Or(sandboxTerm(sandbox), "xterm-256color"))
+
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)}) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to start shell: %w", err)
+	}
+
+	t := &nativeTerminal{
+		pty:        f,
+		cmd:        cmd,
+		screen:     newEmulator(cols, rows, v.Options.Theme),
+		done:       make(chan struct{}),
+		charWidth:  charWidth,
+		charHeight: charHeight,
+	}
+	t.screen.colorMode = v.Options.ColorMode
+	go func() {
+		_, _ = io.Copy(t.screen, f)
+		close(t.done)
+	}()
+	return t, nil
+}
+
+// nativeCellSize returns the size of a terminal cell in pixels, measured from
+// the font when it is installed.
+func nativeCellSize(opts *Options) (float64, float64) {
+	size := float64(opts.FontSize)
+	advance := size * monospaceAdvance
+	height := size * monospaceLineHeight
+	if face, err := getFontLoader().LoadFont(opts.FontFamily, size); err == nil {
+		if a, ok := face.GlyphAdvance('M'); ok {
+			advance = fixedToFloat(a)
+		}
+		metrics := face.Metrics()
+		height = fixedToFloat(metrics.Ascent + metrics.Descent)
+	}
+	return advance + opts.LetterSpacing, math.Ceil(height * opts.LineHeight)
+}
+
+func fixedToFloat[T interface{ ~int32 }](x T) float64 {
+	return float64(x) / 64
+}
+
+// write sends input to the shell.
+func (t *nativeTerminal) write(s string) error {
+	if _, err := io.WriteString(t.pty, s); err != nil {
+		return fmt.Errorf("failed to write to the terminal: %w", err)
+	}
+	return nil
+}
+
+// resize changes the size of the pseudo terminal and the screen.
+func (t *nativeTerminal) resize(cols, rows int) error {
+	if err := pty.Setsize(t.pty, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)}); err != nil { //nolint:gosec
+		return fmt.Errorf("failed to resize terminal: %w", err)
+	}
+	t.screen.Resize(cols, rows)
+	return nil
+}
+
+// close stops the shell.
+func (t *nativeTerminal) close() error {
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	err := t.pty.Close()
+	<-t.done
+	_ = t.cmd.Wait()
+	return err //nolint:wrapcheck
+}
+
+// frame returns the current screen as an SVG frame.
+func (t *nativeTerminal) frame() SVGFrame {
+	frame := t.screen.Frame()
+	frame.CharWidth = t.charWidth
+	frame.CharHeight = t.charHeight
+	return frame
+}
+
+// checkNativeOutputs returns an error if an output needs the browser backend.
+func checkNativeOutputs(outputs VideoOutputs) error {
+	for _, output := range []string{outputs.GIF, outputs.MP4, outputs.WebM, outputs.Frames} {
+		if output != "" {
+			return fmt.Errorf("%s requires the browser backend, the native backend only renders SVG and text outputs", output)
+		}
+	}
+	return nil
+}
+
+// evaluateNative runs the commands of the tape with the native backend. The
+// Shell and Env settings have already been applied.
+func evaluateNative(ctx context.Context, cmds []parser.Command, v *VHS, out io.Writer, opts []EvaluatorOption) []error {
+	// The terminal is sized by the settings, so nothing can be captured until
+	// they have been applied.
+	v.PauseRecording()
+	offset := len(cmds)
+	for i, cmd := range cmds {
+		if cmd.Type != token.SET && cmd.Type != token.OUTPUT && cmd.Type != token.REQUIRE {
+			offset = i
+			break
+		}
+		_, _ = fmt.Fprintln(out, Highlight(cmd, false))
+		if cmd.Options != "Shell" {
+			if err := Execute(cmd, v); err != nil {
+				return []error{err}
+			}
+		}
+	}
+	if len(v.Errors) > 0 {
+		return v.Errors
+	}
+	if err := checkNativeOutputs(v.Options.Video.Output); err != nil {
+		return []error{err}
+	}
+
+	t, err := startNative(v)
+	if err != nil {
+		return []error{err}
+	}
+	v.native = t
+	defer func() { _ = t.close() }()
+	if err := v.waitForShell(v.Options.WaitTimeout); err != nil {
+		return []error{err}
+	}
+	v.ResumeRecording()
+
+	// Capture frames on a ticker while recording, as the browser backend does.
+	ctx, cancel := context.WithCancel(ctx)
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		capture := v.traceCapture()
+		defer capture.end()
+		ticker := time.NewTicker(v.Options.Video.captureInterval())
+		defer ticker.Stop()
+		captures := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if v.recording && !v.Options.Video.Deterministic {
+					captures++
+					_ = capture.capture(v, v.Options.Video.capturedFrames(captures))
+				}
+			}
+		}
+	}()
+	teardown := func() {
+		v.limits.stop()
+		cancel()
+		<-recorded
+	}
+
+	for i, cmd := range cmds[offset:] {
+		if ctx.Err() != nil {
+			teardown()
+			v.renderTruncated(v.renderNative)
+			return []error{ctx.Err()}
+		}
+		if err := v.limits.Err(); err != nil {
+			teardown()
+			v.renderTruncated(v.renderNative)
+			return []error{err}
+		}
+		if cmd.Type == token.SET && cmd.Options != "TypingSpeed" || cmd.Type == token.REQUIRE {
+			_, _ = fmt.Fprintln(out, Highlight(cmd, true))
+			continue
+		}
+		_, _ = fmt.Fprintln(out, Highlight(cmd, !v.recording || cmd.Type == token.SHOW || cmd.Type == token.HIDE))
+		v.events.Publish(Event{Type: EventCommand, Command: cmd.String(), Line: cmd.Line})
+		if err := Execute(cmd, v); err != nil {
+			teardown()
+			v.renderTruncated(v.renderNative)
+			return []error{err}
+		}
+		v.publishProgress(cmd, offset+i+1, len(cmds))
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if err := v.captureTranscript(); err != nil {
+		teardown()
+		return []error{err}
+	}
+
+	if v.Options.Test.Golden != "" {
+		if err := v.takeSnapshot(finalSnapshot); err != nil {
+			teardown()
+			return []error{err}
+		}
+	}
+
+	teardown()
+	if err := v.renderNative(); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// renderNative renders the outputs of the native backend.
+func (vhs *VHS) renderNative() error {
+	if len(vhs.Options.Video.Output.renderedOutputs()) == 0 {
+		return nil
+	}
+	_, span := startSpan(vhs.trace, "vhs.render_outputs", attribute.Int("vhs.frames", len(vhs.svgFrames)))
+	err := renderOutputs(vhs)
+	endSpan(span, err)
+	if err != nil {
+		return err
+	}
+	vhs.events.Publish(progressEvent(PhaseOptimize, 1, 1))
+	return nil
+}
+
+// captureNativeFrames writes the current screen as the next n frames.
+func (vhs *VHS) captureNativeFrames(n int) {
+	frame := vhs.nativeFrame()
+	var screen, cursor []byte
+	if vhs.Options.Video.maxIdleFrames() > 0 {
+		screen = fmt.Appendf(nil, "%q %v", frame.Lines, frame.LineColors)
+		cursor = fmt.Appendf(nil, "%d %d", frame.CursorX, frame.CursorY)
+	}
+	for i := 0; i < n; i++ {
+		if vhs.skipIdleFrame(screen, cursor) {
+			continue
+		}
+		vhs.mutex.Lock()
+		vhs.totalFrames++
+		counter := vhs.totalFrames
+		vhs.mutex.Unlock()
+		vhs.checkFrames(counter, 0)
+		vhs.events.Publish(Event{Type: EventFrame, Frame: counter})
+
+		frame.Timestamp = float64(counter) / float64(vhs.Options.Video.Framerate)
+		frame.Selection, _ = vhs.selection(counter)
+		vhs.svgFrames = append(vhs.svgFrames, frame)
+	}
+}
+
+// nativeCommandFuncs replace the commands which drive the browser when the
+// native backend is used.
+var nativeCommandFuncs = map[parser.CommandType]CommandFunc{
+	token.BACKSPACE:   executeNativeKey("\x7f"),
+	token.DELETE:      executeNativeKey("\x1b[3~"),
+	token.INSERT:      executeNativeKey("\x1b[2~"),
+	token.DOWN:        executeNativeKey("\x1b[B"),
+	token.ENTER:       executeNativeKey("\r"),
+	token.LEFT:        executeNativeKey("\x1b[D"),
+	token.RIGHT:       executeNativeKey("\x1b[C"),
+	token.SPACE:       executeNativeKey(" "),
+	token.UP:          executeNativeKey("\x1b[A"),
+	token.TAB:         executeNativeKey("\t"),
+	token.ESCAPE:      executeNativeKey("\x1b"),
+	token.PAGE_UP:     executeNativeKey("\x1b[5~"),
+	token.PAGE_DOWN:   executeNativeKey("\x1b[6~"),
+	token.HOME:        executeNativeKey("\x1b[H"),
+	token.END:         executeNativeKey("\x1b[F"),
+	token.KEY:         executeNativeKeySequence,
+	token.SCROLL_UP:   executeNativeUnsupported,
+	token.SCROLL_DOWN: executeNativeUnsupported,
+	token.TYPE:        executeNativeType,
+	token.TYPE_FILE:   executeNativeTypeFile,
+	token.TITLE_CARD:  executeNativeTitleCard,
+	token.CTRL:        executeNativeCtrl,
+	token.ALT:         executeNativeAlt,
+	token.SHIFT:       executeNativeShift,
+	token.SCREENSHOT:  executeNativeScreenshot,
+	token.PASTE:       executeNativePaste,
+	token.RESIZE:      executeNativeResize,
+	token.HIGHLIGHT:   executeNativeHighlight,
+	token.SCRIPT:      executeNativeScript,
+}
+
+// executeNativeKey returns a CommandFunc writing the key sequence, repeated
+// and delayed like ExecuteKey.
+func executeNativeKey(seq string) CommandFunc {
+	return func(c parser.Command, v *VHS) error {
+		typingSpeed, err := time.ParseDuration(c.Options)
+		if err != nil {
+			typingSpeed = v.Options.TypingSpeed
+		}
+		repeat, err := strconv.Atoi(c.Args)
+		if err != nil {
+			repeat = 1
+		}
+		for i := 0; i < repeat; i++ {
+			if err := v.native.write(seq); err != nil {
+				return err
+			}
+			if err := v.sleep(typingSpeed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// executeNativeKeySequence writes the sequence of a function key, or an
+// escape sequence.
+func executeNativeKeySequence(c parser.Command, v *VHS) error {
+	key, repeat := keyArgs(c.Args)
+	seq, err := keySequence(key)
+	if err != nil {
+		return err
+	}
+	c.Args = repeat
+	return executeNativeKey(seq)(c, v)
+}
+
+func executeNativeType(c parser.Command, v *VHS) error {
+	typingSpeed := v.Options.TypingSpeed
+	if c.Options != "" {
+		var err error
+		typingSpeed, err = time.ParseDuration(c.Options)
+		if err != nil {
+			return fmt.Errorf("failed to parse typing speed: %w", err)
+		}
+	}
+	for _, r := range c.Args {
+		if err := v.native.write(string(r)); err != nil {
+			return err
+		}
+		if err := v.sleep(typingSpeed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func executeNativeCtrl(c parser.Command, v *VHS) error {
+	seq, err := ctrlSequence(c.Args)
+	if err != nil {
+		return err
+	}
+	return v.native.write(seq)
+}
+
+func executeNativeAlt(c parser.Command, v *VHS) error {
+	return v.native.write(altSequence(c.Args))
+}
+
+func executeNativeShift(c parser.Command, v *VHS) error {
+	return v.native.write(shiftSequence(c.Args))
+}
+
+func executeNativePaste(_ parser.Command, v *VHS) error {
+	clip, err := clipboard.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	return v.native.write(clip)
+}
+
+func executeNativeScript(c parser.Command, v *VHS) error {
+	if err := v.native.write(strings.ReplaceAll(c.Args, "\n", "\r") + "\r"); err != nil {
+		return err
+	}
+	return waitForPrompt(v)
+}
+
+func executeNativeResize(c parser.Command, v *VHS) error {
+	var cols, rows int
+	if _, err := fmt.Sscanf(c.Args, "%d %d", &cols, &rows); err != nil {
+		return fmt.Errorf("failed to parse terminal size: %w", err)
+	}
+	return v.native.resize(cols, rows)
+}
+
+// executeNativeScreenshot takes golden snapshots, but cannot render images.
+func executeNativeScreenshot(c parser.Command, v *VHS) error {
+	if v.Options.Test.Golden != "" {
+		return v.takeSnapshot(c.Args)
+	}
+	return fmt.Errorf("%s: %w", c.Type, errNativeUnsupported)
+}
+
+func executeNativeUnsupported(c parser.Command, _ *VHS) error {
+	return fmt.Errorf("%s: %w", c.Type, errNativeUnsupported)
+}
+
+// ctrlSequence returns the bytes sent for a Ctrl combination, e.g. "Alt c".
+func ctrlSequence(args string) (string, error) {
+	keys := strings.Split(args, " ")
+	prefix := ""
+	for _, modifier := range keys[:len(keys)-1] {
+		if modifier == "Alt" {
+			prefix = "\x1b"
+		}
+	}
+
+	var b byte
+	switch key := keys[len(keys)-1]; key {
+	case "Enter":
+		b = '\r'
+	case "Space", "@":
+		b = 0
+	case "Backspace":
+		b = '\b'
+	case "":
+		return "", errors.New("Ctrl expects a key")
+	default:
+		r := unicode.ToUpper(rune(key[0]))
+		if r < '@' || r > '_' {
+			return "", fmt.Errorf("Ctrl+%s: %w", key, errNativeUnsupported)
+		}
+		b = byte(r) & 0x1f
+	}
+	return prefix + string(rune(b)), nil
+}
+
+// altSequence returns the bytes sent for an Alt combination, which prefixes
+// the keys with escape.
+func altSequence(args string) string {
+	if seq, ok := navigationSequence(args, modifierAlt); ok {
+		return seq
+	}
+	switch token.Keywords[args] {
+	case token.ENTER:
+		return "\x1b\r"
+	case token.TAB:
+		return "\x1b\t"
+	}
+	return "\x1b" + args
+}
+
+// shiftSequence returns the bytes sent for a Shift combination.
+func shiftSequence(args string) string {
+	if seq, ok := navigationSequence(args, modifierShift); ok {
+		return seq
+	}
+	switch token.Keywords[args] {
+	case token.ENTER:
+		return "\r"
+	case token.TAB:
+		return "\x1b[Z"
+	}
+	return strings.ToUpper(args)
+}
+
+// executeNativeHighlight selects the given span of the terminal in the SVG
+// output of the native backend.
+func executeNativeHighlight(c parser.Command, v *VHS) error {
+	_, err := v.startHighlight(c)
+	return err
+}
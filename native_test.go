@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCtrlSequence(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"C", "\x03"},
+		{"c", "\x03"},
+		{"L", "\x0c"},
+		{"[", "\x1b"},
+		{"Space", "\x00"},
+		{"Enter", "\r"},
+		{"Alt c", "\x1b\x03"},
+		{"Shift Alt c", "\x1b\x03"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.args, func(t *testing.T) {
+			got, err := ctrlSequence(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("ctrlSequence(%q) = %q, want %q", tc.args, got, tc.want)
+			}
+		})
+	}
+
+	if _, err := ctrlSequence("1"); err == nil {
+		t.Error("expected an error for Ctrl+1")
+	}
+}
+
+func TestAltShiftSequence(t *testing.T) {
+	if got := altSequence("Enter"); got != "\x1b\r" {
+		t.Errorf("altSequence(Enter) = %q", got)
+	}
+	if got := altSequence("x"); got != "\x1bx" {
+		t.Errorf("altSequence(x) = %q", got)
+	}
+	if got := shiftSequence("Tab"); got != "\x1b[Z" {
+		t.Errorf("shiftSequence(Tab) = %q", got)
+	}
+	if got := shiftSequence("abc"); got != "ABC" {
+		t.Errorf("shiftSequence(abc) = %q", got)
+	}
+	if got := shiftSequence("Up"); got != "\x1b[1;2A" {
+		t.Errorf("shiftSequence(Up) = %q", got)
+	}
+	if got := altSequence("PageDown"); got != "\x1b[6;3~" {
+		t.Errorf("altSequence(PageDown) = %q", got)
+	}
+}
+
+func TestKeySequence(t *testing.T) {
+	tests := map[string]string{
+		"F1":        "\x1bOP",
+		"F13":       "\x1b[1;2P",
+		"F24":       "\x1b[24;2~",
+		`\x1b[1;5C`: "\x1b[1;5C",
+		`\e[H`:      "\x1b[H",
+	}
+	for key, want := range tests {
+		got, err := keySequence(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("keySequence(%q) = %q, want %q", key, got, want)
+		}
+	}
+	if _, err := keySequence(`\xZZ`); err == nil {
+		t.Error("expected an error for an invalid escape sequence")
+	}
+}
+
+func TestCheckNativeOutputs(t *testing.T) {
+	if err := checkNativeOutputs(VideoOutputs{SVG: "out.svg"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := checkNativeOutputs(VideoOutputs{SVG: "out.svg", GIF: "out.gif"})
+	if err == nil || !strings.Contains(err.Error(), "out.gif") {
+		t.Errorf("expected an error naming out.gif, got %v", err)
+	}
+}
+
+func TestEvaluateUnknownBackend(t *testing.T) {
+	errs := Evaluate(context.Background(), "Type hello", io.Discard, WithBackend("teletype"))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unknown backend") {
+		t.Errorf("expected an unknown backend error, got %v", errs)
+	}
+}
+
+func TestEvaluateNative(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	svg := filepath.Join(t.TempDir(), "out.svg")
+	tape := `Set Shell bash
+Type "echo $((6*7))"
+Enter
+Wait+Screen /42/
+Highlight 2,1 2,2
+Zoom 2x 1,1 100ms
+Annotate "answer" --arrow 2,1 --for 1s
+Sleep 200ms`
+
+	var v *VHS
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = svg
+		vhs.Options.Test.Output = ""
+		v = vhs
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(v.svgFrames) == 0 {
+		t.Fatal("expected SVG frames to be captured")
+	}
+
+	last := v.svgFrames[len(v.svgFrames)-1]
+	if !strings.Contains(strings.Join(last.Lines, "\n"), "\n42") {
+		t.Errorf("expected the last frame to show the output, got %q", last.Lines)
+	}
+	if last.Selection == nil || last.Selection.EndCol != 1 {
+		t.Errorf("expected the last frame to be highlighted, got %+v", last.Selection)
+	}
+	if len(v.camera) != 2 || v.camera[1].Zoom != 2 {
+		t.Errorf("expected the camera to zoom in, got %+v", v.camera)
+	}
+	if len(v.annotations) != 1 || v.annotations[0].ArrowRow != 1 {
+		t.Errorf("expected an annotation, got %+v", v.annotations)
+	}
+	if last.CharWidth <= 0 || last.CharHeight <= 0 {
+		t.Errorf("expected a cell size, got %vx%v", last.CharWidth, last.CharHeight)
+	}
+}
+
+func TestEvaluateNativeProgress(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	bus := NewEventBus()
+	events := bus.Subscribe(1024)
+	tape := "Set Shell bash\n\nType \"echo hi\"\nEnter"
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), WithEvents(bus), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+	})
+	bus.Close()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	var progress []Event
+	for e := range events {
+		if e.Type == EventProgress {
+			progress = append(progress, e)
+		}
+	}
+	// Settings are applied before the recording starts.
+	if len(progress) != 3 {
+		t.Fatalf("expected progress for each command and the SVG, got %+v", progress)
+	}
+	if progress[0].Line != 3 || !strings.HasPrefix(progress[0].Command, "Type") || progress[0].Percent != 66.7 {
+		t.Errorf("unexpected progress of Type: %+v", progress[0])
+	}
+	if last := progress[2]; last.Phase != PhaseOptimize || last.Percent != 100 {
+		t.Errorf("expected the SVG to complete the recording, got %+v", last)
+	}
+}
+
+func TestEvaluateNativeScript(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	tape := "Set Shell bash\nScript <<EOF\ncd /\nx=$((6*7))\nEOF\nType \"echo $PWD$x\"\nEnter\nWait+Screen /\\/42/"
+
+	var v *VHS
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+		v = vhs
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	lines, _ := v.Buffer()
+	if !strings.Contains(strings.Join(lines, "\n"), "\n/42") {
+		t.Errorf("expected the script to run in the shell, got %q", lines)
+	}
+}
+
+func TestEvaluateNativeSignal(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	tape := `Set Shell bash
+Type "false"
+Enter
+WaitExit 5s --status 1
+Type "sleep 30"
+Enter
+Sleep 200ms
+Signal SIGTERM
+WaitExit 5s --status 143`
+
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	errs = Evaluate(context.Background(), "Set Shell bash\nType \"true\"\nEnter\nWaitExit 5s --status 2", io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+	})
+	if len(errs) == 0 || !strings.Contains(errs[0].Error(), "expected the command to exit with status 2, got 0") {
+		t.Errorf("expected an exit status error, got %v", errs)
+	}
+}
+
+func TestForegroundProcessGroup(t *testing.T) {
+	ps := `    1     0     -1
+  100     1     -1
+  101   100    105
+  105   101    105`
+
+	if pgrp, err := foregroundProcessGroup(ps, 100, false); err != nil || pgrp != 105 {
+		t.Errorf("expected the group of the child of ttyd, got %d (%v)", pgrp, err)
+	}
+	if pgrp, err := foregroundProcessGroup(ps, 101, true); err != nil || pgrp != 105 {
+		t.Errorf("expected the group of the terminal of the shell, got %d (%v)", pgrp, err)
+	}
+	if _, err := foregroundProcessGroup(ps, 1, true); err == nil {
+		t.Error("expected an error without a terminal")
+	}
+}
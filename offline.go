@@ -0,0 +1,80 @@
+//go:build !js
+
+// Package vhs offline.go lets VHS run without network access.
+//
+// VHS bundles the Go Mono font, which is always available to the SVG and
+// window bar renderers under the name "Go Mono". In offline mode the font is
+// also loaded into the browser as the last fallback before the generic
+// families, so recordings look the same on machines without monospace fonts,
+// and Chromium is never downloaded: it must be installed. ttyd serves the
+// xterm.js bundle itself, so nothing else is fetched.
+//
+// vhs --offline demo.tape
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-rod/rod/lib/launcher"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+// errBrowserNotFound is returned in offline mode if Chromium is not installed.
+var errBrowserNotFound = errors.New("chromium is not installed and cannot be downloaded in offline mode. " +
+	"Install chromium or google-chrome, or set ROD_BROWSER_BIN to its path")
+
+// WithOffline returns an EvaluatorOption that prevents network access.
+func WithOffline(offline bool) EvaluatorOption {
+	return func(v *VHS) {
+		v.Options.Offline = offline
+	}
+}
+
+// offlineFromEnv reports whether VHS_OFFLINE enables offline mode.
+func offlineFromEnv() bool {
+	offline, _ := strconv.ParseBool(os.Getenv("VHS_OFFLINE"))
+	return offline
+}
+
+// browserPath returns the path of the browser to launch. An empty path lets
+// go-rod download Chromium, which is an error in offline mode.
+func browserPath(offline bool) (string, error) {
+	if path := os.Getenv("ROD_BROWSER_BIN"); path != "" {
+		return path, nil
+	}
+	path, found := launcher.LookPath()
+	if !found {
+		if offline {
+			return "", errBrowserNotFound
+		}
+		return "", nil
+	}
+	return path, nil
+}
+
+// loadBundledFont makes the bundled font available to xterm.js.
+func (vhs *VHS) loadBundledFont() error {
+	_, err := vhs.Page.Eval(`async (data) => {
+		const face = new FontFace(`+strconv.Quote(bundledFontFamily)+`, "url(data:font/ttf;base64," + data + ")");
+		await face.load();
+		document.fonts.add(face);
+	}`, base64.StdEncoding.EncodeToString(gomono.TTF))
+	if err != nil {
+		return fmt.Errorf("failed to load bundled font: %w", err)
+	}
+	return nil
+}
+
+// terminalFontFamily returns the font family of the terminal, with the emoji
+// font, which falls back to the bundled font in offline mode.
+func (vhs *VHS) terminalFontFamily() string {
+	fontFamily := withEmojiFont(vhs.Options.FontFamily, vhs.Options.EmojiFont)
+	if vhs.Options.Offline {
+		return fontFamily + fontsSeparator + bundledFontFamily
+	}
+	return fontFamily
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBrowserPathEnv(t *testing.T) {
+	t.Setenv("ROD_BROWSER_BIN", "/opt/chromium/chrome")
+	path, err := browserPath(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/opt/chromium/chrome" {
+		t.Errorf("expected ROD_BROWSER_BIN to be used, got %q", path)
+	}
+}
+
+func TestBundledFont(t *testing.T) {
+	face, err := getFontLoader().LoadFont("Missing Font, "+bundledFontFamily, 20)
+	if err != nil {
+		t.Fatalf("expected the bundled font to load: %v", err)
+	}
+	advance, ok := face.GlyphAdvance('M')
+	if !ok || advance <= 0 {
+		t.Errorf("expected a glyph advance, got %v", advance)
+	}
+	if getFontLoader().findFont(bundledFontFamily) == nil {
+		t.Error("expected the bundled font to be found")
+	}
+}
+
+func TestTerminalFontFamilyOffline(t *testing.T) {
+	v := New()
+	v.Options.FontFamily = "JetBrains Mono, monospace"
+	if got := fontStack(v.terminalFontFamily()); strings.Contains(got, bundledFontFamily) {
+		t.Errorf("expected no bundled font outside offline mode, got %s", got)
+	}
+
+	v.Options.Offline = true
+	got := fontStack(v.terminalFontFamily())
+	if !strings.Contains(got, `"Go Mono"`) || !strings.HasSuffix(got, "monospace") {
+		t.Errorf("expected the bundled font before the generic families, got %s", got)
+	}
+}
@@ -0,0 +1,145 @@
+//go:build !js
+
+// Package vhs options.go reads the Set defaults of --options.
+//
+// An options file is a JSON object of the values of Set commands, by setting,
+// applied before the commands of the tape, which can still override them. It
+// lets tools which generate tapes style them without templating Set commands,
+// and the themes can be Theme objects as in Set Theme. Tapes can be written as
+// a JSON array of commands too, as described by the schema of vhs schema.
+//
+// vhs demo.tape --options options.json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+)
+
+// OptionsFile holds the values of Set commands by setting, e.g.
+// {"FontSize": 22, "TypingSpeed": "75ms", "Theme": "Dracula"}. Values are
+// strings, numbers, booleans, or Theme objects for Theme and ThemeDark.
+type OptionsFile map[string]any
+
+// bareOption matches the values of options written without quotes, the
+// numbers and durations which Set commands do not accept as strings.
+var bareOption = regexp.MustCompile(`^[0-9.]+(ms|s|m)?$`)
+
+// Commands returns the Set commands of the options, in the order of their
+// settings.
+func (o OptionsFile) Commands() ([]parser.Command, error) {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	cmds := make([]parser.Command, 0, len(o))
+	for _, name := range names {
+		if !token.IsSetting(token.Keywords[name]) {
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+		value, err := optionValue(o[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid option %s: %w", name, err)
+		}
+		p := parser.New(lexer.New("Set " + name + " " + value))
+		parsed := p.Parse()
+		if errs := p.Errors(); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid option %s: %s", name, errs[0].Msg)
+		}
+		if len(parsed) != 1 {
+			return nil, fmt.Errorf("invalid option %s: %s", name, value)
+		}
+		parsed[0].Line = 0
+		cmds = append(cmds, parsed[0])
+	}
+	return cmds, nil
+}
+
+// optionValue returns the value as written in a Set command.
+func optionValue(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		if bareOption.MatchString(value) {
+			return value, nil
+		}
+		return quoteOption(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case map[string]any:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		return quoteOption(string(data))
+	default:
+		return "", fmt.Errorf("unexpected value %v", value)
+	}
+}
+
+// quoteOption quotes the value with quotes it does not contain.
+func quoteOption(value string) (string, error) {
+	for _, quote := range []string{"`", `"`, "'"} {
+		if !strings.Contains(value, quote) {
+			return quote + value + quote, nil
+		}
+	}
+	return "", errors.New("value contains every kind of quote")
+}
+
+// readOptionsFile returns the Set commands of the options file.
+func readOptionsFile(path string) ([]parser.Command, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read options: %w", err)
+	}
+	var options OptionsFile
+	if err := json.Unmarshal(data, &options); err != nil {
+		return nil, fmt.Errorf("invalid options %s: %w", path, err)
+	}
+	cmds, err := options.Commands()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cmds, nil
+}
+
+// WithDefaults returns an EvaluatorOption which executes the Set commands
+// before the commands of the tape.
+func WithDefaults(cmds []parser.Command) EvaluatorOption {
+	return func(v *VHS) {
+		v.defaults = cmds
+	}
+}
+
+// isJSONTape returns whether the tape is a JSON array of commands.
+func isJSONTape(tape string) bool {
+	return strings.HasPrefix(strings.TrimSpace(tape), "[")
+}
+
+// parseTape returns the commands of the tape, in the tape language or as a
+// JSON array of commands.
+func parseTape(tape string) ([]parser.Command, error) {
+	if isJSONTape(tape) {
+		return parser.ParseJSON([]byte(strings.TrimSpace(tape))) //nolint:wrapcheck
+	}
+	p := parser.New(lexer.New(tape))
+	cmds := p.Parse()
+	if errs := p.Errors(); len(errs) != 0 || len(cmds) == 0 {
+		return nil, InvalidSyntaxError{errs}
+	}
+	return cmds, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentstation/vhs/token"
+)
+
+func TestOptionsFileCommands(t *testing.T) {
+	var options OptionsFile
+	data := `{
+		"FontSize": 22,
+		"FontFamily": "JetBrains Mono",
+		"TypingSpeed": "75ms",
+		"CursorBlink": false,
+		"WindowBar": "Colorful",
+		"MarginFill": "#674EFF",
+		"Theme": {"name": "Custom", "background": "#000000", "foreground": "#ffffff"}
+	}`
+	if err := json.Unmarshal([]byte(data), &options); err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := options.Commands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 7 || cmds[0].Type != token.SET || cmds[0].Options != "CursorBlink" {
+		t.Fatalf("expected the Set commands in the order of their settings, got %+v", cmds)
+	}
+
+	v := New()
+	for _, cmd := range cmds {
+		if err := Execute(cmd, &v); err != nil {
+			t.Fatalf("%s: %v", cmd.Options, err)
+		}
+	}
+	if v.Options.FontSize != 22 || v.Options.FontFamily != "JetBrains Mono" || v.Options.TypingSpeed != 75*time.Millisecond {
+		t.Errorf("expected the options to be set, got %d, %q and %v", v.Options.FontSize, v.Options.FontFamily, v.Options.TypingSpeed)
+	}
+	if v.Options.CursorBlink || v.Options.Video.Style.WindowBar != "Colorful" || v.Options.Video.Style.MarginFill != "#674EFF" {
+		t.Errorf("expected the style to be set, got %+v", v.Options.Video.Style)
+	}
+	if v.Options.Theme.Name != "Custom" || v.Options.Theme.Background != "#000000" {
+		t.Errorf("expected the custom theme, got %+v", v.Options.Theme)
+	}
+}
+
+func TestOptionsFileErrors(t *testing.T) {
+	for _, options := range []OptionsFile{
+		{"FontSzie": 22.0},
+		{"KeyboardLayout": "colemak"},
+		{"Framerate": []any{1.0}},
+	} {
+		if _, err := options.Commands(); err == nil {
+			t.Errorf("expected an error for %v", options)
+		}
+	}
+}
+
+func TestReadOptionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.json")
+	if err := os.WriteFile(path, []byte(`{"KeyboardLayout": "de"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := readOptionsFile(path)
+	if err != nil || len(cmds) != 1 || cmds[0].Args != "de" {
+		t.Errorf("expected Set KeyboardLayout de, got %+v (%v)", cmds, err)
+	}
+}
+
+func TestParseTapeJSON(t *testing.T) {
+	cmds, err := parseTape(`[{"type": "SET", "options": "FontSize", "args": "22"}, {"type": "TYPE", "args": "echo hi"}]`)
+	if err != nil || len(cmds) != 2 || cmds[1].Args != "echo hi" {
+		t.Errorf("expected the commands of the JSON tape, got %+v (%v)", cmds, err)
+	}
+	if _, err := parseTape(`Typo "echo hi"`); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
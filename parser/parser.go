@@ -2,10 +2,12 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +32,9 @@ var CommandTypes = []CommandType{ //nolint: deadcode
 	token.BACKSPACE,
 	token.DELETE,
 	token.INSERT,
+	token.HOME,
+	token.END,
+	token.KEY,
 	token.CTRL,
 	token.ALT,
 	token.DOWN,
@@ -56,6 +61,20 @@ var CommandTypes = []CommandType{ //nolint: deadcode
 	token.COPY,
 	token.PASTE,
 	token.ENV,
+	token.SCENE,
+	token.CHAPTER,
+	token.RESIZE,
+	token.SCROLL_UP,
+	token.SCROLL_DOWN,
+	token.HIGHLIGHT,
+	token.ZOOM,
+	token.PAN,
+	token.ANNOTATE,
+	token.SCRIPT,
+	token.SIGNAL,
+	token.WAIT_EXIT,
+	token.TYPE_FILE,
+	token.TITLE_CARD,
 }
 
 // String returns the string representation of the command.
@@ -63,10 +82,57 @@ func (c CommandType) String() string { return token.ToCamel(string(c)) }
 
 // Command represents a command with options and arguments.
 type Command struct {
-	Type    CommandType
-	Options string
-	Args    string
-	Source  string
+	Type    CommandType `json:"type"`
+	Options string      `json:"options,omitempty"`
+	Args    string      `json:"args,omitempty"`
+	Source  string      `json:"source,omitempty"`
+	// Line is the line of the command in the tape, or of the Source command
+	// which included it.
+	Line int `json:"line,omitempty"`
+}
+
+// jsonArgsCommands are the commands which the parser only returns with
+// arguments.
+var jsonArgsCommands = []CommandType{
+	token.CTRL, token.ALT, token.KEY, token.OUTPUT, token.SET, token.REQUIRE,
+	token.TYPE, token.TYPE_FILE, token.COPY, token.ENV, token.CHAPTER,
+	token.RESIZE, token.HIGHLIGHT, token.ZOOM, token.PAN, token.ANNOTATE,
+	token.TITLE_CARD, token.SCRIPT, token.SIGNAL, token.SCREENSHOT,
+}
+
+// ParseJSON returns the commands of a tape written as a JSON array of
+// commands, e.g. by tools which generate tapes. Commands are checked as the
+// parser would return them: Source is expanded by the parser, so it is not a
+// command of its own.
+func ParseJSON(data []byte) ([]Command, error) {
+	var cmds []Command
+	if err := json.Unmarshal(data, &cmds); err != nil {
+		return nil, fmt.Errorf("invalid JSON tape: %w", err)
+	}
+	for i, cmd := range cmds {
+		if err := validateJSONCommand(cmd); err != nil {
+			return nil, fmt.Errorf("command %d: %w", i+1, err)
+		}
+	}
+	return cmds, nil
+}
+
+// validateJSONCommand returns an error if the parser would not return the
+// command.
+func validateJSONCommand(cmd Command) error {
+	switch {
+	case !slices.Contains(CommandTypes, cmd.Type) || cmd.Type == token.SOURCE || cmd.Type == token.ILLEGAL:
+		return fmt.Errorf("unknown command type %q", cmd.Type)
+	case cmd.Type == token.SET && !token.IsSetting(token.Keywords[cmd.Options]):
+		return fmt.Errorf("unknown setting %q", cmd.Options)
+	case cmd.Type == token.ENV && cmd.Options == "":
+		return fmt.Errorf("%s expects a variable name", cmd.Type)
+	case slices.Contains(jsonArgsCommands, cmd.Type) && cmd.Args == "":
+		return fmt.Errorf("%s expects args", cmd.Type)
+	case (cmd.Type == token.CTRL || cmd.Type == token.ALT) && slices.Contains(strings.Split(cmd.Args, " "), ""):
+		return fmt.Errorf("%s expects keys separated by single spaces, got %q", cmd.Type, cmd.Args)
+	}
+	return nil
 }
 
 // String returns the string representation of the command.
@@ -125,7 +191,11 @@ func (p *Parser) Parse() []Command {
 			p.nextToken()
 			continue
 		}
-		cmds = append(cmds, p.parseCommand()...)
+		line := p.cur.Line
+		for _, cmd := range p.parseCommand() {
+			cmd.Line = line
+			cmds = append(cmds, cmd)
+		}
 		p.nextToken()
 	}
 
@@ -147,8 +217,14 @@ func (p *Parser) parseCommand() []Command {
 		token.RIGHT,
 		token.UP,
 		token.PAGE_UP,
-		token.PAGE_DOWN:
+		token.PAGE_DOWN,
+		token.HOME,
+		token.END,
+		token.SCROLL_UP,
+		token.SCROLL_DOWN:
 		return []Command{p.parseKeypress(p.cur.Type)}
+	case token.KEY:
+		return []Command{p.parseKey()}
 	case token.SET:
 		return []Command{p.parseSet()}
 	case token.OUTPUT:
@@ -181,6 +257,30 @@ func (p *Parser) parseCommand() []Command {
 		return []Command{p.parsePaste()}
 	case token.ENV:
 		return []Command{p.parseEnv()}
+	case token.SCENE:
+		return []Command{p.parseScene()}
+	case token.CHAPTER:
+		return []Command{p.parseChapter()}
+	case token.RESIZE:
+		return []Command{p.parseResize()}
+	case token.HIGHLIGHT:
+		return []Command{p.parseHighlight()}
+	case token.ZOOM:
+		return []Command{p.parseZoom()}
+	case token.PAN:
+		return []Command{p.parsePan()}
+	case token.ANNOTATE:
+		return []Command{p.parseAnnotate()}
+	case token.SCRIPT:
+		return []Command{p.parseScript()}
+	case token.SIGNAL:
+		return []Command{p.parseSignal()}
+	case token.WAIT_EXIT:
+		return []Command{p.parseWaitExit()}
+	case token.TYPE_FILE:
+		return []Command{p.parseTypeFile()}
+	case token.TITLE_CARD:
+		return []Command{p.parseTitleCard()}
 	default:
 		p.errors = append(p.errors, NewError(p.cur, "Invalid command: "+p.cur.Literal))
 		return []Command{{Type: token.ILLEGAL}}
@@ -270,6 +370,12 @@ func (p *Parser) parseTime() string {
 		return ""
 	}
 
+	return p.parseTimeUnit(t)
+}
+
+// parseTimeUnit parses the optional unit of a time whose number has already
+// been parsed, defaulting to seconds.
+func (p *Parser) parseTimeUnit(t string) string {
 	// Allow TypingSpeed to have bare units (e.g. 50ms, 100ms)
 	if p.peek.Type == token.MILLISECONDS || p.peek.Type == token.SECONDS || p.peek.Type == token.MINUTES {
 		t += p.peek.Literal
@@ -344,9 +450,13 @@ func (p *Parser) parseCtrl() Command {
 }
 
 // parseAlt parses an alt command.
-// An alt command takes a character to type while the modifier is held down.
+// An alt command takes a character or a navigation key to type while the
+// modifier is held down.
 //
 //	Alt+<character>
+//	E.g.
+//	Alt+b
+//	Alt+Left
 func (p *Parser) parseAlt() Command {
 	if p.peek.Type == token.PLUS {
 		p.nextToken()
@@ -354,7 +464,8 @@ func (p *Parser) parseAlt() Command {
 			p.peek.Type == token.ENTER ||
 			p.peek.Type == token.LEFT_BRACKET ||
 			p.peek.Type == token.RIGHT_BRACKET ||
-			p.peek.Type == token.TAB {
+			p.peek.Type == token.TAB ||
+			IsNavigationKey(p.peek.Type) {
 			c := p.peek.Literal
 			p.nextToken()
 			return Command{Type: token.ALT, Args: c}
@@ -373,6 +484,7 @@ func (p *Parser) parseAlt() Command {
 //	Shift+A
 //	Shift+Tab
 //	Shift+Enter
+//	Shift+Up
 func (p *Parser) parseShift() Command {
 	if p.peek.Type == token.PLUS {
 		p.nextToken()
@@ -380,7 +492,8 @@ func (p *Parser) parseShift() Command {
 			p.peek.Type == token.ENTER ||
 			p.peek.Type == token.LEFT_BRACKET ||
 			p.peek.Type == token.RIGHT_BRACKET ||
-			p.peek.Type == token.TAB {
+			p.peek.Type == token.TAB ||
+			IsNavigationKey(p.peek.Type) {
 			c := p.peek.Literal
 			p.nextToken()
 			return Command{Type: token.SHIFT, Args: c}
@@ -391,6 +504,56 @@ func (p *Parser) parseShift() Command {
 	return Command{Type: token.SHIFT}
 }
 
+// IsNavigationKey returns whether the token is a key which sends a sequence
+// that modifiers such as Alt and Shift change, e.g. the arrow keys.
+func IsNavigationKey(t token.Type) bool {
+	switch t {
+	case token.UP, token.DOWN, token.LEFT, token.RIGHT, token.HOME, token.END,
+		token.PAGE_UP, token.PAGE_DOWN, token.INSERT, token.DELETE:
+		return true
+	default:
+		return false
+	}
+}
+
+// functionKey matches the names of the function keys.
+var functionKey = regexp.MustCompile(`^F([1-9]|1[0-9]|2[0-4])$`)
+
+// parseKey parses a key command.
+// A key command takes a function key, or the escape sequence of any other key
+// as written in a Go string, with an optional typing speed and count.
+//
+//	Key[@<time>] <F1-F24|"sequence"> [count]
+//	E.g.
+//	Key F13
+//	Key "\x1b[1;5C"
+func (p *Parser) parseKey() Command {
+	cmd := Command{Type: token.KEY}
+	cmd.Options = p.parseSpeed()
+
+	if p.peek.Type != token.STRING || p.peek.Literal == "" {
+		p.errors = append(p.errors, NewError(p.peek, "Expected function key or escape sequence after Key"))
+		return cmd
+	}
+	key := p.peek.Literal
+	if !functionKey.MatchString(key) {
+		if _, err := KeySequence(key); err != nil {
+			p.errors = append(p.errors, NewError(p.peek, "Invalid escape sequence: "+key))
+		}
+	}
+	p.nextToken()
+
+	cmd.Args = key + " " + p.parseRepeat()
+	return cmd
+}
+
+// KeySequence returns the bytes of an escape sequence written as in a Go
+// string, which may also write escape as \e.
+func KeySequence(s string) (string, error) {
+	s = strings.ReplaceAll(s, `\e`, `\x1b`)
+	return strconv.Unquote(`"` + strings.ReplaceAll(s, `"`, `\"`) + `"`)
+}
+
 // parseKeypress parses a repeatable and time adjustable keypress command.
 // A keypress command takes an optional typing speed and optional count.
 //
@@ -402,10 +565,13 @@ func (p *Parser) parseKeypress(ct token.Type) Command {
 	return cmd
 }
 
+// lottieExt is the extension of Lottie outputs, which are JSON files.
+const lottieExt = ".lottie.json"
+
 // parseOutput parses an output command.
 // An output command takes a file path to which to output.
 //
-//	Output <path>
+//	Output <path> [--loops <count>]
 func (p *Parser) parseOutput() Command {
 	cmd := Command{Type: token.OUTPUT}
 
@@ -415,6 +581,9 @@ func (p *Parser) parseOutput() Command {
 	}
 
 	ext := filepath.Ext(p.peek.Literal)
+	if strings.HasSuffix(p.peek.Literal, lottieExt) {
+		ext = lottieExt
+	}
 	if ext != "" {
 		cmd.Options = ext
 	} else {
@@ -426,6 +595,32 @@ func (p *Parser) parseOutput() Command {
 
 	cmd.Args = p.peek.Literal
 	p.nextToken()
+
+	// Allow the number of times a GIF plays.
+	// Output demo.gif --loops 3
+	if p.peek.Type == token.MINUS {
+		p.nextToken()
+		if p.peek.Type != token.MINUS {
+			p.errors = append(p.errors, NewError(p.peek, "Output options start with --"))
+			return cmd
+		}
+		p.nextToken()
+		if p.peek.Literal != "loops" {
+			p.errors = append(p.errors, NewError(p.peek, "Invalid Output option: --"+p.peek.Literal))
+			return cmd
+		}
+		p.nextToken()
+		if ext != ".gif" {
+			p.errors = append(p.errors, NewError(p.cur, "--loops only applies to GIF outputs"))
+		}
+
+		p.nextToken()
+		if n, err := strconv.Atoi(p.cur.Literal); err != nil || n < 0 {
+			p.errors = append(p.errors, NewError(p.cur, "--loops expects a number of loops"))
+			return cmd
+		}
+		cmd.Options += " loops=" + p.cur.Literal
+	}
 	return cmd
 }
 
@@ -444,7 +639,7 @@ func (p *Parser) parseSet() Command {
 	p.nextToken()
 
 	switch p.cur.Type {
-	case token.WAIT_TIMEOUT:
+	case token.WAIT_TIMEOUT, token.LOOP_DELAY, token.TIMEOUT, token.MAX_IDLE:
 		cmd.Args = p.parseTime()
 	case token.WAIT_PATTERN:
 		cmd.Args = p.peek.Literal
@@ -485,13 +680,13 @@ func (p *Parser) parseSet() Command {
 				NewError(p.cur, windowBar+" is not a valid bar style."),
 			)
 		}
-	case token.MARGIN_FILL:
+	case token.MARGIN_FILL, token.WINDOW_BAR_TITLE_COLOR:
 		cmd.Args = p.peek.Literal
 		p.nextToken()
 
 		marginFill := p.cur.Literal
 
-		// Check if margin color is a valid hex string
+		// Check if the color is a valid hex string
 		if strings.HasPrefix(marginFill, "#") {
 			_, err := strconv.ParseUint(marginFill[1:], 16, 64)
 
@@ -505,7 +700,8 @@ func (p *Parser) parseSet() Command {
 				)
 			}
 		}
-	case token.CURSOR_BLINK:
+	case token.CURSOR_BLINK, token.UNDERLINE_LINKS, token.SCROLLBACK, token.FONT_LIGATURES, token.ACCESSIBLE_TRANSCRIPT,
+		token.CURSOR_TWEEN, token.SANDBOX:
 		cmd.Args = p.peek.Literal
 		p.nextToken()
 
@@ -515,7 +711,201 @@ func (p *Parser) parseSet() Command {
 				NewError(p.cur, "expected boolean value."),
 			)
 		}
+	case token.TRANSITION:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		transition := p.cur.Literal
+		if !isValidTransition(transition) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, transition+" is not a valid transition."),
+			)
+		}
+
+		// Allow an optional duration after the transition type.
+		// Set Transition fade 500ms
+		if p.peek.Type == token.NUMBER {
+			cmd.Args += " " + p.parseTime()
+		}
+
+	case token.GIF_COLORS:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		colors, err := strconv.Atoi(p.cur.Literal)
+		if err != nil || colors < 2 || colors > 256 {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "GIFColors expects a number between 2 and 256."),
+			)
+		}
+	case token.CAPTURE_RATE:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		rate, err := strconv.Atoi(p.cur.Literal)
+		if p.cur.Literal != "adaptive" && (err != nil || rate < 1) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "CaptureRate expects a number of frames per second or adaptive."),
+			)
+		}
+	case token.KEYBOARD_LAYOUT:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if !isValidKeyboardLayout(p.cur.Literal) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, p.cur.Literal+" is not a valid keyboard layout."),
+			)
+		}
+	case token.SVG_OPTIMIZE_LEVEL:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		level, err := strconv.Atoi(p.cur.Literal)
+		if err != nil || level < 0 || level > 3 {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "SVGOptimizeLevel expects a number between 0 and 3."),
+			)
+		}
+	case token.GIF_DITHER:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if !isValidGIFDither(p.cur.Literal) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, p.cur.Literal+" is not a valid dither algorithm."),
+			)
+		}
+	case token.GIF_STATS_MODE:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if !isValidGIFStatsMode(p.cur.Literal) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, p.cur.Literal+" is not a valid stats mode."),
+			)
+		}
+	case token.QUALITY:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if !isValidQuality(p.cur.Literal) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, p.cur.Literal+" is not a valid quality."),
+			)
+		}
+	case token.MAX_FILE_SIZE, token.MAX_DISK_USAGE:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if p.cur.Type != token.NUMBER {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, cmd.Options+" expects a size, e.g. 10MB."),
+			)
+			break
+		}
+
+		// Allow a unit after the size.
+		// Set MaxFileSize 10MB
+		if p.peek.Type == token.STRING && isValidFileSizeUnit(p.peek.Literal) {
+			cmd.Args += p.peek.Literal
+			p.nextToken()
+		}
+	case token.VIDEO_FILTER:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		// The filters are chained into the filter graph of the outputs, so
+		// they cannot have labels or chains of their own.
+		if p.cur.Type != token.STRING || strings.ContainsAny(p.cur.Literal, "[];") {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "VideoFilter expects a filter chain, e.g. \"eq=saturation=1.2,unsharp\"."),
+			)
+		}
+	case token.FRAME_COMMAND:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if p.cur.Type != token.STRING || p.cur.Literal == "" {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "FrameCommand expects a command."),
+			)
+		}
+	case token.WORKING_DIRECTORY:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if p.cur.Type != token.STRING || p.cur.Literal == "" {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "WorkingDirectory expects a directory."),
+			)
+		}
+	case token.THEME_FILTER:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if !isValidThemeFilter(p.cur.Literal) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, p.cur.Literal+" is not a valid theme filter."),
+			)
+		}
+	case token.COLOR_MODE:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if !isValidColorMode(p.cur.Literal) {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, p.cur.Literal+" is not a valid color mode."),
+			)
+		}
+	case token.CURSOR_COLOR, token.CURSOR_ACCENT_COLOR:
+		setting := p.cur.Type
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		// The cursor, but not its accent, may invert the cell under it
+		if !isValidHexColor(p.cur.Literal) && (setting != token.CURSOR_COLOR || p.cur.Literal != "reverse") {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "\""+p.cur.Literal+"\" is not a valid cursor color."),
+			)
+		}
+	case token.DESCRIPTION:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
+
+		if p.cur.Type != token.STRING || strings.TrimSpace(p.cur.Literal) == "" {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "Description expects a description of the recording."),
+			)
+		}
+	case token.MAX_FRAMES:
+		cmd.Args = p.peek.Literal
+		p.nextToken()
 
+		if frames, err := strconv.Atoi(p.cur.Literal); err != nil || frames < 1 {
+			p.errors = append(
+				p.errors,
+				NewError(p.cur, "MaxFrames expects a positive number."),
+			)
+		}
+	case token.WATERMARK:
+		cmd.Args = p.parseWatermark()
 	default:
 		cmd.Args = p.peek.Literal
 		p.nextToken()
@@ -524,6 +914,51 @@ func (p *Parser) parseSet() Command {
 	return cmd
 }
 
+// parseWatermark parses the image (or text) and options of a watermark into
+// "<position> <opacity> <image or text>".
+//
+//	Set Watermark <image|"text"> [--position <position>] [--opacity <opacity>]
+func (p *Parser) parseWatermark() string {
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, "Watermark expects an image or text"))
+		return ""
+	}
+	p.nextToken()
+	value := p.cur.Literal
+	position, opacity := "bottom-right", "1"
+
+	for p.peek.Type == token.MINUS {
+		p.nextToken()
+		if p.peek.Type != token.MINUS {
+			p.errors = append(p.errors, NewError(p.peek, "Watermark options start with --"))
+			return ""
+		}
+		p.nextToken()
+		name := p.peek
+		p.nextToken()
+
+		switch name.Literal {
+		case "position":
+			p.nextToken()
+			position = p.cur.Literal
+			if !isValidWatermarkPosition(position) {
+				p.errors = append(p.errors, NewError(p.cur, position+" is not a valid watermark position."))
+			}
+		case "opacity":
+			p.nextToken()
+			opacity = p.cur.Literal
+			if o, err := strconv.ParseFloat(opacity, 64); err != nil || o < 0 || o > 1 {
+				p.errors = append(p.errors, NewError(p.cur, "Watermark opacity expects a number between 0 and 1."))
+			}
+		default:
+			p.errors = append(p.errors, NewError(name, "Invalid Watermark option: --"+name.Literal))
+			return ""
+		}
+	}
+
+	return position + " " + opacity + " " + value
+}
+
 // parseSleep parses a sleep command.
 // A sleep command takes a time for how long to sleep.
 //
@@ -599,6 +1034,60 @@ func (p *Parser) parseType() Command {
 	return cmd
 }
 
+// parseTypeFile parses a type file command.
+// A type file command types the contents of a file, which is read when the
+// tape is parsed. Its newlines are pressed as Enter, or typed as line feeds
+// with --literal, e.g. in editors.
+//
+//	TypeFile[@<time>] <path> [--speed <time>] [--literal]
+func (p *Parser) parseTypeFile() Command {
+	cmd := Command{Type: token.TYPE_FILE}
+	cmd.Options = p.parseSpeed()
+
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, "Expected path after TypeFile"))
+		return cmd
+	}
+	path := p.peek
+	p.nextToken()
+
+	literal := false
+	for p.peek.Type == token.MINUS {
+		p.nextToken()
+		if p.peek.Type != token.MINUS {
+			p.errors = append(p.errors, NewError(p.peek, "TypeFile options start with --"))
+			return cmd
+		}
+		p.nextToken()
+		name := p.peek
+		p.nextToken()
+		switch name.Literal {
+		case "speed":
+			cmd.Options = p.parseTime()
+		case "literal":
+			literal = true
+		default:
+			p.errors = append(p.errors, NewError(name, "Invalid TypeFile option: --"+name.Literal))
+			return cmd
+		}
+	}
+	if literal {
+		cmd.Options = strings.TrimSpace(cmd.Options + " literal")
+	}
+
+	d, err := os.ReadFile(path.Literal)
+	if err != nil {
+		p.errors = append(p.errors, NewError(path, "Unable to read file: "+path.Literal))
+		return cmd
+	}
+	if len(d) == 0 {
+		p.errors = append(p.errors, NewError(path, "File "+path.Literal+" is empty"))
+		return cmd
+	}
+	cmd.Args = strings.ReplaceAll(string(d), "\r\n", "\n")
+	return cmd
+}
+
 // parseCopy parses a copy command
 // A copy command takes a string to the clipboard
 //
@@ -657,6 +1146,385 @@ func (p *Parser) parseEnv() Command {
 	return cmd
 }
 
+// parseScene parses a Scene command.
+// A Scene command marks the start of a named section of the recording. With
+// the +Reset modifier the terminal is cleared before the scene begins.
+//
+//	Scene[+Reset] "<name>"
+func (p *Parser) parseScene() Command {
+	cmd := Command{Type: token.SCENE}
+
+	if p.peek.Type == token.PLUS {
+		p.nextToken()
+		if p.peek.Type != token.STRING || p.peek.Literal != "Reset" {
+			p.errors = append(p.errors, NewError(p.peek, "Scene+ expects Reset"))
+			return cmd
+		}
+		cmd.Options = p.peek.Literal
+		p.nextToken()
+	}
+
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, p.cur.Literal+" expects scene name"))
+		return cmd
+	}
+
+	cmd.Args = p.peek.Literal
+	p.nextToken()
+
+	return cmd
+}
+
+// parseChapter parses a Chapter command.
+// A Chapter command marks a named position in the recording which is embedded
+// as chapter metadata in the video outputs.
+//
+//	Chapter "<name>"
+func (p *Parser) parseChapter() Command {
+	cmd := Command{Type: token.CHAPTER}
+
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, p.cur.Literal+" expects chapter name"))
+		return cmd
+	}
+
+	cmd.Args = p.peek.Literal
+	p.nextToken()
+
+	return cmd
+}
+
+// parseResize parses a Resize command.
+// A Resize command changes the dimensions of the terminal (in columns and
+// rows) during the recording.
+//
+//	Resize <cols> <rows>
+func (p *Parser) parseResize() Command {
+	cmd := Command{Type: token.RESIZE}
+
+	var dimensions []string
+	for range 2 {
+		if p.peek.Type != token.NUMBER {
+			p.errors = append(p.errors, NewError(p.peek, "Resize expects columns and rows"))
+			return cmd
+		}
+		if n, err := strconv.Atoi(p.peek.Literal); err != nil || n <= 0 {
+			p.errors = append(p.errors, NewError(p.peek, p.peek.Literal+" is not a valid dimension"))
+		}
+		dimensions = append(dimensions, p.peek.Literal)
+		p.nextToken()
+	}
+
+	cmd.Args = strings.Join(dimensions, " ")
+	return cmd
+}
+
+// parseHighlight parses a Highlight command.
+// A Highlight command selects the text from the start to the end position
+// (inclusive, 1-based) for the given duration.
+//
+//	Highlight[@<time>] <row>,<col> <row>,<col>
+func (p *Parser) parseHighlight() Command {
+	cmd := Command{Type: token.HIGHLIGHT}
+	cmd.Options = p.parseSpeed()
+
+	var positions []string
+	for range 2 {
+		if p.peek.Type != token.NUMBER {
+			p.errors = append(p.errors, NewError(p.peek, "Highlight expects a start and end position"))
+			return cmd
+		}
+		p.nextToken()
+		position, ok := p.parsePosition("Highlight")
+		if !ok {
+			return cmd
+		}
+		positions = append(positions, position)
+	}
+
+	cmd.Args = strings.Join(positions, " ")
+	return cmd
+}
+
+// parseZoom parses a Zoom command.
+// A Zoom command magnifies the terminal by a factor around the given
+// position (or the current center of the camera) over the given duration.
+//
+//	Zoom <factor>x [<row>,<col>] [<time>]
+func (p *Parser) parseZoom() Command {
+	cmd := Command{Type: token.ZOOM}
+
+	if p.peek.Type != token.NUMBER {
+		p.errors = append(p.errors, NewError(p.peek, "Zoom expects a factor"))
+		return cmd
+	}
+	p.nextToken()
+	if f, err := strconv.ParseFloat(p.cur.Literal, 64); err != nil || f < 1 {
+		p.errors = append(p.errors, NewError(p.cur, p.cur.Literal+" is not a valid zoom factor, it must be at least 1"))
+	}
+	cmd.Args = p.cur.Literal
+	if p.peek.Type == token.STRING && p.peek.Literal == "x" {
+		p.nextToken()
+	}
+
+	if p.peek.Type != token.NUMBER {
+		return cmd
+	}
+	p.nextToken()
+	if p.peek.Type != token.COMMA {
+		cmd.Options = p.parseTimeUnit(p.cur.Literal)
+		return cmd
+	}
+	position, ok := p.parsePosition("Zoom")
+	if !ok {
+		return cmd
+	}
+	cmd.Args += " " + position
+	if p.peek.Type == token.NUMBER {
+		cmd.Options = p.parseTime()
+	}
+	return cmd
+}
+
+// parsePan parses a Pan command.
+// A Pan command moves the center of the camera to the given position over
+// the given duration, keeping the zoom factor.
+//
+//	Pan <row>,<col> [<time>]
+func (p *Parser) parsePan() Command {
+	cmd := Command{Type: token.PAN}
+
+	if p.peek.Type != token.NUMBER {
+		p.errors = append(p.errors, NewError(p.peek, "Pan expects a position"))
+		return cmd
+	}
+	p.nextToken()
+	position, ok := p.parsePosition("Pan")
+	if !ok {
+		return cmd
+	}
+	cmd.Args = position
+	if p.peek.Type == token.NUMBER {
+		cmd.Options = p.parseTime()
+	}
+	return cmd
+}
+
+// parseAnnotate parses an Annotate command.
+// An Annotate command shows a callout with the text, optionally at a
+// position and with an arrow pointing at a cell. The options are stored as
+// space separated name=value pairs.
+//
+//	Annotate "<text>" [--position <row>,<col>] [--arrow <row>,<col>] [--at <time>] [--for <time>]
+func (p *Parser) parseAnnotate() Command {
+	cmd := Command{Type: token.ANNOTATE}
+
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, "Annotate expects text"))
+		return cmd
+	}
+	cmd.Args = p.peek.Literal
+	p.nextToken()
+
+	var options []string
+	for p.peek.Type == token.MINUS {
+		p.nextToken()
+		if p.peek.Type != token.MINUS {
+			p.errors = append(p.errors, NewError(p.peek, "Annotate options start with --"))
+			return cmd
+		}
+		p.nextToken()
+		name := p.peek
+		p.nextToken()
+
+		switch name.Literal {
+		case "position", "arrow":
+			if p.peek.Type != token.NUMBER {
+				p.errors = append(p.errors, NewError(p.peek, "--"+name.Literal+" expects a position"))
+				return cmd
+			}
+			p.nextToken()
+			position, ok := p.parsePosition("--" + name.Literal)
+			if !ok {
+				return cmd
+			}
+			options = append(options, name.Literal+"="+position)
+		case "at", "for":
+			options = append(options, name.Literal+"="+p.parseTime())
+		default:
+			p.errors = append(p.errors, NewError(name, "Invalid Annotate option: --"+name.Literal))
+			return cmd
+		}
+	}
+
+	cmd.Options = strings.Join(options, " ")
+	return cmd
+}
+
+// parseTitleCard parses a TitleCard command. The title, subtitle and logo are
+// the lines of the arguments, and the time is the option, 3s by default.
+//
+//	TitleCard "<title>" [<time>] [--subtitle "<subtitle>"] [--logo <image>]
+func (p *Parser) parseTitleCard() Command {
+	cmd := Command{Type: token.TITLE_CARD, Options: "3s"}
+
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, "TitleCard expects a title"))
+		return cmd
+	}
+	p.nextToken()
+	title := p.cur
+	if p.peek.Type == token.NUMBER {
+		cmd.Options = p.parseTime()
+	}
+
+	var subtitle, logo string
+	for p.peek.Type == token.MINUS {
+		p.nextToken()
+		if p.peek.Type != token.MINUS {
+			p.errors = append(p.errors, NewError(p.peek, "TitleCard options start with --"))
+			return cmd
+		}
+		p.nextToken()
+		name := p.peek
+		p.nextToken()
+
+		if p.peek.Type != token.STRING {
+			p.errors = append(p.errors, NewError(p.peek, "--"+name.Literal+" expects a string"))
+			return cmd
+		}
+		switch name.Literal {
+		case "subtitle":
+			subtitle = p.peek.Literal
+		case "logo":
+			logo = p.peek.Literal
+		default:
+			p.errors = append(p.errors, NewError(name, "Invalid TitleCard option: --"+name.Literal))
+			return cmd
+		}
+		p.nextToken()
+	}
+
+	if strings.Contains(title.Literal+subtitle+logo, "\n") {
+		p.errors = append(p.errors, NewError(title, "TitleCard expects a title and subtitle of one line"))
+		return cmd
+	}
+	cmd.Args = strings.TrimRight(title.Literal+"\n"+subtitle+"\n"+logo, "\n")
+	return cmd
+}
+
+// parseScript parses a Script command.
+// A Script command takes a heredoc (or string) of shell commands.
+//
+//	Script <<EOF
+//	<commands>
+//	EOF
+func (p *Parser) parseScript() Command {
+	cmd := Command{Type: token.SCRIPT}
+
+	if p.peek.Type != token.STRING {
+		p.errors = append(p.errors, NewError(p.peek, "Script expects a heredoc or string"))
+		return cmd
+	}
+	cmd.Args = p.peek.Literal
+	p.nextToken()
+	return cmd
+}
+
+// parseSignal parses a Signal command.
+// A Signal command takes the name of the signal to send to the foreground
+// process group of the terminal.
+//
+//	Signal SIGINT|SIGTERM|SIGTSTP|SIGQUIT|SIGHUP|SIGKILL|SIGCONT
+func (p *Parser) parseSignal() Command {
+	cmd := Command{Type: token.SIGNAL}
+
+	if p.peek.Type != token.STRING || !isValidSignal(p.peek.Literal) {
+		p.errors = append(p.errors, NewError(p.peek, "Signal expects SIGINT, SIGTERM, SIGTSTP, SIGQUIT, SIGHUP, SIGKILL or SIGCONT"))
+		return cmd
+	}
+	cmd.Args = p.peek.Literal
+	p.nextToken()
+	return cmd
+}
+
+// parseWaitExit parses a WaitExit command.
+// A WaitExit command waits for the command launched last to exit, optionally
+// failing unless it exits with the given status.
+//
+//	WaitExit [<time>] [--status <status>]
+func (p *Parser) parseWaitExit() Command {
+	cmd := Command{Type: token.WAIT_EXIT}
+
+	if p.peek.Type == token.NUMBER {
+		cmd.Args = p.parseTime()
+		if dur, _ := time.ParseDuration(cmd.Args); dur <= 0 {
+			p.errors = append(p.errors, NewError(p.cur, "WaitExit expects positive duration"))
+			return cmd
+		}
+	}
+
+	if p.peek.Type != token.MINUS {
+		return cmd
+	}
+	p.nextToken()
+	if p.peek.Type != token.MINUS {
+		p.errors = append(p.errors, NewError(p.peek, "WaitExit options start with --"))
+		return cmd
+	}
+	p.nextToken()
+	name := p.peek
+	p.nextToken()
+	if name.Literal != "status" {
+		p.errors = append(p.errors, NewError(name, "Invalid WaitExit option: --"+name.Literal))
+		return cmd
+	}
+	if p.peek.Type != token.NUMBER {
+		p.errors = append(p.errors, NewError(p.peek, "--status expects an exit status"))
+		return cmd
+	}
+	if _, err := strconv.Atoi(p.peek.Literal); err != nil {
+		p.errors = append(p.errors, NewError(p.peek, p.peek.Literal+" is not a valid exit status"))
+		return cmd
+	}
+	cmd.Options = "status=" + p.peek.Literal
+	p.nextToken()
+	return cmd
+}
+
+// isValidSignal returns whether the name is a signal Signal can send.
+func isValidSignal(name string) bool {
+	switch name {
+	case "SIGINT", "SIGTERM", "SIGTSTP", "SIGQUIT", "SIGHUP", "SIGKILL", "SIGCONT":
+		return true
+	default:
+		return false
+	}
+}
+
+// parsePosition parses a 1-based <row>,<col> position of a terminal cell,
+// whose row is the current token.
+func (p *Parser) parsePosition(command string) (string, bool) {
+	row := p.cur
+	if p.peek.Type != token.COMMA {
+		p.errors = append(p.errors, NewError(p.peek, command+" expects positions as <row>,<col>"))
+		return "", false
+	}
+	p.nextToken()
+	if p.peek.Type != token.NUMBER {
+		p.errors = append(p.errors, NewError(p.peek, command+" expects positions as <row>,<col>"))
+		return "", false
+	}
+	p.nextToken()
+
+	for _, t := range []token.Token{row, p.cur} {
+		if n, err := strconv.Atoi(t.Literal); err != nil || n <= 0 {
+			p.errors = append(p.errors, NewError(t, t.Literal+" is not a valid position"))
+		}
+	}
+	return row.Literal + "," + p.cur.Literal, true
+}
+
 // parseSource parses source command.
 // Source command takes a tape path to include in current tape.
 //
@@ -782,6 +1650,76 @@ func (p *Parser) nextToken() {
 	p.peek = p.l.NextToken()
 }
 
+// Check if a given transition type is valid.
+func isValidTransition(t string) bool {
+	return t == "none" || t == "fade" || t == "slide"
+}
+
+// Check if a given keyboard layout is valid.
+func isValidKeyboardLayout(l string) bool {
+	return l == "us" || l == "de" || l == "fr" || l == "dvorak"
+}
+
+// Check if a given theme filter is valid.
+func isValidThemeFilter(f string) bool {
+	return f == "deuteranopia" || f == "protanopia" || f == "high-contrast"
+}
+
+// Check if a given color mode is valid.
+func isValidColorMode(m string) bool {
+	return m == "16" || m == "256" || m == "truecolor" || m == "mono"
+}
+
+// Check if a given color is a hex color, e.g. #ff00ff.
+func isValidHexColor(c string) bool {
+	if len(c) != 7 || !strings.HasPrefix(c, "#") {
+		return false
+	}
+	_, err := strconv.ParseUint(c[1:], 16, 64)
+	return err == nil
+}
+
+// Check if a given watermark position is valid.
+func isValidWatermarkPosition(position string) bool {
+	switch position {
+	case "top-left", "top-right", "bottom-left", "bottom-right", "center":
+		return true
+	default:
+		return false
+	}
+}
+
+// Check if a given GIF dither algorithm is supported by ffmpeg's paletteuse.
+func isValidGIFDither(d string) bool {
+	switch d {
+	case "none", "bayer", "heckbert", "floyd_steinberg",
+		"sierra2", "sierra2_4a", "sierra3", "burkes", "atkinson":
+		return true
+	default:
+		return false
+	}
+}
+
+// Check if a given GIF stats mode is supported by ffmpeg's palettegen.
+func isValidGIFStatsMode(m string) bool {
+	return m == "full" || m == "diff" || m == "single"
+}
+
+// Check if a given quality preset is valid.
+func isValidQuality(q string) bool {
+	return q == "low" || q == "medium" || q == "high" || q == "lossless"
+}
+
+// Check if a given file size unit is valid.
+func isValidFileSizeUnit(u string) bool {
+	switch strings.ToUpper(u) {
+	case "B", "KB", "MB", "GB":
+		return true
+	default:
+		return false
+	}
+}
+
 // Check if a given windowbar type is valid.
 func isValidWindowBar(w string) bool {
 	return w == "" ||
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -29,11 +30,78 @@ Down 2
 Ctrl+C
 Ctrl+L
 Alt+.
+Alt+Left
+Shift+Up
+Home
+End 2
+Key F13
+Key@50ms "\x1b[1;5C" 2
 Sleep 100ms
 Sleep 3
 Wait
 Wait+Screen
-Wait@100ms /foobar/`
+Wait@100ms /foobar/
+Set Transition fade 500ms
+Scene "Install"
+Scene+Reset "Usage"
+Chapter "Flags"
+Set GIFColors 128
+Set GIFDither sierra2_4a
+Set GIFStatsMode diff
+Set Quality lossless
+Set MaxFileSize 10MB
+Set PixelRatio 2
+Resize 120 30
+Set UnderlineLinks true
+ScrollUp 5
+ScrollDown@100ms
+Set Scrollback true
+Set FontLigatures false
+Highlight 2,5 2,20
+Highlight@500ms 1,1 3,80
+Zoom 2x 10,5 3s
+Zoom 1.5 500ms
+Zoom 1x
+Pan 4,20
+Annotate "Click here" --arrow 40,12 --at 5s --for 3s
+Annotate "Done" --position 2,3
+TitleCard "My Tool v2.0" 2s --subtitle "now with plugins"
+TitleCard "Thanks" --logo "logo.png"
+Set Watermark ./logo.png --position bottom-left --opacity 0.4
+Set Watermark "ACME Inc."
+Set LoopDelay 3s
+Set Timeout 2m
+Set MaxFrames 10000
+Set MaxDiskUsage 2GB
+Set VideoFilter "eq=saturation=1.2,unsharp"
+Set FrameCommand "mogrify -sharpen 0x1 frame-text-*.png"
+Set Description "Installing the CLI"
+Set AccessibleTranscript true
+Set ThemeFilter high-contrast
+Set ThemeDark "Catppuccin Mocha"
+Set WindowBarTitleColor "#ffffff"
+Set ColorMode 16
+Set EmojiFont "Noto Color Emoji"
+Set CursorColor "#ff00ff"
+Set CursorAccentColor "#000000"
+Set MaxIdle 2s
+Set SVGOptimizeLevel 3
+Set CursorTween true
+Set WorkingDirectory ./demo-project
+Set Sandbox true
+Set CaptureRate adaptive
+Set KeyboardLayout de
+Set SyntaxHighlight go
+Output demo.gif --loops 3
+Output demo.lottie.json
+Script <<EOF
+npm install
+npm run build
+EOF
+Script "make"
+Signal SIGINT
+WaitExit
+WaitExit 10s --status 130`
 
 	expected := []Command{
 		{Type: token.SET, Options: "TypingSpeed", Args: "100ms"},
@@ -54,11 +122,75 @@ Wait@100ms /foobar/`
 		{Type: token.CTRL, Options: "", Args: "C"},
 		{Type: token.CTRL, Options: "", Args: "L"},
 		{Type: token.ALT, Options: "", Args: "."},
+		{Type: token.ALT, Options: "", Args: "Left"},
+		{Type: token.SHIFT, Options: "", Args: "Up"},
+		{Type: token.HOME, Options: "", Args: "1"},
+		{Type: token.END, Options: "", Args: "2"},
+		{Type: token.KEY, Options: "", Args: "F13 1"},
+		{Type: token.KEY, Options: "50ms", Args: "\\x1b[1;5C 2"},
 		{Type: token.SLEEP, Args: "100ms"},
 		{Type: token.SLEEP, Args: "3s"},
 		{Type: token.WAIT, Args: "Line"},
 		{Type: token.WAIT, Args: "Screen"},
 		{Type: token.WAIT, Options: "100ms", Args: "Line foobar"},
+		{Type: token.SET, Options: "Transition", Args: "fade 500ms"},
+		{Type: token.SCENE, Options: "", Args: "Install"},
+		{Type: token.SCENE, Options: "Reset", Args: "Usage"},
+		{Type: token.CHAPTER, Options: "", Args: "Flags"},
+		{Type: token.SET, Options: "GIFColors", Args: "128"},
+		{Type: token.SET, Options: "GIFDither", Args: "sierra2_4a"},
+		{Type: token.SET, Options: "GIFStatsMode", Args: "diff"},
+		{Type: token.SET, Options: "Quality", Args: "lossless"},
+		{Type: token.SET, Options: "MaxFileSize", Args: "10MB"},
+		{Type: token.SET, Options: "PixelRatio", Args: "2"},
+		{Type: token.RESIZE, Options: "", Args: "120 30"},
+		{Type: token.SET, Options: "UnderlineLinks", Args: "true"},
+		{Type: token.SCROLL_UP, Options: "", Args: "5"},
+		{Type: token.SCROLL_DOWN, Options: "100ms", Args: "1"},
+		{Type: token.SET, Options: "Scrollback", Args: "true"},
+		{Type: token.SET, Options: "FontLigatures", Args: "false"},
+		{Type: token.HIGHLIGHT, Options: "", Args: "2,5 2,20"},
+		{Type: token.HIGHLIGHT, Options: "500ms", Args: "1,1 3,80"},
+		{Type: token.ZOOM, Options: "3s", Args: "2 10,5"},
+		{Type: token.ZOOM, Options: "500ms", Args: "1.5"},
+		{Type: token.ZOOM, Options: "", Args: "1"},
+		{Type: token.PAN, Options: "", Args: "4,20"},
+		{Type: token.ANNOTATE, Options: "arrow=40,12 at=5s for=3s", Args: "Click here"},
+		{Type: token.ANNOTATE, Options: "position=2,3", Args: "Done"},
+		{Type: token.TITLE_CARD, Options: "2s", Args: "My Tool v2.0\nnow with plugins"},
+		{Type: token.TITLE_CARD, Options: "3s", Args: "Thanks\n\nlogo.png"},
+		{Type: token.SET, Options: "Watermark", Args: "bottom-left 0.4 ./logo.png"},
+		{Type: token.SET, Options: "Watermark", Args: "bottom-right 1 ACME Inc."},
+		{Type: token.SET, Options: "LoopDelay", Args: "3s"},
+		{Type: token.SET, Options: "Timeout", Args: "2m"},
+		{Type: token.SET, Options: "MaxFrames", Args: "10000"},
+		{Type: token.SET, Options: "MaxDiskUsage", Args: "2GB"},
+		{Type: token.SET, Options: "VideoFilter", Args: "eq=saturation=1.2,unsharp"},
+		{Type: token.SET, Options: "FrameCommand", Args: "mogrify -sharpen 0x1 frame-text-*.png"},
+		{Type: token.SET, Options: "Description", Args: "Installing the CLI"},
+		{Type: token.SET, Options: "AccessibleTranscript", Args: "true"},
+		{Type: token.SET, Options: "ThemeFilter", Args: "high-contrast"},
+		{Type: token.SET, Options: "ThemeDark", Args: "Catppuccin Mocha"},
+		{Type: token.SET, Options: "WindowBarTitleColor", Args: "#ffffff"},
+		{Type: token.SET, Options: "ColorMode", Args: "16"},
+		{Type: token.SET, Options: "EmojiFont", Args: "Noto Color Emoji"},
+		{Type: token.SET, Options: "CursorColor", Args: "#ff00ff"},
+		{Type: token.SET, Options: "CursorAccentColor", Args: "#000000"},
+		{Type: token.SET, Options: "MaxIdle", Args: "2s"},
+		{Type: token.SET, Options: "SVGOptimizeLevel", Args: "3"},
+		{Type: token.SET, Options: "CursorTween", Args: "true"},
+		{Type: token.SET, Options: "WorkingDirectory", Args: "./demo-project"},
+		{Type: token.SET, Options: "Sandbox", Args: "true"},
+		{Type: token.SET, Options: "CaptureRate", Args: "adaptive"},
+		{Type: token.SET, Options: "KeyboardLayout", Args: "de"},
+		{Type: token.SET, Options: "SyntaxHighlight", Args: "go"},
+		{Type: token.OUTPUT, Options: ".gif loops=3", Args: "demo.gif"},
+		{Type: token.OUTPUT, Options: ".lottie.json", Args: "demo.lottie.json"},
+		{Type: token.SCRIPT, Options: "", Args: "npm install\nnpm run build"},
+		{Type: token.SCRIPT, Options: "", Args: "make"},
+		{Type: token.SIGNAL, Options: "", Args: "SIGINT"},
+		{Type: token.WAIT_EXIT, Options: "", Args: ""},
+		{Type: token.WAIT_EXIT, Options: "status=130", Args: "10s"},
 	}
 
 	l := lexer.New(input)
@@ -88,7 +220,18 @@ func TestParserErrors(t *testing.T) {
 Type Enter
 Type "echo 'Hello, World!'" Enter
 Foo
-Sleep Bar`
+Sleep Bar
+Set GIFDither dots
+Set Watermark logo.png --position middle
+Output demo.mp4 --loops 2
+Signal SIGUSR1
+WaitExit --code 1
+Set MaxFrames 0
+Set VideoFilter "[0]scale=2"
+Set Description ""
+Set ThemeFilter tritanopia
+Key "\xZZ"
+Highlight 2,0 2`
 
 	l := lexer.New(input)
 	p := New(l)
@@ -100,6 +243,20 @@ Sleep Bar`
 		" 4:1  │ Invalid command: Foo",
 		" 5:1  │ Expected time after Sleep",
 		" 5:7  │ Invalid command: Bar",
+		" 6:15 │ dots is not a valid dither algorithm.",
+		" 7:35 │ middle is not a valid watermark position.",
+		" 8:19 │ --loops only applies to GIF outputs",
+		" 9:8  │ Signal expects SIGINT, SIGTERM, SIGTSTP, SIGQUIT, SIGHUP, SIGKILL or SIGCONT",
+		" 9:8  │ Invalid command: SIGUSR1",
+		"10:12 │ Invalid WaitExit option: --code",
+		"10:17 │ Invalid command: 1",
+		"11:15 │ MaxFrames expects a positive number.",
+		"12:17 │ VideoFilter expects a filter chain, e.g. \"eq=saturation=1.2,unsharp\".",
+		"13:17 │ Description expects a description of the recording.",
+		"14:17 │ tritanopia is not a valid theme filter.",
+		"15:5  │ Invalid escape sequence: \\xZZ",
+		"16:13 │ 0 is not a valid position",
+		"16:16 │ Highlight expects positions as <row>,<col>",
 	}
 
 	if len(p.errors) != len(expectedErrors) {
@@ -113,6 +270,29 @@ Sleep Bar`
 	}
 }
 
+func TestParserLines(t *testing.T) {
+	input := `# comment
+Type "ls"
+
+Enter 2
+Script <<EOF
+a
+b
+EOF
+Sleep 1`
+
+	cmds := New(lexer.New(input)).Parse()
+	expected := []int{2, 4, 5, 9}
+	if len(cmds) != len(expected) {
+		t.Fatalf("Expected %d commands, got %d", len(expected), len(cmds))
+	}
+	for i, cmd := range cmds {
+		if cmd.Line != expected[i] {
+			t.Errorf("Expected %s on line %d, got %d", cmd.Type, expected[i], cmd.Line)
+		}
+	}
+}
+
 func TestParseTapeFile(t *testing.T) {
 	input, err := os.ReadFile("../examples/fixtures/all.tape")
 	if err != nil {
@@ -428,3 +608,75 @@ func TestParseScreeenshot(t *testing.T) {
 		test.run(t)
 	})
 }
+
+func TestParseTypeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippet.py")
+	if err := os.WriteFile(path, []byte("print('hi')\r\nprint('bye')\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tape string
+		want Command
+		err  string
+	}{
+		{
+			tape: "TypeFile \"" + path + "\"",
+			want: Command{Type: token.TYPE_FILE, Args: "print('hi')\nprint('bye')\n"},
+		},
+		{
+			tape: "TypeFile@10ms \"" + path + "\" --literal",
+			want: Command{Type: token.TYPE_FILE, Options: "10ms literal", Args: "print('hi')\nprint('bye')\n"},
+		},
+		{
+			tape: "TypeFile \"" + path + "\" --speed 20ms",
+			want: Command{Type: token.TYPE_FILE, Options: "20ms", Args: "print('hi')\nprint('bye')\n"},
+		},
+		{tape: "TypeFile", err: "Expected path after TypeFile"},
+		{tape: "TypeFile missing.py", err: "Unable to read file: missing.py"},
+		{tape: "TypeFile \"" + path + "\" --fast", err: "Invalid TypeFile option: --fast"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.tape, func(t *testing.T) {
+			p := New(lexer.New(tc.tape))
+			cmds := p.Parse()
+			if tc.err != "" {
+				if len(p.errors) == 0 || p.errors[0].Msg != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, p.errors)
+				}
+				return
+			}
+			if len(p.errors) > 0 {
+				t.Fatalf("unexpected errors: %v", p.errors)
+			}
+			cmds[0].Line = 0
+			if cmds[0] != tc.want {
+				t.Errorf("expected %+v, got %+v", tc.want, cmds[0])
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	cmds, err := ParseJSON([]byte(`[{"type": "SET", "options": "TypingSpeed", "args": "75ms"}, {"type": "CTRL", "args": "C"}]`))
+	if err != nil || len(cmds) != 2 || cmds[0].Options != "TypingSpeed" || cmds[1].Type != token.CTRL {
+		t.Fatalf("expected the commands, got %+v (%v)", cmds, err)
+	}
+	for _, data := range []string{
+		`{"type": "TYPE"}`,
+		`[{"type": "TYPO", "args": "hi"}]`,
+		`[{"type": "SET", "options": "FontSzie", "args": "22"}]`,
+		`[{"type": "SOURCE", "args": "demo.tape"}]`,
+		`[{"type": "ILLEGAL"}]`,
+		`[{"type": "CTRL"}]`,
+		`[{"type": "CTRL", "args": "Alt "}]`,
+		`[{"type": "TYPE"}]`,
+		`[{"type": "SET", "options": "FontSize"}]`,
+		`[{"type": "ENV", "args": "1"}]`,
+	} {
+		if _, err := ParseJSON([]byte(data)); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
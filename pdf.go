@@ -0,0 +1,165 @@
+// Package vhs pdf.go writes PDF documents.
+//
+// Only what the storyboard needs is supported: pages of filled rectangles and
+// text set in the standard Courier and Helvetica fonts, which every PDF
+// reader provides, so no font has to be embedded. Text is encoded with
+// WinAnsiEncoding, characters outside of it are approximated.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// pdfFont is one of the standard fonts of the PDF documents.
+type pdfFont string
+
+// Fonts of the PDF documents, by resource name.
+const (
+	pdfCourier     pdfFont = "F1"
+	pdfCourierBold pdfFont = "F2"
+	pdfHelvetica   pdfFont = "F3"
+)
+
+// pdfCourierAdvance is the advance of Courier characters, in em.
+const pdfCourierAdvance = 0.6
+
+// pdfFonts maps the fonts to their PostScript names.
+var pdfFonts = []struct {
+	Font pdfFont
+	Name string
+}{
+	{pdfCourier, "Courier"},
+	{pdfCourierBold, "Courier-Bold"},
+	{pdfHelvetica, "Helvetica"},
+}
+
+// pdfDocument is a PDF document of pages of the same size, in points.
+type pdfDocument struct {
+	width, height float64
+	pages         []*pdfPage
+}
+
+// pdfPage is a page of a PDF document. Coordinates are in points from the
+// top left corner of the page.
+type pdfPage struct {
+	height  float64
+	content bytes.Buffer
+}
+
+// newPDFDocument returns an empty document with pages of the given size.
+func newPDFDocument(width, height float64) *pdfDocument {
+	return &pdfDocument{width: width, height: height}
+}
+
+// addPage adds a blank page to the document.
+func (d *pdfDocument) addPage() *pdfPage {
+	p := &pdfPage{height: d.height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// fillRect fills the rectangle with the color.
+func (p *pdfPage) fillRect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n",
+		pdfColor(c), pdfNumber(x), pdfNumber(p.height-y-h), pdfNumber(w), pdfNumber(h))
+}
+
+// text writes the text with its baseline at y.
+func (p *pdfPage) text(x, y float64, font pdfFont, size float64, c color.RGBA, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s rg %s %s Td (%s) Tj ET\n",
+		font, pdfNumber(size), pdfColor(c), pdfNumber(x), pdfNumber(p.height-y), pdfString(s))
+}
+
+// Bytes returns the encoded document.
+func (d *pdfDocument) Bytes() []byte {
+	var b bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...any) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\nendobj\n")
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and the page tree, followed by the
+	// fonts and then by every page and its content stream.
+	firstPage := 3 + len(pdfFonts)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), pdfNumber(d.width), pdfNumber(d.height))
+
+	var fonts strings.Builder
+	for i, f := range pdfFonts {
+		object("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.Name)
+		fmt.Fprintf(&fonts, "/%s %d 0 R ", f.Font, 3+i)
+	}
+
+	for i, p := range d.pages {
+		object("<< /Type /Page /Parent 2 0 R /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			fonts.String(), firstPage+2*i+1)
+		object("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String())
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
+
+// pdfNumber formats a number with at most two decimals.
+func pdfNumber(f float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", f), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// pdfColor formats a color as the operands of the rg operator.
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%s %s %s",
+		pdfNumber(float64(c.R)/0xff), pdfNumber(float64(c.G)/0xff), pdfNumber(float64(c.B)/0xff))
+}
+
+// pdfApproximations are the WinAnsiEncoding bytes of common characters
+// outside of Latin-1, and the ASCII approximations of box drawing characters.
+var pdfApproximations = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+	'─': '-', '━': '-', '═': '=', '│': '|', '┃': '|', '║': '|',
+	'┌': '+', '┐': '+', '└': '+', '┘': '+', '├': '+', '┤': '+',
+	'┬': '+', '┴': '+', '┼': '+', '╭': '+', '╮': '+', '╯': '+', '╰': '+',
+	'█': '#', '▌': '#', '▐': '#', '░': ':', '▒': ':', '▓': '#',
+	'❯': '>', '➜': '>', '→': '>', '←': '<', '✓': 'v', '✔': 'v', '✗': 'x', '✘': 'x',
+}
+
+// pdfString encodes the string as the content of a PDF string literal.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r >= ' ' && r < 0x7f || r >= 0xa0 && r <= 0xff:
+			c = byte(r)
+		default:
+			var ok bool
+			if c, ok = pdfApproximations[r]; !ok {
+				c = '?'
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+//go:build !js
+
+// Package vhs pixelratio.go renders the outputs at a higher device pixel
+// ratio so they stay crisp on high-DPI displays.
+//
+// The headless browser renders the terminal at the given device scale
+// factor, and the raster outputs (GIF, WebM, MP4 and PNG) are rendered at the
+// scaled dimensions. SVG outputs are resolution independent and keep the
+// dimensions set in the tape.
+//
+// Set PixelRatio 2
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+const defaultPixelRatio = 1.0
+
+// ExecuteSetPixelRatio sets the device pixel ratio used for the recording.
+func ExecuteSetPixelRatio(c parser.Command, v *VHS) error {
+	ratio, err := strconv.ParseFloat(c.Args, bitSize)
+	if err != nil {
+		return fmt.Errorf("failed to parse pixel ratio: %w", err)
+	}
+	if ratio <= 0 {
+		return fmt.Errorf("pixel ratio must be positive: %s", c.Args)
+	}
+
+	v.Options.PixelRatio = ratio
+	return nil
+}
+
+// Scaled returns a copy of the style with every dimension multiplied by the
+// pixel ratio.
+func (s StyleOptions) Scaled(ratio float64) *StyleOptions {
+	scale := func(n int) int { return int(math.Round(float64(n) * ratio)) }
+
+	s.Width = scale(s.Width)
+	s.Height = scale(s.Height)
+	s.Padding = scale(s.Padding)
+	s.Margin = scale(s.Margin)
+	s.WindowBarSize = scale(s.WindowBarSize)
+	s.BorderRadius = scale(s.BorderRadius)
+	s.FontSize = scale(s.FontSize)
+	s.WindowBarFontSize = scale(s.WindowBarFontSize)
+	return &s
+}
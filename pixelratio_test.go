@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestStyleOptionsScaled(t *testing.T) {
+	style := DefaultStyleOptions()
+	style.WindowBar = "Colorful"
+	style.BorderRadius = 8
+	style.FontSize = 22
+
+	scaled := style.Scaled(2)
+
+	if scaled.Width != 2*defaultWidth || scaled.Height != 2*defaultHeight {
+		t.Errorf("expected %dx%d, got %dx%d", 2*defaultWidth, 2*defaultHeight, scaled.Width, scaled.Height)
+	}
+	if scaled.Padding != 2*defaultPadding {
+		t.Errorf("expected padding %d, got %d", 2*defaultPadding, scaled.Padding)
+	}
+	if scaled.WindowBarSize != 2*defaultWindowBarSize || scaled.BorderRadius != 16 || scaled.FontSize != 44 {
+		t.Errorf("expected window bar, radius and font to scale, got %+v", scaled)
+	}
+	if scaled.WindowBar != "Colorful" || scaled.BackgroundColor != style.BackgroundColor {
+		t.Errorf("expected non-dimension options to be kept, got %+v", scaled)
+	}
+	if style.Width != defaultWidth {
+		t.Errorf("expected original style to be unchanged, got width %d", style.Width)
+	}
+}
@@ -0,0 +1,221 @@
+//go:build !js
+
+// Package vhs preview.go extracts the frame of a tape at a given time.
+//
+// vhs preview records the tape once into a cache, keyed by its contents: the
+// raw frames, and a JSON recording of their text. Stills are then extracted
+// from the cache without recording the tape again, PNGs composed from the raw
+// frames like screenshots, and SVGs rendered from the text of the frame. The
+// outputs and screenshots of the tape are not written. The native backend
+// records no raw frames, so its previews are SVGs.
+//
+// vhs preview demo.tape --at 12.5s -o frame.png
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Files of a cached recording.
+const (
+	previewFrames    = "frames"
+	previewRecording = "recording" + svgRecordingExt
+)
+
+var (
+	previewAt      string
+	previewOutput  string
+	previewRefresh bool
+
+	previewCmd = &cobra.Command{
+		Use:   "preview <file>",
+		Short: "Extract the frame of a tape at a time as a PNG or SVG",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			at, err := parsePreviewTime(previewAt)
+			if err != nil {
+				return err
+			}
+			png := filepath.Ext(previewOutput) == ".png"
+			if !png && filepath.Ext(previewOutput) != svg {
+				return fmt.Errorf("preview outputs are .png or .svg files, got %s", previewOutput)
+			}
+			if png && backendFlag == nativeBackend {
+				return errors.New("PNG previews need the browser backend, the native backend previews SVGs")
+			}
+			tape, err := readTape(cmd, args[0])
+			if err != nil {
+				return err
+			}
+
+			dir, err := previewCacheDir(tape)
+			if err != nil {
+				return err
+			}
+			if previewRefresh || !previewCached(dir, png) {
+				if backendFlag != nativeBackend {
+					if err := ensureDependencies(); err != nil {
+						return err
+					}
+				}
+				if err := recordPreview(cmd.Context(), string(tape), dir); err != nil {
+					return err
+				}
+			}
+			return extractPreview(dir, at, previewOutput)
+		},
+	}
+)
+
+func init() {
+	previewCmd.Flags().StringVar(&previewAt, "at", "0s", "time of the frame in the recording, e.g. 12.5s")
+	previewCmd.Flags().StringVarP(&previewOutput, "output", "o", "preview.png", "file to write the frame to, a .png or .svg")
+	previewCmd.Flags().BoolVar(&previewRefresh, "refresh", false, "record the tape again instead of using the cached recording")
+	previewCmd.Flags().StringVar(&backendFlag, "backend", browserBackend, "capture backend: browser, or native for SVG previews")
+}
+
+// parsePreviewTime parses a time as a duration, or in seconds.
+func parsePreviewTime(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return 0, fmt.Errorf("invalid time %q, e.g. 12.5s", s)
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid time %q, it must not be negative", s)
+	}
+	return d, nil
+}
+
+// previewCacheDir returns the directory of the cached recording of the tape.
+func previewCacheDir(tape []byte) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the cache directory: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(Version+"\n"), tape...))
+	return filepath.Join(cache, "vhs", "preview", hex.EncodeToString(sum[:8])), nil
+}
+
+// previewCached returns whether the tape is recorded in the cache directory,
+// with the raw frames of PNG previews.
+func previewCached(dir string, png bool) bool {
+	files := []string{previewRecording}
+	if png {
+		files = append(files, previewFrames)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// previewTape comments out the outputs and screenshots of the tape, keeping
+// the lines of its commands.
+func previewTape(tape string) string {
+	lines := strings.Split(tape, "\n")
+	for i, line := range lines {
+		command, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if command == "Output" || command == "Screenshot" {
+			lines[i] = "# " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withPreviewCache returns an EvaluatorOption recording the raw frames and
+// their text into the cache directory.
+func withPreviewCache(dir string) EvaluatorOption {
+	return func(v *VHS) {
+		v.Options.Video.Output = VideoOutputs{
+			Rendered: map[string]string{svgRecordingExt: filepath.Join(dir, previewRecording)},
+		}
+		if v.Options.Backend != nativeBackend {
+			v.Options.Video.Output.Frames = filepath.Join(dir, previewFrames)
+		}
+	}
+}
+
+// recordPreview records the tape into the cache directory.
+func recordPreview(ctx context.Context, tape, dir string) error {
+	log.Println(GrayStyle.Render("Recording the tape into " + dir + "..."))
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear the cached recording: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create the cache directory: %w", err)
+	}
+	// The tape is recorded once, in the light theme of Set ThemeDark.
+	errs := Evaluate(ctx, previewTape(tape), io.Discard,
+		WithBackend(backendFlag), withPreviewCache(dir), withColorScheme(colorSchemeLight))
+	if len(errs) > 0 {
+		_ = os.RemoveAll(dir)
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// previewFrame returns the index of the frame shown at the time of the
+// recording, the last one after its end.
+func previewFrame(frames []SVGFrame, at time.Duration, playbackSpeed float64) int {
+	t := frames[0].Timestamp + at.Seconds()*playbackSpeed
+	i := 0
+	for i+1 < len(frames) && frames[i+1].Timestamp <= t {
+		i++
+	}
+	return i
+}
+
+// extractPreview writes the frame at the time of the cached recording.
+func extractPreview(dir string, at time.Duration, output string) error {
+	data, err := os.ReadFile(filepath.Join(dir, previewRecording))
+	if err != nil {
+		return fmt.Errorf("failed to read the cached recording: %w", err)
+	}
+	cfg, err := ParseSVGRecording(data)
+	if err != nil {
+		return err
+	}
+	i := previewFrame(cfg.Frames, at, cfg.PlaybackSpeed)
+	ensureDir(output)
+
+	if filepath.Ext(output) == svg {
+		frame := cfg.Frames[i]
+		frame.Timestamp = 0
+		cfg.Frames = []SVGFrame{frame}
+		cfg.Duration = 0
+		cfg.CursorBlink = false
+		if err := os.WriteFile(output, []byte(NewSVGGenerator(cfg).Generate()), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		return nil
+	}
+
+	// The raw frames are numbered from 1, as the frames of the recording.
+	screenshot := NewScreenshotOptions(filepath.Join(dir, previewFrames), cfg.Style)
+	screenshot.screenshots[output] = i + 1
+	for _, cmd := range MakeScreenshots(screenshot) {
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to extract the frame: %w\n%s", err, out)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePreviewTime(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"12.5s": 12500 * time.Millisecond,
+		"1m":    time.Minute,
+		"2.25":  2250 * time.Millisecond,
+	} {
+		got, err := parsePreviewTime(s)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v (%v)", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"soon", "-1s"} {
+		if _, err := parsePreviewTime(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestPreviewTape(t *testing.T) {
+	tape := "Output demo.gif\n  Screenshot shot.png\nType \"Output\"\nOutputs"
+	want := "# Output demo.gif\n#   Screenshot shot.png\nType \"Output\"\nOutputs"
+	if got := previewTape(tape); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPreviewFrame(t *testing.T) {
+	frames := []SVGFrame{{Timestamp: 0.02}, {Timestamp: 0.04}, {Timestamp: 1.02}, {Timestamp: 2.02}}
+	for _, tt := range []struct {
+		at    time.Duration
+		speed float64
+		want  int
+	}{
+		{0, 1, 0},
+		{500 * time.Millisecond, 1, 1},
+		{time.Second, 1, 2},
+		{time.Second, 2, 3},
+		{time.Hour, 1, 3},
+	} {
+		if got := previewFrame(frames, tt.at, tt.speed); got != tt.want {
+			t.Errorf("%v at %vx: expected frame %d, got %d", tt.at, tt.speed, tt.want, got)
+		}
+	}
+}
+
+func TestExtractPreviewSVG(t *testing.T) {
+	dir := t.TempDir()
+	cfg := createTestSVGConfig()
+	cfg.Frames = []SVGFrame{
+		{Lines: []string{"> echo hello"}, Timestamp: 0.02, CharWidth: 10, CharHeight: 20},
+		{Lines: []string{"> echo hello", "hello"}, Timestamp: 1.02, CharWidth: 10, CharHeight: 20},
+	}
+	var buf bytes.Buffer
+	if err := (svgRecordingRenderer{}).Render(&buf, cfg.Frames, cfg, DefaultTheme, Timeline{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, previewRecording), buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "out", "frame.svg")
+	if err := extractPreview(dir, 500*time.Millisecond, output); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(b), "hello") != 1 {
+		t.Errorf("expected the first frame only, got %s", b)
+	}
+	if !previewCached(dir, false) || previewCached(dir, true) {
+		t.Error("expected the recording to be cached without the raw frames")
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -25,8 +27,8 @@ const (
 )
 
 var publishCmd = &cobra.Command{
-	Use:           "publish <gif>",
-	Short:         "Publish your GIF to vhs.charm.sh and get a shareable URL",
+	Use:           "publish <file>",
+	Short:         "Publish your GIF to vhs.charm.sh, or any output to your own backend, and get a shareable URL",
 	Args:          cobra.ExactArgs(1),
 	SilenceUsage:  true,
 	SilenceErrors: true, // we print our own errors
@@ -34,13 +36,10 @@ var publishCmd = &cobra.Command{
 		file := args[0]
 		if strings.HasSuffix(file, ".tape") {
 			log.Printf("Use vhs %s --publish flag to publish tapes\n", file)
-			return errors.New("must pass a GIF file")
-		}
-		if !strings.HasSuffix(file, gif) {
-			return errors.New("must pass a GIF file")
+			return errors.New("must pass an output file, not a tape")
 		}
 
-		url, err := Publish(cmd.Context(), file)
+		url, err := publishWithBackend(cmd.Context(), file)
 		if err != nil {
 			return err
 		}
@@ -48,7 +47,7 @@ var publishCmd = &cobra.Command{
 			fmt.Println(url)
 			return nil
 		}
-		publishShareInstructions(url)
+		publishShareInstructions(url, file)
 		cmd.Print("  " + URLStyle.Render(url))
 		cmd.Println()
 		return nil
@@ -135,9 +134,9 @@ func sshSession() (*ssh.Session, error) {
 
 // publishShareInstructions log shareable URL
 // If log level is set to `logLevelQuiet` the log message will be forced.
-func publishShareInstructions(url string) {
-	log.Println("\n" + GrayStyle.Render("  Share your GIF with Markdown:"))
-	log.Println(CommandStyle.Render("  ![Made with VHS]") + URLStyle.Render("("+url+")"))
+func publishShareInstructions(url, file string) {
+	log.Println("\n" + GrayStyle.Render("  Share your "+strings.ToUpper(strings.TrimPrefix(filepath.Ext(file), "."))+" with Markdown:"))
+	log.Println(CommandStyle.Render("  " + publishMarkdown(url, file)))
 	log.Println(GrayStyle.Render("\n  Or HTML (with badge):"))
 	log.Println(CommandStyle.Render("  <img ") + CommandStyle.Render("src=") + URLStyle.Render(`"`+url+`"`) + CommandStyle.Render(" alt=") + URLStyle.Render(`"Made with VHS"`) + CommandStyle.Render(">"))
 	log.Println(CommandStyle.Render("  <a ") + CommandStyle.Render("href=") + URLStyle.Render(`"https://vhs.charm.sh"`) + CommandStyle.Render(">"))
@@ -0,0 +1,465 @@
+//go:build !js
+
+// Package vhs publisher.go uploads outputs to pluggable publishing backends.
+//
+// By default, GIFs are published to vhs.charm.sh. Teams hosting demos on
+// their own infrastructure can publish any output to an S3-compatible
+// bucket, as a GitHub release asset or with a generic HTTP PUT instead. The
+// backend is configured with flags or a JSON config file, and credentials are
+// read from the environment.
+//
+// vhs publish --backend s3 --s3-bucket demos demo.gif
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/spf13/pflag"
+)
+
+// Publishing backends.
+const (
+	backendVHS    = "vhs"
+	backendS3     = "s3"
+	backendGitHub = "github"
+	backendHTTP   = "http"
+)
+
+const (
+	defaultS3Region     = "us-east-1"
+	defaultGitHubAPIURL = "https://api.github.com"
+	publishNamePattern  = "{name}"
+)
+
+// Publisher uploads a file and returns the URL it can be shared with.
+type Publisher interface {
+	Publish(ctx context.Context, path string) (string, error)
+}
+
+// PublishConfig configures the publishing backend.
+type PublishConfig struct {
+	Backend string       `json:"backend"`
+	S3      S3Config     `json:"s3"`
+	GitHub  GitHubConfig `json:"github"`
+	HTTP    HTTPConfig   `json:"http"`
+}
+
+// S3Config configures publishing to an S3-compatible bucket.
+type S3Config struct {
+	Bucket          string `json:"bucket"`
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Prefix          string `json:"prefix"`
+	PublicURL       string `json:"public_url"`
+	AccessKeyID     string `json:"-" env:"AWS_ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"-" env:"AWS_SECRET_ACCESS_KEY"`
+	SessionToken    string `json:"-" env:"AWS_SESSION_TOKEN"`
+}
+
+// GitHubConfig configures publishing as an asset of a GitHub release.
+type GitHubConfig struct {
+	Repo   string `json:"repo"`
+	Tag    string `json:"tag"`
+	APIURL string `json:"api_url"`
+	Token  string `json:"-" env:"GITHUB_TOKEN"`
+}
+
+// HTTPConfig configures publishing with an HTTP PUT request. The name of the
+// published file replaces {name} in the URLs.
+type HTTPConfig struct {
+	URL       string   `json:"url"`
+	Headers   []string `json:"headers"`
+	PublicURL string   `json:"public_url"`
+	Auth      string   `json:"-" env:"VHS_PUBLISH_AUTH"`
+}
+
+// publishConfigPath and publishFlags hold the publishing flags, which take
+// precedence over the config file.
+var (
+	publishConfigPath string
+	publishFlags      PublishConfig
+)
+
+// addPublishFlags registers the publishing backend flags. The backend flag is
+// named backendFlag, as the root command uses --backend for the capture
+// backend.
+func addPublishFlags(flags *pflag.FlagSet, backendFlag string) {
+	flags.StringVar(&publishConfigPath, "publish-config", "", "JSON file configuring the publishing backend")
+	flags.StringVar(&publishFlags.Backend, backendFlag, "", "publishing backend: vhs, s3, github or http")
+	flags.StringVar(&publishFlags.S3.Bucket, "s3-bucket", "", "S3 bucket to publish to")
+	flags.StringVar(&publishFlags.S3.Endpoint, "s3-endpoint", "", "S3-compatible endpoint (default AWS)")
+	flags.StringVar(&publishFlags.S3.Region, "s3-region", "", "S3 region (default us-east-1)")
+	flags.StringVar(&publishFlags.S3.Prefix, "s3-prefix", "", "key prefix of published files")
+	flags.StringVar(&publishFlags.S3.PublicURL, "s3-public-url", "", "public base URL of the bucket, e.g. a CDN")
+	flags.StringVar(&publishFlags.GitHub.Repo, "github-repo", "", "GitHub repository (owner/name) to publish to")
+	flags.StringVar(&publishFlags.GitHub.Tag, "github-tag", "", "tag of the GitHub release to attach the file to")
+	flags.StringVar(&publishFlags.HTTP.URL, "http-url", "", "URL to PUT the file to, {name} is replaced by the file name")
+	flags.StringArrayVar(&publishFlags.HTTP.Headers, "http-header", nil, `header added to the PUT request, e.g. "Authorization: Bearer <token>"`)
+	flags.StringVar(&publishFlags.HTTP.PublicURL, "http-public-url", "", "URL the file is served at, {name} is replaced by the file name")
+}
+
+// LoadPublishConfig reads the publishing config file, if any, applies the
+// flags on top of it and reads the credentials from the environment.
+func LoadPublishConfig(path string, flags PublishConfig) (PublishConfig, error) {
+	var cfg PublishConfig
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read publish config: %w", err)
+		}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return cfg, fmt.Errorf("failed to parse publish config: %w", err)
+		}
+	}
+
+	override(&cfg.Backend, flags.Backend)
+	override(&cfg.S3.Bucket, flags.S3.Bucket)
+	override(&cfg.S3.Endpoint, flags.S3.Endpoint)
+	override(&cfg.S3.Region, flags.S3.Region)
+	override(&cfg.S3.Prefix, flags.S3.Prefix)
+	override(&cfg.S3.PublicURL, flags.S3.PublicURL)
+	override(&cfg.GitHub.Repo, flags.GitHub.Repo)
+	override(&cfg.GitHub.Tag, flags.GitHub.Tag)
+	override(&cfg.HTTP.URL, flags.HTTP.URL)
+	override(&cfg.HTTP.PublicURL, flags.HTTP.PublicURL)
+	cfg.HTTP.Headers = append(cfg.HTTP.Headers, flags.HTTP.Headers...)
+
+	if err := env.Parse(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to read publish credentials: %w", err)
+	}
+	return cfg, nil
+}
+
+func override(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// NewPublisher returns the publisher of the configured backend.
+func NewPublisher(cfg PublishConfig) (Publisher, error) {
+	switch cfg.Backend {
+	case "", backendVHS:
+		return vhsPublisher{}, nil
+	case backendS3:
+		if cfg.S3.Bucket == "" {
+			return nil, errors.New("s3 backend requires a bucket")
+		}
+		if cfg.S3.AccessKeyID == "" || cfg.S3.SecretAccessKey == "" {
+			return nil, errors.New("s3 backend requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return s3Publisher{cfg.S3}, nil
+	case backendGitHub:
+		if cfg.GitHub.Repo == "" || cfg.GitHub.Tag == "" {
+			return nil, errors.New("github backend requires a repository and a release tag")
+		}
+		if cfg.GitHub.Token == "" {
+			return nil, errors.New("github backend requires GITHUB_TOKEN")
+		}
+		return githubPublisher{cfg.GitHub}, nil
+	case backendHTTP:
+		if cfg.HTTP.URL == "" {
+			return nil, errors.New("http backend requires a URL")
+		}
+		return httpPublisher{cfg.HTTP}, nil
+	default:
+		return nil, fmt.Errorf("unknown publishing backend %q", cfg.Backend)
+	}
+}
+
+// publishWithBackend publishes a file with the backend configured by the flags.
+func publishWithBackend(ctx context.Context, file string) (string, error) {
+	cfg, err := LoadPublishConfig(publishConfigPath, publishFlags)
+	if err != nil {
+		return "", err
+	}
+	publisher, err := NewPublisher(cfg)
+	if err != nil {
+		return "", err
+	}
+	return publisher.Publish(ctx, file) //nolint:wrapcheck
+}
+
+// publishMarkdown returns the markdown embedding the published file.
+func publishMarkdown(link, file string) string {
+	switch filepath.Ext(file) {
+	case mp4, webm:
+		return fmt.Sprintf(`<video src="%s" controls></video>`, link)
+	default:
+		return fmt.Sprintf("![Made with VHS](%s)", link)
+	}
+}
+
+// vhsPublisher publishes GIFs to vhs.charm.sh.
+type vhsPublisher struct{}
+
+func (vhsPublisher) Publish(ctx context.Context, file string) (string, error) {
+	if !strings.HasSuffix(file, gif) {
+		return "", errors.New("vhs.charm.sh only hosts GIF files")
+	}
+	return Publish(ctx, file)
+}
+
+// s3Publisher uploads files to an S3-compatible bucket with a signed PUT
+// request.
+type s3Publisher struct {
+	cfg S3Config
+}
+
+func (p s3Publisher) Publish(ctx context.Context, file string) (string, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	region := p.cfg.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	endpoint := strings.TrimSuffix(p.cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	key := path.Join(p.cfg.Prefix, filepath.Base(file))
+	objectURL := endpoint + "/" + p.cfg.Bucket + "/" + escapePath(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType(file))
+	signS3Request(req, body, p.cfg, region, time.Now())
+
+	if _, err := doPublishRequest(req); err != nil {
+		return "", err
+	}
+
+	if p.cfg.PublicURL != "" {
+		return strings.TrimSuffix(p.cfg.PublicURL, "/") + "/" + escapePath(key), nil
+	}
+	return objectURL, nil
+}
+
+// signS3Request signs the request with AWS Signature Version 4.
+func signS3Request(req *http.Request, body []byte, cfg S3Config, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath escapes every segment of a slash separated path.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// githubPublisher uploads files as assets of an existing GitHub release.
+type githubPublisher struct {
+	cfg GitHubConfig
+}
+
+func (p githubPublisher) Publish(ctx context.Context, file string) (string, error) {
+	apiURL := strings.TrimSuffix(p.cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/repos/%s/releases/tags/%s", apiURL, p.cfg.Repo, url.PathEscape(p.cfg.Tag)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	p.authorize(req)
+	b, err := doPublishRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to find release %s: %w", p.cfg.Tag, err)
+	}
+	var release struct {
+		UploadURL string `json:"upload_url"`
+	}
+	if err := json.Unmarshal(b, &release); err != nil {
+		return "", fmt.Errorf("failed to parse release: %w", err)
+	}
+
+	// The upload URL is a URI template, e.g. .../assets{?name,label}
+	uploadURL, _, _ := strings.Cut(release.UploadURL, "{")
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost,
+		uploadURL+"?name="+url.QueryEscape(filepath.Base(file)), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	p.authorize(req)
+	req.Header.Set("Content-Type", contentType(file))
+	b, err = doPublishRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload release asset: %w", err)
+	}
+	var asset struct {
+		BrowserDownloadURL string `json:"browser_download_url"`
+	}
+	if err := json.Unmarshal(b, &asset); err != nil {
+		return "", fmt.Errorf("failed to parse release asset: %w", err)
+	}
+	return asset.BrowserDownloadURL, nil
+}
+
+func (p githubPublisher) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+}
+
+// httpPublisher uploads files with a PUT request to an arbitrary server.
+type httpPublisher struct {
+	cfg HTTPConfig
+}
+
+func (p httpPublisher) Publish(ctx context.Context, file string) (string, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	name := url.PathEscape(filepath.Base(file))
+	target := strings.ReplaceAll(p.cfg.URL, publishNamePattern, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType(file))
+	if p.cfg.Auth != "" {
+		req.Header.Set("Authorization", p.cfg.Auth)
+	}
+	for _, header := range p.cfg.Headers {
+		k, v, ok := strings.Cut(header, ":")
+		if !ok {
+			return "", fmt.Errorf("invalid header %q", header)
+		}
+		req.Header.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+
+	b, err := doPublishRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case p.cfg.PublicURL != "":
+		return strings.ReplaceAll(p.cfg.PublicURL, publishNamePattern, name), nil
+	case strings.HasPrefix(strings.TrimSpace(string(b)), "http"):
+		// Servers may respond with the URL of the uploaded file.
+		return strings.TrimSpace(string(b)), nil
+	default:
+		return target, nil
+	}
+}
+
+// doPublishRequest sends the request and returns the response body, or an
+// error if the server did not respond with success.
+func doPublishRequest(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// contentType returns the MIME type of a file based on its extension.
+func contentType(file string) string {
+	if t := mime.TypeByExtension(filepath.Ext(file)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePublishFile(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("GIF89a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPublishConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "publish.json")
+	config := `{"backend": "s3", "s3": {"bucket": "demos", "region": "eu-west-1"}}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	cfg, err := LoadPublishConfig(path, PublishConfig{S3: S3Config{Region: "us-west-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Backend != backendS3 || cfg.S3.Bucket != "demos" {
+		t.Errorf("expected config file to be read, got %+v", cfg)
+	}
+	if cfg.S3.Region != "us-west-2" {
+		t.Errorf("expected flags to override the config file, got region %s", cfg.S3.Region)
+	}
+	if cfg.S3.AccessKeyID != "key" || cfg.S3.SecretAccessKey != "secret" {
+		t.Errorf("expected credentials from the environment, got %+v", cfg.S3)
+	}
+}
+
+func TestNewPublisherErrors(t *testing.T) {
+	for _, cfg := range []PublishConfig{
+		{Backend: "ftp"},
+		{Backend: backendS3},
+		{Backend: backendS3, S3: S3Config{Bucket: "demos"}},
+		{Backend: backendGitHub, GitHub: GitHubConfig{Repo: "o/r"}},
+		{Backend: backendHTTP},
+	} {
+		if _, err := NewPublisher(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestS3Publisher(t *testing.T) {
+	var req *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+	}))
+	defer server.Close()
+
+	p := s3Publisher{S3Config{
+		Bucket:          "demos",
+		Endpoint:        server.URL,
+		Prefix:          "vhs",
+		PublicURL:       "https://cdn.example.com",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	}}
+	url, err := p.Publish(context.Background(), writePublishFile(t, "demo.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != "https://cdn.example.com/vhs/demo.gif" {
+		t.Errorf("unexpected url %s", url)
+	}
+	if req.Method != http.MethodPut || req.URL.Path != "/demos/vhs/demo.gif" {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+	}
+	if req.Header.Get("Content-Type") != "image/gif" {
+		t.Errorf("unexpected content type %s", req.Header.Get("Content-Type"))
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-east-1/s3/aws4_request") {
+		t.Errorf("unexpected authorization %s", auth)
+	}
+}
+
+func TestSignS3RequestDeterministic(t *testing.T) {
+	sign := func() string {
+		req := httptest.NewRequest(http.MethodPut, "https://s3.amazonaws.com/demos/demo.gif", nil)
+		signS3Request(req, []byte("GIF89a"), S3Config{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "us-east-1",
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		return req.Header.Get("Authorization")
+	}
+	if a, b := sign(), sign(); a != b {
+		t.Errorf("expected stable signature, got %s and %s", a, b)
+	}
+	if !strings.Contains(sign(), "Credential=AKID/20240102/us-east-1/s3/aws4_request") {
+		t.Errorf("unexpected credential scope in %s", sign())
+	}
+}
+
+func TestGitHubPublisher(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/releases/tags/v1.0.0":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"upload_url": server.URL + "/uploads/1/assets{?name,label}",
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/uploads/1/assets":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"browser_download_url": "https://github.com/o/r/releases/download/v1.0.0/" + r.URL.Query().Get("name"),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := githubPublisher{GitHubConfig{Repo: "o/r", Tag: "v1.0.0", APIURL: server.URL, Token: "token"}}
+	url, err := p.Publish(context.Background(), writePublishFile(t, "demo.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://github.com/o/r/releases/download/v1.0.0/demo.gif" {
+		t.Errorf("unexpected url %s", url)
+	}
+
+	p.cfg.Tag = "missing"
+	if _, err := p.Publish(context.Background(), writePublishFile(t, "demo.gif")); err == nil {
+		t.Error("expected error for a missing release")
+	}
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "GIF89a" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "https://files.example.com%s\n", r.URL.Path)
+	}))
+	defer server.Close()
+
+	file := writePublishFile(t, "demo.gif")
+	p := httpPublisher{HTTPConfig{URL: server.URL + "/upload/{name}", Headers: []string{"X-Api-Key: secret"}}}
+	url, err := p.Publish(context.Background(), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://files.example.com/upload/demo.gif" {
+		t.Errorf("expected url from the response, got %s", url)
+	}
+
+	p.cfg.PublicURL = "https://example.com/{name}"
+	if url, _ := p.Publish(context.Background(), file); url != "https://example.com/demo.gif" {
+		t.Errorf("expected public url, got %s", url)
+	}
+
+	p.cfg.Headers = nil
+	if _, err := p.Publish(context.Background(), file); err == nil {
+		t.Error("expected error when the server rejects the upload")
+	}
+}
+
+func TestPublishMarkdown(t *testing.T) {
+	if md := publishMarkdown("https://example.com/demo.gif", "demo.gif"); md != "![Made with VHS](https://example.com/demo.gif)" {
+		t.Errorf("unexpected markdown %s", md)
+	}
+	if md := publishMarkdown("https://example.com/demo.mp4", "demo.mp4"); md != `<video src="https://example.com/demo.mp4" controls></video>` {
+		t.Errorf("unexpected markdown %s", md)
+	}
+}
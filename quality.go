@@ -0,0 +1,84 @@
+//go:build !js
+
+// Package vhs quality.go maps quality presets onto encoder settings for every
+// output format.
+//
+// # Set Quality low|medium|high|lossless
+//
+// The preset sets the CRF of video outputs, the palette of GIF outputs and
+// the optimization of SVG outputs. GIF and SVG settings set after the preset
+// take precedence.
+package main
+
+import (
+	"fmt"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+const defaultQuality = "high"
+
+// QualityPreset is the set of encoder settings used for a quality level.
+type QualityPreset struct {
+	// MP4CRF and WebMCRF are the constant rate factors of the video encoders.
+	MP4CRF  int
+	WebMCRF int
+	// Lossless disables lossy compression for video outputs.
+	Lossless bool
+	// GIFColors and GIFDither configure the GIF palette.
+	GIFColors int
+	GIFDither string
+	// SVGOptimize enables the compact SVG output.
+	SVGOptimize bool
+}
+
+// QualityPresets maps the quality levels to their encoder settings.
+var QualityPresets = map[string]QualityPreset{
+	"low": {
+		MP4CRF:      30,
+		WebMCRF:     45,
+		GIFColors:   64,
+		GIFDither:   "bayer",
+		SVGOptimize: true,
+	},
+	"medium": {
+		MP4CRF:      25,
+		WebMCRF:     38,
+		GIFColors:   128,
+		SVGOptimize: true,
+	},
+	"high": {
+		MP4CRF:      20,
+		WebMCRF:     30,
+		GIFColors:   256,
+		SVGOptimize: true,
+	},
+	"lossless": {
+		Lossless:  true,
+		GIFColors: 256,
+		GIFDither: "none",
+	},
+}
+
+// qualityPresetFor returns the preset for a quality level, falling back to the
+// default quality.
+func qualityPresetFor(quality string) QualityPreset {
+	if preset, ok := QualityPresets[quality]; ok {
+		return preset
+	}
+	return QualityPresets[defaultQuality]
+}
+
+// ExecuteSetQuality applies a quality preset to every output.
+func ExecuteSetQuality(c parser.Command, v *VHS) error {
+	preset, ok := QualityPresets[c.Args]
+	if !ok {
+		return fmt.Errorf("invalid quality %s", c.Args)
+	}
+
+	v.Options.Video.Quality = c.Args
+	v.Options.Video.MaxColors = preset.GIFColors
+	v.Options.Video.GIFDither = preset.GIFDither
+	v.Options.SVG.OptimizeSize = preset.SVGOptimize
+	return nil
+}
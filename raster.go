@@ -0,0 +1,159 @@
+//go:build !js
+
+// Package vhs raster.go draws the text of frames as images.
+//
+// Recordings which are not captured from the browser, e.g. converted
+// asciicasts, have the text of their frames only. They are drawn into the
+// text and cursor layers the browser captures, so that the raster outputs are
+// encoded from them by ffmpeg like the frames of a tape.
+package main
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// frameRasterizer draws frames in a theme and font.
+type frameRasterizer struct {
+	theme                 Theme
+	face                  font.Face
+	charWidth, charHeight float64
+}
+
+// newFrameRasterizer returns a rasterizer drawing cells of the given size.
+func newFrameRasterizer(theme Theme, fontFamily string, fontSize int, charWidth, charHeight float64) *frameRasterizer {
+	return &frameRasterizer{
+		theme:      theme,
+		face:       getWindowBarFont(fontFamily, float64(fontSize)),
+		charWidth:  charWidth,
+		charHeight: charHeight,
+	}
+}
+
+// Size returns the size in pixels of a terminal of the given size.
+func (r *frameRasterizer) Size(cols, rows int) (int, int) {
+	return int(math.Ceil(float64(cols) * r.charWidth)), int(math.Ceil(float64(rows) * r.charHeight))
+}
+
+// Draw returns the text layer of the frame, and its cursor layer.
+func (r *frameRasterizer) Draw(frame SVGFrame) (*image.RGBA, *image.RGBA) {
+	width, height := r.Size(frame.Cols, frame.Rows)
+	text := image.NewRGBA(image.Rect(0, 0, width, height))
+	cursor := image.NewRGBA(text.Bounds())
+	draw.Draw(text, text.Bounds(), image.NewUniform(parseHexColorOr(r.theme.Background, "#000000")), image.Point{}, draw.Src)
+
+	for y, line := range frame.Lines {
+		var styles []CharStyle
+		if y < len(frame.LineColors) {
+			styles = frame.LineColors[y]
+		}
+		// Backgrounds span the cells past the end of the text.
+		for x, style := range styles {
+			if _, bg := r.colors(style); bg != r.theme.Background {
+				r.fill(text, x, y, max(style.Width, 1), bg)
+			}
+		}
+		x := 0
+		graphemes := uniseg.NewGraphemes(line)
+		for graphemes.Next() {
+			var style CharStyle
+			if x < len(styles) {
+				style = styles[x]
+			}
+			fg, _ := r.colors(style)
+			r.glyph(text, x, y, graphemes.Str(), style, fg)
+			x += max(uniseg.StringWidth(graphemes.Str()), 1)
+		}
+	}
+
+	if frame.CursorY >= 0 && frame.CursorY < frame.Rows {
+		r.fill(cursor, frame.CursorX, frame.CursorY, 1, r.theme.Cursor)
+		if char := frameChar(frame, frame.CursorX, frame.CursorY); char != "" {
+			r.glyph(cursor, frame.CursorX, frame.CursorY, char, CharStyle{}, r.theme.Background)
+		}
+	}
+	return text, cursor
+}
+
+// colors returns the foreground and background colors of the style.
+func (r *frameRasterizer) colors(style CharStyle) (string, string) {
+	fg, bg := style.FgColor, style.BgColor
+	if fg == "" {
+		fg = r.theme.Foreground
+	}
+	if bg == "" {
+		bg = r.theme.Background
+	}
+	if style.Inverse {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// fill paints the cells in the color.
+func (r *frameRasterizer) fill(img *image.RGBA, x, y, cells int, c string) {
+	rect := image.Rect(
+		int(math.Round(float64(x)*r.charWidth)), int(math.Round(float64(y)*r.charHeight)),
+		int(math.Round(float64(x+cells)*r.charWidth)), int(math.Round(float64(y+1)*r.charHeight)),
+	)
+	draw.Draw(img, rect, image.NewUniform(parseHexColorOr(c, r.theme.Foreground)), image.Point{}, draw.Src)
+}
+
+// glyph draws the text of the cell in the color.
+func (r *frameRasterizer) glyph(img *image.RGBA, x, y int, s string, style CharStyle, c string) {
+	if s == " " || s == "" {
+		return
+	}
+	ink := parseHexColorOr(c, r.theme.Foreground)
+	// Dim text is drawn at half opacity, in premultiplied colors.
+	if style.Dim {
+		ink.R, ink.G, ink.B, ink.A = ink.R/2, ink.G/2, ink.B/2, ink.A/2 //nolint:mnd
+	}
+	metrics := r.face.Metrics()
+	top := float64(y) * r.charHeight
+	baseline := top + (r.charHeight-fixedToFloat(metrics.Ascent+metrics.Descent))/2 + fixedToFloat(metrics.Ascent)
+	left := float64(x) * r.charWidth
+
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(ink),
+		Face: r.face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(left * 64), Y: fixed.Int26_6(baseline * 64)}, //nolint:mnd
+	}
+	d.DrawString(s)
+
+	right := int(math.Round(left + r.charWidth*float64(max(uniseg.StringWidth(s), 1))))
+	for _, line := range []struct {
+		on bool
+		y  float64
+	}{
+		{style.Underline, baseline + 1},
+		{style.Strikethrough, top + r.charHeight/2},
+	} {
+		if line.on {
+			draw.Draw(img, image.Rect(int(math.Round(left)), int(line.y), right, int(line.y)+1),
+				image.NewUniform(ink), image.Point{}, draw.Over)
+		}
+	}
+}
+
+// frameChar returns the text of the cell of the frame.
+func frameChar(frame SVGFrame, x, y int) string {
+	if y >= len(frame.Lines) {
+		return ""
+	}
+	col := 0
+	graphemes := uniseg.NewGraphemes(frame.Lines[y])
+	for graphemes.Next() {
+		if col == x {
+			return graphemes.Str()
+		}
+		col += max(uniseg.StringWidth(graphemes.Str()), 1)
+	}
+	return ""
+}
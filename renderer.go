@@ -0,0 +1,207 @@
+//go:build !js
+
+// Package vhs renderer.go renders the outputs of a recording by extension.
+//
+// Outputs which are rendered from the text of the captured frames, rather
+// than encoded by ffmpeg, are Renderers registered for their extension. The
+// render step looks up the renderer of every such output, so that a format
+// is added by registering a Renderer, without touching the evaluator.
+//
+// RegisterRenderer(".json", jsonRenderer{})
+// Output demo.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Renderer renders the frames of a recording into an output. Renderers which
+// have nothing to render write nothing, and no file is created.
+type Renderer interface {
+	Render(w io.Writer, frames []SVGFrame, style SVGConfig, theme Theme, timeline Timeline) error
+}
+
+// Timeline is the timing of a recording.
+type Timeline struct {
+	// Framerate is the number of frames per second.
+	Framerate int
+	// Duration is the duration of the recording, in seconds.
+	Duration float64
+	// Scenes are the scenes of the recording, by their first frame.
+	Scenes []Scene
+	// Transcript is the text of the terminal at the end of every scene.
+	Transcript []transcriptScene
+}
+
+// renderers are the Renderers by extension.
+var renderers = map[string]Renderer{
+	svg:             svgRenderer{},
+	md:              transcriptRenderer{},
+	pdf:             storyboardRenderer{},
+	lottie:          lottieRenderer{},
+	svgRecordingExt: svgRecordingRenderer{},
+}
+
+// RegisterRenderer registers the renderer of the outputs with the extension.
+func RegisterRenderer(ext string, r Renderer) {
+	renderers[ext] = r
+}
+
+// renderedOutput is an output of a renderer.
+type renderedOutput struct {
+	Ext  string
+	Path string
+}
+
+// renderedOutputs returns the outputs of renderers, the built-in ones first.
+func (o VideoOutputs) renderedOutputs() []renderedOutput {
+	var outputs []renderedOutput
+	for _, output := range []renderedOutput{
+		{md, o.Transcript}, {pdf, o.PDF}, {lottie, o.Lottie}, {svg, o.SVG},
+	} {
+		if output.Path != "" {
+			outputs = append(outputs, output)
+		}
+	}
+	for _, ext := range slices.Sorted(maps.Keys(o.Rendered)) {
+		outputs = append(outputs, renderedOutput{ext, o.Rendered[ext]})
+	}
+	return outputs
+}
+
+// setRendered sets the output of the renderer of the extension.
+func (o *VideoOutputs) setRendered(ext, path string) {
+	switch ext {
+	case svg:
+		o.SVG = path
+	case md:
+		o.Transcript = path
+	case pdf:
+		o.PDF = path
+	case lottie:
+		o.Lottie = path
+	default:
+		if o.Rendered == nil {
+			o.Rendered = map[string]string{}
+		}
+		o.Rendered[ext] = path
+	}
+}
+
+// rendererExt returns the longest registered extension the path ends with,
+// so that demo.lottie.json is not taken for a .json output.
+func rendererExt(path string) (string, bool) {
+	var match string
+	for ext := range renderers {
+		if strings.HasSuffix(path, ext) && len(ext) > len(match) {
+			match = ext
+		}
+	}
+	return match, match != ""
+}
+
+// renderOutputs renders every output of a renderer.
+func renderOutputs(v *VHS) error {
+	for _, output := range v.Options.Video.Output.renderedOutputs() {
+		if err := renderOutput(v, output.Ext, output.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderOutput renders the output with the renderer of the extension.
+func renderOutput(v *VHS, ext, output string) (err error) {
+	r, ok := renderers[ext]
+	if output == "" || !ok {
+		return nil
+	}
+	_, span := startSpan(v.trace, "vhs.render_output", attribute.String("vhs.output", output))
+	defer func() { endSpan(span, err) }()
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, v.svgFrames, svgConfig(v), v.Options.Theme, v.timeline()); err != nil {
+		return fmt.Errorf("failed to render %s: %w", output, err)
+	}
+	if buf.Len() == 0 {
+		log.Printf("Nothing to render for %s", output)
+		return nil
+	}
+
+	log.Println(GrayStyle.Render("Creating " + output + "..."))
+	ensureDir(output)
+	if err := os.WriteFile(output, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return nil
+}
+
+// timeline returns the timeline of the recording.
+func (vhs *VHS) timeline() Timeline {
+	framerate := max(vhs.Options.Video.Framerate, 1)
+	return Timeline{
+		Framerate:  framerate,
+		Duration:   float64(len(vhs.svgFrames)) / float64(framerate),
+		Scenes:     vhs.scenes,
+		Transcript: vhs.transcriptScenes,
+	}
+}
+
+// svgRenderer renders animated SVGs.
+type svgRenderer struct{}
+
+// Render implements Renderer.
+func (svgRenderer) Render(w io.Writer, frames []SVGFrame, style SVGConfig, theme Theme, _ Timeline) error {
+	if len(frames) == 0 {
+		return nil
+	}
+	style.Frames, style.Theme = frames, theme
+	out := NewSVGGenerator(style).Generate()
+	if style.OptimizeLevel >= svgOptimizePool {
+		optimized := optimizeSVG(out, style.OptimizeLevel)
+		log.Println(GrayStyle.Render(fmt.Sprintf("Optimized SVG from %s to %s",
+			formatFileSize(int64(len(out))), formatFileSize(int64(len(optimized))))))
+		out = optimized
+	}
+	_, err := io.WriteString(w, out)
+	return err //nolint:wrapcheck
+}
+
+// lottieRenderer renders Lottie animations.
+type lottieRenderer struct{}
+
+// Render implements Renderer.
+func (lottieRenderer) Render(w io.Writer, frames []SVGFrame, style SVGConfig, theme Theme, _ Timeline) error {
+	if len(frames) == 0 {
+		return nil
+	}
+	style.Frames, style.Theme = frames, theme
+	b, err := NewLottieGenerator(style).Generate()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err //nolint:wrapcheck
+}
+
+// svgRecordingRenderer renders JSON recordings.
+type svgRecordingRenderer struct{}
+
+// Render implements Renderer.
+func (svgRecordingRenderer) Render(w io.Writer, frames []SVGFrame, style SVGConfig, theme Theme, _ Timeline) error {
+	if len(frames) == 0 {
+		return nil
+	}
+	style.Frames, style.Theme = frames, theme
+	return json.NewEncoder(w).Encode(NewSVGRecording(style)) //nolint:wrapcheck
+}
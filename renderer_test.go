@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// linesRenderer renders the number of lines of every frame.
+type linesRenderer struct{}
+
+func (linesRenderer) Render(w io.Writer, frames []SVGFrame, _ SVGConfig, _ Theme, timeline Timeline) error {
+	if len(frames) == 0 {
+		return nil
+	}
+	for _, f := range frames {
+		fmt.Fprintln(w, len(f.Lines))
+	}
+	_, err := fmt.Fprintf(w, "%d fps\n", timeline.Framerate)
+	return err
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer(".lines", linesRenderer{})
+	t.Cleanup(func() { delete(renderers, ".lines") })
+
+	dir := t.TempDir()
+	v := &VHS{Options: &Options{Video: VideoOptions{Framerate: 50}}}
+	for _, output := range []string{"demo.lines", "demo.lottie.json", "demo.gif"} {
+		if err := ExecuteOutput(parser.Command{Options: filepath.Ext(output), Args: filepath.Join(dir, output)}, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs := v.Options.Video.Output
+	if outputs.Rendered[".lines"] == "" || outputs.Lottie == "" || outputs.GIF == "" || len(outputs.Rendered) != 1 {
+		t.Fatalf("unexpected outputs %+v", outputs)
+	}
+	if !outputs.textFrames() {
+		t.Error("expected the frames to be captured for the renderer")
+	}
+
+	// Renderers with nothing to render create no file.
+	outputs.Lottie = ""
+	v.Options.Video.Output = outputs
+	if err := renderOutputs(v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outputs.Rendered[".lines"]); !os.IsNotExist(err) {
+		t.Errorf("expected no output without frames, got %v", err)
+	}
+
+	v.svgFrames = []SVGFrame{{Lines: []string{"> "}}, {Lines: []string{"> ls", "a"}}}
+	if err := renderOutputs(v); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(outputs.Rendered[".lines"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n2\n50 fps\n"; string(b) != want {
+		t.Errorf("expected %q, got %q", want, b)
+	}
+}
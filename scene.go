@@ -0,0 +1,161 @@
+// Package vhs scene.go implements multi-scene tapes.
+//
+// A Scene command marks the start of a named section of the recording. The
+// optional Reset modifier clears the terminal before the scene begins.
+//
+// Scene "Install"
+// Scene+Reset "Usage"
+//
+// Transitions between scenes are configured with the Set command and are
+// applied when rendering the outputs (xfade for videos, opacity keyframes for
+// SVG).
+//
+// Set Transition fade 500ms
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transition types supported between scenes.
+const (
+	transitionNone  = "none"
+	transitionFade  = "fade"
+	transitionSlide = "slide"
+)
+
+const defaultTransitionDuration = 500 * time.Millisecond
+
+// Scene marks the start of a named section of the recording.
+type Scene struct {
+	Name string
+	// Frame is the first captured frame (1-based) that belongs to the scene.
+	Frame int
+	Reset bool
+}
+
+// Transition is the effect applied between two consecutive scenes.
+type Transition struct {
+	Type     string
+	Duration time.Duration
+}
+
+// Enabled returns whether the transition should be rendered.
+func (t Transition) Enabled() bool {
+	return t.Type != "" && t.Type != transitionNone && t.Duration > 0
+}
+
+// sceneBoundaries converts the scene start frames into 0-based offsets into
+// the rendered frame sequence (which starts at startingFrame). Boundaries at
+// the very start or past the end of the recording are dropped since there is
+// nothing to transition from or to.
+func sceneBoundaries(scenes []Scene, startingFrame, totalFrames int) []int {
+	seen := map[int]bool{}
+	var boundaries []int
+	for _, s := range scenes {
+		b := s.Frame - startingFrame
+		if b <= 0 || b >= totalFrames || seen[b] {
+			continue
+		}
+		seen[b] = true
+		boundaries = append(boundaries, b)
+	}
+	sort.Ints(boundaries)
+	return boundaries
+}
+
+// sceneTransitionFilter returns the ffmpeg filtergraph which splits the input
+// stream at the scene boundaries and joins the pieces back together with
+// xfade. It returns an empty string if there is nothing to transition.
+func sceneTransitionFilter(input, output string, boundaries []int, framerate int, t Transition) string {
+	duration := sceneTransitionDuration(boundaries, framerate, t)
+	if duration <= 0 {
+		return ""
+	}
+
+	xfade := transitionFade
+	if t.Type == transitionSlide {
+		xfade = "slideleft"
+	}
+
+	segments := len(boundaries) + 1
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "[%s]split=%d", input, segments)
+	for i := 0; i < segments; i++ {
+		_, _ = fmt.Fprintf(&sb, "[sc%d]", i)
+	}
+	sb.WriteString(";\n")
+
+	start := 0
+	for i := 0; i < segments; i++ {
+		if i < len(boundaries) {
+			_, _ = fmt.Fprintf(&sb, "[sc%d]trim=start_frame=%d:end_frame=%d,setpts=PTS-STARTPTS[seg%d];\n", i, start, boundaries[i], i)
+			start = boundaries[i]
+		} else {
+			_, _ = fmt.Fprintf(&sb, "[sc%d]trim=start_frame=%d,setpts=PTS-STARTPTS[seg%d];\n", i, start, i)
+		}
+	}
+
+	prevLabel := "seg0"
+	for i := 1; i < segments; i++ {
+		elapsed := float64(boundaries[i-1]) / float64(framerate)
+		label := fmt.Sprintf("xf%d", i)
+		if i == segments-1 {
+			label = output
+		}
+		offset := elapsed - float64(i)*duration
+		_, _ = fmt.Fprintf(&sb, "[%s][seg%d]xfade=transition=%s:duration=%.3f:offset=%.3f[%s]",
+			prevLabel, i, xfade, duration, offset, label)
+		if i != segments-1 {
+			sb.WriteString(";\n")
+		}
+		prevLabel = label
+	}
+
+	return sb.String()
+}
+
+// sceneTransitionDuration returns the duration (in seconds) of every
+// transition between scenes, or 0 if no transition is rendered. Each
+// transition overlaps the scenes it joins, shortening the output by this
+// amount.
+func sceneTransitionDuration(boundaries []int, framerate int, t Transition) float64 {
+	if !t.Enabled() || len(boundaries) == 0 || framerate <= 0 {
+		return 0
+	}
+
+	// Shortest duration (in seconds) of every scene except the last one,
+	// which runs until the end of the stream.
+	prev := 0
+	shortest := 0.0
+	for i, b := range boundaries {
+		d := float64(b-prev) / float64(framerate)
+		if i == 0 || d < shortest {
+			shortest = d
+		}
+		prev = b
+	}
+
+	// xfade fails if a transition is longer than the segments it joins.
+	duration := t.Duration.Seconds()
+	if duration > shortest/doublingFactor {
+		duration = shortest / doublingFactor
+	}
+	return duration
+}
+
+// sceneTimes returns the start time (in seconds) of every scene after the
+// first captured frame, for use in the SVG timeline.
+func sceneTimes(scenes []Scene, framerate, frames int) []float64 {
+	if framerate <= 0 {
+		return nil
+	}
+	var times []float64
+	for _, b := range sceneBoundaries(scenes, 1, frames) {
+		times = append(times, float64(b)/float64(framerate))
+	}
+	return times
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSceneBoundaries(t *testing.T) {
+	scenes := []Scene{
+		{Name: "Intro", Frame: 1},
+		{Name: "Install", Frame: 51},
+		{Name: "Usage", Frame: 101},
+		{Name: "Duplicate", Frame: 101},
+		{Name: "Past the end", Frame: 500},
+	}
+
+	got := sceneBoundaries(scenes, 1, 200)
+	want := []int{50, 100}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected boundary %d to be %d, got %d", i, want[i], got[i])
+		}
+	}
+}
+
+func TestSceneTransitionFilter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		f := sceneTransitionFilter("scaled", "scenes", []int{50}, 50, Transition{Type: transitionNone})
+		if f != "" {
+			t.Errorf("expected no filter, got %q", f)
+		}
+	})
+
+	t.Run("fade", func(t *testing.T) {
+		transition := Transition{Type: transitionFade, Duration: 500 * time.Millisecond}
+		f := sceneTransitionFilter("scaled", "scenes", []int{100, 200}, 50, transition)
+		for _, expected := range []string{
+			"[scaled]split=3[sc0][sc1][sc2]",
+			"[sc0]trim=start_frame=0:end_frame=100",
+			"[sc2]trim=start_frame=200,",
+			"[seg0][seg1]xfade=transition=fade:duration=0.500:offset=1.500[xf1]",
+			"[xf1][seg2]xfade=transition=fade:duration=0.500:offset=3.000[scenes]",
+		} {
+			if !strings.Contains(f, expected) {
+				t.Errorf("expected filter to contain %q, got:\n%s", expected, f)
+			}
+		}
+	})
+
+	t.Run("clamps to short scenes", func(t *testing.T) {
+		transition := Transition{Type: transitionSlide, Duration: 2 * time.Second}
+		f := sceneTransitionFilter("scaled", "scenes", []int{10}, 10, transition)
+		if !strings.Contains(f, "xfade=transition=slideleft:duration=0.500:offset=0.500") {
+			t.Errorf("expected clamped slide transition, got:\n%s", f)
+		}
+	})
+}
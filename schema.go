@@ -0,0 +1,126 @@
+//go:build !js
+
+// Package vhs schema.go prints the JSON schema of options files and tapes.
+//
+// vhs schema prints the JSON schema of the documents tools can give vhs
+// instead of templating tape text: options files of --options and tapes
+// written as a JSON array of commands, along with the Theme and StyleOptions
+// types they hold. The schema is derived from the Go types, and published in
+// schema.json.
+//
+// vhs schema > schema.json
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON schema of options files and JSON tapes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		data, err := jsonSchema()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return err //nolint:wrapcheck
+	},
+}
+
+// jsonSchema returns the schema of the options files and JSON tapes.
+func jsonSchema() ([]byte, error) {
+	settings := map[string]any{}
+	for name, t := range token.Keywords {
+		if !token.IsSetting(t) {
+			continue
+		}
+		settings[name] = map[string]any{"type": []string{"string", "number", "boolean"}}
+		if t == token.THEME || t == token.THEME_DARK {
+			settings[name] = map[string]any{"anyOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{"$ref": "#/$defs/Theme"},
+			}}
+		}
+	}
+
+	// Source is expanded by the parser, so JSON tapes cannot use it.
+	var types []string
+	for _, t := range parser.CommandTypes {
+		if t != token.SOURCE && t != token.ILLEGAL {
+			types = append(types, string(t))
+		}
+	}
+	command := structSchema(reflect.TypeFor[parser.Command]())
+	command["properties"].(map[string]any)["type"] = map[string]any{"enum": types}
+	command["required"] = []string{"type"}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "VHS options file or JSON tape",
+		"oneOf": []any{
+			map[string]any{"$ref": "#/$defs/Options"},
+			map[string]any{"$ref": "#/$defs/Tape"},
+		},
+		"$defs": map[string]any{
+			"Options": map[string]any{
+				"description":          "Values of Set commands by setting, applied before the commands of the tape.",
+				"type":                 "object",
+				"properties":           settings,
+				"additionalProperties": false,
+			},
+			"Tape": map[string]any{
+				"description": "Commands of a tape.",
+				"type":        "array",
+				"items":       map[string]any{"$ref": "#/$defs/Command"},
+			},
+			"Command":      command,
+			"Theme":        structSchema(reflect.TypeFor[Theme]()),
+			"StyleOptions": structSchema(reflect.TypeFor[StyleOptions]()),
+		},
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return data, nil
+}
+
+// structSchema returns the schema of the JSON object of the struct.
+func structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		var kind string
+		switch field.Type.Kind() { //nolint:exhaustive
+		case reflect.String:
+			kind = "string"
+		case reflect.Int, reflect.Int64:
+			kind = "integer"
+		case reflect.Float64:
+			kind = "number"
+		case reflect.Bool:
+			kind = "boolean"
+		default:
+			continue
+		}
+		properties[name] = map[string]any{"type": kind}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := jsonSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := os.ReadFile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(published), data) {
+		t.Error("schema.json is out of date, run vhs schema > schema.json")
+	}
+
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	for def, property := range map[string]string{
+		"Options":      "KeyboardLayout",
+		"Theme":        "brightWhite",
+		"StyleOptions": "windowBarTitle",
+		"Command":      "args",
+	} {
+		if _, ok := schema.Defs[def].Properties[property]; !ok {
+			t.Errorf("expected %s to have the property %s", def, property)
+		}
+	}
+}
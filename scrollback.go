@@ -0,0 +1,132 @@
+//go:build !js
+
+// Package vhs scrollback.go scrolls the terminal viewport and captures the
+// full scrollback of the recording.
+//
+// ScrollUp and ScrollDown move the viewport through the scrollback while
+// recording, so every output shows the scrolled content.
+//
+// ScrollUp[@<time>] [lines]
+// ScrollDown[@<time>] [lines]
+//
+// With Scrollback enabled, the complete transcript of the terminal (including
+// the lines that scrolled off-screen) is presented below the animation of SVG
+// outputs as a scrollable panel.
+//
+// Set Scrollback true
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// ExecuteScroll is a higher-order function that returns a CommandFunc to
+// scroll the terminal viewport by the given number of lines per repeat,
+// negative values scroll up.
+func ExecuteScroll(lines int) CommandFunc {
+	return func(c parser.Command, v *VHS) error {
+		typingSpeed, err := time.ParseDuration(c.Options)
+		if err != nil {
+			typingSpeed = v.Options.TypingSpeed
+		}
+		repeat, err := strconv.Atoi(c.Args)
+		if err != nil {
+			repeat = 1
+		}
+		for i := 0; i < repeat; i++ {
+			_, err = v.Page.Eval(fmt.Sprintf("() => term.scrollLines(%d)", lines))
+			if err != nil {
+				return fmt.Errorf("failed to scroll terminal: %w", err)
+			}
+			if err := v.sleep(typingSpeed); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ExecuteSetScrollback sets whether the scrollback is captured for the SVG
+// transcript.
+func ExecuteSetScrollback(c parser.Command, v *VHS) error {
+	var err error
+	v.Options.SVG.Scrollback, err = strconv.ParseBool(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse scrollback: %w", err)
+	}
+
+	return nil
+}
+
+// captureScrollbackJS returns every line of the normal buffer, which keeps
+// the scrollback while full-screen programs use the alternate buffer.
+const captureScrollbackJS = `() => {
+	const buffer = term.buffer.normal;
+	const lines = [];
+	for (let y = 0; y < buffer.length; y++) {
+		const line = buffer.getLine(y);
+		if (!line) continue;
+		if (line.isWrapped && lines.length > 0) {
+			lines[lines.length - 1] += line.translateToString(true);
+		} else {
+			lines.push(line.translateToString(true));
+		}
+	}
+	return lines;
+}`
+
+// CaptureScrollback stores the full transcript of the terminal. It must be
+// called before the browser is closed.
+func (vhs *VHS) CaptureScrollback() error {
+	res, err := vhs.Page.Eval(captureScrollbackJS)
+	if err != nil {
+		return fmt.Errorf("failed to capture scrollback: %w", err)
+	}
+
+	var lines []string
+	for _, line := range res.Value.Arr() {
+		lines = append(lines, line.Str())
+	}
+	vhs.scrollback = trimTranscript(lines)
+	return nil
+}
+
+// transcript returns the scrollback shown below the SVG animation, if
+// enabled.
+func (vhs *VHS) transcript() []string {
+	if !vhs.Options.SVG.Scrollback {
+		return nil
+	}
+	return vhs.scrollback
+}
+
+// accessibleTranscript returns the text of the terminal for screen readers:
+// the scrollback if it was captured, otherwise the final screen.
+func (vhs *VHS) accessibleTranscript() []string {
+	if len(vhs.scrollback) > 0 {
+		return vhs.scrollback
+	}
+	if len(vhs.svgFrames) == 0 {
+		return nil
+	}
+	return trimTranscript(slices.Clone(vhs.svgFrames[len(vhs.svgFrames)-1].Lines))
+}
+
+// trimTranscript removes trailing whitespace and the empty lines at the end
+// of the buffer.
+func trimTranscript(lines []string) []string {
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -32,12 +34,15 @@ type config struct {
 	UID                int    `env:"UID" envDefault:"0"`
 	KeyPath            string `env:"KEY_PATH" envDefault:""`
 	AuthorizedKeysPath string `env:"AUTHORIZED_KEYS_PATH"`
+	HTTP               httpConfig
 }
 
+var httpAddr string
+
 //nolint:wrapcheck
 var serveCmd = &cobra.Command{
 	Use:   "serve",
-	Short: "Start the VHS SSH server",
+	Short: "Start the VHS SSH server, or the HTTP API with --http",
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		var cfg config
 		if err := env.ParseWithOptions(&cfg, env.Options{
@@ -45,6 +50,9 @@ var serveCmd = &cobra.Command{
 		}); err != nil {
 			return err
 		}
+		if httpAddr != "" {
+			return serveHTTP(cmd.Context(), httpAddr, cfg.HTTP)
+		}
 		key := cfg.KeyPath
 		if key == "" {
 			key = filepath.Join(".ssh", "vhs_ed25519")
@@ -91,29 +99,10 @@ var serveCmd = &cobra.Command{
 
 						//nolint:gosec
 						rand := rand.Int63n(maxNumber)
-						tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("vhs-%d", rand))
+						var tempFile string
 						defer func() { _ = os.Remove(tempFile) }()
-						errs := Evaluate(s.Context(), b.String(), s.Stderr(), func(v *VHS) {
-							var gifOutput, mp4Output, webmOutput, svgOutput string
-							switch {
-							case v.Options.Video.Output.MP4 != "":
-								tempFile += mp4
-								mp4Output = tempFile
-							case v.Options.Video.Output.WebM != "":
-								tempFile += webm
-								webmOutput = tempFile
-							case v.Options.Video.Output.SVG != "":
-								tempFile += svg
-								svgOutput = tempFile
-							default:
-								tempFile += gif
-								gifOutput = tempFile
-							}
-							v.Options.Video.Output.GIF = gifOutput
-							v.Options.Video.Output.MP4 = mp4Output
-							v.Options.Video.Output.WebM = webmOutput
-							v.Options.Video.Output.SVG = svgOutput
-						})
+						errs := Evaluate(s.Context(), b.String(), s.Stderr(),
+							withServeOutput(filepath.Join(os.TempDir(), fmt.Sprintf("vhs-%d", rand)), "", &tempFile))
 
 						if len(errs) > 0 {
 							printErrors(s.Stderr(), b.String(), errs)
@@ -1,5 +1,16 @@
+//go:build !js
+
 package main
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Supported shells of VH.
 const (
 	bash       = "bash"
@@ -13,64 +24,260 @@ const (
 	zsh        = "zsh"
 )
 
-// Shell is a type that contains a prompt and the command to set up the shell.
-type Shell struct {
-	Command []string
-	Env     []string
+// Shell is an adapter for a shell supported by VHS. It starts the shell
+// isolated from the configuration of the user, with a known prompt, and
+// knows the quoting rules of its language.
+type Shell interface {
+	// Name is the name of the shell in Set Shell.
+	Name() string
+	// Command starts the shell without reading RC files or history.
+	Command() []string
+	// Env holds the environment variables setting up the shell, or nil if it
+	// inherits the environment of VHS unchanged.
+	Env() []string
+	// Prompt matches the current line once the shell is ready for input.
+	Prompt() *regexp.Regexp
+	// Quote quotes the string as a single literal argument.
+	Quote(s string) string
+}
+
+// promptMarker is the sequence every shell prints (invisibly) before its
+// prompt, the start of a prompt in the semantic prompts of FinalTerm (OSC 133).
+// Waiting for it tells VHS when the shell reads input without guessing.
+//
+// Shells which know the exit status of the last command also print it before
+// the prompt, as OSC 133;D;<status>.
+const promptMarker = "\x1b]133;A\x07"
+
+// defaultPrompt matches the prompt every shell is set up with, "> " (which
+// is trimmed when reading the terminal).
+var defaultPrompt = regexp.MustCompile(`>$`)
+
+// shellAdapter is a Shell described by its command line, environment and
+// quoting function.
+type shellAdapter struct {
+	name    string
+	command []string
+	env     []string
+	quote   func(string) string
 }
 
-// Shells contains a mapping from shell names to their Shell struct.
+func (s shellAdapter) Name() string            { return s.name }
+func (s shellAdapter) Command() []string       { return s.command }
+func (s shellAdapter) Env() []string           { return s.env }
+func (s shellAdapter) Prompt() *regexp.Regexp  { return defaultPrompt }
+func (s shellAdapter) Quote(str string) string { return s.quote(str) }
+
+// Shells contains a mapping from shell names to their adapters.
 var Shells = map[string]Shell{
-	bash: {
-		Env:     []string{"PS1=\\[\\e[38;2;90;86;224m\\]> \\[\\e[0m\\]", "BASH_SILENCE_DEPRECATION_WARNING=1"},
-		Command: []string{"bash", "--noprofile", "--norc", "--login", "+o", "history"},
+	bash: shellAdapter{
+		name:    bash,
+		env:     []string{"PS1=\\[\\e[38;2;90;86;224m\\]> \\[\\e[0m\\]", `PROMPT_COMMAND=printf '\033]133;D;%s\007\033]133;A\007' "$?"`, "BASH_SILENCE_DEPRECATION_WARNING=1"},
+		command: []string{"bash", "--noprofile", "--norc", "--login", "+o", "history"},
+		quote:   quotePOSIX,
 	},
-	zsh: {
-		Env:     []string{`PROMPT=%F{#5B56E0}> %F{reset_color}`},
-		Command: []string{"zsh", "--histnostore", "--no-rcs"},
+	zsh: shellAdapter{
+		name:    zsh,
+		env:     []string{"PROMPT=%{\x1b]133;D;%?\x07" + promptMarker + "%}%F{#5B56E0}> %F{reset_color}"},
+		command: []string{"zsh", "--histnostore", "--no-rcs"},
+		quote:   quotePOSIX,
 	},
-	fish: {
-		Command: []string{
+	fish: shellAdapter{
+		name: fish,
+		command: []string{
 			"fish",
 			"--login",
 			"--no-config",
 			"--private",
 			"-C", "function fish_greeting; end",
-			"-C", `function fish_prompt; set_color 5B56E0; echo -n "> "; set_color normal; end`,
+			"-C", `function fish_prompt; printf '\e]133;D;%s\a\e]133;A\a' $status; set_color 5B56E0; echo -n "> "; set_color normal; end`,
 		},
+		quote: quoteFish,
 	},
-	powershell: {
-		Command: []string{
+	powershell: shellAdapter{
+		name: powershell,
+		command: []string{
 			"powershell",
 			"-NoLogo",
 			"-NoExit",
 			"-NoProfile",
 			"-Command",
-			`Set-PSReadLineOption -HistorySaveStyle SaveNothing; function prompt { Write-Host '>' -NoNewLine -ForegroundColor Blue; return ' ' }`,
+			`Set-PSReadLineOption -HistorySaveStyle SaveNothing; function prompt { $s = if ($?) { 0 } elseif ($LASTEXITCODE) { $LASTEXITCODE } else { 1 }; Write-Host "$([char]27)]133;D;$s$([char]7)$([char]27)]133;A$([char]7)" -NoNewLine; Write-Host '>' -NoNewLine -ForegroundColor Blue; return ' ' }`,
 		},
+		quote: quotePowerShell,
 	},
-	pwsh: {
-		Command: []string{
+	pwsh: shellAdapter{
+		name: pwsh,
+		command: []string{
 			"pwsh",
 			"-Login",
 			"-NoLogo",
 			"-NoExit",
 			"-NoProfile",
 			"-Command",
-			`Set-PSReadLineOption -HistorySaveStyle SaveNothing; Function prompt { Write-Host -ForegroundColor Blue -NoNewLine '>'; return ' ' }`,
+			`Set-PSReadLineOption -HistorySaveStyle SaveNothing; Function prompt { $s = if ($?) { 0 } elseif ($LASTEXITCODE) { $LASTEXITCODE } else { 1 }; Write-Host -NoNewLine "$([char]27)]133;D;$s$([char]7)$([char]27)]133;A$([char]7)"; Write-Host -ForegroundColor Blue -NoNewLine '>'; return ' ' }`,
 		},
+		quote: quotePowerShell,
 	},
-	cmdexe: {
-		Command: []string{"cmd.exe", "/k", "prompt=^> "},
+	cmdexe: shellAdapter{
+		name: cmdexe,
+		// /d skips the AutoRun commands of the registry.
+		command: []string{"cmd.exe", "/d", "/k", "prompt=$E]133;A$E\\^> "},
+		quote:   quoteCmd,
 	},
-	nushell: {
-		Command: []string{"nu", "--execute", "$env.PROMPT_COMMAND = {'\033[;38;2;91;86;224m>\033[m '}; $env.PROMPT_COMMAND_RIGHT = {''}"},
+	nushell: shellAdapter{
+		name:    nushell,
+		command: []string{"nu", "--no-config-file", "--execute", "$env.PROMPT_COMMAND = {$'\x1b]133;D;($env.LAST_EXIT_CODE)\x07" + promptMarker + "\033[;38;2;91;86;224m>\033[m '}; $env.PROMPT_COMMAND_RIGHT = {''}"},
+		quote:   quoteNushell,
 	},
-	osh: {
-		Env:     []string{"PS1=\\[\\e[38;2;90;86;224m\\]> \\[\\e[0m\\]"},
-		Command: []string{"osh", "--norc"},
+	osh: shellAdapter{
+		name:    osh,
+		env:     []string{"PS1=\\[\\e[38;2;90;86;224m\\]> \\[\\e[0m\\]", `PROMPT_COMMAND=printf '\033]133;D;%s\007\033]133;A\007' "$?"`},
+		command: []string{"osh", "--norc"},
+		quote:   quotePOSIX,
 	},
-	xonsh: {
-		Command: []string{"xonsh", "--no-rc", "-D", "PROMPT=\033[;38;2;91;86;224m>\033[m "},
+	xonsh: shellAdapter{
+		name:    xonsh,
+		command: []string{"xonsh", "--no-rc", "-D", "PROMPT=\x1b]133;D;{last_return_code}\x07" + promptMarker + "\033[;38;2;91;86;224m>\033[m "},
+		quote:   strconv.Quote,
 	},
 }
+
+// quotePOSIX quotes the string for sh-like shells, in single quotes which
+// cannot be escaped within.
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteFish quotes the string for fish, whose single quotes escape
+// backslashes and single quotes.
+func quoteFish(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// quotePowerShell quotes the string for PowerShell, whose single quotes are
+// doubled within.
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteCmd quotes the string for cmd.exe, whose double quotes are doubled
+// within.
+func quoteCmd(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteNushell quotes the string for nushell in single quotes, or a raw
+// string if it contains one.
+func quoteNushell(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	hashes := "#"
+	for strings.Contains(s, "'"+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + "'" + s + "'" + hashes
+}
+
+// waitForShell waits until the shell marks its prompt, so that the first keys
+// are not typed before the shell reads them. Prompts which are not marked are
+// recognized by the current line matching the prompt of the shell or the
+// WaitPattern, and given up on after the timeout.
+func (vhs *VHS) waitForShell(timeout time.Duration) error {
+	var exited <-chan struct{}
+	if vhs.native != nil {
+		exited = vhs.native.done
+	}
+	tick := time.NewTicker(WaitTick)
+	defer tick.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		state, err := vhs.ShellState()
+		if err != nil {
+			return err
+		}
+		if state.Prompts > 0 {
+			return nil
+		}
+		line, err := vhs.CurrentLine()
+		if err != nil {
+			return err
+		}
+		if vhs.Options.Shell.Prompt().MatchString(line) || vhs.Options.WaitPattern.MatchString(line) {
+			return nil
+		}
+		select {
+		case <-tick.C:
+		case <-vhs.limits.Done():
+			return vhs.limits.Err()
+		case <-exited:
+			return errors.New("the shell exited before printing a prompt")
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// ShellState is what the shell has marked with semantic prompts.
+type ShellState struct {
+	// Prompts is the number of prompts shown.
+	Prompts int `json:"prompts"`
+	// Exits is the number of commands which finished, and Status the exit
+	// status of the last one.
+	Exits  int `json:"exits"`
+	Status int `json:"status"`
+}
+
+// mark updates the state with a semantic prompt sequence (without OSC 133;).
+func (s *ShellState) mark(data string) {
+	switch {
+	case data == "A" || strings.HasPrefix(data, "A;"):
+		s.Prompts++
+	case strings.HasPrefix(data, "D"):
+		s.Exits++
+		s.Status, _ = strconv.Atoi(strings.TrimPrefix(data, "D;"))
+	}
+}
+
+// ShellState returns what the shell has marked so far.
+func (vhs *VHS) ShellState() (ShellState, error) {
+	if vhs.native != nil {
+		return vhs.native.screen.ShellState(), nil
+	}
+
+	var state ShellState
+	res, err := vhs.Page.Eval("() => window.vhsShell")
+	if err != nil {
+		return state, fmt.Errorf("read shell state: %w", err)
+	}
+	if err := res.Value.Unmarshal(&state); err != nil {
+		return state, fmt.Errorf("read shell state: %w", err)
+	}
+	return state, nil
+}
+
+// shellStateScript tracks the semantic prompts of the shell in the browser,
+// as ShellState.mark does. It runs before ttyd creates the terminal, so that
+// no marker is missed.
+const shellStateScript = `(() => {
+  let term;
+  window.vhsShell = { prompts: 0, exits: 0, status: 0 };
+  Object.defineProperty(window, 'term', {
+    configurable: true,
+    get: () => term,
+    set: (t) => {
+      term = t;
+      t.parser.registerOscHandler(133, (data) => {
+        if (data === 'A' || data.startsWith('A;')) {
+          window.vhsShell.prompts++;
+        } else if (data.startsWith('D')) {
+          window.vhsShell.exits++;
+          window.vhsShell.status = parseInt(data.slice(2), 10) || 0;
+        }
+        return false;
+      });
+    },
+  });
+})()`
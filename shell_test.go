@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestShellNames(t *testing.T) {
+	for name, shell := range Shells {
+		if shell.Name() != name {
+			t.Errorf("expected shell %q to be named %q", name, shell.Name())
+		}
+		if len(shell.Command()) == 0 {
+			t.Errorf("expected shell %q to have a command", name)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		shell    string
+		in       string
+		expected string
+	}{
+		{bash, "hello world", `'hello world'`},
+		{bash, "it's", `'it'\''s'`},
+		{zsh, "$HOME", `'$HOME'`},
+		{fish, `it's a \ path`, `'it\'s a \\ path'`},
+		{pwsh, "it's", `'it''s'`},
+		{powershell, "$env:PATH", `'$env:PATH'`},
+		{cmdexe, `say "hi"`, `"say ""hi"""`},
+		{nushell, "hello", `'hello'`},
+		{nushell, "it's", `r#'it's'#`},
+		{nushell, "'#", `r##''#'##`},
+		{xonsh, `a "b"`, `"a \"b\""`},
+	}
+
+	for _, tc := range tests {
+		if got := Shells[tc.shell].Quote(tc.in); got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.shell, tc.expected, got)
+		}
+	}
+}
+
+func TestShellPrompt(t *testing.T) {
+	for name, shell := range Shells {
+		if !shell.Prompt().MatchString("~/src >") {
+			t.Errorf("expected shell %q to match its prompt", name)
+		}
+		if shell.Prompt().MatchString("Loading...") {
+			t.Errorf("expected shell %q not to match output", name)
+		}
+	}
+}
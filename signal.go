@@ -0,0 +1,135 @@
+//go:build !js
+
+// Package vhs signal.go sends signals to the program running in the terminal
+// and waits for it to exit.
+//
+// Signals go to the foreground process group of the terminal, as with the
+// keys of the terminal (Ctrl+C, Ctrl+Z) but without relying on the program
+// reading them. The exit status comes from the semantic prompts of the shell
+// (see promptMarker).
+//
+// Signal SIGTERM
+// WaitExit 5s --status 143
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// ExecuteSignal sends a signal to the foreground process group of the
+// terminal.
+func ExecuteSignal(c parser.Command, v *VHS) error {
+	var pid int
+	onTerminal := v.native != nil
+	if onTerminal {
+		pid = v.native.cmd.Process.Pid
+	} else {
+		pid = v.tty.Process.Pid
+	}
+
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,tpgid=").Output() //nolint:noctx
+	if err != nil {
+		return fmt.Errorf("failed to list processes: %w", err)
+	}
+	pgrp, err := foregroundProcessGroup(string(out), pid, onTerminal)
+	if err != nil {
+		return err
+	}
+	return signalProcessGroup(pgrp, c.Args)
+}
+
+// foregroundProcessGroup returns the foreground process group of the terminal
+// of the shell from the output of `ps -A -o pid=,ppid=,tpgid=`. The process of
+// the given pid runs on the terminal, or else (as ttyd) is the parent of the
+// shell running on it.
+func foregroundProcessGroup(ps string, pid int, onTerminal bool) (int, error) {
+	for _, line := range strings.Split(ps, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		p, _ := strconv.Atoi(fields[0])
+		ppid, _ := strconv.Atoi(fields[1])
+		tpgid, _ := strconv.Atoi(fields[2])
+		if tpgid > 0 && ((onTerminal && p == pid) || (!onTerminal && ppid == pid)) {
+			return tpgid, nil
+		}
+	}
+	return 0, errors.New("failed to find the foreground process of the terminal")
+}
+
+// ExecuteWaitExit waits for the command launched by the last Enter (or
+// Script) to exit, which the shell marks before its next prompt, and checks
+// its exit status.
+func ExecuteWaitExit(c parser.Command, v *VHS) error {
+	timeout := v.Options.WaitTimeout
+	if c.Args != "" {
+		t, err := time.ParseDuration(c.Args)
+		if err != nil {
+			// Shouldn't be possible due to parse validation.
+			return fmt.Errorf("failed to parse duration: %w", err)
+		}
+		timeout = t
+	}
+
+	var exited <-chan struct{}
+	if v.native != nil {
+		exited = v.native.done
+	}
+	checkT := time.NewTicker(WaitTick)
+	defer checkT.Stop()
+	timeoutT := time.NewTimer(timeout)
+	defer timeoutT.Stop()
+
+	for {
+		state, err := v.ShellState()
+		if err != nil {
+			return err
+		}
+		if state.Exits > v.launched {
+			return checkExitStatus(c.Options, state.Status)
+		}
+
+		select {
+		case <-checkT.C:
+			continue
+		case <-v.limits.Done():
+			return v.limits.Err()
+		case <-exited:
+			return errors.New("the shell exited while waiting for the command")
+		case <-timeoutT.C:
+			return fmt.Errorf("timeout waiting for the command to exit after %s", timeout)
+		}
+	}
+}
+
+// checkExitStatus checks the exit status against the status= option of
+// WaitExit, if any.
+func checkExitStatus(options string, status int) error {
+	want, ok := strings.CutPrefix(options, "status=")
+	if !ok {
+		return nil
+	}
+	if n, _ := strconv.Atoi(want); n != status {
+		return fmt.Errorf("expected the command to exit with status %s, got %d", want, status)
+	}
+	return nil
+}
+
+// markLaunch remembers how many commands finished before a command is
+// launched, for WaitExit to wait for the next one.
+func (v *VHS) markLaunch() error {
+	state, err := v.ShellState()
+	if err != nil {
+		return err
+	}
+	v.launched = state.Exits
+	return nil
+}
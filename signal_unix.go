@@ -0,0 +1,30 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// signals maps the names accepted by Signal to their signals.
+var signals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGTSTP": syscall.SIGTSTP,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGCONT": syscall.SIGCONT,
+}
+
+func signalProcessGroup(pgrp int, name string) error {
+	sig, ok := signals[name]
+	if !ok {
+		return fmt.Errorf("unknown signal %s", name)
+	}
+	if err := syscall.Kill(-pgrp, sig); err != nil {
+		return fmt.Errorf("failed to send %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+import "errors"
+
+func signalProcessGroup(int, string) error {
+	return errors.New("signals are not supported on Windows")
+}
@@ -0,0 +1,248 @@
+//go:build !js
+
+// Package vhs stats.go reports what a recording costs.
+//
+// With --stats, the spans of the recording (see tracing.go) are collected
+// locally, whether or not they are exported, and summarized once it ends: the
+// frames captured and the unique states the SVG keeps of them, the time spent
+// in every phase, the outputs with the weight of the styles, keyframes and
+// text of SVGs, and the peak memory of VHS and of its child processes, e.g.
+// Chromium and ffmpeg. It tells why a tape produces a large SVG, and what to
+// tune.
+//
+// vhs demo.tape --stats
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// statsSpan is a span of the recording which ended.
+type statsSpan struct {
+	name     string
+	duration time.Duration
+	attrs    map[attribute.Key]attribute.Value
+}
+
+// statsCollector collects the spans of the recording.
+type statsCollector struct {
+	mu    sync.Mutex
+	spans []statsSpan
+}
+
+// setupStats collects the spans of the recording, with the tracer provider
+// exporting them if tracing is set up.
+func setupStats() *statsCollector {
+	provider, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	if !ok {
+		provider = sdktrace.NewTracerProvider()
+		otel.SetTracerProvider(provider)
+	}
+	stats := &statsCollector{}
+	provider.RegisterSpanProcessor(stats)
+	return stats
+}
+
+// OnStart implements sdktrace.SpanProcessor.
+func (*statsCollector) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd implements sdktrace.SpanProcessor.
+func (s *statsCollector) OnEnd(span sdktrace.ReadOnlySpan) {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spans = append(s.spans, statsSpan{
+		name:     span.Name(),
+		duration: span.EndTime().Sub(span.StartTime()),
+		attrs:    attrs,
+	})
+}
+
+// Shutdown implements sdktrace.SpanProcessor.
+func (*statsCollector) Shutdown(context.Context) error { return nil }
+
+// ForceFlush implements sdktrace.SpanProcessor.
+func (*statsCollector) ForceFlush(context.Context) error { return nil }
+
+// phase returns the total duration and the number of the spans of the name,
+// or of the prefix of names ending with a space.
+func (s *statsCollector) phase(name string) (time.Duration, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total time.Duration
+	var n int
+	for _, span := range s.spans {
+		if span.name == name || strings.HasSuffix(name, " ") && strings.HasPrefix(span.name, name) {
+			total += span.duration
+			n++
+		}
+	}
+	return total, n
+}
+
+// named returns the spans of the name, e.g. one per output.
+func (s *statsCollector) named(name string) []statsSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var spans []statsSpan
+	for _, span := range s.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// svgWeight is the size of the parts of an SVG, in bytes.
+type svgWeight struct {
+	Styles, Keyframes, Text, Other int
+}
+
+// weighSVG splits the size of the SVG into its style sheet, the keyframes of
+// its animations, its text and the rest, e.g. the window and backgrounds.
+func weighSVG(svg string) svgWeight {
+	var w svgWeight
+	rest := svg
+	for {
+		start := strings.Index(rest, "<style>")
+		end := strings.Index(rest, "</style>")
+		if start < 0 || end < start {
+			break
+		}
+		css := rest[start : end+len("</style>")]
+		w.Keyframes += keyframesSize(css)
+		w.Styles += len(css)
+		rest = rest[end+len("</style>"):]
+	}
+	w.Styles -= w.Keyframes
+
+	rest = svg
+	for {
+		start := strings.Index(rest, "<text")
+		end := strings.Index(rest, "</text>")
+		if start < 0 || end < start {
+			break
+		}
+		w.Text += end + len("</text>") - start
+		rest = rest[end+len("</text>"):]
+	}
+	w.Other = len(svg) - w.Styles - w.Keyframes - w.Text
+	return w
+}
+
+// keyframesSize returns the size of the @keyframes rules of the CSS.
+func keyframesSize(css string) int {
+	var size int
+	for {
+		start := strings.Index(css, "@keyframes")
+		if start < 0 {
+			return size
+		}
+		depth, end := 0, len(css)
+		for i := start; i < len(css); i++ {
+			if css[i] == '{' {
+				depth++
+			} else if css[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i + 1
+					break
+				}
+			}
+		}
+		size += end - start
+		css = css[end:]
+	}
+}
+
+// uniqueStates returns the number of states the SVG keeps of the frames.
+func uniqueStates(v *VHS) int {
+	cfg := svgConfig(v)
+	cfg.Debug = false
+	g := NewSVGGenerator(cfg)
+	g.processFrames()
+	return len(g.states)
+}
+
+// printStats writes the statistics of the recording.
+func printStats(out io.Writer, v *VHS, stats *statsCollector) {
+	_, _ = fmt.Fprintln(out, "Frames:")
+	_, _ = fmt.Fprintf(out, "  %-12s %d\n", "captured", v.totalFrames)
+	if v.idle.skipped > 0 {
+		_, _ = fmt.Fprintf(out, "  %-12s %d\n", "idle skipped", v.idle.skipped)
+	}
+	if len(v.svgFrames) > 0 {
+		_, _ = fmt.Fprintf(out, "  %-12s %d\n", "unique", uniqueStates(v))
+	}
+
+	_, _ = fmt.Fprintln(out, "\nPhases:")
+	for _, phase := range []struct{ label, name string }{
+		{"setup", "vhs.setup"},
+		{"commands", "vhs.command "},
+		{"capture", "vhs.capture"},
+		{"render", "vhs.render"},
+		{"encode", "vhs.encode"},
+		{"outputs", "vhs.render_output"},
+		{"total", "vhs.evaluate"},
+	} {
+		d, n := stats.phase(phase.name)
+		if n == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "  %-12s %s\n", phase.label, d.Round(time.Millisecond))
+		if phase.name == "vhs.capture" {
+			for _, span := range stats.named(phase.name) {
+				busy := time.Duration(span.attrs["vhs.capture.busy_ms"].AsInt64()) * time.Millisecond
+				_, _ = fmt.Fprintf(out, "    %d frames, %s capturing\n", span.attrs["vhs.frames"].AsInt64(), busy)
+			}
+		}
+		if phase.name == "vhs.encode" || phase.name == "vhs.render_output" {
+			for _, span := range stats.named(phase.name) {
+				_, _ = fmt.Fprintf(out, "    %-10s %s\n", span.duration.Round(time.Millisecond), span.attrs["vhs.output"].AsString())
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintln(out, "\nOutputs:")
+	outputs := v.Options.Video.Output
+	paths := []string{outputs.GIF, outputs.MP4, outputs.WebM}
+	for _, o := range outputs.renderedOutputs() {
+		paths = append(paths, o.Path)
+	}
+	for _, output := range paths {
+		if output == "" {
+			continue
+		}
+		data, err := os.ReadFile(output) //nolint:gosec
+		if err != nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "  %-10s %s\n", formatFileSize(int64(len(data))), output)
+		if strings.HasSuffix(output, ".svg") {
+			w := weighSVG(string(data))
+			_, _ = fmt.Fprintf(out, "    styles %s, keyframes %s, text %s, other %s\n",
+				formatFileSize(int64(w.Styles)), formatFileSize(int64(w.Keyframes)),
+				formatFileSize(int64(w.Text)), formatFileSize(int64(w.Other)))
+		}
+	}
+
+	self, children := peakMemory()
+	_, _ = fmt.Fprintln(out, "\nPeak memory:")
+	_, _ = fmt.Fprintf(out, "  %-12s %s\n", "vhs", formatFileSize(self))
+	if children > 0 {
+		_, _ = fmt.Fprintf(out, "  %-12s %s\n", "children", formatFileSize(children))
+	}
+}
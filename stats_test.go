@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestWeighSVG(t *testing.T) {
+	style := `<style>.f { fill: #fff; }@keyframes slide { 0% { transform: none; } 100% { transform: none; } }</style>`
+	text := `<text y="16"><tspan>hi</tspan></text>`
+	svg := `<svg>` + style + `<rect/>` + text + `</svg>`
+
+	w := weighSVG(svg)
+	keyframes := len(`@keyframes slide { 0% { transform: none; } 100% { transform: none; } }`)
+	if w.Keyframes != keyframes {
+		t.Errorf("expected %d bytes of keyframes, got %d", keyframes, w.Keyframes)
+	}
+	if w.Styles != len(style)-keyframes {
+		t.Errorf("expected %d bytes of styles, got %d", len(style)-keyframes, w.Styles)
+	}
+	if w.Text != len(text) {
+		t.Errorf("expected %d bytes of text, got %d", len(text), w.Text)
+	}
+	if w.Styles+w.Keyframes+w.Text+w.Other != len(svg) {
+		t.Errorf("expected the parts to add up to %d bytes, got %+v", len(svg), w)
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	stats := &statsCollector{}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(stats))
+	tracer := provider.Tracer("test")
+	svg := filepath.Join(t.TempDir(), "out.svg")
+	for _, name := range []string{"vhs.command TYPE", "vhs.command ENTER", "vhs.evaluate"} {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+	_, span := tracer.Start(context.Background(), "vhs.render_output")
+	span.SetAttributes(attribute.String("vhs.output", svg))
+	span.End()
+	if _, n := stats.phase("vhs.command "); n != 2 {
+		t.Errorf("expected the commands to add up, got %d", n)
+	}
+
+	v := New()
+	v.Options.Video.Output.SVG = svg
+	v.svgFrames = []SVGFrame{{Lines: []string{"a"}}, {Lines: []string{"a"}}, {Lines: []string{"b"}}}
+	v.totalFrames = len(v.svgFrames)
+	if err := os.WriteFile(svg, []byte(`<svg><text>a</text></svg>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	printStats(&out, &v, stats)
+	for _, want := range []string{"captured     3", "unique       2", "commands", "outputs", "text 14B", "Peak memory"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the stats, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package main
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakMemory returns the peak resident memory of VHS and of its largest
+// child process, in bytes.
+func peakMemory() (int64, int64) {
+	return maxRSS(syscall.RUSAGE_SELF), maxRSS(syscall.RUSAGE_CHILDREN)
+}
+
+// maxRSS returns the peak resident memory of the processes, which Linux and
+// the BSDs count in kilobytes and macOS in bytes.
+func maxRSS(who int) int64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(who, &usage); err != nil {
+		return 0
+	}
+	if runtime.GOOS == "darwin" {
+		return int64(usage.Maxrss)
+	}
+	return int64(usage.Maxrss) << 10 //nolint:mnd
+}
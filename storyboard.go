@@ -0,0 +1,210 @@
+//go:build !js
+
+// Package vhs storyboard.go lays out frames of the recording on the pages of
+// a PDF, for design reviews and printed docs.
+//
+// The storyboard shows the last frame of every scene, with the name of the
+// scene and its timestamp. Without scenes, it shows the last frame of every
+// interval of the recording instead, the interval being chosen for the
+// storyboard to fit on a page.
+//
+// Output storyboard.pdf
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Layout of the storyboard, in points.
+const (
+	storyboardPageWidth  = 842 // A4, landscape
+	storyboardPageHeight = 595
+	storyboardMargin     = 36
+	storyboardGap        = 18
+	storyboardPadding    = 8
+	storyboardColumns    = 2
+	storyboardCaption    = 16
+	storyboardLineHeight = 1.2
+)
+
+// storyboardMaxPanels is the number of panels of a storyboard without scenes.
+const storyboardMaxPanels = 12
+
+// storyboardPanel is a frame shown on the storyboard.
+type storyboardPanel struct {
+	Title string
+	Frame SVGFrame
+}
+
+// storyboardPanels selects the frames of the storyboard: the last frame of
+// every scene or, without scenes, the last frame of every interval of the
+// recording. Panels which show the same text as the previous one are dropped.
+func storyboardPanels(frames []SVGFrame, scenes []Scene, framerate int) []storyboardPanel {
+	if len(frames) == 0 || framerate <= 0 {
+		return nil
+	}
+
+	// bucket returns the index of the scene or interval of the frame, or -1
+	// if it was captured before the first scene.
+	var bucket func(frame SVGFrame) int
+	if len(scenes) > 0 {
+		bucket = func(frame SVGFrame) int {
+			counter := int(math.Round(frame.Timestamp * float64(framerate)))
+			next := slices.IndexFunc(scenes, func(s Scene) bool { return s.Frame > counter })
+			if next < 0 {
+				next = len(scenes)
+			}
+			return next - 1
+		}
+	} else {
+		interval := math.Max(1, math.Ceil(frames[len(frames)-1].Timestamp/storyboardMaxPanels))
+		bucket = func(frame SVGFrame) int {
+			return max(0, int(math.Ceil(frame.Timestamp/interval))-1)
+		}
+	}
+
+	var panels []storyboardPanel
+	last := -1
+	for _, frame := range frames {
+		b := bucket(frame)
+		if b < 0 {
+			// The frame was captured before the first scene.
+			continue
+		}
+		if b != last {
+			panels = append(panels, storyboardPanel{})
+			last = b
+		}
+		panels[len(panels)-1].Frame = frame
+		if len(scenes) > 0 {
+			panels[len(panels)-1].Title = scenes[b].Name
+		}
+	}
+
+	return slices.CompactFunc(panels, func(a, b storyboardPanel) bool {
+		return slices.Equal(a.Frame.Lines, b.Frame.Lines)
+	})
+}
+
+// MakeStoryboard writes the storyboard, if requested.
+func MakeStoryboard(v *VHS) error {
+	return renderOutput(v, pdf, v.Options.Video.Output.PDF)
+}
+
+// storyboardRenderer renders PDF storyboards.
+type storyboardRenderer struct{}
+
+// Render implements Renderer.
+func (storyboardRenderer) Render(w io.Writer, frames []SVGFrame, _ SVGConfig, theme Theme, timeline Timeline) error {
+	panels := storyboardPanels(frames, timeline.Scenes, timeline.Framerate)
+	if len(panels) == 0 {
+		return nil
+	}
+	_, err := w.Write(storyboardDocument(panels, theme).Bytes())
+	return err //nolint:wrapcheck
+}
+
+// storyboardDocument lays out the panels in a grid, as many rows per page as
+// fit. The font size is chosen for the terminal to fill the width of a panel.
+func storyboardDocument(panels []storyboardPanel, theme Theme) *pdfDocument {
+	cols, rows := 1, 1
+	for _, p := range panels {
+		cols = max(cols, p.Frame.Cols, maxLineWidth(p.Frame.Lines))
+		rows = max(rows, p.Frame.Rows, len(p.Frame.Lines))
+	}
+
+	panelWidth := (storyboardPageWidth - 2*storyboardMargin - (storyboardColumns-1)*storyboardGap) / float64(storyboardColumns)
+	charWidth := (panelWidth - 2*storyboardPadding) / float64(cols)
+	fontSize := charWidth / pdfCourierAdvance
+	lineHeight := fontSize * storyboardLineHeight
+	panelHeight := float64(rows)*lineHeight + 2*storyboardPadding
+	cellHeight := panelHeight + storyboardCaption
+	rowsPerPage := max(1, int((storyboardPageHeight-2*storyboardMargin+storyboardGap)/(cellHeight+storyboardGap)))
+
+	doc := newPDFDocument(storyboardPageWidth, storyboardPageHeight)
+	var page *pdfPage
+	for i, panel := range panels {
+		slot := i % (rowsPerPage * storyboardColumns)
+		if slot == 0 {
+			page = doc.addPage()
+		}
+		x := storyboardMargin + float64(slot%storyboardColumns)*(panelWidth+storyboardGap)
+		y := storyboardMargin + float64(slot/storyboardColumns)*(cellHeight+storyboardGap)
+		drawStoryboardPanel(page, panel.Frame, theme, x, y, panelWidth, panelHeight, fontSize)
+
+		caption := fmt.Sprintf("%d. %s", i+1, formatStoryboardTime(panel.Frame.Timestamp))
+		if panel.Title != "" {
+			caption = fmt.Sprintf("%d. %s  %s", i+1, panel.Title, formatStoryboardTime(panel.Frame.Timestamp))
+		}
+		page.text(x, y+panelHeight+storyboardCaption-4, pdfHelvetica, 9, color.RGBA{0x55, 0x55, 0x55, 0xff}, caption)
+	}
+	return doc
+}
+
+// drawStoryboardPanel draws the text of the frame on the background of the
+// theme.
+func drawStoryboardPanel(page *pdfPage, frame SVGFrame, theme Theme, x, y, w, h, fontSize float64) {
+	background := parseHexColorOr(theme.Background, "#000000")
+	foreground := parseHexColorOr(theme.Foreground, "#ffffff")
+	page.fillRect(x, y, w, h, background)
+
+	charWidth := fontSize * pdfCourierAdvance
+	lineHeight := fontSize * storyboardLineHeight
+	for row, line := range frame.Lines {
+		var styles []CharStyle
+		if row < len(frame.LineColors) {
+			styles = frame.LineColors[row]
+		}
+		top := y + storyboardPadding + float64(row)*lineHeight
+		baseline := top + (lineHeight+fontSize*0.7)/2
+		for _, run := range splitRuns(line, styles) {
+			style := run.Style
+			fg, bg := style.FgColor, style.BgColor
+			if style.Inverse {
+				fg, bg = cmp.Or(bg, theme.Background), cmp.Or(fg, theme.Foreground)
+			}
+
+			left := x + storyboardPadding + float64(run.Col)*charWidth
+			width := float64(run.Width) * charWidth
+			if bg != "" {
+				page.fillRect(left, top, width, lineHeight, parseHexColorOr(bg, theme.Background))
+			}
+			if strings.TrimSpace(run.Text) == "" {
+				continue
+			}
+			c := foreground
+			if fg != "" {
+				c = parseHexColorOr(fg, theme.Foreground)
+			}
+			font := pdfCourier
+			if style.Bold {
+				font = pdfCourierBold
+			}
+			page.text(left, baseline, font, fontSize, c, run.Text)
+			if style.Underline {
+				page.fillRect(left, baseline+fontSize*0.1, width, fontSize*0.05, c)
+			}
+		}
+	}
+}
+
+// maxLineWidth returns the number of cells of the widest line.
+func maxLineWidth(lines []string) int {
+	width := 0
+	for _, line := range lines {
+		width = max(width, cellWidth(line))
+	}
+	return width
+}
+
+// formatStoryboardTime formats a timestamp as minutes and seconds.
+func formatStoryboardTime(seconds float64) string {
+	minutes := int(seconds) / 60
+	return fmt.Sprintf("%d:%04.1f", minutes, seconds-float64(minutes*60))
+}
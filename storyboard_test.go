@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestStoryboardPanels(t *testing.T) {
+	frame := func(ts float64, line string) SVGFrame {
+		return SVGFrame{Timestamp: ts, Lines: []string{line}}
+	}
+	frames := []SVGFrame{
+		frame(0.1, "> "), frame(0.2, "> ls"),
+		frame(0.3, "> make"), frame(0.4, "ok"),
+		frame(0.5, "ok"), frame(0.6, "> exit"),
+	}
+
+	scenes := []Scene{{Name: "Build", Frame: 3}, {Name: "Idle", Frame: 5}, {Name: "Exit", Frame: 6}}
+	panels := storyboardPanels(frames, scenes, 10)
+	var got []string
+	for _, p := range panels {
+		got = append(got, p.Title+"="+p.Frame.Lines[0])
+	}
+	// Idle shows the same text as Build and the frames before the first
+	// scene are left out.
+	if want := "[Build=ok Exit=> exit]"; fmt.Sprint(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// Without scenes, the last frame of every second is shown.
+	frames = []SVGFrame{frame(0.5, "a"), frame(1, "b"), frame(1.5, "c"), frame(2.5, "d")}
+	got = nil
+	for _, p := range storyboardPanels(frames, nil, 10) {
+		got = append(got, p.Frame.Lines[0])
+	}
+	if want := "[b c d]"; fmt.Sprint(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestSplitRuns(t *testing.T) {
+	red := CharStyle{FgColor: "#ff0000", Width: 1}
+	runs := splitRuns("ab日c", []CharStyle{red, red, {Width: 2}, {}, red})
+	want := []textRun{
+		{Text: "ab", Col: 0, Width: 2, Style: CharStyle{FgColor: "#ff0000"}},
+		{Text: "日", Col: 2, Width: 2},
+		{Text: "c", Col: 4, Width: 1, Style: CharStyle{FgColor: "#ff0000"}},
+	}
+	if fmt.Sprint(runs) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, runs)
+	}
+}
+
+func TestStoryboardDocument(t *testing.T) {
+	var panels []storyboardPanel
+	for i := range 7 {
+		panels = append(panels, storyboardPanel{
+			Title: "Scene (1)",
+			Frame: SVGFrame{Lines: []string{"> echo │ done"}, Cols: 80, Rows: 24, Timestamp: float64(i)},
+		})
+	}
+	b := storyboardDocument(panels, DefaultTheme).Bytes()
+
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF document")
+	}
+	// Two rows of two panels of 80x24 fit on a page.
+	if !bytes.Contains(b, []byte("/Count 2 ")) {
+		t.Error("expected 2 pages")
+	}
+	if !bytes.Contains(b, []byte(`(7. Scene \(1\)  0:06.0) Tj`)) || !bytes.Contains(b, []byte("(> echo | done) Tj")) {
+		t.Error("expected escaped captions and approximated box drawing characters")
+	}
+
+	// Every object of the cross-reference table is where it says.
+	start := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(b)
+	xref, _ := strconv.Atoi(string(start[1]))
+	for i, m := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(b[xref:], -1) {
+		offset, _ := strconv.Atoi(string(m[1]))
+		if !bytes.HasPrefix(b[offset:], fmt.Appendf(nil, "%d 0 obj", i+1)) {
+			t.Errorf("expected object %d at offset %d", i+1, offset)
+		}
+	}
+}
@@ -60,21 +60,22 @@ var (
 
 // StyleOptions represents the ui options for video and screenshots.
 type StyleOptions struct {
-	Width               int
-	Height              int
-	Padding             int
-	BackgroundColor     string
-	MarginFill          string
-	Margin              int
-	WindowBar           string
-	WindowBarSize       int
-	WindowBarColor      string
-	WindowBarTitle      string
-	BorderRadius        int
-	FontFamily          string // Font family passed from VHS options
-	FontSize            int    // Font size passed from VHS options
-	WindowBarFontFamily string // Font family specifically for window bar title
-	WindowBarFontSize   int    // Font size specifically for window bar title
+	Width               int    `json:"width"`
+	Height              int    `json:"height"`
+	Padding             int    `json:"padding"`
+	BackgroundColor     string `json:"backgroundColor"`
+	MarginFill          string `json:"marginFill"`
+	Margin              int    `json:"margin"`
+	WindowBar           string `json:"windowBar"`
+	WindowBarSize       int    `json:"windowBarSize"`
+	WindowBarColor      string `json:"windowBarColor"`
+	WindowBarTitle      string `json:"windowBarTitle"`
+	BorderRadius        int    `json:"borderRadius"`
+	FontFamily          string `json:"fontFamily"`          // Font family passed from VHS options
+	FontSize            int    `json:"fontSize"`            // Font size passed from VHS options
+	WindowBarFontFamily string `json:"windowBarFontFamily"` // Font family specifically for window bar title
+	WindowBarFontSize   int    `json:"windowBarFontSize"`   // Font size specifically for window bar title
+	WindowBarTitleColor string `json:"windowBarTitleColor"` // Color of the window bar title
 }
 
 // DefaultStyleOptions returns default Style config.
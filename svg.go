@@ -1,13 +1,14 @@
 package main
 
 import (
+	"cmp"
 	"crypto/md5" //nolint:gosec // MD5 is used for deduplication, not security
 	"fmt"
 	"html"
 	"log"
+	"net/url"
+	"strconv"
 	"strings"
-
-	"github.com/go-rod/rod"
 )
 
 // Default colors used throughout SVG generation.
@@ -21,52 +22,107 @@ const (
 	// Style constants.
 	nilValue                = "<nil>"
 	nullValue               = "null"
-	fontWeightBold          = "font-weight:bold;"
-	fontStyleItalic         = "font-style:italic;"
-	textDecorationUnderline = "text-decoration:underline;"
 	svgDefaultFontFamily    = "monospace"
+
+	// cursorReverse is the cursor color inverting the cell under the cursor.
+	cursorReverse = "reverse"
 )
 
+// textAttribute is a text attribute rendered with a CSS class.
+type textAttribute struct {
+	class string
+	css   string
+	has   func(CharStyle) bool
+}
+
+// textAttributes are the text attributes of SVG outputs, in the order of
+// their classes.
+var textAttributes = []textAttribute{
+	{"b", "font-weight: bold;", func(s CharStyle) bool { return s.Bold }},
+	{"i", "font-style: italic;", func(s CharStyle) bool { return s.Italic }},
+	{"u", "text-decoration: underline;", func(s CharStyle) bool { return s.Underline }},
+	{"s", "text-decoration: line-through;", func(s CharStyle) bool { return s.Strikethrough }},
+	{"d", "fill-opacity: 0.5;", func(s CharStyle) bool { return s.Dim }},
+}
+
 // Window control colors (macOS-style).
 var windowControlColors = []string{"#ff5f58", "#ffbd2e", "#18c132"}
 
 // SVGFrame represents a single frame in the SVG animation.
 type SVGFrame struct {
-	Lines      []string
-	LineColors [][]CharStyle // Color/style info for each character on each line
-	CursorX    int
-	CursorY    int
-	Timestamp  float64
-	CharWidth  float64
-	CharHeight float64
-	CursorChar string // The cursor character (e.g., '█' for block)
+	Lines      []string      `json:"lines"`
+	LineColors [][]CharStyle `json:"lineColors,omitempty"` // Color/style info for each character on each line
+	CursorX    int           `json:"cursorX"`
+	CursorY    int           `json:"cursorY"`
+	Timestamp  float64       `json:"timestamp"` // Seconds since the start of the recording; the animation follows them
+	CharWidth  float64       `json:"charWidth,omitempty"`
+	CharHeight float64       `json:"charHeight,omitempty"`
+	CursorChar string        `json:"cursorChar,omitempty"` // The cursor character (e.g., '█' for block)
+	Cols       int           `json:"cols,omitempty"`       // Terminal dimensions, which change with Resize
+	Rows       int           `json:"rows,omitempty"`
+	Image      string        `json:"image,omitempty"`     // PNG data URL of inline images (Sixel, iTerm2), if any
+	Selection  *Selection    `json:"selection,omitempty"` // Highlighted cells, if any
 }
 
 // CharStyle represents the style of a character.
 type CharStyle struct {
-	FgColor   string
-	BgColor   string
-	Bold      bool
-	Italic    bool
-	Underline bool
+	FgColor       string `json:"fg,omitempty"`
+	BgColor       string `json:"bg,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Dim           bool   `json:"dim,omitempty"`
+	Inverse       bool   `json:"inverse,omitempty"` // Swaps the foreground and background colors
+	Link          string `json:"link,omitempty"`    // OSC 8 hyperlink target, if any
+	Width         int    `json:"width"`             // Cells occupied by the character, 0 for the second half of a wide character
 }
 
 // SVGConfig contains the full configuration for SVG generation.
 type SVGConfig struct {
-	Width         int
-	Height        int
-	FontSize      int
-	FontFamily    string
-	Theme         Theme
-	Frames        []SVGFrame
-	Duration      float64
-	Style         *StyleOptions // Include all style options
-	LineHeight    float64
-	CursorBlink   bool
-	PlaybackSpeed float64
-	LoopOffset    float64
-	OptimizeSize  bool // Enable size optimizations for smaller output
-	Debug         bool // Enable debug logging
+	Width          int
+	Height         int
+	FontSize       int
+	FontFamily     string
+	Theme          Theme
+	Frames         []SVGFrame
+	Duration       float64       // Minimum duration in seconds; frame timestamps may extend it
+	Style          *StyleOptions // Include all style options
+	LineHeight     float64
+	LetterSpacing  float64
+	CursorBlink    bool
+	PlaybackSpeed  float64
+	LoopOffset     float64
+	OptimizeSize   bool             // Enable size optimizations for smaller output
+	Debug          bool             // Enable debug logging
+	SceneTimes     []float64        // Start time (in seconds) of every scene after the first
+	Transition     Transition       // Transition rendered between scenes
+	UnderlineLinks bool             // Underline OSC 8 hyperlinks
+	Transcript     []string         // Full scrollback shown below the animation
+	NoLigatures    bool             // Position every character on the terminal grid instead of shaping ligatures
+	Camera         []CameraKeyframe // Keyframes of the virtual camera (Zoom and Pan), by frame index
+	Annotations    []Annotation     // Callouts overlaid on the terminal
+	Watermark      *Watermark       // Logo or text shown above the animation
+	Description    string           // Accessible description of the recording
+	// AccessibleTranscript is the text of the terminal, read by screen
+	// readers but not shown.
+	AccessibleTranscript []string
+	// OptimizeLevel runs the optimizer over the SVG from level 2, see
+	// optimizeSVG.
+	OptimizeLevel int
+	// CursorColor overrides the color of the cursor, or inverts the cell
+	// under it if reverse, and CursorAccentColor draws the text under it.
+	CursorColor       string
+	CursorAccentColor string
+	// CursorTween slides the cursor between the positions of the states,
+	// see takeTweenCursors.
+	CursorTween bool
+	// DarkTheme replaces the theme for viewers who prefer a dark color
+	// scheme, if set.
+	DarkTheme *Theme
+	// SyntaxHighlight highlights the lines without colors as the language,
+	// see highlightSyntax.
+	SyntaxHighlight string
 }
 
 // TerminalState represents a unique terminal state for deduplication.
@@ -79,6 +135,10 @@ type TerminalState struct {
 	IsCursorActive bool    // Whether cursor moved from previous state
 	CursorIdleTime float64 // Time since last cursor movement in seconds
 	CursorChar     string  // The cursor character (e.g., '█' for block)
+	Cols           int     // Terminal dimensions, which change with Resize
+	Rows           int
+	Image          string     // PNG data URL of inline images, if any
+	Selection      *Selection // Highlighted cells, if any
 }
 
 // KeyframeStop represents a point in the animation timeline.
@@ -121,8 +181,9 @@ type FramePattern struct {
 // SVGGenerator handles the generation of optimized animated SVG files.
 type SVGGenerator struct {
 	options             SVGConfig
-	charWidth           float64
-	charHeight          float64
+	charWidth           float64 // Cell width, including the letter spacing
+	charHeight          float64 // Cell height, including the line height
+	glyphHeight         float64 // Height of the glyphs, centered in the cell
 	fontSize            float64
 	states              []TerminalState // Unique terminal states
 	stateMap            map[string]int  // Hash -> state index
@@ -132,6 +193,10 @@ type SVGGenerator struct {
 	prevCursorX         int             // Previous cursor X position for activity detection
 	prevCursorY         int             // Previous cursor Y position for activity detection
 	cursorIdleThreshold float64         // Time threshold before cursor starts blinking (seconds)
+	imageIDs            map[string]string // Inline image data URL -> defs id
+	tweenCursors        []tweenCursor     // Cursor of every state, if CursorTween
+	frameTimes          []float64         // Start of every frame, in percent of the duration
+	duration            float64           // Duration of the frames, in seconds
 	// Class names (shorter when OptimizeSize is enabled)
 	textClass         string
 	cursorActiveClass string
@@ -140,14 +205,25 @@ type SVGGenerator struct {
 
 // NewSVGGenerator creates a new SVG generator.
 func NewSVGGenerator(opts SVGConfig) *SVGGenerator {
-	// Get character dimensions from the first frame if available
-	charWidth := float64(opts.FontSize) * 0.55 // fallback
-	charHeight := float64(opts.FontSize) * 1.2 // fallback
+	if opts.SyntaxHighlight != "" {
+		opts.Frames = highlightSyntax(opts.Frames, opts.SyntaxHighlight, opts.Theme)
+	}
+	lineHeight := opts.LineHeight
+	if lineHeight <= 0 {
+		lineHeight = 1.0
+	}
+
+	// Get cell dimensions from the first frame if available
+	glyphHeight := float64(opts.FontSize) * 1.2 // fallback
+	charWidth := float64(opts.FontSize)*0.55 + opts.LetterSpacing
+	charHeight := glyphHeight * lineHeight
 
 	if len(opts.Frames) > 0 && opts.Frames[0].CharWidth > 0 {
-		// Use actual dimensions from xterm.js
+		// Use actual dimensions from xterm.js, whose cells already include
+		// the letter spacing and line height
 		charWidth = opts.Frames[0].CharWidth
 		charHeight = opts.Frames[0].CharHeight
+		glyphHeight = charHeight / lineHeight
 	}
 
 	// Get style for calculating frame spacing
@@ -173,6 +249,7 @@ func NewSVGGenerator(opts SVGConfig) *SVGGenerator {
 		options:             opts,
 		charWidth:           charWidth,
 		charHeight:          charHeight,
+		glyphHeight:         glyphHeight,
 		stateMap:            make(map[string]int),
 		frameSpacing:        float64(innerWidth), // Frame spacing matches inner terminal width
 		prevCursorX:         -1,                  // Initialize to -1 to detect first frame
@@ -198,6 +275,7 @@ func (g *SVGGenerator) Generate() string {
 
 	// Process frames to extract unique states
 	g.processFrames()
+	g.takeTweenCursors()
 
 	// Calculate fontSize early so it's available for symbol generation
 	g.fontSize = float64(g.options.FontSize)
@@ -215,10 +293,21 @@ func (g *SVGGenerator) Generate() string {
 		totalHeight += style.Margin * 2
 	}
 
-	// SVG root element
-	sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`,
-		totalWidth, totalHeight))
+	// The transcript panel is as tall as the terminal window
+	animationHeight := totalHeight
+	if len(g.options.Transcript) > 0 {
+		totalHeight += style.Height
+	}
+
+	// SVG root element, declaring xlink for hyperlinks
+	xlink := ""
+	if g.hasLinks() {
+		xlink = ` xmlns:xlink="http://www.w3.org/1999/xlink"`
+	}
+	sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg"%s width="%d" height="%d"%s>`,
+		xlink, totalWidth, totalHeight, g.ariaAttributes()))
 	g.writeNewline(&sb)
+	g.generateAccessibility(&sb)
 
 	// Add margin group if needed
 	if style.Margin > 0 {
@@ -264,6 +353,9 @@ func (g *SVGGenerator) Generate() string {
 	sb.WriteString(fmt.Sprintf(`<svg x="%d" y="%d" width="%d" height="%d" viewBox="0 0 %s %s">`,
 		innerX, innerY, innerWidth, innerHeight, formatCoord(viewBoxWidth), formatCoord(viewBoxHeight)))
 	g.writeNewline(&sb)
+	if len(g.options.Camera) > 0 && len(g.options.Frames) > 0 {
+		g.generateCamera(&sb, viewBoxWidth, viewBoxHeight)
+	}
 
 	// Add terminal background
 	terminalBgColor := g.options.Theme.Background
@@ -281,9 +373,17 @@ func (g *SVGGenerator) Generate() string {
 	sb.WriteString("<defs>")
 	g.writeNewline(&sb)
 	sb.WriteString(g.generateCursorSymbols())
+	sb.WriteString(g.generateImageDefs())
 	sb.WriteString("</defs>")
 	g.writeNewline(&sb)
 
+	// Scene transitions fade the whole animation in and out
+	hasSceneTransitions := g.hasSceneTransitions()
+	if hasSceneTransitions {
+		sb.WriteString(`<g class="scenes">`)
+		g.writeNewline(&sb)
+	}
+
 	// Animation container without additional clipping (viewBox handles it)
 	sb.WriteString(`<g class="animation-container">`)
 	g.writeNewline(&sb)
@@ -309,6 +409,12 @@ func (g *SVGGenerator) Generate() string {
 
 	sb.WriteString("</g>") // Close animation container
 	g.writeNewline(&sb)
+	g.generateTweenCursor(&sb)
+	if hasSceneTransitions {
+		sb.WriteString("</g>") // Close scenes group
+		g.writeNewline(&sb)
+	}
+	g.generateAnnotations(&sb)
 	sb.WriteString("</svg>") // Close inner SVG
 	g.writeNewline(&sb)
 
@@ -318,6 +424,13 @@ func (g *SVGGenerator) Generate() string {
 		g.writeNewline(&sb)
 	}
 
+	g.generateWatermark(&sb, totalWidth, animationHeight)
+
+	if len(g.options.Transcript) > 0 {
+		sb.WriteString(g.generateTranscript(animationHeight, totalWidth, style.Height, style.Padding))
+	}
+	g.generateAccessibleTranscript(&sb)
+
 	sb.WriteString("</svg>")
 	g.writeNewline(&sb)
 
@@ -326,6 +439,8 @@ func (g *SVGGenerator) Generate() string {
 
 // processFrames deduplicates frames and builds timeline.
 func (g *SVGGenerator) processFrames() {
+	g.timeFrames()
+
 	// First, detect patterns for optimization
 	g.detectPatterns()
 	
@@ -354,10 +469,14 @@ func (g *SVGGenerator) processFrames() {
 		// Create state from frame
 		state := TerminalState{
 			Lines:      frame.Lines,
-			LineColors: frame.LineColors,
+			LineColors: g.resolveInverse(frame.LineColors),
 			CursorX:    frame.CursorX,
 			CursorY:    frame.CursorY,
 			CursorChar: frame.CursorChar,
+			Cols:       frame.Cols,
+			Rows:       frame.Rows,
+			Image:      frame.Image,
+			Selection:  frame.Selection,
 		}
 
 		// Detect cursor activity
@@ -406,7 +525,7 @@ func (g *SVGGenerator) processFrames() {
 			// Reuse existing state - only add to timeline if state changed
 			if idx != lastStateIndex {
 				g.timeline = append(g.timeline, KeyframeStop{
-					Percentage: float64(i) / float64(len(g.options.Frames)-1) * 100,
+					Percentage: g.framePercentage(i),
 					StateIndex: idx,
 				})
 				lastStateIndex = idx
@@ -433,7 +552,7 @@ func (g *SVGGenerator) processFrames() {
 			}
 
 			g.timeline = append(g.timeline, KeyframeStop{
-				Percentage: float64(i) / float64(len(g.options.Frames)-1) * 100,
+				Percentage: g.framePercentage(i),
 				StateIndex: idx,
 			})
 			lastStateIndex = idx
@@ -512,16 +631,20 @@ func (g *SVGGenerator) hashState(state *TerminalState) string {
 		// Include color information in hash
 		if i < len(state.LineColors) {
 			for _, style := range state.LineColors[i] {
-				_, _ = fmt.Fprintf(h, "%s,%s,%t,%t,%t|",
-					style.FgColor, style.BgColor, style.Bold, style.Italic, style.Underline)
+				_, _ = fmt.Fprintf(h, "%s,%s,%t,%t,%t,%s|",
+					style.FgColor, style.BgColor, style.Bold, style.Italic, style.Underline, style.Link)
 			}
 		}
 		h.Write([]byte("\n"))
 	}
 	// Include cursor position and activity state in hash for accuracy
 	// Only include active/idle status, not exact idle time to allow deduplication
-	_, _ = fmt.Fprintf(h, "%d,%d,%v",
-		state.CursorX, state.CursorY, state.IsCursorActive)
+	_, _ = fmt.Fprintf(h, "%d,%d,%v,%dx%d",
+		state.CursorX, state.CursorY, state.IsCursorActive, state.Cols, state.Rows)
+	h.Write([]byte(state.Image))
+	if s := state.Selection; s != nil {
+		_, _ = fmt.Fprintf(h, "%d,%d-%d,%d", s.StartRow, s.StartCol, s.EndRow, s.EndCol)
+	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
@@ -886,7 +1009,7 @@ func abs(x int) int {
 // generateTypingCSS generates CSS animation for a typing pattern.
 func (g *SVGGenerator) generateTypingCSS(sb *strings.Builder, index int, pattern FramePattern) {
 	// Calculate the width of the typed text
-	textWidth := float64(len(pattern.Text)) * g.charWidth
+	textWidth := float64(cellWidth(pattern.Text)) * g.charWidth
 	duration := pattern.EndTime - pattern.StartTime
 	
 	// Generate the keyframe animation
@@ -909,7 +1032,7 @@ func (g *SVGGenerator) generateTypingCSS(sb *strings.Builder, index int, pattern
 	sb.WriteString("  display: inline-block;")
 	g.writeNewline(sb)
 	fmt.Fprintf(sb, "  animation: typing_%d %ss steps(%d, end) forwards;",
-		index, formatDuration(duration), len(pattern.Text))
+		index, formatDuration(duration), graphemeCount(pattern.Text))
 	g.writeNewline(sb)
 	fmt.Fprintf(sb, "  animation-delay: %ss;", formatDuration(pattern.StartTime))
 	g.writeNewline(sb)
@@ -921,7 +1044,7 @@ func (g *SVGGenerator) generateTypingCSS(sb *strings.Builder, index int, pattern
 // generateBackspaceCSS generates CSS animation for a backspace pattern.
 func (g *SVGGenerator) generateBackspaceCSS(sb *strings.Builder, index int, pattern FramePattern) {
 	// Calculate the width of the deleted text
-	startWidth := float64(len(pattern.DeletedText)) * g.charWidth
+	startWidth := float64(cellWidth(pattern.DeletedText)) * g.charWidth
 	duration := pattern.EndTime - pattern.StartTime
 	
 	// Generate the keyframe animation (reverse of typing)
@@ -992,24 +1115,7 @@ func (g *SVGGenerator) generateStyles() string {
 	// Animation container style
 	sb.WriteString(".animation-container {")
 	g.writeNewline(&sb)
-	// Apply playback speed to animation duration
-	animationDuration := g.options.Duration
-	if g.options.PlaybackSpeed > 0 {
-		animationDuration = g.options.Duration / g.options.PlaybackSpeed
-	}
-
-	// Calculate animation delay based on LoopOffset
-	animationDelay := 0.0
-	if g.options.LoopOffset > 0 {
-		// LoopOffset can be a percentage (0-100) or frame number
-		if g.options.LoopOffset <= 1.0 {
-			// Treat as percentage
-			animationDelay = -animationDuration * g.options.LoopOffset
-		} else {
-			// Treat as frame number
-			animationDelay = -(g.options.LoopOffset / float64(len(g.options.Frames))) * animationDuration
-		}
-	}
+	animationDuration, animationDelay := g.animationTiming()
 
 	// Use step-end timing to ensure frames change instantly
 	sb.WriteString(fmt.Sprintf("  animation: slide %ss step-end %ss infinite;", formatDuration(animationDuration), formatDuration(animationDelay)))
@@ -1018,6 +1124,14 @@ func (g *SVGGenerator) generateStyles() string {
 	g.writeNewline(&sb)
 	g.writeNewline(&sb)
 
+	if g.hasSceneTransitions() {
+		g.generateSceneTransitionCSS(&sb, animationDuration, animationDelay)
+	}
+	if len(g.options.Annotations) > 0 {
+		g.generateAnnotationCSS(&sb, animationDuration, animationDelay)
+	}
+	g.generateCursorTweenCSS(&sb, animationDuration, animationDelay)
+
 	// Terminal styles
 	theme := g.options.Theme
 
@@ -1043,39 +1157,46 @@ func (g *SVGGenerator) generateStyles() string {
 		foregroundColor = defaultForegroundColor
 	}
 	// Use a simpler font stack for better compatibility
-	textStyle := fmt.Sprintf("fill: %s; font-family: %s, monospace; font-size: %spx;",
-		foregroundColor, fontFamily, formatCoord(g.fontSize))
-	// Don't apply letter-spacing in SVG as it causes cursor misalignment
-	// The character positions from xterm.js already account for the terminal's letter spacing
+	textStyle := fmt.Sprintf("fill: %s; font-family: %s; font-size: %spx;",
+		foregroundColor, fontStack(fontFamily), formatCoord(g.fontSize))
+	if g.options.NoLigatures {
+		textStyle += " font-variant-ligatures: none;"
+	}
+	// Text flowing from the start of a segment advances by the glyph width
+	// plus the letter spacing, like the cells of the terminal
+	if g.options.LetterSpacing != 0 {
+		textStyle += fmt.Sprintf(" letter-spacing: %spx;", formatCoord(g.options.LetterSpacing))
+	}
 	sb.WriteString(fmt.Sprintf(".%s { %s }", textClass, textStyle))
 	g.writeNewline(&sb)
 
-	// Add ANSI color classes - use a map to avoid duplicates
-	colorClasses := map[string]string{
-		"black":   theme.Black,
-		"red":     theme.Red,
-		"green":   theme.Green,
-		"yellow":  theme.Yellow,
-		"blue":    theme.Blue,
-		"magenta": theme.Magenta,
-		"cyan":    theme.Cyan,
-		"white":   theme.White,
+	// Add ANSI color classes, in a fixed order so that the output is
+	// reproducible
+	colorClasses := [][2]string{
+		{"black", theme.Black},
+		{"red", theme.Red},
+		{"green", theme.Green},
+		{"yellow", theme.Yellow},
+		{"blue", theme.Blue},
+		{"magenta", theme.Magenta},
+		{"cyan", theme.Cyan},
+		{"white", theme.White},
 	}
 
 	if g.options.OptimizeSize {
 		// Use single-letter class names for colors
-		shortColorClasses := map[string]string{
-			"k": theme.Black, // blacK
-			"r": theme.Red,
-			"g": theme.Green,
-			"y": theme.Yellow,
-			"b": theme.Blue,
-			"m": theme.Magenta,
-			"c": theme.Cyan,
-			"w": theme.White,
-		}
-		for name, color := range shortColorClasses {
-			sb.WriteString(fmt.Sprintf(".%s { fill: %s; }", name, color))
+		shortColorClasses := [][2]string{
+			{"k", theme.Black}, // blacK
+			{"r", theme.Red},
+			{"g", theme.Green},
+			{"y", theme.Yellow},
+			{"l", theme.Blue}, // bLue, as b is bold
+			{"m", theme.Magenta},
+			{"c", theme.Cyan},
+			{"w", theme.White},
+		}
+		for _, class := range shortColorClasses {
+			sb.WriteString(fmt.Sprintf(".%s { fill: %s; }", class[0], class[1]))
 			g.writeNewline(&sb)
 		}
 		// Add prompt color class if we detect it's used frequently
@@ -1084,12 +1205,26 @@ func (g *SVGGenerator) generateStyles() string {
 			g.writeNewline(&sb)
 		}
 	} else {
-		for name, color := range colorClasses {
-			sb.WriteString(fmt.Sprintf(".%s { fill: %s; }", name, color))
+		for _, class := range colorClasses {
+			sb.WriteString(fmt.Sprintf(".%s { fill: %s; }", class[0], class[1]))
 			g.writeNewline(&sb)
 		}
 	}
 
+	// Text attribute classes, for the attributes the frames use
+	var decorations []string
+	for _, attr := range g.usedTextAttributes() {
+		sb.WriteString(fmt.Sprintf(".%s { %s }", attr.class, attr.css))
+		g.writeNewline(&sb)
+		if attr.class == "u" || attr.class == "s" {
+			decorations = append(decorations, attr.class)
+		}
+	}
+	if len(decorations) == 2 {
+		sb.WriteString(".u.s { text-decoration: underline line-through; }")
+		g.writeNewline(&sb)
+	}
+
 	// Cursor styles - for inline cursor with background
 	// Note: SVG doesn't support background property on tspan, we'll need to use a different approach
 	// We'll render a rect behind the cursor character
@@ -1103,6 +1238,7 @@ func (g *SVGGenerator) generateStyles() string {
 		sb.WriteString(fmt.Sprintf(".%s { animation: blink 1s infinite; }", cursorIdleClass))
 		g.writeNewline(&sb)
 	}
+	g.generateDarkThemeCSS(&sb)
 
 	sb.WriteString("</style>")
 	g.writeNewline(&sb)
@@ -1110,6 +1246,235 @@ func (g *SVGGenerator) generateStyles() string {
 	return sb.String()
 }
 
+// hasSceneTransitions returns whether opacity transitions between scenes
+// should be rendered.
+func (g *SVGGenerator) hasSceneTransitions() bool {
+	return g.options.Transition.Enabled() && len(g.options.SceneTimes) > 0 && g.duration > 0
+}
+
+// timeFrames places the frames on the timeline of the animation by their
+// timestamps, so that it keeps the pacing of the recording when frames were
+// not captured at regular intervals. Every frame lasts until the next one, and
+// the last one as long as the average frame. Frames without increasing
+// timestamps are spread evenly over the duration instead.
+func (g *SVGGenerator) timeFrames() {
+	frames := g.options.Frames
+	g.frameTimes = make([]float64, len(frames))
+	g.duration = g.options.Duration
+	if len(frames) < 2 {
+		return
+	}
+
+	span := frames[len(frames)-1].Timestamp - frames[0].Timestamp
+	increasing := span > 0
+	for i := 1; i < len(frames) && increasing; i++ {
+		increasing = frames[i].Timestamp >= frames[i-1].Timestamp
+	}
+	if !increasing {
+		for i := range frames {
+			g.frameTimes[i] = float64(i) / float64(len(frames)-1) * 100
+		}
+		return
+	}
+
+	total := span + span/float64(len(frames)-1)
+	if g.duration < total {
+		g.duration = total
+	}
+	for i, frame := range frames {
+		g.frameTimes[i] = (frame.Timestamp - frames[0].Timestamp) / g.duration * 100
+	}
+}
+
+// framePercentage returns when the frame at the index starts, in percent of
+// the duration of the animation, or 100 past the last frame.
+func (g *SVGGenerator) framePercentage(i int) float64 {
+	if i >= len(g.frameTimes) {
+		return 100
+	}
+	return g.frameTimes[max(0, i)]
+}
+
+// animationTiming returns the duration and delay (in seconds) of the
+// animation, accounting for the playback speed and loop offset.
+func (g *SVGGenerator) animationTiming() (float64, float64) {
+	// Apply playback speed to animation duration
+	animationDuration := g.duration
+	if g.options.PlaybackSpeed > 0 {
+		animationDuration = g.duration / g.options.PlaybackSpeed
+	}
+
+	// Calculate animation delay based on LoopOffset
+	animationDelay := 0.0
+	if g.options.LoopOffset > 0 {
+		// LoopOffset can be a percentage (0-100) or frame number
+		if g.options.LoopOffset <= 1.0 {
+			// Treat as percentage
+			animationDelay = -animationDuration * g.options.LoopOffset
+		} else {
+			// Treat as frame number
+			animationDelay = -(g.options.LoopOffset / float64(len(g.options.Frames))) * animationDuration
+		}
+	}
+	return animationDuration, animationDelay
+}
+
+// generateCamera animates the viewBox of the terminal along the keyframes of
+// the camera.
+func (g *SVGGenerator) generateCamera(sb *strings.Builder, width, height float64) {
+	frames := len(g.options.Frames)
+	keyframes := []CameraKeyframe{cameraAt(g.options.Camera, 0)}
+	for _, k := range g.options.Camera {
+		if k.Frame > 0 && k.Frame < frames {
+			keyframes = append(keyframes, k)
+		}
+	}
+	keyframes = append(keyframes, cameraAt(g.options.Camera, frames))
+
+	values := make([]string, len(keyframes))
+	keyTimes := make([]string, len(keyframes))
+	for i, k := range keyframes {
+		w, h := width/k.Zoom, height/k.Zoom
+		values[i] = fmt.Sprintf("%s %s %s %s",
+			formatCoord(k.X*width-w/2), formatCoord(k.Y*height-h/2), formatCoord(w), formatCoord(h))
+		keyTimes[i] = strconv.FormatFloat(g.framePercentage(k.Frame)/100, 'f', 4, 64)
+	}
+
+	duration, delay := g.animationTiming()
+	fmt.Fprintf(sb, `<animate attributeName="viewBox" values="%s" keyTimes="%s" dur="%ss" begin="%ss" repeatCount="indefinite"/>`,
+		strings.Join(values, ";"), strings.Join(keyTimes, ";"), formatDuration(duration), formatDuration(delay))
+	g.writeNewline(sb)
+}
+
+// generateAnnotationCSS generates the keyframes which show every callout
+// between its start and end frames.
+func (g *SVGGenerator) generateAnnotationCSS(sb *strings.Builder, animationDuration, animationDelay float64) {
+	sb.WriteString(".annotation { opacity: 0; }")
+	g.writeNewline(sb)
+	for i, a := range g.options.Annotations {
+		start := g.framePercentage(a.Start - 1)
+		end := g.framePercentage(a.End - 1)
+		fmt.Fprintf(sb, "@keyframes annotation%d { 0%% { opacity: 0; } %s%% { opacity: 1; } %s%% { opacity: 0; } }",
+			i, formatPercentage(start, len(g.options.Frames)), formatPercentage(end, len(g.options.Frames)))
+		g.writeNewline(sb)
+		fmt.Fprintf(sb, ".annotation%d { animation: annotation%d %ss step-end %ss infinite; }",
+			i, i, formatDuration(animationDuration), formatDuration(animationDelay))
+		g.writeNewline(sb)
+	}
+	g.writeNewline(sb)
+}
+
+// generateAnnotations draws the callouts above the terminal.
+func (g *SVGGenerator) generateAnnotations(sb *strings.Builder) {
+	for i, a := range g.options.Annotations {
+		s := a.shape(g.charWidth, g.charHeight)
+		fmt.Fprintf(sb, `<g class="annotation annotation%d">`, i)
+		if s.Arrow {
+			fmt.Fprintf(sb, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"/>`,
+				formatCoord(s.From.X), formatCoord(s.From.Y), formatCoord(s.To.X), formatCoord(s.To.Y),
+				a.Foreground, formatCoord(max(2, g.charHeight/8)))
+			fmt.Fprintf(sb, `<polygon points="%s,%s %s,%s %s,%s" fill="%s"/>`,
+				formatCoord(s.Head[0].X), formatCoord(s.Head[0].Y), formatCoord(s.Head[1].X), formatCoord(s.Head[1].Y),
+				formatCoord(s.Head[2].X), formatCoord(s.Head[2].Y), a.Foreground)
+		}
+		fmt.Fprintf(sb, `<rect x="%s" y="%s" width="%s" height="%s" rx="%s" fill="%s"/>`,
+			formatCoord(s.X), formatCoord(s.Y), formatCoord(s.Width), formatCoord(s.Height),
+			formatCoord(g.charHeight/4), a.Foreground)
+		fmt.Fprintf(sb, `<text x="%s" y="%s" class="%s" style="fill: %s">%s</text>`,
+			formatCoord(s.TextX), formatCoord(s.TextY), g.textClass, a.Background, html.EscapeString(a.Text))
+		sb.WriteString("</g>")
+		g.writeNewline(sb)
+	}
+}
+
+// generateSceneTransitionCSS generates the opacity keyframes that fade the
+// terminal out and back in around every scene boundary.
+func (g *SVGGenerator) generateSceneTransitionCSS(sb *strings.Builder, animationDuration, animationDelay float64) {
+	half := g.options.Transition.Duration.Seconds() / doublingFactor
+	keyframeCount := len(g.options.SceneTimes) * 3
+
+	sb.WriteString("@keyframes scene {")
+	g.writeNewline(sb)
+	for _, t := range g.options.SceneTimes {
+		start := (t - half) / g.duration * 100
+		mid := t / g.duration * 100
+		end := (t + half) / g.duration * 100
+		if start < 0 {
+			start = 0
+		}
+		if end > 100 {
+			end = 100
+		}
+		fmt.Fprintf(sb, "  %s%%, %s%% { opacity: 1; }", formatPercentage(start, keyframeCount), formatPercentage(end, keyframeCount))
+		g.writeNewline(sb)
+		fmt.Fprintf(sb, "  %s%% { opacity: 0; }", formatPercentage(mid, keyframeCount))
+		g.writeNewline(sb)
+	}
+	sb.WriteString("}")
+	g.writeNewline(sb)
+
+	sb.WriteString(".scenes {")
+	g.writeNewline(sb)
+	fmt.Fprintf(sb, "  animation: scene %ss linear %ss infinite;", formatDuration(animationDuration), formatDuration(animationDelay))
+	g.writeNewline(sb)
+	sb.WriteString("}")
+	g.writeNewline(sb)
+	g.writeNewline(sb)
+}
+
+// stateScale returns the factor a state has to be scaled by to fit into the
+// terminal viewport, which is less than 1 if the terminal was resized beyond
+// its original dimensions.
+func (g *SVGGenerator) stateScale(state *TerminalState) float64 {
+	if state.Cols <= 0 || state.Rows <= 0 {
+		return 1
+	}
+
+	style := g.options.Style
+	if style == nil {
+		style = DefaultStyleOptions()
+	}
+	barHeight := 0
+	if style.WindowBar != "" {
+		barHeight = style.WindowBarSize
+	}
+	viewportHeight := float64(style.Height - barHeight - style.Padding*2)
+
+	scale := 1.0
+	if width := float64(state.Cols) * g.charWidth; width > g.frameSpacing {
+		scale = g.frameSpacing / width
+	}
+	if height := float64(state.Rows) * g.charHeight; height > viewportHeight {
+		scale = min(scale, viewportHeight/height)
+	}
+	return scale
+}
+
+// renderSelection draws the selection color behind the highlighted cells of
+// the state, one rectangle per row. Themes without a selection color use a
+// translucent foreground.
+func (g *SVGGenerator) renderSelection(sb *strings.Builder, state *TerminalState) {
+	fill := fmt.Sprintf(`fill="%s"`, g.options.Theme.Selection)
+	if g.options.Theme.Selection == "" {
+		fill = fmt.Sprintf(`fill="%s" fill-opacity="0.3"`, g.options.Theme.Foreground)
+	}
+	cols := state.Cols
+	if cols <= 0 {
+		cols = int(g.frameSpacing / g.charWidth)
+	}
+
+	for y := state.Selection.StartRow; y <= state.Selection.EndRow; y++ {
+		start, end, ok := state.Selection.Columns(y, cols)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(sb, `<rect x="%s" y="%s" width="%s" height="%s" %s/>`,
+			formatCoord(float64(start)*g.charWidth), formatCoord(float64(y)*g.charHeight),
+			formatCoord(float64(end-start)*g.charWidth), formatCoord(g.charHeight), fill)
+		g.writeNewline(sb)
+	}
+}
+
 // generateState creates a group for a single terminal state.
 func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 	var sb strings.Builder
@@ -1117,9 +1482,24 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 	// Position this state in the animation sequence
 	xOffset := float64(index) * g.frameSpacing
 
-	sb.WriteString(fmt.Sprintf(`<g transform="translate(%s,0)">`, formatCoord(xOffset)))
+	// Shrink states which no longer fit into the viewport after a Resize
+	if scale := g.stateScale(state); scale < 1 {
+		sb.WriteString(fmt.Sprintf(`<g transform="translate(%s,0) scale(%s)">`, formatCoord(xOffset), formatScale(scale)))
+	} else {
+		sb.WriteString(fmt.Sprintf(`<g transform="translate(%s,0)">`, formatCoord(xOffset)))
+	}
 	g.writeNewline(&sb)
 
+	// Inline images are drawn below the text, as blank cells are transparent
+	if id, ok := g.imageIDs[state.Image]; ok {
+		sb.WriteString(fmt.Sprintf(`<use href="#%s"/>`, id))
+		g.writeNewline(&sb)
+	}
+
+	if state.Selection != nil {
+		g.renderSelection(&sb, state)
+	}
+
 	// Debug specific state with background colors
 	if g.options.Debug && index == 19 {
 		log.Printf("=== Generating state 19 with background colors ===")
@@ -1188,11 +1568,7 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 		// Render if line has content, is cursor line, or has background colors
 		if strings.TrimSpace(line) != "" || isCursorLine || hasBackgroundColors {
 			// Render with colors using natural text flow
-			lineHeight := g.options.LineHeight
-			if lineHeight <= 0 {
-				lineHeight = 1.0
-			}
-			yPos := float64(y)*g.charHeight*lineHeight + g.charHeight*0.8
+			yPos := g.baseline(y)
 
 			// First, render any background rectangles if we have color data
 			if hasColors && y < len(state.LineColors) {
@@ -1202,8 +1578,8 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 				// Make sure we check all color entries, not just up to line length
 				// This is important for lines that are all spaces with background colors
 				maxX := len(state.LineColors[y])
-				if len(line) > maxX {
-					maxX = len(line)
+				if width := cellWidth(line); width > maxX {
+					maxX = width
 				}
 				bgFound := false
 				for x := 0; x < maxX && x < len(state.LineColors[y]); x++ {
@@ -1216,7 +1592,7 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 						}
 						charX := float64(x) * g.charWidth
 						sb.WriteString(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s" shape-rendering="crispEdges"/>`,
-							formatCoord(charX), formatCoord(float64(y)*g.charHeight*lineHeight), formatCoord(g.charWidth), formatCoord(g.charHeight), style.BgColor))
+							formatCoord(charX), formatCoord(float64(y)*g.charHeight), formatCoord(g.charWidth), formatCoord(g.charHeight), style.BgColor))
 						g.writeNewline(&sb)
 					}
 				}
@@ -1231,6 +1607,14 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 
 			// Note: cursor background will be rendered inline with text to ensure proper alignment
 
+			// Wide and combining characters break the assumption that every
+			// rune is one cell, so such lines are positioned cell by cell.
+			// Without ligatures, every line is.
+			if g.options.NoLigatures || !isCellAligned(line) {
+				g.renderCellLine(&sb, state, y, line, yPos, isCursorLine && state.CursorChar != "", hasColors)
+				continue
+			}
+
 			// Convert line to runes to handle UTF-8 properly
 			runes := []rune(line)
 
@@ -1273,28 +1657,11 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 				}
 
 				// Render cursor as inline element with background
-				cursorClass := g.cursorActiveClass
-				if !state.IsCursorActive {
-					cursorClass = g.cursorIdleClass
-				}
-
-				// Get cursor color (cursor is rendered as a block with foreground color)
-				cursorBgColor := g.options.Theme.Foreground
-				if cursorBgColor == "" {
-					cursorBgColor = defaultCursorColor
-				}
-
-				// Render cursor inline
-				// For a true inline solution, we'll render the cursor as a colored block character
-				if state.CursorChar != "" && state.CursorChar != " " {
-					// Use the cursor character from xterm.js (usually █)
-					sb.WriteString(fmt.Sprintf(`<tspan class="%s %s" style="fill:%s;">%s</tspan>`,
-						g.textClass, cursorClass, cursorBgColor, html.EscapeString(state.CursorChar)))
-				} else {
-					// Fallback to block character
-					sb.WriteString(fmt.Sprintf(`<tspan class="%s %s" style="fill:%s;">█</tspan>`,
-						g.textClass, cursorClass, cursorBgColor))
+				var underCursor string
+				if state.CursorX < len(runes) {
+					underCursor = string(runes[state.CursorX])
 				}
+				g.writeInlineCursor(&sb, state, "", underCursor)
 
 				// Render text after cursor
 				if afterCursor != "" {
@@ -1302,56 +1669,14 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 					afterRunes := []rune(afterCursor)
 					for i := 0; i < len(afterRunes); {
 						// Similar logic to renderTextSegment but without x positioning
-						charPos := state.CursorX + 1 + i
-
-						// Get initial style
-						var styleStr string
-						var colorClass string
-						if hasColors && y < len(state.LineColors) && charPos < len(state.LineColors[y]) {
-							style := state.LineColors[y][charPos]
-							if style.FgColor != "" && style.FgColor != nilValue {
-								colorClass = g.getColorClass(style.FgColor)
-								if colorClass == "" {
-									styleStr = fmt.Sprintf("fill:%s;", style.FgColor)
-								}
-							}
-							if style.Bold {
-								styleStr += fontWeightBold
-							}
-							if style.Italic {
-								styleStr += fontStyleItalic
-							}
-							if style.Underline {
-								styleStr += textDecorationUnderline
-							}
-						}
+						colorClass, styleStr := g.lineStyle(state.LineColors, hasColors, y, state.CursorX+1+i)
 
 						// Collect characters with same style
 						segmentText := string(afterRunes[i])
 						i++
 
 						for i < len(afterRunes) {
-							nextCharPos := state.CursorX + 1 + i
-							var nextStyleStr string
-							var nextColorClass string
-							if hasColors && y < len(state.LineColors) && nextCharPos < len(state.LineColors[y]) {
-								nextStyle := state.LineColors[y][nextCharPos]
-								if nextStyle.FgColor != "" && nextStyle.FgColor != nilValue {
-									nextColorClass = g.getColorClass(nextStyle.FgColor)
-									if nextColorClass == "" {
-										nextStyleStr = fmt.Sprintf("fill:%s;", nextStyle.FgColor)
-									}
-								}
-								if nextStyle.Bold {
-									nextStyleStr += fontWeightBold
-								}
-								if nextStyle.Italic {
-									nextStyleStr += fontStyleItalic
-								}
-								if nextStyle.Underline {
-									nextStyleStr += textDecorationUnderline
-								}
-							}
+							nextColorClass, nextStyleStr := g.lineStyle(state.LineColors, hasColors, y, state.CursorX+1+i)
 
 							if styleStr != nextStyleStr || colorClass != nextColorClass {
 								break
@@ -1388,12 +1713,117 @@ func (g *SVGGenerator) generateState(index int, state *TerminalState) string {
 		}
 	}
 
+	// Hyperlinks are drawn on top of the text so they can be clicked
+	g.generateLinks(&sb, state)
+
 	sb.WriteString("</g>")
 	g.writeNewline(&sb)
 
 	return sb.String()
 }
 
+// generateTranscript renders the full scrollback as a scrollable panel below
+// the animation.
+func (g *SVGGenerator) generateTranscript(y, width, height, padding int) string {
+	var sb strings.Builder
+
+	background := g.options.Theme.Background
+	if background == "" {
+		background = defaultBackgroundColor
+	}
+	foreground := g.options.Theme.Foreground
+	if foreground == "" {
+		foreground = defaultForegroundColor
+	}
+	fontFamily := buildSVGFontFamily(g.options.FontFamily)
+
+	sb.WriteString(fmt.Sprintf(`<foreignObject x="0" y="%d" width="%d" height="%d">`, y, width, height))
+	g.writeNewline(&sb)
+	sb.WriteString(fmt.Sprintf(`<div xmlns="http://www.w3.org/1999/xhtml" class="transcript" style="box-sizing:border-box;height:100%%;overflow:auto;padding:%dpx;background:%s;color:%s;font-family:%s;font-size:%spx;">`,
+		padding, background, foreground, html.EscapeString(fontFamily), formatCoord(g.fontSize)))
+	sb.WriteString(`<pre style="margin:0;font:inherit;">`)
+	sb.WriteString(html.EscapeString(strings.Join(g.options.Transcript, "\n")))
+	sb.WriteString("</pre></div>")
+	g.writeNewline(&sb)
+	sb.WriteString("</foreignObject>")
+	g.writeNewline(&sb)
+
+	return sb.String()
+}
+
+// hasLinks returns whether any state contains an OSC 8 hyperlink.
+func (g *SVGGenerator) hasLinks() bool {
+	for _, state := range g.states {
+		for _, line := range state.LineColors {
+			for _, style := range line {
+				if isSafeLink(style.Link) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isSafeLink returns whether an OSC 8 hyperlink target can be written to the
+// SVG. Links come from program output, so only http, https and mailto URIs are
+// allowed; javascript: and other schemes would run in the viewer's browser.
+func isSafeLink(link string) bool {
+	if link == "" {
+		return false
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// generateLinks renders a clickable area over every OSC 8 hyperlink of a
+// state, optionally underlining it.
+func (g *SVGGenerator) generateLinks(sb *strings.Builder, state *TerminalState) {
+	for y, line := range state.LineColors {
+		for x := 0; x < len(line); {
+			link := line[x].Link
+			if !isSafeLink(link) {
+				x++
+				continue
+			}
+
+			// Group consecutive cells pointing to the same target
+			start := x
+			for x < len(line) && line[x].Link == link {
+				x++
+			}
+
+			charX := float64(start) * g.charWidth
+			charY := float64(y) * g.charHeight
+			width := float64(x-start) * g.charWidth
+
+			fmt.Fprintf(sb, `<a xlink:href="%s" target="_blank">`, html.EscapeString(link))
+			fmt.Fprintf(sb, `<rect x="%s" y="%s" width="%s" height="%s" fill="transparent"/>`,
+				formatCoord(charX), formatCoord(charY), formatCoord(width), formatCoord(g.charHeight))
+			if g.options.UnderlineLinks {
+				color := line[start].FgColor
+				if color == "" {
+					color = g.options.Theme.Foreground
+				}
+				if color == "" {
+					color = defaultForegroundColor
+				}
+				fmt.Fprintf(sb, `<rect x="%s" y="%s" width="%s" height="1" fill="%s"/>`,
+					formatCoord(charX), formatCoord(charY+g.charHeight*0.9), formatCoord(width), color)
+			}
+			sb.WriteString("</a>")
+			g.writeNewline(sb)
+		}
+	}
+}
+
 // getColorClass returns the appropriate CSS class for a color.
 func (g *SVGGenerator) getColorClass(color string) string {
 	if !g.options.OptimizeSize {
@@ -1411,7 +1841,7 @@ func (g *SVGGenerator) getColorClass(color string) string {
 	case theme.Yellow:
 		return "y"
 	case theme.Blue:
-		return "b"
+		return "l"
 	case theme.Magenta:
 		return "m"
 	case theme.Cyan:
@@ -1433,60 +1863,14 @@ func (g *SVGGenerator) renderTextSegment(sb *strings.Builder, text string, lineI
 	for x < len(runes) {
 		// Group consecutive characters with the same style
 		startX := x
-		var style CharStyle
-		var styleStr string
-
-		// Get style if we have color data for this position
-		charPos := startChar + x
-		var colorClass string
-		if hasColors && lineIndex < len(lineColors) && charPos < len(lineColors[lineIndex]) {
-			style = lineColors[lineIndex][charPos]
-			// Check if we can use a color class instead of inline style
-			if style.FgColor != "" && style.FgColor != nilValue {
-				colorClass = g.getColorClass(style.FgColor)
-				if colorClass == "" {
-					// No matching class, use inline style
-					styleStr += fmt.Sprintf("fill:%s;", style.FgColor)
-				}
-			}
-			if style.Bold {
-				styleStr += fontWeightBold
-			}
-			if style.Italic {
-				styleStr += fontStyleItalic
-			}
-			if style.Underline {
-				styleStr += textDecorationUnderline
-			}
-		}
+		colorClass, styleStr := g.lineStyle(lineColors, hasColors, lineIndex, startChar+x)
 
 		// Collect characters with same style
 		segmentText := string(runes[x])
 		x++
 
 		for x < len(runes) {
-			nextCharPos := startChar + x
-			var nextStyleStr string
-
-			var nextColorClass string
-			if hasColors && lineIndex < len(lineColors) && nextCharPos < len(lineColors[lineIndex]) {
-				nextStyle := lineColors[lineIndex][nextCharPos]
-				if nextStyle.FgColor != "" && nextStyle.FgColor != nilValue {
-					nextColorClass = g.getColorClass(nextStyle.FgColor)
-					if nextColorClass == "" {
-						nextStyleStr += fmt.Sprintf("fill:%s;", nextStyle.FgColor)
-					}
-				}
-				if nextStyle.Bold {
-					nextStyleStr += fontWeightBold
-				}
-				if nextStyle.Italic {
-					nextStyleStr += fontStyleItalic
-				}
-				if nextStyle.Underline {
-					nextStyleStr += textDecorationUnderline
-				}
-			}
+			nextColorClass, nextStyleStr := g.lineStyle(lineColors, hasColors, lineIndex, startChar+x)
 
 			// If style changes, break
 			if styleStr != nextStyleStr || colorClass != nextColorClass {
@@ -1523,6 +1907,222 @@ func (g *SVGGenerator) renderTextSegment(sb *strings.Builder, text string, lineI
 	}
 }
 
+// writeInlineCursor renders the cursor as a block character in the current
+// text element. attrs are added to the tspan, e.g. to position it. With an
+// accent color, or a reverse cursor, the text under the cursor is drawn over
+// the block.
+func (g *SVGGenerator) writeInlineCursor(sb *strings.Builder, state *TerminalState, attrs, under string) {
+	cursorClass := g.cursorActiveClass
+	if !state.IsCursorActive {
+		cursorClass = g.cursorIdleClass
+	}
+
+	// Get cursor color (cursor is rendered as a block with foreground color)
+	cursorBgColor := cmp.Or(g.options.CursorColor, g.options.Theme.Foreground, defaultCursorColor)
+	accentColor := g.options.CursorAccentColor
+
+	// A reverse cursor swaps the colors of the cell under it
+	if g.options.CursorColor == cursorReverse {
+		var style CharStyle
+		if state.CursorY < len(state.LineColors) && state.CursorX < len(state.LineColors[state.CursorY]) {
+			style = state.LineColors[state.CursorY][state.CursorX]
+		}
+		fg := cmp.Or(strings.TrimPrefix(style.FgColor, nilValue), g.options.Theme.Foreground, defaultForegroundColor)
+		bg := cmp.Or(strings.TrimPrefix(style.BgColor, nilValue), g.options.Theme.Background, defaultBackgroundColor)
+		if style.Inverse {
+			fg, bg = bg, fg
+		}
+		cursorBgColor, accentColor = fg, bg
+	}
+
+	// Use the cursor character from xterm.js (usually █), falling back to a
+	// block character
+	cursorChar := "█"
+	if state.CursorChar != "" && state.CursorChar != " " {
+		cursorChar = state.CursorChar
+	}
+	fmt.Fprintf(sb, `<tspan%s class="%s %s" style="fill:%s;">%s</tspan>`,
+		attrs, g.textClass, cursorClass, cursorBgColor, html.EscapeString(cursorChar))
+
+	if accentColor != "" && strings.TrimSpace(under) != "" {
+		fmt.Fprintf(sb, `<tspan x="%s" class="%s %s" style="fill:%s;">%s</tspan>`,
+			formatCoord(float64(state.CursorX)*g.charWidth), g.textClass, cursorClass, accentColor, html.EscapeString(under))
+	}
+}
+
+// segmentStyle returns the classes and inline style of a character: its
+// color class, if any, followed by the classes of its text attributes.
+func (g *SVGGenerator) segmentStyle(style CharStyle) (classes, styleStr string) {
+	var names []string
+	if style.FgColor != "" && style.FgColor != nilValue {
+		if colorClass := g.getColorClass(style.FgColor); colorClass != "" {
+			names = append(names, colorClass)
+		} else {
+			styleStr = fmt.Sprintf("fill:%s;", style.FgColor)
+		}
+	}
+	for _, attr := range textAttributes {
+		if attr.has(style) {
+			names = append(names, attr.class)
+		}
+	}
+	return strings.Join(names, " "), styleStr
+}
+
+// usedTextAttributes returns the text attributes of the characters of the
+// frames.
+func (g *SVGGenerator) usedTextAttributes() []textAttribute {
+	var used []textAttribute
+	for _, attr := range textAttributes {
+		if g.anyStyle(attr.has) {
+			used = append(used, attr)
+		}
+	}
+	return used
+}
+
+// anyStyle reports whether a character of the frames has a style matching f.
+func (g *SVGGenerator) anyStyle(f func(CharStyle) bool) bool {
+	for _, frame := range g.options.Frames {
+		if hasStyle(frame.LineColors, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasStyle(lineColors [][]CharStyle, f func(CharStyle) bool) bool {
+	for _, line := range lineColors {
+		for _, style := range line {
+			if f(style) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveInverse returns the styles with the colors of inverse characters
+// swapped, using the theme colors for default ones.
+func (g *SVGGenerator) resolveInverse(lineColors [][]CharStyle) [][]CharStyle {
+	if !hasStyle(lineColors, func(s CharStyle) bool { return s.Inverse }) {
+		return lineColors
+	}
+
+	resolved := make([][]CharStyle, len(lineColors))
+	for y, line := range lineColors {
+		resolved[y] = append([]CharStyle(nil), line...)
+		for x, style := range line {
+			if !style.Inverse {
+				continue
+			}
+			fg, bg := style.FgColor, style.BgColor
+			if fg == "" {
+				fg = g.options.Theme.Foreground
+			}
+			if bg == "" {
+				bg = g.options.Theme.Background
+			}
+			resolved[y][x].FgColor, resolved[y][x].BgColor = bg, fg
+			resolved[y][x].Inverse = false
+		}
+	}
+	return resolved
+}
+
+// lineStyle returns the classes and inline style of a character of a line,
+// or none if there is no style information for it.
+func (g *SVGGenerator) lineStyle(lineColors [][]CharStyle, hasColors bool, y, x int) (string, string) {
+	if !hasColors || y >= len(lineColors) || x >= len(lineColors[y]) {
+		return "", ""
+	}
+	return g.segmentStyle(lineColors[y][x])
+}
+
+// renderCellLine renders a line containing wide or combining characters.
+// Every segment is positioned at its terminal column, and wide characters get
+// a segment of their own, so glyph widths of the font cannot shift the text
+// after them off the grid. Without ligatures, every character of a segment is
+// positioned individually.
+func (g *SVGGenerator) renderCellLine(sb *strings.Builder, state *TerminalState, y int, line string, yPos float64, withCursor, hasColors bool) {
+	var styles []CharStyle
+	if hasColors && y < len(state.LineColors) {
+		styles = state.LineColors[y]
+	}
+	cells := splitCells(line, styles)
+
+	fmt.Fprintf(sb, `<text y="%s" xml:space="preserve">`, formatCoord(yPos))
+
+	cursorDrawn := false
+	for i := 0; i < len(cells); {
+		cell := cells[i]
+
+		// The cursor replaces the character underneath it
+		if withCursor && state.CursorX >= cell.Col && state.CursorX < cell.Col+max(cell.Width, 1) {
+			g.writeInlineCursor(sb, state, fmt.Sprintf(` x="%s"`, formatCoord(float64(state.CursorX)*g.charWidth)), cell.Text)
+			cursorDrawn = true
+			i++
+			continue
+		}
+
+		var style CharStyle
+		if cell.Col < len(styles) {
+			style = styles[cell.Col]
+		}
+		colorClass, styleStr := g.segmentStyle(style)
+
+		// Group consecutive single-cell characters with the same style
+		text := cell.Text
+		xs := []string{formatCoord(float64(cell.Col) * g.charWidth)}
+		i++
+		for isSingleCell(cell) && i < len(cells) && isSingleCell(cells[i]) {
+			next := cells[i]
+			if withCursor && state.CursorX == next.Col {
+				break
+			}
+			var nextStyle CharStyle
+			if next.Col < len(styles) {
+				nextStyle = styles[next.Col]
+			}
+			nextClass, nextStyleStr := g.segmentStyle(nextStyle)
+			if nextClass != colorClass || nextStyleStr != styleStr {
+				break
+			}
+			text += next.Text
+			xs = append(xs, formatCoord(float64(next.Col)*g.charWidth))
+			i++
+		}
+
+		classes := g.textClass
+		if colorClass != "" {
+			classes += " " + colorClass
+		}
+		x := xs[0]
+		if g.options.NoLigatures {
+			x = strings.Join(xs, " ")
+		}
+		if styleStr != "" {
+			fmt.Fprintf(sb, `<tspan x="%s" class="%s" style="%s">%s</tspan>`, x, classes, styleStr, html.EscapeString(text))
+		} else {
+			fmt.Fprintf(sb, `<tspan x="%s" class="%s">%s</tspan>`, x, classes, html.EscapeString(text))
+		}
+	}
+
+	// The cursor is past the end of the line
+	if withCursor && !cursorDrawn {
+		g.writeInlineCursor(sb, state, fmt.Sprintf(` x="%s"`, formatCoord(float64(state.CursorX)*g.charWidth)), "")
+	}
+
+	sb.WriteString("</text>")
+	g.writeNewline(sb)
+}
+
+// baseline returns the baseline of the text of a row. Like xterm.js, the
+// glyphs are centered vertically in cells taller than them.
+func (g *SVGGenerator) baseline(y int) float64 {
+	return float64(y)*g.charHeight + (g.charHeight-g.glyphHeight)/2 + g.glyphHeight*0.8
+}
+
 // writeNewline conditionally writes a newline based on optimization settings.
 func (g *SVGGenerator) writeNewline(sb *strings.Builder) {
 	if !g.options.OptimizeSize {
@@ -1545,6 +2145,14 @@ func formatCoord(val float64) string {
 	return formatted
 }
 
+// formatScale formats a scale factor with enough precision to keep the
+// terminal content aligned.
+func formatScale(val float64) string {
+	formatted := strconv.FormatFloat(val, 'f', 4, 64)
+	formatted = strings.TrimRight(formatted, "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
 // formatPercentage formats a percentage value with appropriate precision
 // to avoid keyframe collisions in large animations.
 // The precision is dynamically calculated based on the number of keyframes.
@@ -1599,6 +2207,36 @@ func formatDuration(seconds float64) string {
 	return formatted
 }
 
+// generateImageDefs creates a reusable <image> for every distinct inline
+// image, so states sharing an image embed it only once.
+func (g *SVGGenerator) generateImageDefs() string {
+	g.imageIDs = make(map[string]string)
+
+	var sb strings.Builder
+	for _, state := range g.states {
+		if state.Image == "" {
+			continue
+		}
+		if _, ok := g.imageIDs[state.Image]; ok {
+			continue
+		}
+
+		id := fmt.Sprintf("img%d", len(g.imageIDs))
+		g.imageIDs[state.Image] = id
+
+		// The image layer covers the whole terminal
+		width, height := g.frameSpacing, float64(state.Rows)*g.charHeight
+		if state.Cols > 0 {
+			width = float64(state.Cols) * g.charWidth
+		}
+		sb.WriteString(fmt.Sprintf(`<image id="%s" width="%s" height="%s" href="%s"/>`,
+			id, formatCoord(width), formatCoord(height), state.Image))
+		g.writeNewline(&sb)
+	}
+
+	return sb.String()
+}
+
 // generateCursorSymbols creates reusable cursor symbols.
 func (g *SVGGenerator) generateCursorSymbols() string {
 	// We're now using inline cursor rendering, so no symbols needed
@@ -1668,36 +2306,25 @@ func (g *SVGGenerator) generateWindowBar() string {
 		g.options.Width, barSize, barSize, borderRadius, borderRadius, barColor))
 	g.writeNewline(&sb)
 
-	// Window controls based on style
-	switch style.WindowBar {
-	case "Colorful":
-		// Colorful circles on the left (macOS-style)
-		for i, color := range windowControlColors {
-			x := 20 + i*20
-			sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="6" fill="%s"/>`, x, barSize/2, color))
-			g.writeNewline(&sb)
-		}
-	case "ColorfulRight":
-		// Colorful circles on the right
-		for i, color := range windowControlColors {
-			x := g.options.Width - 80 + i*20
-			sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="6" fill="%s"/>`, x, barSize/2, color))
-			g.writeNewline(&sb)
+	// Window controls based on style, scaled with the bar like the window
+	// bars of the other outputs
+	rings := style.WindowBar == "Rings" || style.WindowBar == "RingsRight"
+	right := style.WindowBar == "ColorfulRight" || style.WindowBar == "RingsRight"
+	radius, gap, spacing := windowControlMetrics(barSize, rings)
+	// Rings are stroked inside their radius
+	strokeWidth := max(1, radius/barToDotBorderRatio)
+	for i, color := range windowControlColors {
+		x := gap + radius + i*spacing
+		if right {
+			x = g.options.Width - x
 		}
-	case "Rings":
-		// Ring circles on the left
-		for i, color := range windowControlColors {
-			x := 20 + i*20
-			sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="6" fill="none" stroke="%s" stroke-width="1"/>`, x, barSize/2, color))
-			g.writeNewline(&sb)
-		}
-	case "RingsRight":
-		// Ring circles on the right
-		for i, color := range windowControlColors {
-			x := g.options.Width - 80 + i*20
-			sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="6" fill="none" stroke="%s" stroke-width="1"/>`, x, barSize/2, color))
-			g.writeNewline(&sb)
+		if rings {
+			sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="%s" fill="none" stroke="%s" stroke-width="%d"/>`,
+				x, barSize/2, formatCoord(float64(radius)-float64(strokeWidth)/2), color, strokeWidth))
+		} else {
+			sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="%d" fill="%s"/>`, x, barSize/2, radius, color))
 		}
+		g.writeNewline(&sb)
 	}
 
 	// Title text (if provided)
@@ -1705,10 +2332,8 @@ func (g *SVGGenerator) generateWindowBar() string {
 		// Get the appropriate font family with fallbacks
 		fontFamily := getWindowBarFontFamily(style, g.options.FontFamily)
 		// Get the appropriate font size with fallback
-		fontSize := style.WindowBarFontSize
-		if fontSize == 0 {
-			fontSize = g.options.FontSize
-		}
+		fontSize := cmp.Or(style.WindowBarFontSize, g.options.FontSize, style.FontSize, defaultFontSize)
+		titleColor := cmp.Or(style.WindowBarTitleColor, defaultWindowBarTitleColor)
 
 		// Calculate vertical position with proper padding for different font sizes
 		// For SVG text, the y position is the baseline
@@ -1735,13 +2360,11 @@ func (g *SVGGenerator) generateWindowBar() string {
 			yPos = centerBaseline
 		}
 
-		// Add text with padding constraints
-		// The text will be centered but constrained to avoid overlapping with window controls
-		// Window controls occupy roughly 80px on each side
+		// Add text centered in the bar
 		centerX := g.options.Width / 2
 
-		sb.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" font-family="%s" font-size="%d" fill="#cccccc">`,
-			centerX, yPos, fontFamily, fontSize))
+		sb.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" font-family="%s" font-size="%d" fill="%s">`,
+			centerX, yPos, fontFamily, fontSize, html.EscapeString(titleColor)))
 		sb.WriteString(html.EscapeString(style.WindowBarTitle))
 		sb.WriteString(`</text>`)
 		g.writeNewline(&sb)
@@ -1753,281 +2376,6 @@ func (g *SVGGenerator) generateWindowBar() string {
 	return sb.String()
 }
 
-// CaptureSVGFrame captures the current terminal state and returns an SVGFrame.
-func CaptureSVGFrame(page *rod.Page, counter int, framerate int) (*SVGFrame, error) {
-	// Get cursor position and exact character positions from xterm.js
-	termInfo, err := page.Eval(`() => {
-		const term = window.term;
-		if (!term) {
-			console.error('term is not available');
-			return null;
-		}
-		const buffer = term.buffer.active;
-		const cursorX = buffer.cursorX;
-		// Cursor Y is relative to the viewport (0 = top of visible area)
-		const cursorY = buffer.cursorY;
-		
-		// Debug logging
-		const cursorLine = buffer.getLine(cursorY + buffer.viewportY);
-		if (cursorLine) {
-			const lineText = cursorLine.translateToString(true);
-			console.log('Cursor Debug - xterm.js cursor position:', cursorX, 'on line:', JSON.stringify(lineText));
-			console.log('Cursor Debug - Line length:', lineText.length, 'chars');
-			
-			// More detailed debugging
-			if (cursorX < lineText.length) {
-				console.log('Cursor Debug - Character at cursor position (' + cursorX + '):', JSON.stringify(lineText[cursorX]));
-				console.log('Cursor Debug - Text before cursor:', JSON.stringify(lineText.substring(0, cursorX)));
-				console.log('Cursor Debug - Text including cursor:', JSON.stringify(lineText.substring(0, cursorX + 1)));
-			} else {
-				console.log('Cursor Debug - Cursor is at end of line (position ' + cursorX + ')');
-			}
-			
-			// Check what xterm.js thinks about cursor positioning
-			console.log('Cursor Debug - buffer.cursorX:', buffer.cursorX);
-			console.log('Cursor Debug - Is cursor at line end?', cursorX >= lineText.length);
-		}
-		
-		// Get character dimensions
-		let charWidth = 0;
-		let charHeight = 0;
-		
-		// Get dimensions from the rendered canvas
-		// This is the most reliable source as it represents the actual rendered output
-		const textCanvas = document.querySelector('canvas.xterm-text-layer');
-		const cols = term.cols;
-		const rows = term.rows;
-		charWidth = textCanvas.width / cols;
-		charHeight = textCanvas.height / rows;
-		
-		// Get cursor character from buffer
-		let cursorChar = '█'; // Default block cursor
-		
-		// Helper function to convert xterm.js color to hex
-		function xtermColorToHex(color) {
-			if (!color) return null;
-			// Handle RGB colors
-			if (color.mode === 'rgb') {
-				const r = color.r.toString(16).padStart(2, '0');
-				const g = color.g.toString(16).padStart(2, '0');
-				const b = color.b.toString(16).padStart(2, '0');
-				return '#' + r + g + b;
-			}
-			// Handle palette colors (0-255)
-			if (typeof color === 'number') {
-				// Use xterm.js's color palette
-				const palette = term.options.theme;
-				if (color < 16 && palette) {
-					// Basic 16 colors
-					const colorNames = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white',
-									   'brightBlack', 'brightRed', 'brightGreen', 'brightYellow', 
-									   'brightBlue', 'brightMagenta', 'brightCyan', 'brightWhite'];
-					return palette[colorNames[color]] || null;
-				}
-				// For extended colors, we'd need the full palette
-				return null;
-			}
-			return null;
-		}
-		
-		// Get color information for all visible lines
-		const lineColors = [];
-		const activeBuffer = term.buffer.active;
-		console.log('term exists:', !!term, 'buffer exists:', !!term.buffer, 'active exists:', !!activeBuffer);
-		const viewportStart = activeBuffer ? activeBuffer.viewportY : 0;
-		const viewportEnd = viewportStart + term.rows;
-		
-		console.log('Capturing colors for viewport:', viewportStart, 'to', viewportEnd, 'buffer length:', activeBuffer ? activeBuffer.length : 'no buffer');
-		
-		let cellCount = 0;
-		for (let y = viewportStart; y < viewportEnd && activeBuffer && y < activeBuffer.length; y++) {
-			const line = activeBuffer.getLine(y);
-			const lineColorData = [];
-			
-			if (line) {
-				// Get the full line including trailing spaces
-				// translateToString(true) preserves trailing whitespace
-				const lineText = line.translateToString(true);
-				// Use term.cols to ensure we capture all columns, not just non-empty ones
-				for (let x = 0; x < term.cols; x++) {
-					const cell = line.getCell(x);
-					if (cell) {
-						cellCount++;
-						const chars = cell.getChars();
-						let fgColor = null;
-						let bgColor = null;
-						
-						
-						// Check if cell has foreground color
-						if (cell.isFgRGB()) {
-							const fg = cell.getFgColor();
-							fgColor = '#' + ((fg >> 16) & 0xff).toString(16).padStart(2, '0') +
-									 ((fg >> 8) & 0xff).toString(16).padStart(2, '0') +
-									 (fg & 0xff).toString(16).padStart(2, '0');
-						} else if (cell.isFgPalette()) {
-							// Handle palette colors
-							const paletteIndex = cell.getFgColor();
-							if (paletteIndex >= 0 && paletteIndex < 16) {
-								const colorNames = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white',
-												   'brightBlack', 'brightRed', 'brightGreen', 'brightYellow', 
-												   'brightBlue', 'brightMagenta', 'brightCyan', 'brightWhite'];
-								const palette = term.options.theme;
-								if (palette && palette[colorNames[paletteIndex]]) {
-									fgColor = palette[colorNames[paletteIndex]];
-								}
-							}
-						}
-						
-						// Check if cell has background color
-						if (cell.isBgRGB()) {
-							const bg = cell.getBgColor();
-							bgColor = '#' + ((bg >> 16) & 0xff).toString(16).padStart(2, '0') +
-									((bg >> 8) & 0xff).toString(16).padStart(2, '0') +
-									(bg & 0xff).toString(16).padStart(2, '0');
-							console.log('Found RGB background color:', bgColor, 'at', x, y);
-						} else if (cell.isBgPalette()) {
-							// Handle palette colors for background
-							const paletteIndex = cell.getBgColor();
-							if (paletteIndex >= 0 && paletteIndex < 16) {
-								const colorNames = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white',
-												   'brightBlack', 'brightRed', 'brightGreen', 'brightYellow', 
-												   'brightBlue', 'brightMagenta', 'brightCyan', 'brightWhite'];
-								const palette = term.options.theme;
-								const colorName = colorNames[paletteIndex];
-								console.log('Background - Palette index:', paletteIndex, 'colorName:', colorName, 'theme exists:', !!palette);
-								if (palette && palette[colorName]) {
-									bgColor = palette[colorName];
-									console.log('Found palette background color:', bgColor, 'for', colorName, 'at', x, y);
-								} else {
-									// Use default ANSI colors if theme doesn't have them
-									const defaultColors = ['#000000', '#cc0000', '#4e9a06', '#c4a000', '#3465a4', '#75507b', '#06989a', '#d3d7cf',
-														   '#555753', '#ef2929', '#8ae234', '#fce94f', '#729fcf', '#ad7fa8', '#34e2e2', '#eeeeec'];
-									bgColor = defaultColors[paletteIndex];
-									console.log('Using default color:', bgColor, 'for palette index:', paletteIndex);
-								}
-							}
-						}
-						
-						
-						lineColorData.push({
-							char: chars || ' ',
-							fgColor: fgColor === null ? '' : fgColor,
-							bgColor: bgColor === null ? '' : bgColor,
-							bold: cell.isBold() === 1,
-							italic: cell.isItalic() === 1,
-							underline: cell.isUnderline() === 1
-						});
-					}
-				}
-			}
-			lineColors.push(lineColorData);
-		}
-		
-		// Note: We no longer need to calculate character positions since
-		// we're using text-push positioning in SVG
-		
-		
-		return {
-			cursorX: cursorX,
-			cursorY: cursorY,
-			charWidth: charWidth,
-			charHeight: charHeight,
-			lineColors: lineColors,
-			cursorChar: cursorChar
-		};
-	}`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to evaluate terminal info: %w", err)
-	}
-
-	// Get buffer content as lines
-	bufferResult, err := page.Eval(`() => {
-		const term = window.term;
-		const buffer = term.buffer.active;
-		const lines = [];
-		
-		// Get all visible lines
-		const viewportStart = buffer.viewportY;
-		const viewportEnd = viewportStart + term.rows;
-		
-		for (let y = viewportStart; y < viewportEnd && y < buffer.length; y++) {
-			const line = buffer.getLine(y);
-			if (line) {
-				// translateToString(true) preserves trailing whitespace
-				lines.push(line.translateToString(true).trimEnd());
-			} else {
-				lines.push('');
-			}
-		}
-		
-		return lines;
-	}`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get buffer content: %w", err)
-	}
-
-	// Convert buffer result to string slice
-	buffer := []string{}
-	bufferArray := bufferResult.Value.Arr()
-	for _, line := range bufferArray {
-		buffer = append(buffer, line.Str())
-	}
-
-	// Parse the terminal info
-	cursorX := termInfo.Value.Get("cursorX").Int()
-	cursorY := termInfo.Value.Get("cursorY").Int()
-	charWidth := termInfo.Value.Get("charWidth").Num()
-	charHeight := termInfo.Value.Get("charHeight").Num()
-	cursorChar := termInfo.Value.Get("cursorChar").Str()
-
-	// Parse line colors
-	lineColors := [][]CharStyle{}
-	lineColorsJSON := termInfo.Value.Get("lineColors")
-	if !lineColorsJSON.Nil() {
-		lines := lineColorsJSON.Arr()
-		for _, line := range lines {
-			lineStyles := []CharStyle{}
-			chars := line.Arr()
-			for _, charData := range chars {
-				// Handle nil/null values properly
-				fgColor := ""
-				bgColor := ""
-				fgColorVal := charData.Get("fgColor")
-				bgColorVal := charData.Get("bgColor")
-				if !fgColorVal.Nil() && fgColorVal.Str() != nilValue {
-					fgColor = fgColorVal.Str()
-				}
-				if !bgColorVal.Nil() && bgColorVal.Str() != nilValue {
-					bgColor = bgColorVal.Str()
-				}
-
-				style := CharStyle{
-					FgColor:   fgColor,
-					BgColor:   bgColor,
-					Bold:      charData.Get("bold").Bool(),
-					Italic:    charData.Get("italic").Bool(),
-					Underline: charData.Get("underline").Bool(),
-				}
-				lineStyles = append(lineStyles, style)
-			}
-			lineColors = append(lineColors, lineStyles)
-		}
-	}
-
-	svgFrame := &SVGFrame{
-		Lines:      buffer,
-		LineColors: lineColors,
-		CursorX:    cursorX,
-		CursorY:    cursorY,
-		CharWidth:  charWidth,
-		CharHeight: charHeight,
-		Timestamp:  float64(counter) / float64(framerate),
-		CursorChar: cursorChar,
-	}
-
-	return svgFrame, nil
-}
-
 // parseFontFamily parses a font family string and returns a list of individual fonts.
 func parseFontFamily(fontFamily string) []string {
 	if fontFamily == "" {
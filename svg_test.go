@@ -2,11 +2,13 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"regexp"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Helper functions
@@ -134,7 +136,7 @@ func TestSVGGenerator_StyleOptions(t *testing.T) {
 			{
 				name:     "Colorful",
 				style:    "Colorful",
-				contains: []string{`cx="20"`, `cx="40"`, `cx="60"`, `fill="#ff5f58"`},
+				contains: []string{`cx="20"`, `cx="38"`, `cx="56"`, `r="6"`, `fill="#ff5f58"`},
 			},
 			{
 				name:     "ColorfulRight",
@@ -547,9 +549,39 @@ func TestSVGGenerator_WindowBarFonts(t *testing.T) {
 		assertContains(t, svg, "Test App", "Window bar title")
 		assertContains(t, svg, "JetBrains Mono", "Falls back to main font family")
 		assertContains(t, svg, "font-size=\"16\"", "Falls back to main font size")
+		assertContains(t, svg, `fill="#cccccc"`, "Default title color")
+	})
+
+	t.Run("uses custom window bar title color", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Style = &StyleOptions{
+			WindowBar:           "Colorful",
+			WindowBarTitle:      "Test App",
+			WindowBarTitleColor: "#ff00ff",
+		}
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, `fill="#ff00ff">Test App</text>`, "Window bar title color")
 	})
 }
 
+// Window controls scale with the window bar
+func TestSVGGenerator_WindowBarControls(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Width = 800
+	opts.Style = DefaultStyleOptions()
+	opts.Style.WindowBar = "RingsRight"
+	opts.Style.WindowBarSize = 60
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	// A radius of 12, 18px from the edge and 34px apart, stroked inside
+	for _, expected := range []string{`cx="770" cy="30" r="11"`, `cx="736"`, `cx="702"`, `stroke-width="2"`} {
+		assertContains(t, svg, expected, "Scaled window control")
+	}
+}
+
 // Integration Tests
 func TestMakeSVG(t *testing.T) {
 	t.Run("generates SVG file", func(t *testing.T) {
@@ -899,7 +931,7 @@ func TestSVGGenerator_UtilityFunctions(t *testing.T) {
 			{gen.options.Theme.Red, "r", "red color"},
 			{gen.options.Theme.Green, "g", "green color"},
 			{gen.options.Theme.Yellow, "y", "yellow color"},
-			{gen.options.Theme.Blue, "b", "blue color"},
+			{gen.options.Theme.Blue, "l", "blue color"},
 			{gen.options.Theme.Magenta, "m", "magenta color"},
 			{gen.options.Theme.Cyan, "c", "cyan color"},
 			{gen.options.Theme.White, "w", "white color"},
@@ -1478,3 +1510,401 @@ func TestSVGGenerator_TypingAnimationCSS(t *testing.T) {
 		}
 	})
 }
+
+func TestSVGGenerator_SceneTransitions(t *testing.T) {
+	t.Run("fades between scenes", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Duration = 10
+		opts.SceneTimes = []float64{5}
+		opts.Transition = Transition{Type: transitionFade, Duration: time.Second}
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, "@keyframes scene", "Scene keyframes")
+		assertContains(t, svg, "45%, 55% { opacity: 1; }", "Scene fade bounds")
+		assertContains(t, svg, "50% { opacity: 0; }", "Scene fade midpoint")
+		assertContains(t, svg, `<g class="scenes">`, "Scene group")
+	})
+
+	t.Run("no transition without scenes", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Transition = Transition{Type: transitionFade, Duration: time.Second}
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertNotContains(t, svg, "@keyframes scene", "No scene keyframes")
+	})
+}
+
+func TestSVGGenerator_Resize(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{
+		{Lines: []string{"$ htop"}, CharWidth: 10, CharHeight: 20, Cols: 80, Rows: 20, Timestamp: 0},
+		{Lines: []string{"$ htop"}, CharWidth: 10, CharHeight: 20, Cols: 160, Rows: 20, Timestamp: 1},
+	}
+
+	gen := NewSVGGenerator(opts)
+	scale := gen.stateScale(&TerminalState{Cols: 160, Rows: 20})
+	expected := gen.frameSpacing / 1600
+	if scale != expected {
+		t.Errorf("expected scale %v, got %v", expected, scale)
+	}
+	if scale := gen.stateScale(&TerminalState{Cols: 10, Rows: 2}); scale != 1 {
+		t.Errorf("expected small terminal not to be scaled, got %v", scale)
+	}
+
+	svg := gen.Generate()
+	assertContains(t, svg, "scale("+formatScale(expected)+")", "Resized state is scaled")
+}
+
+func TestSVGGenerator_InlineImages(t *testing.T) {
+	const image = "data:image/png;base64,iVBORw0KGgo="
+
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{
+		{Lines: []string{"$ chafa logo.png"}, CharWidth: 10, CharHeight: 20, Cols: 80, Rows: 24, Timestamp: 0},
+		{Lines: []string{"$ chafa logo.png", "", "$"}, CharWidth: 10, CharHeight: 20, Cols: 80, Rows: 24, Image: image, Timestamp: 0.5},
+		{Lines: []string{"$ chafa logo.png", "", "$ "}, CharWidth: 10, CharHeight: 20, Cols: 80, Rows: 24, Image: image, Timestamp: 1},
+	}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `<image id="img0" width="800" height="480" href="`+image+`"/>`, "Image definition")
+	assertContains(t, svg, `<use href="#img0"/>`, "Image reference")
+	if n := strings.Count(svg, image); n != 1 {
+		t.Errorf("expected image to be embedded once, got %d", n)
+	}
+}
+
+func TestSVGGenerator_Hyperlinks(t *testing.T) {
+	link := CharStyle{Link: "https://github.com/agentstation/vhs"}
+	frame := SVGFrame{
+		Lines:      []string{"see vhs"},
+		LineColors: [][]CharStyle{{{}, {}, {}, {}, link, link, link}},
+		CharWidth:  10,
+		CharHeight: 20,
+	}
+
+	t.Run("clickable", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{frame}
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, `xmlns:xlink="http://www.w3.org/1999/xlink"`, "xlink namespace")
+		assertContains(t, svg, `<a xlink:href="https://github.com/agentstation/vhs" target="_blank"><rect x="40" y="0" width="30" height="20" fill="transparent"/></a>`, "Link area")
+	})
+
+	t.Run("underlined", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{frame}
+		opts.UnderlineLinks = true
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, `<rect x="40" y="18" width="30" height="1"`, "Link underline")
+	})
+
+	t.Run("unsafe scheme", func(t *testing.T) {
+		script := CharStyle{Link: "javascript:alert(document.cookie)"}
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{{
+			Lines:      []string{"click me"},
+			LineColors: [][]CharStyle{{script, script, script, script, script}},
+			CharWidth:  10,
+			CharHeight: 20,
+		}}
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertNotContains(t, svg, "javascript:", "No javascript: link")
+		assertNotContains(t, svg, "<a ", "No anchor")
+		assertContains(t, svg, "click", "Text is still rendered")
+	})
+
+	t.Run("no links", func(t *testing.T) {
+		svg := NewSVGGenerator(createTestSVGConfig()).Generate()
+		assertNotContains(t, svg, "xmlns:xlink", "No xlink namespace")
+	})
+}
+
+func TestSVGGenerator_Transcript(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Transcript = []string{"$ seq 3", "1", "2", "3 <done>"}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, fmt.Sprintf(`height="%d"`, 2*opts.Style.Height), "SVG extends below the animation")
+	assertContains(t, svg, fmt.Sprintf(`<foreignObject x="0" y="%d"`, opts.Style.Height), "Transcript panel")
+	assertContains(t, svg, "overflow:auto", "Scrollable transcript")
+	assertContains(t, svg, "$ seq 3\n1\n2\n3 &lt;done&gt;</pre>", "Escaped transcript")
+}
+
+func TestSVGGenerator_WideCharacters(t *testing.T) {
+	red := CharStyle{FgColor: "#ff0000"}
+	wide := CharStyle{Width: 2}
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{{
+		Lines:      []string{"日本 ok", "é👍!", "日$"},
+		LineColors: [][]CharStyle{{wide, {}, wide, {}, {}, red, red}},
+		CursorX:    3,
+		CursorY:    2,
+		CursorChar: "█",
+		CharWidth:  10,
+		CharHeight: 20,
+	}}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `<tspan x="20" class="f">本</tspan>`, "Wide character starts after the previous wide character")
+	assertContains(t, svg, `<tspan x="50" class="f" style="fill:#ff0000;">ok</tspan>`, "Text after wide characters stays on the grid")
+	assertContains(t, svg, "<tspan x=\"0\" class=\"f\">e\u0301</tspan>", "Combining mark stays with its base character")
+	assertContains(t, svg, `<tspan x="30" class="f">!</tspan>`, "Text after emoji stays on the grid")
+	assertContains(t, svg, `<tspan x="30" class="f c`, "Cursor is placed at its terminal column")
+}
+
+func TestSVGGenerator_Emoji(t *testing.T) {
+	// The frame is captured like the native backend does, from the emulator.
+	e := newEmulator(20, 1, DefaultTheme)
+	_, _ = e.Write([]byte("✅ done 🎉 ❤️ x"))
+	frame := e.Frame()
+	frame.CharWidth, frame.CharHeight = 10, 20
+	frame.CursorChar = "█"
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{frame}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `<tspan x="20" class="f"> done </tspan>`, "Text after an emoji starts two cells after it")
+	assertContains(t, svg, `<tspan x="80" class="f">🎉</tspan>`, "Emoji are positioned by cell, not by byte")
+	assertContains(t, svg, `<tspan x="120" class="f"> x</tspan>`, "Emoji with a variation selector keep the width of the capture")
+	assertContains(t, svg, `<tspan x="140" class="f c`, "Cursor is placed after the emoji")
+	assertContains(t, svg, `"Noto Color Emoji", monospace`, "Emoji fonts are declared in the font stack")
+}
+
+func TestSVGGenerator_CursorColor(t *testing.T) {
+	e := newEmulator(10, 1, DefaultTheme)
+	_, _ = e.Write([]byte("ab\x1b[31mc\x1b[0m\x1b[D"))
+	frame := e.Frame()
+	frame.CharWidth, frame.CharHeight = 10, 20
+	frame.CursorChar = "█"
+
+	t.Run("color", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{frame}
+		opts.CursorColor = "#ff00ff"
+		opts.CursorAccentColor = "#000000"
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, `class="f cursor-active" style="fill:#ff00ff;">█</tspan>`, "The cursor is in the cursor color")
+		assertContains(t, svg, `<tspan x="20" class="f cursor-active" style="fill:#000000;">c</tspan>`, "The text under the cursor is in the accent color")
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{frame}
+		opts.CursorColor = cursorReverse
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, fmt.Sprintf(`class="f cursor-active" style="fill:%s;">█</tspan>`, DefaultTheme.Red), "The cursor is in the color of the text under it")
+		assertContains(t, svg, fmt.Sprintf(`<tspan x="20" class="f cursor-active" style="fill:%s;">c</tspan>`, opts.Theme.Background), "The text under the cursor is in its background")
+	})
+}
+
+func TestSVGGenerator_NoLigatures(t *testing.T) {
+	frame := SVGFrame{Lines: []string{"a != b"}, CharWidth: 10, CharHeight: 20}
+
+	t.Run("ligatures", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{frame}
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, `<tspan class="f">a != b</tspan>`, "Text is shaped as a run")
+		assertNotContains(t, svg, "font-variant-ligatures", "Ligatures are enabled")
+	})
+
+	t.Run("grid", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.Frames = []SVGFrame{frame}
+		opts.NoLigatures = true
+
+		svg := NewSVGGenerator(opts).Generate()
+
+		assertContains(t, svg, `<tspan x="0 10 20 30 40 50" class="f">a != b</tspan>`, "Every character is on the grid")
+		assertContains(t, svg, "font-variant-ligatures: none;", "Ligatures are disabled")
+	})
+}
+
+func TestSVGGenerator_Spacing(t *testing.T) {
+	t.Run("recorded cells", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.LineHeight = 1.5
+		opts.LetterSpacing = 2
+		opts.Frames = []SVGFrame{{Lines: []string{"one", "two"}, CharWidth: 12, CharHeight: 30}}
+
+		gen := NewSVGGenerator(opts)
+		svg := gen.Generate()
+
+		// The cells already include the line height, which is not applied twice
+		assertContains(t, svg, `<text y="51"`, "Second row is one cell below the first, centered")
+		assertContains(t, svg, "letter-spacing: 2px;", "Letter spacing is applied to flowing text")
+		if gen.charWidth != 12 {
+			t.Errorf("expected the recorded cell width, got %v", gen.charWidth)
+		}
+	})
+
+	t.Run("fallback cells", func(t *testing.T) {
+		opts := createTestSVGConfig()
+		opts.FontSize = 20
+		opts.LineHeight = 1.5
+		opts.LetterSpacing = 2
+		opts.Frames = []SVGFrame{{Lines: []string{"one"}}}
+
+		gen := NewSVGGenerator(opts)
+		if gen.charWidth != 13 {
+			t.Errorf("expected the cell width to include the letter spacing, got %v", gen.charWidth)
+		}
+		if gen.charHeight != 36 {
+			t.Errorf("expected the cell height to include the line height, got %v", gen.charHeight)
+		}
+	})
+}
+
+func TestSVGGenerator_TextAttributes(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{{
+		Lines: []string{"bold under strike", "inv"},
+		LineColors: [][]CharStyle{
+			append(append(
+				repeatStyle(CharStyle{Bold: true}, 5),
+				repeatStyle(CharStyle{Underline: true, Strikethrough: true}, 6)...),
+				repeatStyle(CharStyle{Dim: true}, 6)...),
+			repeatStyle(CharStyle{Inverse: true}, 3),
+		},
+		CharWidth:  10,
+		CharHeight: 20,
+	}}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, ".b { font-weight: bold; }", "Bold class")
+	assertContains(t, svg, ".u.s { text-decoration: underline line-through; }", "Combined decorations")
+	assertContains(t, svg, ".d { fill-opacity: 0.5; }", "Dim class")
+	assertNotContains(t, svg, ".i {", "Unused attributes have no class")
+	assertContains(t, svg, `class="f b">bold </tspan>`, "Bold text")
+	assertContains(t, svg, `class="f u s">under </tspan>`, "Underlined and struck through text")
+	assertContains(t, svg, `class="f d">strike</tspan>`, "Dim text")
+	assertContains(t, svg, fmt.Sprintf(`fill="%s"`, DefaultTheme.Foreground), "Inverse text has a foreground background")
+	assertContains(t, svg, fmt.Sprintf(`style="fill:%s;">inv</tspan>`, DefaultTheme.Background), "Inverse text is drawn in the background color")
+}
+
+func repeatStyle(style CharStyle, n int) []CharStyle {
+	styles := make([]CharStyle, n)
+	for i := range styles {
+		styles[i] = style
+	}
+	return styles
+}
+
+func TestSVGGenerator_Highlight(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Theme.Selection = "#bfdbfe"
+	opts.Frames = []SVGFrame{
+		{Lines: []string{"hello world", "second line"}, CharWidth: 8.8, CharHeight: 20, Cols: 20, Rows: 2},
+		{
+			Lines: []string{"hello world", "second line"}, CharWidth: 8.8, CharHeight: 20, Cols: 20, Rows: 2,
+			Selection: &Selection{StartRow: 0, StartCol: 6, EndRow: 1, EndCol: 5},
+			Timestamp: 0.1,
+		},
+	}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `<rect x="52.8" y="0" width="123.2" height="20" fill="#bfdbfe"/>`, "First row is selected to the end")
+	assertContains(t, svg, `<rect x="0" y="20" width="52.8" height="20" fill="#bfdbfe"/>`, "Last row is selected to the end column")
+
+	opts.Theme.Selection = ""
+	svg = NewSVGGenerator(opts).Generate()
+	assertContains(t, svg, `fill-opacity="0.3"`, "Themes without a selection color use a translucent foreground")
+}
+
+func TestSVGGenerator_Camera(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Frames = make([]SVGFrame, 100)
+	for i := range opts.Frames {
+		opts.Frames[i] = SVGFrame{Lines: []string{"dashboard"}, CharWidth: 8.8, CharHeight: 20, Timestamp: float64(i) / 50}
+	}
+	opts.Duration = 2
+	opts.Camera = []CameraKeyframe{
+		{Frame: 25, Zoom: 1, X: 0.5, Y: 0.5},
+		{Frame: 50, Zoom: 2, X: 0.25, Y: 0.25},
+	}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `<animate attributeName="viewBox"`, "Camera animates the viewBox")
+	assertContains(t, svg, `keyTimes="0.0000;0.2500;0.5000;1.0000"`, "Keyframes are placed by frame")
+	assertContains(t, svg, `dur="2s"`, "Camera loops with the animation")
+
+	opts.Camera = nil
+	assertNotContains(t, NewSVGGenerator(opts).Generate(), `attributeName="viewBox"`, "No camera without Zoom")
+}
+
+func TestSVGGenerator_Annotations(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Frames = make([]SVGFrame, 100)
+	for i := range opts.Frames {
+		opts.Frames[i] = SVGFrame{Lines: []string{"dashboard"}, CharWidth: 8.8, CharHeight: 20, Timestamp: float64(i) / 50}
+	}
+	opts.Annotations = []Annotation{{
+		Text: "Click <here>", Row: 1, Col: 1, Arrow: true, ArrowRow: 5, ArrowCol: 4,
+		Start: 26, End: 76, Foreground: "#ffffff", Background: "#000000",
+	}}
+
+	svg := NewSVGGenerator(opts).Generate()
+
+	assertContains(t, svg, `<g class="annotation annotation0">`, "Callout is drawn")
+	assertContains(t, svg, "Click &lt;here&gt;</text>", "Callout text is escaped")
+	assertContains(t, svg, "<polygon points=", "Arrow head is drawn")
+	assertContains(t, svg, "@keyframes annotation0 { 0% { opacity: 0; } 25% { opacity: 1; } 75% { opacity: 0; } }",
+		"Callout is shown between its frames")
+}
+
+func TestSVGGenerator_FrameTimestamps(t *testing.T) {
+	opts := createTestSVGConfig()
+	opts.Frames = []SVGFrame{
+		{Lines: []string{"a"}, Timestamp: 1},
+		{Lines: []string{"ab"}, Timestamp: 1.1},
+		{Lines: []string{"abc"}, Timestamp: 2},
+	}
+	opts.Duration = 0
+
+	g := NewSVGGenerator(opts)
+	g.processFrames()
+
+	// The last frame lasts as long as the average frame, 0.5s.
+	if duration, _ := g.animationTiming(); duration != 1.5 {
+		t.Errorf("expected the duration of the timestamps, got %v", duration)
+	}
+	want := []float64{0, 0.1 / 1.5 * 100, 1 / 1.5 * 100, 100}
+	if len(g.timeline) != len(want) {
+		t.Fatalf("expected %d stops, got %+v", len(want), g.timeline)
+	}
+	for i, stop := range g.timeline {
+		if math.Abs(stop.Percentage-want[i]) > 1e-9 {
+			t.Errorf("stop %d: expected %v%%, got %v%%", i, want[i], stop.Percentage)
+		}
+	}
+
+	// Frames without timestamps are spread evenly.
+	opts.Frames = []SVGFrame{{Lines: []string{"a"}}, {Lines: []string{"b"}}, {Lines: []string{"c"}}}
+	opts.Duration = 3
+	g = NewSVGGenerator(opts)
+	g.processFrames()
+	if g.timeline[1].Percentage != 50 {
+		t.Errorf("expected the frames to be spread evenly, got %+v", g.timeline)
+	}
+}
@@ -0,0 +1,134 @@
+// Package vhs svgjson.go renders SVG outputs of frames captured beforehand.
+//
+// A JSON recording holds the frames of the terminal with the style to render
+// them in, so that they can be rendered again without recording the tape,
+// e.g. by the WebAssembly build to preview a tape in a web playground, or by
+// vhs preview. The theme is a theme name or a theme as JSON, as in Set Theme.
+// A .vhs.json output writes the recording of a tape.
+//
+//	{"theme": "Dracula", "fontSize": 22, "frames": [{"lines": ["> ls"], "timestamp": 0.02}]}
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// svgRecordingExt is the extension of JSON recordings.
+const svgRecordingExt = ".vhs.json"
+
+// SVGRecording is a JSON recording of the frames of an SVG output. Zero
+// values take the defaults of VHS.
+type SVGRecording struct {
+	Frames []SVGFrame `json:"frames"`
+	// Duration is the duration of the recording in seconds, the timestamp of
+	// the last frame by default.
+	Duration       float64         `json:"duration,omitempty"`
+	Theme          json.RawMessage `json:"theme,omitempty"`
+	FontSize       int             `json:"fontSize,omitempty"`
+	FontFamily     string          `json:"fontFamily,omitempty"`
+	LineHeight     float64         `json:"lineHeight,omitempty"`
+	LetterSpacing  float64         `json:"letterSpacing,omitempty"`
+	Width          int             `json:"width,omitempty"`
+	Height         int             `json:"height,omitempty"`
+	Padding        *int            `json:"padding,omitempty"`
+	Margin         int             `json:"margin,omitempty"`
+	MarginFill     string          `json:"marginFill,omitempty"`
+	BorderRadius   int             `json:"borderRadius,omitempty"`
+	WindowBar      string          `json:"windowBar,omitempty"`
+	WindowBarTitle string          `json:"windowBarTitle,omitempty"`
+	WindowBarSize  int             `json:"windowBarSize,omitempty"`
+	CursorBlink    *bool           `json:"cursorBlink,omitempty"`
+	PlaybackSpeed  float64         `json:"playbackSpeed,omitempty"`
+	Description    string          `json:"description,omitempty"`
+}
+
+// ParseSVGRecording parses a JSON recording into the configuration of its
+// SVG output.
+func ParseSVGRecording(data []byte) (SVGConfig, error) {
+	var r SVGRecording
+	if err := json.Unmarshal(data, &r); err != nil {
+		return SVGConfig{}, fmt.Errorf("invalid recording: %w", err)
+	}
+	if len(r.Frames) == 0 {
+		return SVGConfig{}, errors.New("invalid recording: no frames")
+	}
+
+	theme := DefaultTheme
+	if len(r.Theme) > 0 {
+		name := string(r.Theme)
+		if err := json.Unmarshal(r.Theme, &name); err != nil {
+			name = string(r.Theme)
+		}
+		var err error
+		if theme, err = getTheme(name); err != nil {
+			return SVGConfig{}, err
+		}
+	}
+
+	style := DefaultStyleOptions()
+	style.BackgroundColor = theme.Background
+	style.WindowBarColor = theme.Background
+	style.Width = cmp.Or(r.Width, style.Width)
+	style.Height = cmp.Or(r.Height, style.Height)
+	if r.Padding != nil {
+		style.Padding = *r.Padding
+	}
+	style.Margin = r.Margin
+	style.MarginFill = cmp.Or(r.MarginFill, style.MarginFill)
+	style.BorderRadius = r.BorderRadius
+	style.WindowBar = r.WindowBar
+	style.WindowBarTitle = r.WindowBarTitle
+	style.WindowBarSize = cmp.Or(r.WindowBarSize, style.WindowBarSize)
+
+	cfg := SVGConfig{
+		Width:         style.Width,
+		Height:        style.Height,
+		FontSize:      cmp.Or(r.FontSize, defaultFontSize),
+		FontFamily:    cmp.Or(r.FontFamily, defaultFontFamily),
+		Theme:         theme,
+		Frames:        r.Frames,
+		Duration:      cmp.Or(r.Duration, r.Frames[len(r.Frames)-1].Timestamp),
+		Style:         style,
+		LineHeight:    cmp.Or(r.LineHeight, defaultLineHeight),
+		LetterSpacing: cmp.Or(r.LetterSpacing, defaultLetterSpacing),
+		CursorBlink:   r.CursorBlink == nil || *r.CursorBlink,
+		PlaybackSpeed: cmp.Or(r.PlaybackSpeed, defaultPlaybackSpeed),
+		OptimizeSize:  true,
+		Description:   r.Description,
+	}
+	style.FontFamily = cfg.FontFamily
+	style.FontSize = cfg.FontSize
+	return cfg, nil
+}
+
+// NewSVGRecording returns the JSON recording of the frames of an SVG output.
+func NewSVGRecording(cfg SVGConfig) SVGRecording {
+	theme, _ := json.Marshal(cfg.Theme)
+	r := SVGRecording{
+		Frames:        cfg.Frames,
+		Duration:      cfg.Duration,
+		Theme:         theme,
+		FontSize:      cfg.FontSize,
+		FontFamily:    cfg.FontFamily,
+		LineHeight:    cfg.LineHeight,
+		LetterSpacing: cfg.LetterSpacing,
+		Width:         cfg.Width,
+		Height:        cfg.Height,
+		CursorBlink:   &cfg.CursorBlink,
+		PlaybackSpeed: cfg.PlaybackSpeed,
+		Description:   cfg.Description,
+	}
+	if style := cfg.Style; style != nil {
+		r.Padding = &style.Padding
+		r.Margin = style.Margin
+		r.MarginFill = style.MarginFill
+		r.BorderRadius = style.BorderRadius
+		r.WindowBar = style.WindowBar
+		r.WindowBarTitle = style.WindowBarTitle
+		r.WindowBarSize = style.WindowBarSize
+	}
+	return r
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSVGRecording(t *testing.T) {
+	cfg, err := ParseSVGRecording([]byte(`{
+		"theme": "Dracula",
+		"padding": 0,
+		"windowBar": "Colorful",
+		"frames": [
+			{"lines": ["> l"], "cursorX": 3, "timestamp": 0.02},
+			{"lines": ["> ls"], "cursorX": 4, "timestamp": 0.04}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme.Name != "Dracula" || cfg.Style.BackgroundColor != cfg.Theme.Background {
+		t.Errorf("expected the Dracula theme, got %+v", cfg.Theme)
+	}
+	if cfg.Style.Padding != 0 || cfg.Style.WindowBar != "Colorful" || cfg.Width != defaultWidth {
+		t.Errorf("unexpected style %+v", cfg.Style)
+	}
+	if cfg.FontSize != defaultFontSize || cfg.FontFamily != defaultFontFamily || !cfg.CursorBlink {
+		t.Errorf("expected the default font and cursor, got %+v", cfg)
+	}
+	if cfg.Duration != 0.04 || len(cfg.Frames) != 2 || cfg.Frames[1].Lines[0] != "> ls" {
+		t.Errorf("unexpected frames %+v over %vs", cfg.Frames, cfg.Duration)
+	}
+
+	svg := NewSVGGenerator(cfg).Generate()
+	if !strings.Contains(svg, "&gt; ls") {
+		t.Errorf("expected the frames in the SVG, got %s", svg)
+	}
+}
+
+func TestParseSVGRecordingTheme(t *testing.T) {
+	cfg, err := ParseSVGRecording([]byte(`{"theme": {"background": "#000000"}, "frames": [{"lines": [""]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme.Background != "#000000" {
+		t.Errorf("expected the JSON theme, got %+v", cfg.Theme)
+	}
+}
+
+func TestParseSVGRecordingErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"frames": []}`,
+		`{"frames": [{"lines": [""]}], "theme": "No Such Theme"}`,
+		`{"frames": "> ls"}`,
+	} {
+		if _, err := ParseSVGRecording([]byte(data)); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
+
+func TestSVGFrameJSON(t *testing.T) {
+	frame := SVGFrame{
+		Lines:      []string{"ok"},
+		LineColors: [][]CharStyle{{{FgColor: "#ff0000", Bold: true, Width: 1}, {Width: 1}}},
+		CursorX:    2,
+		Timestamp:  0.5,
+		Selection:  &Selection{EndCol: 1},
+	}
+	b, err := json.Marshal(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got SVGFrame
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, frame) {
+		t.Errorf("expected %+v, got %+v from %s", frame, got, b)
+	}
+}
@@ -0,0 +1,147 @@
+// Package vhs svgopt.go shrinks the generated SVGs.
+//
+// Set SVGOptimizeLevel sets how hard SVG outputs are optimized. 0 keeps the
+// readable output of the generator, and 1, the default, its short class
+// names without newlines. 2 also runs the optimizer over the generated SVG:
+// it pools the lines repeated across states into shared elements, which the
+// states then use, and minifies the style sheet and path data. 3 also rounds
+// the coordinates to whole pixels, keeping the edges of adjacent backgrounds
+// together.
+//
+// Set SVGOptimizeLevel 3
+package main
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The levels of Set SVGOptimizeLevel.
+const (
+	svgOptimizeNone = iota
+	svgOptimizeGenerator
+	svgOptimizePool
+	svgOptimizeRound
+)
+
+var (
+	svgStyle      = regexp.MustCompile(`(?s)<style>.*?</style>`)
+	svgStyleSpace = regexp.MustCompile(`\s*([{};:,])\s*`)
+	svgPath       = regexp.MustCompile(`\bd="[^"]*"`)
+	svgPathSpace  = regexp.MustCompile(`\s*([MLHVCSQTAZmlhvcsqtaz])\s*`)
+	svgText       = regexp.MustCompile(`<text [^>]*>.*?</text>`)
+	svgRect       = regexp.MustCompile(`<rect [^>]*>`)
+	svgCoord      = regexp.MustCompile(`\b(x|y|width|height|cx|cy|r)="(-?[\d.]+(?: -?[\d.]+)*)"`)
+	svgTranslate  = regexp.MustCompile(`translate\((-?[\d.]+),(-?[\d.]+)\)`)
+)
+
+// optimizeSVG runs the passes of the level over the SVG.
+func optimizeSVG(svg string, level int) string {
+	if level < svgOptimizePool {
+		return svg
+	}
+	svg = svgStyle.ReplaceAllStringFunc(svg, minifyCSS)
+	svg = svgPath.ReplaceAllStringFunc(svg, func(d string) string {
+		return svgPathSpace.ReplaceAllString(d, "$1")
+	})
+	// Rounded lines are more likely to be repeated.
+	if level >= svgOptimizeRound {
+		svg = roundCoords(svg)
+	}
+	return poolLines(svg)
+}
+
+// minifyCSS removes the whitespace and the last semicolons of the rules of
+// the style sheet.
+func minifyCSS(css string) string {
+	css = svgStyleSpace.ReplaceAllString(css, "$1")
+	return strings.ReplaceAll(css, ";}", "}")
+}
+
+// roundCoords rounds the coordinates of the SVG to whole pixels. The edges of
+// rects are rounded rather than their size, so that adjacent backgrounds
+// neither overlap nor leave a gap.
+func roundCoords(svg string) string {
+	svg = svgRect.ReplaceAllStringFunc(svg, roundRect)
+	svg = svgCoord.ReplaceAllStringFunc(svg, func(attr string) string {
+		m := svgCoord.FindStringSubmatch(attr)
+		values := strings.Fields(m[2])
+		for i, value := range values {
+			values[i] = roundNumber(value)
+		}
+		return m[1] + `="` + strings.Join(values, " ") + `"`
+	})
+	return svgTranslate.ReplaceAllStringFunc(svg, func(t string) string {
+		m := svgTranslate.FindStringSubmatch(t)
+		return "translate(" + roundNumber(m[1]) + "," + roundNumber(m[2]) + ")"
+	})
+}
+
+// roundRect rounds the edges of a rect.
+func roundRect(rect string) string {
+	attrs := make(map[string]float64)
+	for _, m := range svgCoord.FindAllStringSubmatch(rect, -1) {
+		if v, err := strconv.ParseFloat(m[2], 64); err == nil {
+			attrs[m[1]] = v
+		}
+	}
+	for _, edge := range [][2]string{{"x", "width"}, {"y", "height"}} {
+		start, size := attrs[edge[0]], attrs[edge[1]]
+		if _, ok := attrs[edge[1]]; !ok {
+			continue
+		}
+		rounded := math.Round(start + size)
+		rect = strings.Replace(rect, edge[1]+`="`+formatCoord(size)+`"`,
+			edge[1]+`="`+formatCoord(rounded-math.Round(start))+`"`, 1)
+	}
+	return rect
+}
+
+// roundNumber rounds a number to a whole number.
+func roundNumber(s string) string {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	return strconv.Itoa(int(math.Round(v)))
+}
+
+// poolLines defines the lines repeated across states once, and uses them
+// wherever they are repeated when that is shorter.
+func poolLines(svg string) string {
+	counts := make(map[string]int)
+	var lines []string
+	for _, line := range svgText.FindAllString(svg, -1) {
+		if counts[line] == 0 {
+			lines = append(lines, line)
+		}
+		counts[line]++
+	}
+
+	ids := make(map[string]string)
+	var defs strings.Builder
+	for _, line := range lines {
+		id := "l" + strconv.FormatInt(int64(len(ids)), 36)
+		use := `<use href="#` + id + `"/>`
+		pooled := len(line) + len(` id=""`) + len(id) + counts[line]*len(use)
+		if counts[line] < 2 || pooled >= counts[line]*len(line) {
+			continue
+		}
+		ids[line] = id
+		defs.WriteString(strings.Replace(line, "<text ", `<text id="`+id+`" `, 1))
+	}
+	if len(ids) == 0 {
+		return svg
+	}
+
+	svg = svgText.ReplaceAllStringFunc(svg, func(line string) string {
+		if id, ok := ids[line]; ok {
+			return `<use href="#` + id + `"/>`
+		}
+		return line
+	})
+	i := strings.LastIndex(svg, "</svg>")
+	return svg[:i] + "<defs>" + defs.String() + "</defs>" + svg[i:]
+}
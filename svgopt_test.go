@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestOptimizeSVG(t *testing.T) {
+	line := `<text y="21.6" xml:space="preserve"><tspan x="0" class="t">hello world</tspan></text>`
+	svg := `<svg><style>.t { fill: #fff; font-family: "Fira Code", monospace; }</style>` +
+		`<path d="M 10,0 L 20,0 Z"/>` +
+		`<g transform="translate(0,0)">` + line + `</g>` +
+		`<g transform="translate(400.4,0)">` + line + `<rect x="14.2" y="0" width="14.2" height="27"/><rect x="28.4" y="0" width="14.2" height="27"/></g>` +
+		`</svg>`
+
+	for _, level := range []int{svgOptimizeNone, svgOptimizeGenerator} {
+		if got := optimizeSVG(svg, level); got != svg {
+			t.Errorf("level %d: expected the SVG to be left as is, got %s", level, got)
+		}
+	}
+
+	t.Run("pool", func(t *testing.T) {
+		got := optimizeSVG(svg, svgOptimizePool)
+		assertContains(t, got, `<style>.t{fill:#fff;font-family:"Fira Code",monospace}</style>`, "The styles are minified")
+		assertContains(t, got, `d="M10,0L20,0Z"`, "The path data is minified")
+		if n := strings.Count(got, `<use href="#l0"/>`); n != 2 {
+			t.Errorf("expected the repeated line to be used twice, got %d uses in %s", n, got)
+		}
+		assertContains(t, got, `<defs><text id="l0" y="21.6"`, "The repeated line is defined once")
+		assertContains(t, got, `translate(400.4,0)`, "Coordinates are kept")
+	})
+
+	t.Run("round", func(t *testing.T) {
+		got := optimizeSVG(svg, svgOptimizeRound)
+		assertContains(t, got, `<text id="l0" y="22"`, "Coordinates are rounded")
+		assertContains(t, got, `translate(400,0)`, "Translations are rounded")
+		assertContains(t, got, `<rect x="14" y="0" width="14" height="27"/><rect x="28" y="0" width="15" height="27"/>`,
+			"The edges of adjacent rects stay together")
+	})
+
+	t.Run("unique lines", func(t *testing.T) {
+		svg := `<svg><text y="1"><tspan>a</tspan></text><text y="2"><tspan>b</tspan></text></svg>`
+		if got := optimizeSVG(svg, svgOptimizePool); got != svg {
+			t.Errorf("expected lines which are not repeated to be kept, got %s", got)
+		}
+	})
+}
+
+func TestExecuteSetSVGOptimizeLevel(t *testing.T) {
+	v := &VHS{Options: &Options{SVG: DefaultSVGOptions()}}
+	if err := ExecuteSetSVGOptimizeLevel(parser.Command{Args: "0"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.SVG.OptimizeSize {
+		t.Error("expected level 0 to disable the optimization")
+	}
+	if err := ExecuteSetSVGOptimizeLevel(parser.Command{Args: "3"}, v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Options.SVG.OptimizeSize || v.Options.SVG.OptimizeLevel != svgOptimizeRound {
+		t.Errorf("expected level 3, got %+v", v.Options.SVG)
+	}
+}
@@ -0,0 +1,210 @@
+// Package vhs syntaxhighlight.go colors source code in SVG outputs.
+//
+// Programs like cat print source code without colors. With Set
+// SyntaxHighlight, the runs of lines of SVG outputs which have no colors of
+// their own are highlighted as the language, or as the language detected from
+// them with auto: their tokens take the colors of the theme, e.g. keywords in
+// magenta and strings in green, which are rendered with its color classes.
+// Lines with colors, like the prompt, are left as they are, and the other
+// outputs are not highlighted.
+//
+// Set SyntaxHighlight go
+package main
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/rivo/uniseg"
+)
+
+// autoSyntax detects the language of every run of lines.
+const autoSyntax = "auto"
+
+// syntaxLexer returns the lexer of the language, nil if it is unknown or
+// auto.
+func syntaxLexer(language string) chroma.Lexer {
+	if language == autoSyntax {
+		return nil
+	}
+	return lexers.Get(language)
+}
+
+// syntaxColor returns the color of the theme for the type of token, if any.
+func syntaxColor(t chroma.TokenType, theme Theme) string {
+	switch {
+	case t.InCategory(chroma.Comment):
+		return theme.BrightBlack
+	case t == chroma.KeywordType, t == chroma.NameBuiltin, t == chroma.NameBuiltinPseudo:
+		return theme.Cyan
+	case t.InCategory(chroma.Keyword):
+		return theme.Magenta
+	case t.InSubCategory(chroma.LiteralString), t == chroma.GenericInserted:
+		return theme.Green
+	case t.InSubCategory(chroma.LiteralNumber), t == chroma.NameAttribute:
+		return theme.Yellow
+	case t == chroma.NameFunction, t == chroma.NameClass, t == chroma.GenericHeading, t == chroma.GenericSubheading:
+		return theme.Blue
+	case t == chroma.NameTag, t == chroma.GenericDeleted:
+		return theme.Red
+	default:
+		return ""
+	}
+}
+
+// syntaxHighlighter colors the lines of frames as a language.
+type syntaxHighlighter struct {
+	lexer chroma.Lexer
+	theme Theme
+	// colors are the colors of the graphemes of the lines of the runs of
+	// lines highlighted so far, by their text.
+	colors map[string][][]string
+}
+
+// highlightSyntax returns the frames with their lines without colors
+// highlighted as the language. The frames are not modified.
+func highlightSyntax(frames []SVGFrame, language string, theme Theme) []SVGFrame {
+	h := &syntaxHighlighter{
+		lexer:  syntaxLexer(language),
+		theme:  theme,
+		colors: map[string][][]string{},
+	}
+	if h.lexer == nil && language != autoSyntax {
+		return frames
+	}
+
+	highlighted := make([]SVGFrame, len(frames))
+	for i, frame := range frames {
+		highlighted[i] = h.frame(frame)
+	}
+	return highlighted
+}
+
+// frame returns the frame with its runs of lines without colors highlighted.
+func (h *syntaxHighlighter) frame(frame SVGFrame) SVGFrame {
+	lineColors := frame.LineColors
+	copied := false
+	for y := 0; y < len(frame.Lines); {
+		if !plainLine(frame, y) {
+			y++
+			continue
+		}
+		end := y + 1
+		for end < len(frame.Lines) && plainLine(frame, end) {
+			end++
+		}
+		colors := h.lineColors(frame.Lines[y:end])
+		for i, line := range frame.Lines[y:end] {
+			if i >= len(colors) || !hasSyntaxColor(colors[i]) {
+				continue
+			}
+			if !copied {
+				lineColors = make([][]CharStyle, max(len(frame.LineColors), len(frame.Lines)))
+				copy(lineColors, frame.LineColors)
+				copied = true
+			}
+			lineColors[y+i] = colorLine(lineColors[y+i], line, colors[i])
+		}
+		y = end
+	}
+	frame.LineColors = lineColors
+	return frame
+}
+
+// lineColors returns the colors of the graphemes of the lines, highlighted
+// together.
+func (h *syntaxHighlighter) lineColors(lines []string) [][]string {
+	text := strings.Join(lines, "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if colors, ok := h.colors[text]; ok {
+		return colors
+	}
+
+	var colors [][]string
+	lexer := h.lexer
+	if lexer == nil {
+		lexer = lexers.Analyse(text)
+	}
+	if lexer != nil {
+		colors = h.tokenColors(chroma.Coalesce(lexer), text)
+	}
+	h.colors[text] = colors
+	return colors
+}
+
+// tokenColors returns the colors of the graphemes of the lines of the text.
+func (h *syntaxHighlighter) tokenColors(lexer chroma.Lexer, text string) [][]string {
+	tokens, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return nil
+	}
+	colors := [][]string{nil}
+	for _, t := range tokens.Tokens() {
+		color := syntaxColor(t.Type, h.theme)
+		graphemes := uniseg.NewGraphemes(t.Value)
+		for graphemes.Next() {
+			if graphemes.Str() == "\n" {
+				colors = append(colors, nil)
+				continue
+			}
+			colors[len(colors)-1] = append(colors[len(colors)-1], color)
+		}
+	}
+	return colors
+}
+
+// plainLine returns whether the line of the frame has no colors of its own.
+func plainLine(frame SVGFrame, y int) bool {
+	if y >= len(frame.LineColors) {
+		return true
+	}
+	for _, style := range frame.LineColors[y] {
+		if style.FgColor != "" && style.FgColor != nilValue ||
+			style.BgColor != "" && style.BgColor != nilValue || style.Inverse {
+			return false
+		}
+	}
+	return true
+}
+
+// hasSyntaxColor returns whether any grapheme is colored.
+func hasSyntaxColor(colors []string) bool {
+	for _, color := range colors {
+		if color != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// colorLine returns a copy of the styles of the cells of the line with the
+// colors of its graphemes.
+func colorLine(styles []CharStyle, line string, colors []string) []CharStyle {
+	colored := make([]CharStyle, len(styles))
+	copy(colored, styles)
+
+	x, i := 0, 0
+	graphemes := uniseg.NewGraphemes(line)
+	for graphemes.Next() {
+		width := max(graphemes.Width(), 1)
+		if x < len(colored) && colored[x].Width > 0 {
+			width = colored[x].Width
+		}
+		if x >= len(colored) {
+			// Wide characters are followed by a cell of no width.
+			colored = append(colored, CharStyle{Width: width})
+			for range width - 1 {
+				colored = append(colored, CharStyle{})
+			}
+		}
+		if i < len(colors) && colors[i] != "" {
+			colored[x].FgColor = colors[i]
+		}
+		x += width
+		i++
+	}
+	return colored
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestHighlightSyntax(t *testing.T) {
+	prompt := []CharStyle{{FgColor: DefaultTheme.BrightBlue, Width: 1}, {Width: 1}}
+	frames := []SVGFrame{{
+		Lines:      []string{"> cat main.go", "func main() {", `	fmt.Println("漢")`, "}"},
+		LineColors: [][]CharStyle{prompt},
+	}}
+	highlighted := highlightSyntax(frames, "go", DefaultTheme)
+
+	if len(frames[0].LineColors) != 1 {
+		t.Error("expected the frames to be left unmodified")
+	}
+	colors := highlighted[0].LineColors
+	if len(colors[0]) != len(prompt) || colors[0][1].FgColor != "" {
+		t.Errorf("expected the colored prompt to be left as is, got %+v", colors[0])
+	}
+	if colors[1][0].FgColor != DefaultTheme.Magenta || colors[1][5].FgColor != DefaultTheme.Blue {
+		t.Errorf("expected func and main to be highlighted, got %+v", colors[1])
+	}
+	// The string holds a wide character, followed by a cell of no width.
+	if got := colors[2]; len(got) != 18 || got[13].FgColor != DefaultTheme.Green || got[14].Width != 2 || got[15].Width != 0 || got[16].FgColor != DefaultTheme.Green {
+		t.Errorf("expected the string to be highlighted by cell, got %+v", got)
+	}
+
+	// The language of every run of lines is detected with auto.
+	frames = []SVGFrame{{Lines: []string{"#!/bin/bash", "echo hi"}}}
+	if got := highlightSyntax(frames, autoSyntax, DefaultTheme)[0].LineColors; len(got) != 2 || got[0][0].FgColor != DefaultTheme.BrightBlack {
+		t.Errorf("expected the shebang to be highlighted as a comment, got %+v", got)
+	}
+}
+
+func TestSyntaxHighlightSVG(t *testing.T) {
+	svg := NewSVGGenerator(SVGConfig{
+		Width: 400, Height: 200, FontSize: 14, Theme: DefaultTheme, OptimizeSize: true, Duration: 1,
+		Frames:          []SVGFrame{{Lines: []string{"package main"}, CursorX: 12, CursorChar: "█"}},
+		SyntaxHighlight: "go",
+	}).Generate()
+	if !strings.Contains(svg, `class="t m">package</tspan>`) {
+		t.Errorf("expected the keyword to take the magenta color class, got %s", svg)
+	}
+}
+
+func TestExecuteSetSyntaxHighlight(t *testing.T) {
+	v := New()
+	if err := ExecuteSetSyntaxHighlight(parser.Command{Args: "go"}, &v); err != nil || v.Options.SVG.SyntaxHighlight != "go" {
+		t.Errorf("expected Go highlighting, got %q (%v)", v.Options.SVG.SyntaxHighlight, err)
+	}
+	if err := ExecuteSetSyntaxHighlight(parser.Command{Args: autoSyntax}, &v); err != nil {
+		t.Errorf("expected auto to be valid, got %v", err)
+	}
+	if err := ExecuteSetSyntaxHighlight(parser.Command{Args: "klingon"}, &v); err == nil {
+		t.Error("expected an error for an unknown language")
+	}
+}
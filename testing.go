@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -66,6 +68,10 @@ func (v *VHS) SaveOutput() error {
 
 // Buffer returns the current buffer.
 func (v *VHS) Buffer() ([]string, error) {
+	if v.native != nil {
+		return v.native.screen.Lines(), nil
+	}
+
 	// Get the current buffer.
 	buf, err := v.Page.Eval("() => Array(term.rows).fill(0).map((e, i) => term.buffer.active.getLine(i).translateToString().trimEnd())")
 	if err != nil {
@@ -83,6 +89,12 @@ func (v *VHS) Buffer() ([]string, error) {
 
 // CurrentLine returns the current line from the buffer.
 func (v *VHS) CurrentLine() (string, error) {
+	if v.native != nil {
+		lines := v.native.screen.Lines()
+		_, y := v.native.screen.Cursor()
+		return lines[min(y, len(lines)-1)], nil
+	}
+
 	buf, err := v.Page.Eval("() => term.buffer.active.getLine(term.buffer.active.cursorY+term.buffer.active.viewportY).translateToString().trimEnd()")
 	if err != nil {
 		return "", fmt.Errorf("read current line from buffer: %w", err)
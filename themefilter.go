@@ -0,0 +1,229 @@
+// Package vhs themefilter.go transforms the colors of themes for
+// accessibility.
+//
+// The deuteranopia and protanopia filters daltonize the theme: the colors
+// are shifted so that the differences red-green color blind viewers cannot
+// see are carried by the colors they can. The high-contrast filter lightens
+// (or darkens, on light backgrounds) the colors until they reach the WCAG AA
+// contrast ratio against the background. Colors which remain below it are
+// reported.
+//
+// Set Theme "Dracula"
+// Set ThemeFilter deuteranopia
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Theme filters.
+const (
+	themeFilterDeuteranopia = "deuteranopia"
+	themeFilterProtanopia   = "protanopia"
+	themeFilterHighContrast = "high-contrast"
+)
+
+// Contrast ratios of the WCAG: AA for normal text, and AAA which the
+// high-contrast filter gives the foreground.
+const (
+	minContrastRatio     = 4.5
+	minTextContrastRatio = 7
+)
+
+// themeFilterSteps is the number of steps in which the high-contrast filter
+// mixes colors with white or black.
+const themeFilterSteps = 20
+
+// daltonizeCorrection is the share of the lost red shifted into green and
+// blue.
+const daltonizeCorrection = 0.7
+
+// Daltonization matrices, from RGB to LMS, the simulations of the color
+// vision deficiencies in LMS, and from LMS to RGB.
+var (
+	rgbToLMS = [3][3]float64{
+		{17.8824, 43.5161, 4.11935},
+		{3.45565, 27.1554, 3.86714},
+		{0.0299566, 0.184309, 1.46709},
+	}
+	lmsToRGB = [3][3]float64{
+		{0.0809444479, -0.130504409, 0.116721066},
+		{-0.0102485335, 0.0540193266, -0.113614708},
+		{-0.000365296938, -0.00412161469, 0.693511405},
+	}
+	colorBlindness = map[string][3][3]float64{
+		themeFilterProtanopia: {
+			{0, 2.02344, -2.52581},
+			{0, 1, 0},
+			{0, 0, 1},
+		},
+		themeFilterDeuteranopia: {
+			{1, 0, 0},
+			{0.494207, 0, 1.24827},
+			{0, 0, 1},
+		},
+	}
+)
+
+// filterTheme returns the theme with the filter applied to its colors.
+func filterTheme(t Theme, filter string) (Theme, error) {
+	background, err := parseHexColor(t.Background)
+	if err != nil {
+		return t, fmt.Errorf("invalid theme background: %w", err)
+	}
+
+	var transform func(name string, c color.RGBA) color.RGBA
+	switch filter {
+	case themeFilterDeuteranopia, themeFilterProtanopia:
+		simulation := colorBlindness[filter]
+		transform = func(_ string, c color.RGBA) color.RGBA {
+			return daltonize(c, simulation)
+		}
+	case themeFilterHighContrast:
+		transform = func(name string, c color.RGBA) color.RGBA {
+			switch name {
+			case "foreground", "cursor":
+				return increaseContrast(c, background, minTextContrastRatio)
+			case "background", "selection", "cursorAccent", "black", "brightBlack", "white", "brightWhite":
+				return c
+			default:
+				return increaseContrast(c, background, minContrastRatio)
+			}
+		}
+	default:
+		return t, fmt.Errorf("unknown theme filter %q", filter)
+	}
+
+	for _, field := range themeColors(&t) {
+		c, err := parseHexColor(*field.Color)
+		if err != nil {
+			continue
+		}
+		*field.Color = hexColor(transform(field.Name, c))
+	}
+	return t, nil
+}
+
+// themeColor is a color of a theme, by its name in JSON.
+type themeColor struct {
+	Name  string
+	Color *string
+}
+
+// themeColors returns the colors of the theme.
+func themeColors(t *Theme) []themeColor {
+	return []themeColor{
+		{"background", &t.Background}, {"foreground", &t.Foreground},
+		{"selection", &t.Selection}, {"cursor", &t.Cursor}, {"cursorAccent", &t.CursorAccent},
+		{"black", &t.Black}, {"brightBlack", &t.BrightBlack},
+		{"red", &t.Red}, {"brightRed", &t.BrightRed},
+		{"green", &t.Green}, {"brightGreen", &t.BrightGreen},
+		{"yellow", &t.Yellow}, {"brightYellow", &t.BrightYellow},
+		{"blue", &t.Blue}, {"brightBlue", &t.BrightBlue},
+		{"magenta", &t.Magenta}, {"brightMagenta", &t.BrightMagenta},
+		{"cyan", &t.Cyan}, {"brightCyan", &t.BrightCyan},
+		{"white", &t.White}, {"brightWhite", &t.BrightWhite},
+	}
+}
+
+// contrastWarnings returns a warning for every text color of the theme below
+// the WCAG AA contrast ratio against the background. Black and white are
+// left out, since themes blend one of them with the background on purpose.
+func contrastWarnings(t Theme) []string {
+	background, err := parseHexColor(t.Background)
+	if err != nil {
+		return nil
+	}
+	var warnings []string
+	for _, field := range themeColors(&t) {
+		switch field.Name {
+		case "background", "selection", "cursor", "cursorAccent", "black", "brightBlack", "white", "brightWhite":
+			continue
+		}
+		c, err := parseHexColor(*field.Color)
+		if err != nil {
+			continue
+		}
+		if ratio := contrastRatio(c, background); ratio < minContrastRatio {
+			warnings = append(warnings, fmt.Sprintf(
+				"theme color %s (%s) has a contrast ratio of %.1f:1 against the background, below %.1f:1",
+				field.Name, *field.Color, ratio, minContrastRatio))
+		}
+	}
+	return warnings
+}
+
+// daltonize shifts the part of the color which is lost with the color
+// vision deficiency into the channels which remain visible.
+func daltonize(c color.RGBA, simulation [3][3]float64) color.RGBA {
+	rgb := [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+	simulated := multiply(lmsToRGB, multiply(simulation, multiply(rgbToLMS, rgb)))
+	err := [3]float64{rgb[0] - simulated[0], rgb[1] - simulated[1], rgb[2] - simulated[2]}
+	shifted := [3]float64{
+		rgb[0],
+		rgb[1] + daltonizeCorrection*err[0] + err[1],
+		rgb[2] + daltonizeCorrection*err[0] + err[2],
+	}
+	return color.RGBA{R: clampChannel(shifted[0]), G: clampChannel(shifted[1]), B: clampChannel(shifted[2]), A: c.A}
+}
+
+// increaseContrast mixes the color with white on dark backgrounds, or black
+// on light ones, until it reaches the contrast ratio against the background.
+func increaseContrast(c, background color.RGBA, ratio float64) color.RGBA {
+	target := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	if relativeLuminance(background) > 0.5 {
+		target = color.RGBA{A: 0xff}
+	}
+	mixed := c
+	for step := 1; step <= themeFilterSteps && contrastRatio(mixed, background) < ratio; step++ {
+		f := float64(step) / themeFilterSteps
+		mixed = color.RGBA{
+			R: clampChannel(float64(c.R) + (float64(target.R)-float64(c.R))*f),
+			G: clampChannel(float64(c.G) + (float64(target.G)-float64(c.G))*f),
+			B: clampChannel(float64(c.B) + (float64(target.B)-float64(c.B))*f),
+			A: c.A,
+		}
+	}
+	return mixed
+}
+
+// contrastRatio returns the WCAG contrast ratio of two colors, from 1 to 21.
+func contrastRatio(a, b color.RGBA) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	return (math.Max(la, lb) + 0.05) / (math.Min(la, lb) + 0.05)
+}
+
+// relativeLuminance returns the WCAG relative luminance of the color.
+//
+//nolint:mnd
+func relativeLuminance(c color.RGBA) float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 0xff
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// multiply multiplies the matrix and the vector.
+func multiply(m [3][3]float64, v [3]float64) [3]float64 {
+	var r [3]float64
+	for i := range m {
+		r[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2]
+	}
+	return r
+}
+
+// clampChannel rounds a color channel into its range.
+func clampChannel(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(0xff, v))))
+}
+
+// hexColor formats a color as #rrggbb.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestContrastRatio(t *testing.T) {
+	black := color.RGBA{A: 0xff}
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	if r := contrastRatio(black, white); r < 20.9 || r > 21.1 {
+		t.Errorf("expected 21:1 for black and white, got %.2f", r)
+	}
+	if r := contrastRatio(white, white); r != 1 {
+		t.Errorf("expected 1:1 for the same color, got %.2f", r)
+	}
+}
+
+func TestFilterTheme(t *testing.T) {
+	theme := DefaultTheme
+	theme.Red = "#800000"
+	theme.Green = "#008000"
+
+	filtered, err := filterTheme(theme, themeFilterHighContrast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contrastWarnings(filtered)) != 0 {
+		t.Errorf("expected no warnings for the high-contrast theme, got %q", contrastWarnings(filtered))
+	}
+	if filtered.Background != theme.Background || filtered.Black != theme.Black {
+		t.Error("expected the background and black to be left untouched")
+	}
+
+	// Red and green differ in the channels which remain visible.
+	filtered, err = filterTheme(theme, themeFilterDeuteranopia)
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, _ := parseHexColor(filtered.Red)
+	if red.B == 0 {
+		t.Errorf("expected red to be shifted towards blue, got %s", filtered.Red)
+	}
+	if gray, _ := filterTheme(Theme{Background: "#000000", Foreground: "#808080"}, themeFilterProtanopia); gray.Foreground != "#808080" {
+		t.Errorf("expected gray to be left untouched, got %s", gray.Foreground)
+	}
+
+	if _, err := filterTheme(theme, "tritanopia"); err == nil {
+		t.Error("expected an error for an unknown filter")
+	}
+}
+
+func TestContrastWarnings(t *testing.T) {
+	theme := Theme{Background: "#000000", Foreground: "#ffffff", Blue: "#0000ff", Black: "#000000"}
+	warnings := contrastWarnings(theme)
+	if len(warnings) != 1 {
+		t.Fatalf("expected a warning for blue only, got %q", warnings)
+	}
+	if want := "theme color blue (#0000ff) has a contrast ratio of 2.4:1 against the background, below 4.5:1"; warnings[0] != want {
+		t.Errorf("expected %q, got %q", want, warnings[0])
+	}
+}
+
+func TestExecuteSetThemeFilter(t *testing.T) {
+	v := &VHS{Options: &Options{Theme: DefaultTheme, Video: VideoOptions{Style: DefaultStyleOptions()}}}
+	if err := ExecuteSetThemeFilter(parser.Command{Args: themeFilterHighContrast}, v); err != nil {
+		t.Fatal(err)
+	}
+	// The filter applies to themes set afterwards, and is not applied twice.
+	if err := ExecuteSetTheme(parser.Command{Args: "Dracula"}, v); err != nil {
+		t.Fatal(err)
+	}
+	once := v.Options.Theme
+	if err := ExecuteSetThemeFilter(parser.Command{Args: themeFilterHighContrast}, v); err != nil {
+		t.Fatal(err)
+	}
+	dracula, _ := getTheme("Dracula")
+	want, _ := filterTheme(dracula, themeFilterHighContrast)
+	if once != want || v.Options.Theme != want {
+		t.Errorf("expected the filtered Dracula theme, got %+v", v.Options.Theme)
+	}
+}
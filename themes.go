@@ -201,3 +201,23 @@ func parseThemes(bts []byte) ([]Theme, error) {
 	}
 	return themes, nil
 }
+
+func getTheme(s string) (Theme, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultTheme, nil
+	}
+	switch s[0] {
+	case '{':
+		return getJSONTheme(s)
+	default:
+		return findTheme(s)
+	}
+}
+
+func getJSONTheme(s string) (Theme, error) {
+	var t Theme
+	if err := json.Unmarshal([]byte(s), &t); err != nil {
+		return DefaultTheme, fmt.Errorf("invalid `Set Theme %q: %w`", s, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,142 @@
+//go:build !js
+
+// Package vhs titlecard.go shows title cards.
+//
+// TitleCard shows a full screen card with a title, and optionally a subtitle
+// and a logo, for the given time, e.g. as the intro or outro of a demo. The
+// card is written to the terminal itself rather than through the shell, on
+// the alternate screen with the cursor hidden, so it is captured like any
+// other frame, in the colors of the theme, for every output. The screen of
+// the shell comes back once the card ends.
+//
+// TitleCard "My Tool v2.0" 3s --subtitle "now with plugins" --logo ./logo.png
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// titleCardStart switches to the alternate screen, hides the cursor and
+	// clears the screen, and titleCardEnd restores them.
+	titleCardStart = "\x1b[?1049h\x1b[?25l\x1b[H\x1b[2J"
+	titleCardEnd   = "\x1b[?25h\x1b[?1049l"
+	// The logo is a fourth of the rows high.
+	titleCardLogoRows = 4
+)
+
+// titleCardArgs returns the title, subtitle and logo of a TitleCard command.
+func titleCardArgs(args string) (title, subtitle, logo string) {
+	parts := strings.SplitN(args, "\n", 3) //nolint:mnd
+	parts = append(parts, "", "")
+	return parts[0], parts[1], parts[2]
+}
+
+// titleCardSequence returns the escape sequence drawing the card, centered on
+// a terminal of cols by rows whose cells are cellRatio times as high as wide.
+// The logo is shown with the iTerm2 inline image protocol, above the title.
+func titleCardSequence(args string, cols, rows int, cellRatio float64) (string, error) {
+	title, subtitle, logo := titleCardArgs(args)
+
+	var logoImage string
+	var imageRows, imageCols int
+	if logo != "" {
+		data, err := os.ReadFile(logo) //nolint:gosec
+		if err != nil {
+			return "", fmt.Errorf("failed to read logo: %w", err)
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decode logo %s: %w", logo, err)
+		}
+		imageRows = max(rows/titleCardLogoRows, 1)
+		aspect := float64(config.Width) / float64(max(config.Height, 1))
+		imageCols = min(max(int(math.Round(aspect*float64(imageRows)*cellRatio)), 1), cols)
+		logoImage = fmt.Sprintf("\x1b]1337;File=inline=1;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+			imageCols, imageRows, base64.StdEncoding.EncodeToString(data))
+	}
+
+	// The logo and the subtitle are separated from the title by a blank row.
+	height := 1
+	if logoImage != "" {
+		height += imageRows + 1
+	}
+	if subtitle != "" {
+		height += 2
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleCardStart)
+	row := max((rows-height)/2, 0) + 1
+	line := func(text, sgr string) {
+		text = runewidth.Truncate(text, cols, "…")
+		fmt.Fprintf(&sb, "\x1b[%d;%dH%s%s\x1b[0m", row, (cols-runewidth.StringWidth(text))/2+1, sgr, text) //nolint:mnd
+	}
+	if logoImage != "" {
+		fmt.Fprintf(&sb, "\x1b[%d;%dH%s", row, (cols-imageCols)/2+1, logoImage) //nolint:mnd
+		row += imageRows + 1
+	}
+	line(title, "\x1b[1m")
+	if subtitle != "" {
+		row += 2
+		line(subtitle, "\x1b[2m")
+	}
+	return sb.String(), nil
+}
+
+// titleCard shows the card on the terminal of cols by rows with write for the
+// time of the command.
+func titleCard(c parser.Command, v *VHS, cols, rows int, write func(string) error) error {
+	duration, err := time.ParseDuration(c.Options)
+	if err != nil {
+		return fmt.Errorf("failed to parse duration: %w", err)
+	}
+	charWidth, charHeight := nativeCellSize(v.Options)
+	card, err := titleCardSequence(c.Args, cols, rows, charHeight/charWidth)
+	if err != nil {
+		return err
+	}
+	if err := write(card); err != nil {
+		return err
+	}
+	if err := v.sleep(duration); err != nil {
+		return err
+	}
+	return write(titleCardEnd)
+}
+
+// ExecuteTitleCard is a CommandFunc that shows a title card on the terminal
+// of the running instance of vhs.
+func ExecuteTitleCard(c parser.Command, v *VHS) error {
+	size, err := v.Page.Eval("() => [term.cols, term.rows]")
+	if err != nil {
+		return fmt.Errorf("failed to get terminal size: %w", err)
+	}
+	cols, rows := size.Value.Arr()[0].Int(), size.Value.Arr()[1].Int()
+	return titleCard(c, v, cols, rows, func(s string) error {
+		if _, err := v.Page.Eval("(s) => new Promise((resolve) => term.write(s, resolve))", s); err != nil {
+			return fmt.Errorf("failed to write title card: %w", err)
+		}
+		return nil
+	})
+}
+
+// executeNativeTitleCard shows a title card with the native backend, which
+// does not show its logo.
+func executeNativeTitleCard(c parser.Command, v *VHS) error {
+	cols, rows := v.native.screen.Size()
+	return titleCard(c, v, cols, rows, func(s string) error {
+		_, err := v.native.screen.Write([]byte(s))
+		return err //nolint:wrapcheck
+	})
+}
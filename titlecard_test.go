@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTitleCardSequence(t *testing.T) {
+	card, err := titleCardSequence("My Tool\nnow with plugins", 20, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newEmulator(20, 5, DefaultTheme)
+	_, _ = e.Write([]byte("$ ls"))
+	_, _ = e.Write([]byte(card))
+
+	frame := e.Frame()
+	want := []string{"", "      My Tool", "", "  now with plugins", ""}
+	if strings.Join(frame.Lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected the centered card %q, got %q", want, frame.Lines)
+	}
+	if !frame.LineColors[1][6].Bold || frame.CursorChar != "" {
+		t.Errorf("expected a bold title and a hidden cursor, got %+v and %q", frame.LineColors[1][6], frame.CursorChar)
+	}
+
+	_, _ = e.Write([]byte(titleCardEnd))
+	if frame := e.Frame(); frame.Lines[0] != "$ ls" || frame.CursorChar == "" {
+		t.Errorf("expected the screen of the shell back, got %q with cursor %q", frame.Lines, frame.CursorChar)
+	}
+}
+
+func TestTitleCardLogo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(logo, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	card, err := titleCardSequence("My Tool\n\n"+logo, 40, 12, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The logo is 3 rows high, so 12 columns wide, above the title.
+	if !strings.Contains(card, "\x1b[4;15H\x1b]1337;File=inline=1;width=12;height=3;") || !strings.Contains(card, "\x1b[8;17H") {
+		t.Errorf("expected the logo centered above the title, got %q", card)
+	}
+	if _, err := titleCardSequence("My Tool\n\n"+logo+".missing", 40, 12, 2); err == nil {
+		t.Error("expected an error for a missing logo")
+	}
+}
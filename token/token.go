@@ -32,6 +32,7 @@ const (
 	RIGHT_BRACKET = "]" //nolint:revive
 	LEFT_BRACKET  = "[" //nolint:revive
 	CARET         = "^"
+	COMMA         = ","
 
 	EM           = "EM"
 	MILLISECONDS = "MILLISECONDS"
@@ -51,6 +52,7 @@ const (
 	ESCAPE    = "ESCAPE"
 	HOME      = "HOME"
 	INSERT    = "INSERT"
+	KEY       = "KEY"
 	PAGE_DOWN = "PAGE_DOWN" //nolint:revive
 	PAGE_UP   = "PAGE_UP"   //nolint:revive
 	SLEEP     = "SLEEP"
@@ -101,76 +103,174 @@ const (
 	WINDOW_BAR_TITLE       = "WINDOW_BAR_TITLE"       //nolint:revive
 	WINDOW_BAR_FONT_FAMILY = "WINDOW_BAR_FONT_FAMILY" //nolint:revive
 	WINDOW_BAR_FONT_SIZE   = "WINDOW_BAR_FONT_SIZE"   //nolint:revive
+	WINDOW_BAR_TITLE_COLOR = "WINDOW_BAR_TITLE_COLOR" //nolint:revive
 	BORDER_RADIUS          = "CORNER_RADIUS"          //nolint:revive
 	WAIT                   = "WAIT"                   //nolint:revive
 	WAIT_TIMEOUT           = "WAIT_TIMEOUT"           //nolint:revive
 	WAIT_PATTERN           = "WAIT_PATTERN"           //nolint:revive
 	CURSOR_BLINK           = "CURSOR_BLINK"           //nolint:revive
+	SCENE                  = "SCENE"
+	TRANSITION             = "TRANSITION"
+	CHAPTER                = "CHAPTER"
+	GIF_COLORS             = "GIF_COLORS"     //nolint:revive
+	GIF_DITHER             = "GIF_DITHER"     //nolint:revive
+	GIF_STATS_MODE         = "GIF_STATS_MODE" //nolint:revive
+	QUALITY                = "QUALITY"
+	MAX_FILE_SIZE          = "MAX_FILE_SIZE" //nolint:revive
+	PIXEL_RATIO            = "PIXEL_RATIO"   //nolint:revive
+	RESIZE                 = "RESIZE"
+	UNDERLINE_LINKS        = "UNDERLINE_LINKS" //nolint:revive
+	SCROLL_UP              = "SCROLL_UP"       //nolint:revive
+	SCROLL_DOWN            = "SCROLL_DOWN"     //nolint:revive
+	SCROLLBACK             = "SCROLLBACK"
+	FONT_LIGATURES         = "FONT_LIGATURES" //nolint:revive
+	HIGHLIGHT              = "HIGHLIGHT"
+	ZOOM                   = "ZOOM"
+	PAN                    = "PAN"
+	ANNOTATE               = "ANNOTATE"
+	WATERMARK              = "WATERMARK"
+	LOOP_DELAY             = "LOOP_DELAY" //nolint:revive
+	TIMEOUT                = "TIMEOUT"
+	MAX_FRAMES             = "MAX_FRAMES"     //nolint:revive
+	MAX_DISK_USAGE         = "MAX_DISK_USAGE" //nolint:revive
+	VIDEO_FILTER           = "VIDEO_FILTER"   //nolint:revive
+	FRAME_COMMAND          = "FRAME_COMMAND"  //nolint:revive
+	DESCRIPTION            = "DESCRIPTION"
+	ACCESSIBLE_TRANSCRIPT  = "ACCESSIBLE_TRANSCRIPT" //nolint:revive
+	THEME_FILTER           = "THEME_FILTER"          //nolint:revive
+	THEME_DARK             = "THEME_DARK"            //nolint:revive
+	COLOR_MODE             = "COLOR_MODE"            //nolint:revive
+	EMOJI_FONT             = "EMOJI_FONT"            //nolint:revive
+	CURSOR_COLOR           = "CURSOR_COLOR"          //nolint:revive
+	CURSOR_ACCENT_COLOR    = "CURSOR_ACCENT_COLOR"   //nolint:revive
+	MAX_IDLE               = "MAX_IDLE"              //nolint:revive
+	SVG_OPTIMIZE_LEVEL     = "SVG_OPTIMIZE_LEVEL"    //nolint:revive
+	CURSOR_TWEEN           = "CURSOR_TWEEN"          //nolint:revive
+	WORKING_DIRECTORY      = "WORKING_DIRECTORY"     //nolint:revive
+	CAPTURE_RATE           = "CAPTURE_RATE"          //nolint:revive
+	KEYBOARD_LAYOUT        = "KEYBOARD_LAYOUT"       //nolint:revive
+	SYNTAX_HIGHLIGHT       = "SYNTAX_HIGHLIGHT"      //nolint:revive
+	SANDBOX                = "SANDBOX"
+	SCRIPT                 = "SCRIPT"
+	SIGNAL                 = "SIGNAL"
+	WAIT_EXIT              = "WAIT_EXIT"  //nolint:revive
+	TYPE_FILE              = "TYPE_FILE"  //nolint:revive
+	TITLE_CARD             = "TITLE_CARD" //nolint:revive
 )
 
 // Keywords maps keyword strings to tokens.
 var Keywords = map[string]Type{
-	"em":                  EM,
-	"px":                  PX,
-	"ms":                  MILLISECONDS,
-	"s":                   SECONDS,
-	"m":                   MINUTES,
-	"Set":                 SET,
-	"Sleep":               SLEEP,
-	"Type":                TYPE,
-	"Enter":               ENTER,
-	"Space":               SPACE,
-	"Backspace":           BACKSPACE,
-	"Delete":              DELETE,
-	"Insert":              INSERT,
-	"Ctrl":                CTRL,
-	"Alt":                 ALT,
-	"Shift":               SHIFT,
-	"Down":                DOWN,
-	"Left":                LEFT,
-	"Right":               RIGHT,
-	"Up":                  UP,
-	"PageUp":              PAGE_UP,
-	"PageDown":            PAGE_DOWN,
-	"Tab":                 TAB,
-	"Escape":              ESCAPE,
-	"End":                 END,
-	"Hide":                HIDE,
-	"Require":             REQUIRE,
-	"Show":                SHOW,
-	"Output":              OUTPUT,
-	"Shell":               SHELL,
-	"FontFamily":          FONT_FAMILY,
-	"MarginFill":          MARGIN_FILL,
-	"Margin":              MARGIN,
-	"WindowBar":           WINDOW_BAR,
-	"WindowBarSize":       WINDOW_BAR_SIZE,
-	"WindowBarTitle":      WINDOW_BAR_TITLE,
-	"WindowBarFontFamily": WINDOW_BAR_FONT_FAMILY,
-	"WindowBarFontSize":   WINDOW_BAR_FONT_SIZE,
-	"BorderRadius":        BORDER_RADIUS,
-	"FontSize":            FONT_SIZE,
-	"Framerate":           FRAMERATE,
-	"Height":              HEIGHT,
-	"LetterSpacing":       LETTER_SPACING,
-	"LineHeight":          LINE_HEIGHT,
-	"PlaybackSpeed":       PLAYBACK_SPEED,
-	"TypingSpeed":         TYPING_SPEED,
-	"Padding":             PADDING,
-	"Theme":               THEME,
-	"Width":               WIDTH,
-	"LoopOffset":          LOOP_OFFSET,
-	"WaitTimeout":         WAIT_TIMEOUT,
-	"WaitPattern":         WAIT_PATTERN,
-	"Wait":                WAIT,
-	"Source":              SOURCE,
-	"CursorBlink":         CURSOR_BLINK,
-	"true":                BOOLEAN,
-	"false":               BOOLEAN,
-	"Screenshot":          SCREENSHOT,
-	"Copy":                COPY,
-	"Paste":               PASTE,
-	"Env":                 ENV,
+	"em":                   EM,
+	"px":                   PX,
+	"ms":                   MILLISECONDS,
+	"s":                    SECONDS,
+	"m":                    MINUTES,
+	"Set":                  SET,
+	"Sleep":                SLEEP,
+	"Type":                 TYPE,
+	"Enter":                ENTER,
+	"Space":                SPACE,
+	"Backspace":            BACKSPACE,
+	"Delete":               DELETE,
+	"Insert":               INSERT,
+	"Home":                 HOME,
+	"Key":                  KEY,
+	"Ctrl":                 CTRL,
+	"Alt":                  ALT,
+	"Shift":                SHIFT,
+	"Down":                 DOWN,
+	"Left":                 LEFT,
+	"Right":                RIGHT,
+	"Up":                   UP,
+	"PageUp":               PAGE_UP,
+	"PageDown":             PAGE_DOWN,
+	"Tab":                  TAB,
+	"Escape":               ESCAPE,
+	"End":                  END,
+	"Hide":                 HIDE,
+	"Require":              REQUIRE,
+	"Show":                 SHOW,
+	"Output":               OUTPUT,
+	"Shell":                SHELL,
+	"FontFamily":           FONT_FAMILY,
+	"MarginFill":           MARGIN_FILL,
+	"Margin":               MARGIN,
+	"WindowBar":            WINDOW_BAR,
+	"WindowBarSize":        WINDOW_BAR_SIZE,
+	"WindowBarTitle":       WINDOW_BAR_TITLE,
+	"WindowBarFontFamily":  WINDOW_BAR_FONT_FAMILY,
+	"WindowBarFontSize":    WINDOW_BAR_FONT_SIZE,
+	"WindowBarTitleColor":  WINDOW_BAR_TITLE_COLOR,
+	"BorderRadius":         BORDER_RADIUS,
+	"FontSize":             FONT_SIZE,
+	"Framerate":            FRAMERATE,
+	"Height":               HEIGHT,
+	"LetterSpacing":        LETTER_SPACING,
+	"LineHeight":           LINE_HEIGHT,
+	"PlaybackSpeed":        PLAYBACK_SPEED,
+	"TypingSpeed":          TYPING_SPEED,
+	"Padding":              PADDING,
+	"Theme":                THEME,
+	"Width":                WIDTH,
+	"LoopOffset":           LOOP_OFFSET,
+	"WaitTimeout":          WAIT_TIMEOUT,
+	"WaitPattern":          WAIT_PATTERN,
+	"Wait":                 WAIT,
+	"Source":               SOURCE,
+	"CursorBlink":          CURSOR_BLINK,
+	"true":                 BOOLEAN,
+	"false":                BOOLEAN,
+	"Screenshot":           SCREENSHOT,
+	"Copy":                 COPY,
+	"Paste":                PASTE,
+	"Env":                  ENV,
+	"Scene":                SCENE,
+	"Transition":           TRANSITION,
+	"Chapter":              CHAPTER,
+	"GIFColors":            GIF_COLORS,
+	"GIFDither":            GIF_DITHER,
+	"GIFStatsMode":         GIF_STATS_MODE,
+	"Quality":              QUALITY,
+	"MaxFileSize":          MAX_FILE_SIZE,
+	"PixelRatio":           PIXEL_RATIO,
+	"Resize":               RESIZE,
+	"UnderlineLinks":       UNDERLINE_LINKS,
+	"ScrollUp":             SCROLL_UP,
+	"ScrollDown":           SCROLL_DOWN,
+	"Scrollback":           SCROLLBACK,
+	"FontLigatures":        FONT_LIGATURES,
+	"Highlight":            HIGHLIGHT,
+	"Zoom":                 ZOOM,
+	"Pan":                  PAN,
+	"Annotate":             ANNOTATE,
+	"Watermark":            WATERMARK,
+	"LoopDelay":            LOOP_DELAY,
+	"Script":               SCRIPT,
+	"Signal":               SIGNAL,
+	"WaitExit":             WAIT_EXIT,
+	"TypeFile":             TYPE_FILE,
+	"TitleCard":            TITLE_CARD,
+	"Timeout":              TIMEOUT,
+	"MaxFrames":            MAX_FRAMES,
+	"MaxDiskUsage":         MAX_DISK_USAGE,
+	"VideoFilter":          VIDEO_FILTER,
+	"FrameCommand":         FRAME_COMMAND,
+	"Description":          DESCRIPTION,
+	"AccessibleTranscript": ACCESSIBLE_TRANSCRIPT,
+	"ThemeFilter":          THEME_FILTER,
+	"ThemeDark":            THEME_DARK,
+	"ColorMode":            COLOR_MODE,
+	"EmojiFont":            EMOJI_FONT,
+	"CursorColor":          CURSOR_COLOR,
+	"CursorAccentColor":    CURSOR_ACCENT_COLOR,
+	"MaxIdle":              MAX_IDLE,
+	"SVGOptimizeLevel":     SVG_OPTIMIZE_LEVEL,
+	"CursorTween":          CURSOR_TWEEN,
+	"WorkingDirectory":     WORKING_DIRECTORY,
+	"Sandbox":              SANDBOX,
+	"CaptureRate":          CAPTURE_RATE,
+	"KeyboardLayout":       KEYBOARD_LAYOUT,
+	"SyntaxHighlight":      SYNTAX_HIGHLIGHT,
 }
 
 // IsSetting returns whether a token is a setting.
@@ -179,7 +279,12 @@ func IsSetting(t Type) bool {
 	case SHELL, FONT_FAMILY, FONT_SIZE, LETTER_SPACING, LINE_HEIGHT,
 		FRAMERATE, TYPING_SPEED, THEME, PLAYBACK_SPEED, HEIGHT, WIDTH,
 		PADDING, LOOP_OFFSET, MARGIN_FILL, MARGIN, WINDOW_BAR,
-		WINDOW_BAR_SIZE, WINDOW_BAR_TITLE, WINDOW_BAR_FONT_FAMILY, WINDOW_BAR_FONT_SIZE, BORDER_RADIUS, CURSOR_BLINK, WAIT_TIMEOUT, WAIT_PATTERN:
+		WINDOW_BAR_SIZE, WINDOW_BAR_TITLE, WINDOW_BAR_FONT_FAMILY, WINDOW_BAR_FONT_SIZE, WINDOW_BAR_TITLE_COLOR, BORDER_RADIUS, CURSOR_BLINK, WAIT_TIMEOUT, WAIT_PATTERN,
+		TRANSITION, GIF_COLORS, GIF_DITHER, GIF_STATS_MODE, QUALITY, MAX_FILE_SIZE, PIXEL_RATIO, UNDERLINE_LINKS, SCROLLBACK,
+		FONT_LIGATURES, WATERMARK, LOOP_DELAY, TIMEOUT, MAX_FRAMES, MAX_DISK_USAGE,
+		VIDEO_FILTER, FRAME_COMMAND, DESCRIPTION, ACCESSIBLE_TRANSCRIPT, THEME_FILTER, THEME_DARK, COLOR_MODE, EMOJI_FONT,
+		CURSOR_COLOR, CURSOR_ACCENT_COLOR, MAX_IDLE, SVG_OPTIMIZE_LEVEL, CURSOR_TWEEN,
+		WORKING_DIRECTORY, SANDBOX, CAPTURE_RATE, KEYBOARD_LAYOUT, SYNTAX_HIGHLIGHT:
 		return true
 	default:
 		return false
@@ -192,7 +297,9 @@ func IsCommand(t Type) bool {
 	case TYPE, SLEEP,
 		UP, DOWN, RIGHT, LEFT, PAGE_UP, PAGE_DOWN,
 		ENTER, BACKSPACE, DELETE, TAB,
-		ESCAPE, HOME, INSERT, END, CTRL, SOURCE, SCREENSHOT, COPY, PASTE, WAIT:
+		ESCAPE, HOME, INSERT, END, KEY, CTRL, SOURCE, SCREENSHOT, COPY, PASTE, WAIT,
+		SCENE, CHAPTER, RESIZE, SCROLL_UP, SCROLL_DOWN, HIGHLIGHT, ZOOM, PAN, ANNOTATE, SCRIPT,
+		SIGNAL, WAIT_EXIT, TYPE_FILE, TITLE_CARD:
 		return true
 	default:
 		return false
@@ -0,0 +1,159 @@
+//go:build !js
+
+// Package vhs tracing.go instruments recordings with OpenTelemetry.
+//
+// Tracing is opt-in: with --trace, or when an OTLP endpoint is configured in
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, spans are
+// exported over OTLP/HTTP as configured by the standard OTEL_* variables.
+// Otherwise the global tracer provider does nothing and spans cost nothing.
+//
+// A recording is traced as a vhs.evaluate span with the setup of the
+// terminal, each command (with its line in the tape), the frame capture and
+// the rendering of every output as children. Frames are too many to be
+// traced one by one, so the capture span counts them and the time spent
+// capturing them instead. A TRACEPARENT in the environment, e.g. set by the
+// CI job, becomes the parent of the recording.
+//
+// OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318 vhs demo.tape
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingShutdownTimeout bounds the time spent flushing spans before exit,
+// e.g. when the collector is unreachable.
+const tracingShutdownTimeout = 5 * time.Second
+
+// tracer creates the spans of VHS. It forwards to the global tracer provider
+// once tracing is set up.
+var tracer = otel.Tracer("github.com/agentstation/vhs")
+
+// tracingEnabled reports whether spans should be exported.
+func tracingEnabled(flag bool) bool {
+	return flag ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing installs a tracer provider exporting spans over OTLP/HTTP and
+// returns the function flushing them before exit.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	// The OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES variables take
+	// precedence over the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "vhs"),
+			attribute.String("service.version", Version),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// traceParent returns the context with the span of the TRACEPARENT variable,
+// if any, as its parent.
+func traceParent(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// startSpan starts a span as a child of the span of the context, which may be
+// nil outside of a recording.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...)) //nolint:spancheck
+}
+
+// endSpan ends the span, recording the error if any.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// endSpanErrors ends the span, recording the errors if any.
+func endSpanErrors(span trace.Span, errs []error) {
+	endSpan(span, errors.Join(errs...))
+}
+
+// traceCommand starts the span of a command of the tape.
+func (vhs *VHS) traceCommand(c parser.Command) trace.Span {
+	_, span := startSpan(vhs.trace, "vhs.command "+c.Type.String(),
+		attribute.String("vhs.command", c.String()),
+		attribute.Int("vhs.line", c.Line),
+	)
+	return span
+}
+
+// captureSpan traces the frame capture of a recording.
+type captureSpan struct {
+	span   trace.Span
+	frames int
+	busy   time.Duration
+}
+
+// traceCapture starts the span of the frame capture, which lasts until the
+// recording stops.
+func (vhs *VHS) traceCapture() *captureSpan {
+	_, span := startSpan(vhs.trace, "vhs.capture",
+		attribute.Int("vhs.framerate", vhs.Options.Video.Framerate),
+	)
+	return &captureSpan{span: span}
+}
+
+// capture captures n frames, accounting for the time spent.
+func (c *captureSpan) capture(vhs *VHS, n int) error {
+	start := time.Now()
+	err := vhs.captureFrames(n)
+	c.busy += time.Since(start)
+	c.frames += n
+	return err
+}
+
+// end ends the span with the number of frames captured and the time spent
+// capturing them.
+func (c *captureSpan) end() {
+	c.span.SetAttributes(
+		attribute.Int("vhs.frames", c.frames),
+		attribute.Int64("vhs.capture.busy_ms", c.busy.Milliseconds()),
+	)
+	c.span.End()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracingEnabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if tracingEnabled(false) {
+		t.Error("expected tracing to be disabled by default")
+	}
+	if !tracingEnabled(true) {
+		t.Error("expected --trace to enable tracing")
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	if !tracingEnabled(false) {
+		t.Error("expected an OTLP endpoint to enable tracing")
+	}
+}
+
+func TestTraceParent(t *testing.T) {
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, span := provider.Tracer("test").Start(traceParent(context.Background()), "child")
+	span.End()
+
+	if got := recorder.Ended()[0].Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the span to continue the trace of TRACEPARENT, got %s", got)
+	}
+}
+
+func TestEvaluateNativeTracing(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	tape := "Set Shell bash\nType \"echo hi\"\nEnter"
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	root, ok := spans["vhs.evaluate"]
+	if !ok {
+		t.Fatalf("expected a span of the recording, got %v", spans)
+	}
+	for _, name := range []string{"vhs.command Type", "vhs.command Enter", "vhs.capture", "vhs.render_outputs"} {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("expected a %s span", name)
+			continue
+		}
+		if s.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("expected %s to be a child of the recording", name)
+		}
+	}
+	for _, attr := range spans["vhs.command Enter"].Attributes() {
+		if attr.Key == "vhs.line" && attr.Value.AsInt64() != 3 {
+			t.Errorf("expected Enter on line 3, got %d", attr.Value.AsInt64())
+		}
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !js
+
+// Package vhs transcript.go writes a Markdown transcript of the recording.
+//
+// Docs pipelines want copy-pasteable text alongside the animation, so an .md
+// output holds the text of the terminal at the end of every scene in a fenced
+// code block, under the name of the scene as a heading. Without scenes, it
+// holds the final screen.
+//
+// Output demo.md
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// transcriptScene is the text of the terminal at the end of a scene.
+type transcriptScene struct {
+	Name  string
+	Lines []string
+}
+
+// captureTranscript stores the text of the terminal at the end of the
+// current scene, if a transcript is requested.
+func (vhs *VHS) captureTranscript() error {
+	if vhs.Options.Video.Output.Transcript == "" {
+		return nil
+	}
+	lines, err := vhs.Buffer()
+	if err != nil {
+		return err
+	}
+
+	lines = trimTranscript(lines)
+
+	vhs.mutex.Lock()
+	defer vhs.mutex.Unlock()
+	name := ""
+	if len(vhs.scenes) > 0 {
+		name = vhs.scenes[len(vhs.scenes)-1].Name
+	}
+	vhs.transcriptScenes = append(vhs.transcriptScenes, transcriptScene{Name: name, Lines: lines})
+	return nil
+}
+
+// markdownTranscript returns the scenes as Markdown, skipping empty ones and
+// the prompt shown before the first scene.
+func markdownTranscript(scenes []transcriptScene) string {
+	var sb strings.Builder
+	for i, scene := range scenes {
+		if len(scene.Lines) == 0 || i == 0 && scene.Name == "" && len(scene.Lines) == 1 && len(scenes) > 1 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if scene.Name != "" {
+			fmt.Fprintf(&sb, "## %s\n\n", scene.Name)
+		}
+		fence := "```"
+		for strings.Contains(strings.Join(scene.Lines, "\n"), fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&sb, "%stext\n%s\n%s\n", fence, strings.Join(scene.Lines, "\n"), fence)
+	}
+	return sb.String()
+}
+
+// MakeTranscript writes the Markdown transcript, if requested.
+func MakeTranscript(v *VHS) error {
+	return renderOutput(v, md, v.Options.Video.Output.Transcript)
+}
+
+// transcriptRenderer renders Markdown transcripts.
+type transcriptRenderer struct{}
+
+// Render implements Renderer.
+func (transcriptRenderer) Render(w io.Writer, _ []SVGFrame, _ SVGConfig, _ Theme, timeline Timeline) error {
+	_, err := io.WriteString(w, markdownTranscript(timeline.Transcript))
+	return err //nolint:wrapcheck
+}
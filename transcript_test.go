@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestMarkdownTranscript(t *testing.T) {
+	got := markdownTranscript([]transcriptScene{
+		{Name: "", Lines: []string{"> echo hi", "hi"}},
+		{Name: "Prompt", Lines: []string{">"}},
+		{Name: "Empty", Lines: nil},
+		{Name: "Build", Lines: []string{"> make", "```"}},
+	})
+	want := "```text\n> echo hi\nhi\n```\n" +
+		"\n## Prompt\n\n```text\n>\n```\n" +
+		"\n## Build\n\n````text\n> make\n```\n````\n"
+	if got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	got = markdownTranscript([]transcriptScene{{Lines: []string{">"}}, {Name: "Install", Lines: []string{"> brew install vhs"}}})
+	if want := "## Install\n\n```text\n> brew install vhs\n```\n"; got != want {
+		t.Errorf("expected the prompt before the first scene to be skipped, got %q", got)
+	}
+}
+
+func TestMakeTranscript(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "docs", "demo.md")
+	v := &VHS{Options: &Options{}}
+	if err := ExecuteOutput(parser.Command{Options: ".md", Args: output}, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Options.Video.Output.Transcript != output {
+		t.Fatalf("expected the .md output to be the transcript, got %+v", v.Options.Video.Output)
+	}
+
+	v.transcriptScenes = []transcriptScene{{Name: "Install", Lines: []string{"> brew install vhs"}}}
+	if err := MakeTranscript(v); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Install\n\n```text\n> brew install vhs\n```\n"; string(b) != want {
+		t.Errorf("expected %q, got %q", want, b)
+	}
+}
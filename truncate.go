@@ -0,0 +1,55 @@
+//go:build !js
+
+// Package vhs truncate.go keeps the work of recordings which fail.
+//
+// When a recording is interrupted, times out or a command fails, the frames
+// captured so far are rendered into the requested outputs with .truncated
+// before their extension (e.g. demo.truncated.gif) instead of being thrown
+// away. The name marks them as incomplete and keeps a complete output of a
+// previous run from being overwritten.
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// truncatedSuffix is inserted before the extension of truncated outputs.
+const truncatedSuffix = ".truncated"
+
+// truncatedPath returns the path of the truncated output of a path.
+func truncatedPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + truncatedSuffix + ext
+}
+
+// renderTruncated renders the frames captured before the recording failed
+// into truncated outputs, if any were captured.
+func (vhs *VHS) renderTruncated(render func() error) {
+	if vhs.totalFrames == 0 {
+		return
+	}
+
+	outputs := &vhs.Options.Video.Output
+	var written []string
+	for _, o := range []*string{&outputs.GIF, &outputs.MP4, &outputs.WebM, &outputs.SVG, &outputs.Transcript, &outputs.PDF, &outputs.Lottie} {
+		if *o != "" {
+			*o = truncatedPath(*o)
+			written = append(written, *o)
+		}
+	}
+	for ext, o := range outputs.Rendered {
+		outputs.Rendered[ext] = truncatedPath(o)
+		written = append(written, outputs.Rendered[ext])
+	}
+	if len(written) == 0 {
+		return
+	}
+
+	if err := render(); err != nil {
+		log.Println(ErrorStyle.Render("Failed to write the truncated outputs: " + err.Error()))
+		return
+	}
+	log.Println(ErrorStyle.Render("The recording failed, its frames were written to " + strings.Join(written, ", ")))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTruncatedPath(t *testing.T) {
+	tests := map[string]string{
+		"demo.gif":         "demo.truncated.gif",
+		"out/demo.min.svg": "out/demo.min.truncated.svg",
+		"demo":             "demo.truncated",
+	}
+	for path, expected := range tests {
+		if got := truncatedPath(path); got != expected {
+			t.Errorf("expected %s to be truncated as %s, got %s", path, expected, got)
+		}
+	}
+}
+
+func TestEvaluateNativeInterrupted(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	svg := filepath.Join(t.TempDir(), "out.svg")
+	tape := "Set Shell bash\nType \"echo hi\"\nEnter\nSleep 30s"
+	errs := Evaluate(ctx, tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = svg
+		vhs.Options.Test.Output = ""
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "recording interrupted") {
+		t.Fatalf("expected the recording to be interrupted, got %v", errs)
+	}
+
+	if _, err := os.Stat(svg); err == nil {
+		t.Error("expected no complete output")
+	}
+	b, err := os.ReadFile(truncatedPath(svg))
+	if err != nil {
+		t.Fatalf("expected a truncated output: %v", err)
+	}
+	if !strings.Contains(string(b), "hi") {
+		t.Error("expected the truncated output to show the frames captured")
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 // Package vhs tty.go spawns the ttyd process.
 // It runs on the specified port and is generally meant to run in the background
 // so that other processes (go-rod) can connect to the tty.
@@ -23,8 +25,19 @@ func randomPort() int {
 	return addr.Addr().(*net.TCPAddr).Port
 }
 
+// ShellSandbox confines the recorded shell. The shell runs in Dir, with HOME
+// and TMPDIR pointing to it, and is wrapped in Command (e.g. bwrap or
+// firejail) if set. Env takes precedence over the environment of VHS, which
+// Environ replaces if not nil.
+type ShellSandbox struct {
+	Dir     string
+	Command []string
+	Env     []string
+	Environ []string
+}
+
 // buildTtyCmd builds the ttyd exec.Command on the given port.
-func buildTtyCmd(port int, shell Shell) *exec.Cmd {
+func buildTtyCmd(port int, shell Shell, sandbox ShellSandbox) *exec.Cmd {
 	args := []string{
 		fmt.Sprintf("--port=%d", port),
 		"--interface", "127.0.0.1",
@@ -32,15 +45,48 @@ func buildTtyCmd(port int, shell Shell) *exec.Cmd {
 		"-t", "disableResizeOverlay=true",
 		"-t", "enableSixel=true",
 		"-t", "customGlyphs=true",
+		// Emoji are two cells wide, as in the SVG outputs and the shell.
+		"-t", "unicodeVersion=11",
 		"--once", // will allow one connection and exit
 		"--writable",
 	}
 
-	args = append(args, shell.Command...)
+	// ttyd sets the TERM of the shell itself.
+	if term := sandboxTerm(sandbox); term != "" {
+		args = append(args, "--terminal-type", term)
+	}
+	args = append(args, sandbox.Command...)
+	args = append(args, shell.Command()...)
 
 	cmd := exec.Command("ttyd", args...) //nolint:noctx
-	if shell.Env != nil {
-		cmd.Env = append(shell.Env, os.Environ()...)
-	}
+	cmd.Dir = sandbox.Dir
+	cmd.Env = shellEnv(shell, sandbox)
 	return cmd
 }
+
+// shellEnv returns the environment of the shell, or nil if it inherits the
+// environment of VHS unchanged.
+func shellEnv(shell Shell, sandbox ShellSandbox) []string {
+	environ := sandbox.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	var env []string
+	if shell.Env() != nil || sandbox.Environ != nil {
+		env = append(append(env, shell.Env()...), environ...)
+	}
+
+	// The last occurrence of a variable takes precedence.
+	var overrides []string
+	if sandbox.Dir != "" {
+		overrides = append(overrides, "HOME="+sandbox.Dir, "TMPDIR="+sandbox.Dir)
+	}
+	overrides = append(overrides, sandbox.Env...)
+	if len(overrides) == 0 {
+		return env
+	}
+	if env == nil {
+		env = environ
+	}
+	return append(env, overrides...)
+}
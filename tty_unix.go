@@ -3,3 +3,6 @@
 package main
 
 const defaultShell = bash
+
+// hookShell runs the hooks of manifests.
+var hookShell = []string{"sh", "-c"}
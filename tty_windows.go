@@ -3,3 +3,6 @@
 package main
 
 var defaultShell = cmdexe
+
+// hookShell runs the hooks of manifests.
+var hookShell = []string{"cmd.exe", "/c"}
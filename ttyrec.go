@@ -0,0 +1,149 @@
+//go:build !js
+
+// Package vhs ttyrec.go reads ttyrec and script recordings.
+//
+// Like asciicasts, ttyrec files and the typescripts of script(1) with their
+// timing file are replayed as the output of a terminal. Neither ttyrec files
+// nor classic typescripts record the size of the terminal, which is then
+// given to vhs convert.
+//
+// vhs convert session.ttyrec --cols 120 --rows 30 -o demo.gif
+// vhs convert typescript --timing timing -o demo.gif
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ttyrecExt is the extension of ttyrec files.
+const ttyrecExt = ".ttyrec"
+
+// ttyrecHeaderSize is the size of the header of a ttyrec record: its time in
+// seconds and microseconds, and the length of its data.
+const ttyrecHeaderSize = 12
+
+// parseTtyrec reads the records of a ttyrec file.
+func parseTtyrec(r io.Reader) (termRecording, error) {
+	var rec termRecording
+	var start float64
+	br := bufio.NewReader(r)
+	for {
+		var header [ttyrecHeaderSize]byte
+		if _, err := io.ReadFull(br, header[:]); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return termRecording{}, fmt.Errorf("invalid ttyrec record: %w", err)
+		}
+		sec := binary.LittleEndian.Uint32(header[0:4])
+		usec := binary.LittleEndian.Uint32(header[4:8])
+		data := make([]byte, binary.LittleEndian.Uint32(header[8:12]))
+		if _, err := io.ReadFull(br, data); err != nil {
+			return termRecording{}, fmt.Errorf("invalid ttyrec record: %w", err)
+		}
+
+		t := float64(sec) + float64(usec)/1e6
+		if len(rec.Events) == 0 {
+			start = t
+		}
+		rec.Events = append(rec.Events, termEvent{Time: t - start, Data: string(data)})
+	}
+	return rec, nil
+}
+
+// typescriptSize matches the size of the terminal in the header of a
+// typescript, e.g. COLUMNS="120" LINES="30".
+var typescriptSize = regexp.MustCompile(`\b(COLUMNS|LINES)="(\d+)"`)
+
+// parseTypescript reads a typescript of script(1), timed by its timing file
+// in either the classic format (delay and length of the output) or the
+// advanced one (also the input, resizes and header information).
+func parseTypescript(typescript, timing io.Reader) (termRecording, error) {
+	var rec termRecording
+	out := bufio.NewReader(typescript)
+
+	// The classic typescripts start with a header line, which is not timed.
+	if b, err := out.Peek(len("Script started")); err == nil && string(b) == "Script started" {
+		header, _ := out.ReadString('\n')
+		for _, m := range typescriptSize.FindAllStringSubmatch(header, -1) {
+			rec.setSize(m[1], m[2])
+		}
+	}
+
+	var t float64
+	scanner := bufio.NewScanner(timing)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// Classic entries are output, without a type.
+		if _, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			fields = append([]string{"O"}, fields...)
+		}
+		if len(fields) < 3 { //nolint:mnd
+			return termRecording{}, fmt.Errorf("invalid timing on line %d", line)
+		}
+		delay, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return termRecording{}, fmt.Errorf("invalid timing on line %d: %w", line, err)
+		}
+		t += delay
+
+		switch fields[0] {
+		case "O":
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return termRecording{}, fmt.Errorf("invalid timing on line %d: %w", line, err)
+			}
+			data := make([]byte, n)
+			if _, err := io.ReadFull(out, data); err != nil {
+				return termRecording{}, fmt.Errorf("the typescript is shorter than its timing: %w", err)
+			}
+			rec.Events = append(rec.Events, termEvent{Time: t, Data: string(data)})
+		case "S":
+			// S 0.5 SIGWINCH ROWS=30 COLS=120
+			var cols, rows int
+			for _, field := range fields[3:] {
+				name, value, _ := strings.Cut(field, "=")
+				n, _ := strconv.Atoi(value)
+				switch name {
+				case "COLS":
+					cols = n
+				case "ROWS":
+					rows = n
+				}
+			}
+			if cols > 0 && rows > 0 {
+				rec.Events = append(rec.Events, termEvent{Time: t, Cols: cols, Rows: rows})
+			}
+		case "H":
+			// H 0.0 COLUMNS 120
+			if len(fields) > 3 { //nolint:mnd
+				rec.setSize(fields[2], fields[3])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return termRecording{}, fmt.Errorf("failed to read timing: %w", err)
+	}
+	return rec, nil
+}
+
+// setSize sets the columns or lines of the terminal from a header of a
+// typescript.
+func (rec *termRecording) setSize(name, value string) {
+	n, _ := strconv.Atoi(value)
+	switch name {
+	case "COLUMNS":
+		rec.Cols = n
+	case "LINES":
+		rec.Rows = n
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestParseTtyrec(t *testing.T) {
+	var buf bytes.Buffer
+	for _, r := range []struct {
+		sec, usec uint32
+		data      string
+	}{
+		{1000, 500000, "$ "},
+		{1001, 0, "ls\r\n"},
+	} {
+		_ = binary.Write(&buf, binary.LittleEndian, []uint32{r.sec, r.usec, uint32(len(r.data))})
+		buf.WriteString(r.data)
+	}
+
+	rec, err := parseTtyrec(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []termEvent{{Time: 0, Data: "$ "}, {Time: 0.5, Data: "ls\r\n"}}
+	if len(rec.Events) != len(want) || rec.Events[0] != want[0] || rec.Events[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, rec.Events)
+	}
+
+	if _, err := parseTtyrec(bytes.NewReader([]byte{1, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 'x'})); err == nil {
+		t.Error("expected an error for a truncated record")
+	}
+}
+
+func TestParseTypescript(t *testing.T) {
+	typescript := "Script started on 2024-01-01 [COMMAND=\"bash\" TERM=\"xterm\" COLUMNS=\"120\" LINES=\"30\"]\n$ ls\r\nfile\r\n"
+	rec, err := parseTypescript(strings.NewReader(typescript), strings.NewReader("0.5 2\n0.25 4\n1.0 6\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Cols != 120 || rec.Rows != 30 {
+		t.Errorf("expected the size of the header, got %dx%d", rec.Cols, rec.Rows)
+	}
+	want := []termEvent{{Time: 0.5, Data: "$ "}, {Time: 0.75, Data: "ls\r\n"}, {Time: 1.75, Data: "file\r\n"}}
+	if len(rec.Events) != len(want) {
+		t.Fatalf("expected %v, got %v", want, rec.Events)
+	}
+	for i := range want {
+		if rec.Events[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], rec.Events[i])
+		}
+	}
+
+	// The advanced format also times the input, resizes and header.
+	timing := "H 0.0 COLUMNS 100\nH 0.0 LINES 20\nO 0.5 2\nI 0.25 1\nS 0.25 SIGWINCH ROWS=40 COLS=90\nO 0.5 4\n"
+	rec, err = parseTypescript(strings.NewReader("$ ls\r\n"), strings.NewReader(timing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []termEvent{{Time: 0.5, Data: "$ "}, {Time: 1, Cols: 90, Rows: 40}, {Time: 1.5, Data: "ls\r\n"}}
+	if rec.Cols != 100 || rec.Rows != 20 || len(rec.Events) != len(want) {
+		t.Fatalf("expected a 100x20 terminal and %v, got %dx%d and %v", want, rec.Cols, rec.Rows, rec.Events)
+	}
+	for i := range want {
+		if rec.Events[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], rec.Events[i])
+		}
+	}
+
+	if _, err := parseTypescript(strings.NewReader("ab"), strings.NewReader("0.5 3\n")); err == nil {
+		t.Error("expected an error for a typescript shorter than its timing")
+	}
+}
@@ -0,0 +1,89 @@
+//go:build !js
+
+// Package vhs typefile.go types the contents of files.
+//
+// TypeFile types a file like Type types a string, so that long code samples
+// need not be inlined and escaped in the tape. Its newlines are pressed as
+// Enter, which runs every line of a script in a shell, or typed as line feeds
+// with --literal, which editors insert as newlines.
+//
+// TypeFile ./snippet.py --speed 20ms
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agentstation/vhs/parser"
+	"github.com/agentstation/vhs/token"
+	"github.com/go-rod/rod/lib/input"
+)
+
+// typeFileOptions returns the typing speed and whether newlines are typed as
+// line feeds of a TypeFile command.
+func typeFileOptions(options string) (speed string, literal bool) {
+	speed, literal = strings.CutSuffix(options, "literal")
+	return strings.TrimSpace(speed), literal
+}
+
+// typeFile types the lines of the file with typeLine, and their newlines
+// with newline.
+func typeFile(c parser.Command, v *VHS, typeLine CommandFunc, newline func(literal bool) error) error {
+	speed, literal := typeFileOptions(c.Options)
+	lines := strings.Split(c.Args, "\n")
+	for i, line := range lines {
+		if line != "" {
+			if err := typeLine(parser.Command{Type: token.TYPE, Options: speed, Args: line}, v); err != nil {
+				return err
+			}
+		}
+		if i == len(lines)-1 {
+			break
+		}
+		if err := newline(literal); err != nil {
+			return err
+		}
+		if err := typeFileSleep(v, speed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeFileSleep waits for the typing speed after a newline.
+func typeFileSleep(v *VHS, speed string) error {
+	typingSpeed := v.Options.TypingSpeed
+	if speed != "" {
+		var err error
+		typingSpeed, err = time.ParseDuration(speed)
+		if err != nil {
+			return fmt.Errorf("failed to parse typing speed: %w", err)
+		}
+	}
+	return v.sleep(typingSpeed)
+}
+
+// ExecuteTypeFile is a CommandFunc that types the contents of a file on the
+// running instance of vhs.
+func ExecuteTypeFile(c parser.Command, v *VHS) error {
+	return typeFile(c, v, ExecuteType, func(literal bool) error {
+		if literal {
+			return v.typeSequence("\n")
+		}
+		if err := v.Page.Keyboard.Type(input.Enter); err != nil {
+			return fmt.Errorf("failed to type Enter key: %w", err)
+		}
+		return nil
+	})
+}
+
+// executeNativeTypeFile types the contents of a file with the native backend.
+func executeNativeTypeFile(c parser.Command, v *VHS) error {
+	return typeFile(c, v, executeNativeType, func(literal bool) error {
+		if literal {
+			return v.native.write("\n")
+		}
+		return v.native.write("\r")
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestTypeFile(t *testing.T) {
+	for _, tc := range []struct {
+		options string
+		want    string
+	}{
+		{"", "[def main():]ENTER[    pass]ENTER"},
+		{"10ms literal", "[def main():]LF[    pass]LF"},
+	} {
+		t.Run(tc.options, func(t *testing.T) {
+			v := New()
+			v.Options.TypingSpeed = 0
+			var typed strings.Builder
+			typeLine := func(c parser.Command, _ *VHS) error {
+				typed.WriteString("[" + c.Args + "]")
+				return nil
+			}
+			newline := func(literal bool) error {
+				if literal {
+					typed.WriteString("LF")
+				} else {
+					typed.WriteString("ENTER")
+				}
+				return nil
+			}
+
+			c := parser.Command{Options: tc.options, Args: "def main():\n    pass\n"}
+			if err := typeFile(c, &v, typeLine, newline); err != nil {
+				t.Fatal(err)
+			}
+			if typed.String() != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, typed.String())
+			}
+		})
+	}
+}
+
+func TestTypeFileOptions(t *testing.T) {
+	if speed, literal := typeFileOptions("20ms literal"); speed != "20ms" || !literal {
+		t.Errorf("expected 20ms and literal, got %q and %t", speed, literal)
+	}
+	if speed, literal := typeFileOptions("20ms"); speed != "20ms" || literal {
+		t.Errorf("expected 20ms, got %q and %t", speed, literal)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 package main
 
 import (
@@ -10,13 +12,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"strings"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/agentstation/vhs/parser"
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // VHS is the object that controls the setup.
@@ -34,15 +38,48 @@ type VHS struct {
 	totalFrames  int
 	close        func() error
 	svgFrames    []SVGFrame
+	scenes       []Scene
+	chapters     []chapterMark
+	scrollback   []string
+	events       *EventBus
+	clock        time.Duration
+	snapshots    []snapshot
+	native       *nativeTerminal
+	highlight    *highlight
+	camera       []CameraKeyframe
+	annotations  []Annotation
+	idle         idleTracker
+	lastCapture  lastCapture
+	// workspace is the throwaway copy of the working directory the shell
+	// starts in, if Sandbox is set.
+	workspace string
+	// launched is the number of commands which finished when the last one
+	// was launched (see WaitExit).
+	launched int
+	// trace carries the span of the recording, the parent of the spans of
+	// its phases.
+	trace  context.Context //nolint:containedctx
+	limits *limiter
+	// transcriptScenes is the text of the terminal at the end of every
+	// scene, for the Markdown transcript.
+	transcriptScenes []transcriptScene
+	// diagnostics keeps what the recording reached, if a diagnostics bundle
+	// is written when it fails.
+	diagnostics *diagnostics
+	// defaults are the Set commands of --options, executed before the
+	// commands of the tape.
+	defaults []parser.Command
 }
 
 // Options is the set of options for the setup.
 type Options struct {
 	Shell         Shell
 	FontFamily    string
+	EmojiFont     string
 	FontSize      int
 	LetterSpacing float64
 	LineHeight    float64
+	PixelRatio    float64
 	TypingSpeed   time.Duration
 	Theme         Theme
 	Test          TestOptions
@@ -55,50 +92,65 @@ type Options struct {
 	Style         StyleOptions
 	SVG           SVGOptions
 	DebugConsole  bool // Enable browser console logging
+	Sandbox       ShellSandbox
+	Backend       string
+	Offline       bool
+	Limits        LimitOptions
+	// KeyboardLayout is the layout whose keys Type presses, US by default.
+	KeyboardLayout string
+	// ThemeFilter is the color transform applied to the theme, and
+	// unfilteredTheme the theme it is applied to.
+	ThemeFilter     string
+	unfilteredTheme Theme
+	// ThemeDark is the theme for viewers who prefer a dark color scheme, and
+	// colorScheme the pass of the recording when it is recorded twice.
+	ThemeDark   *Theme
+	colorScheme string
+	// ColorMode is the number of colors of the emulated terminal.
+	ColorMode string
+	// CursorColor and CursorAccentColor override the colors of the cursor
+	// and of the text under it. A reverse cursor inverts the cell under it.
+	CursorColor       string
+	CursorAccentColor string
+	// WorkingDirectory is the directory the shell starts in, and
+	// SandboxWorkspace whether it starts in a throwaway copy of it.
+	WorkingDirectory string
+	SandboxWorkspace bool
 }
 
 // SVGOptions contains SVG-specific configuration options.
 type SVGOptions struct {
-	OptimizeSize bool
+	OptimizeSize   bool
+	UnderlineLinks bool
+	Scrollback     bool
+	FontLigatures  bool
+	CursorTween    bool
+	// OptimizeLevel is how hard optimized SVGs are optimized.
+	OptimizeLevel int
+	// Description is the accessible description of the SVG, and
+	// AccessibleTranscript whether the text of the terminal is embedded for
+	// screen readers.
+	Description          string
+	AccessibleTranscript bool
+	// SyntaxHighlight is the language the lines without colors are
+	// highlighted as, if any.
+	SyntaxHighlight string
 }
 
 const (
-	defaultFontSize      = 22
-	defaultTypingSpeed   = 50 * time.Millisecond
-	defaultLineHeight    = 1.0
-	defaultLetterSpacing = 1.0
-	fontsSeparator       = ","
-	defaultCursorBlink   = true
-	defaultWaitTimeout   = 15 * time.Second
+	defaultTypingSpeed = 50 * time.Millisecond
+	defaultCursorBlink = true
+	defaultWaitTimeout = 15 * time.Second
 )
 
 var defaultWaitPattern = regexp.MustCompile(">$")
 
-var defaultFontFamily = withSymbolsFallback(strings.Join([]string{
-	"JetBrains Mono",
-	"DejaVu Sans Mono",
-	"Menlo",
-	"Bitstream Vera Sans Mono",
-	"Inconsolata",
-	"Roboto Mono",
-	"Hack",
-	"Consolas",
-	"ui-monospace",
-	"monospace",
-}, fontsSeparator))
-
-var symbolsFallback = []string{
-	"Apple Symbols",
-}
-
-func withSymbolsFallback(font string) string {
-	return font + fontsSeparator + strings.Join(symbolsFallback, fontsSeparator)
-}
-
 // DefaultSVGOptions returns the default SVG options.
 func DefaultSVGOptions() SVGOptions {
 	return SVGOptions{
-		OptimizeSize: true, // Default to optimized SVG output
+		OptimizeSize:  true, // Default to optimized SVG output
+		OptimizeLevel: svgOptimizeGenerator,
+		FontLigatures: true,
 	}
 }
 
@@ -114,6 +166,7 @@ func DefaultVHSOptions() Options {
 		FontSize:      defaultFontSize,
 		LetterSpacing: defaultLetterSpacing,
 		LineHeight:    defaultLineHeight,
+		PixelRatio:    defaultPixelRatio,
 		TypingSpeed:   defaultTypingSpeed,
 		Shell:         Shells[defaultShell],
 		Theme:         DefaultTheme,
@@ -147,22 +200,39 @@ func (vhs *VHS) Start() error {
 	}
 
 	port := randomPort()
-	vhs.tty = buildTtyCmd(port, vhs.Options.Shell)
+	sandbox := vhs.Options.Sandbox
+	if vhs.Options.Video.Deterministic {
+		sandbox = deterministicSandbox(sandbox)
+	}
+	if vhs.Options.ColorMode != "" {
+		sandbox = colorModeSandbox(sandbox, vhs.Options.ColorMode)
+	}
+	vhs.tty = buildTtyCmd(port, vhs.Options.Shell, sandbox)
+	vhs.tty.Dir = vhs.shellDir(sandbox)
 	if err := vhs.tty.Start(); err != nil {
 		return fmt.Errorf("could not start tty: %w", err)
 	}
 
-	path, _ := launcher.LookPath()
-	enableNoSandbox := os.Getenv("VHS_NO_SANDBOX") != ""
-	u, err := launcher.New().Leakless(false).Bin(path).NoSandbox(enableNoSandbox).Launch()
-	if err != nil {
-		return fmt.Errorf("could not launch browser: %w", err)
+	// A browser shared between recordings (see WithBrowser) only gets a new
+	// page, which is closed instead of the browser.
+	browser, shared := vhs.browser, vhs.browser != nil
+	if !shared {
+		var err error
+		browser, err = launchBrowser(vhs.Options.Offline)
+		if err != nil {
+			return err
+		}
 	}
-	browser := rod.New().ControlURL(u).MustConnect()
-	page, err := browser.Page(proto.TargetCreateTarget{URL: fmt.Sprintf("http://localhost:%d", port)})
+	page, err := browser.Page(proto.TargetCreateTarget{})
 	if err != nil {
 		return fmt.Errorf("could not open ttyd: %w", err)
 	}
+	if _, err := page.EvalOnNewDocument(shellStateScript); err != nil {
+		return fmt.Errorf("could not watch for prompts: %w", err)
+	}
+	if err := page.Navigate(fmt.Sprintf("http://localhost:%d", port)); err != nil {
+		return fmt.Errorf("could not open ttyd: %w", err)
+	}
 
 	// Enable console logging if debug is enabled
 	if vhs.Options.DebugConsole {
@@ -176,15 +246,34 @@ func (vhs *VHS) Start() error {
 	vhs.browser = browser
 	vhs.Page = page
 	vhs.close = vhs.browser.Close
+	if shared {
+		vhs.close = page.Close
+	}
 	vhs.started = true
 	return nil
 }
 
-// Setup sets up the VHS instance and performs the necessary actions to reflect
-// the options that are default and set by the user.
-func (vhs *VHS) Setup() {
-	// Set Viewport to the correct size, accounting for the padding that will be
-	// added during the render.
+// launchBrowser launches a browser to record in.
+func launchBrowser(offline bool) (*rod.Browser, error) {
+	path, err := browserPath(offline)
+	if err != nil {
+		return nil, err
+	}
+	enableNoSandbox := os.Getenv("VHS_NO_SANDBOX") != ""
+	u, err := launcher.New().Leakless(false).Bin(path).NoSandbox(enableNoSandbox).Launch()
+	if err != nil {
+		return nil, fmt.Errorf("could not launch browser: %w", err)
+	}
+	browser := rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		return nil, fmt.Errorf("could not connect to browser: %w", err)
+	}
+	return browser, nil
+}
+
+// terminalViewport returns the size of the terminal in pixels, which excludes
+// the padding, margin and window bar added during the render.
+func (vhs *VHS) terminalViewport() (int, int) {
 	padding := vhs.Options.Video.Style.Padding
 	margin := 0
 	if vhs.Options.Video.Style.MarginFill != "" {
@@ -196,17 +285,34 @@ func (vhs *VHS) Setup() {
 	}
 	width := vhs.Options.Video.Style.Width - double(padding) - double(margin)
 	height := vhs.Options.Video.Style.Height - double(padding) - double(margin) - bar
-	vhs.Page = vhs.Page.MustSetViewport(width, height, 0, false)
+	return width, height
+}
+
+// Setup sets up the VHS instance and performs the necessary actions to reflect
+// the options that are default and set by the user.
+func (vhs *VHS) Setup() {
+	// Set Viewport to the correct size, accounting for the padding that will be
+	// added during the render.
+	width, height := vhs.terminalViewport()
+	vhs.Page = vhs.Page.MustSetViewport(width, height, vhs.Options.PixelRatio, false)
 
 	// Find xterm.js canvases for the text and cursor layer for recording.
 	vhs.TextCanvas, _ = vhs.Page.Element("canvas.xterm-text-layer")
 	vhs.CursorCanvas, _ = vhs.Page.Element("canvas.xterm-cursor-layer")
 
+	if vhs.Options.Offline || slices.Contains(parseFontFamily(vhs.Options.FontFamily), bundledFontFamily) {
+		if err := vhs.loadBundledFont(); err != nil {
+			log.Println(err)
+		}
+	}
+
 	// Apply options to the terminal
 	// By this point the setting commands have been executed, so the `opts` struct is up to date.
 	vhs.Page.MustEval(fmt.Sprintf("() => { term.options = { fontSize: %d, fontFamily: '%s', letterSpacing: %f, lineHeight: %f, theme: %s, cursorBlink: %t } }",
-		vhs.Options.FontSize, vhs.Options.FontFamily, vhs.Options.LetterSpacing,
-		vhs.Options.LineHeight, vhs.Options.Theme.String(), vhs.Options.CursorBlink))
+		vhs.Options.FontSize, fontStack(vhs.terminalFontFamily()), vhs.Options.LetterSpacing,
+		vhs.Options.LineHeight, vhs.Options.Theme.String(),
+		// The phase of the blinking cursor depends on when frames are captured.
+		vhs.Options.CursorBlink && !vhs.Options.Video.Deterministic))
 
 	// Fit the terminal into the window
 	vhs.Page.MustEval("term.fit")
@@ -229,7 +335,7 @@ func (vhs *VHS) terminate() error {
 	time.Sleep(cleanupWaitTime)
 
 	// Tear down the processes we started.
-	vhs.browser.MustClose()
+	_ = vhs.close()
 	return vhs.tty.Process.Kill()
 }
 
@@ -245,39 +351,82 @@ func (vhs *VHS) Cleanup() error {
 }
 
 // Render starts rendering the individual frames into a video.
-func (vhs *VHS) Render() error {
+func (vhs *VHS) Render() (err error) {
+	ctx, span := startSpan(vhs.trace, "vhs.render", attribute.Int("vhs.frames", vhs.totalFrames))
+	defer func() { endSpan(span, err) }()
+
 	// Apply Loop Offset by modifying frame sequence
 	if err := vhs.ApplyLoopOffset(); err != nil {
 		return err
 	}
 
+	// Map scene markers onto the rendered frame sequence for transitions.
+	vhs.Options.Video.Scenes = sceneBoundaries(vhs.scenes, vhs.Options.Video.StartingFrame, vhs.totalFrames)
+	vhs.Options.Video.Chapters = buildChapters(vhs.chapters, vhs.Options.Video, vhs.totalFrames)
+	vhs.Options.Video.Camera = cameraKeyframes(vhs.camera, vhs.Options.Video.StartingFrame)
+	vhs.Options.Video.Annotations = vhs.annotations
+	vhs.Options.Video.Watermark = vhs.watermark()
+
 	// Ensure the font family and size are set in the style
 	if vhs.Options.Video.Style != nil {
 		vhs.Options.Video.Style.FontFamily = vhs.Options.FontFamily
 		vhs.Options.Video.Style.FontSize = vhs.Options.FontSize
 	}
 
+	// The frames were captured at the pixel ratio, so the raster outputs are
+	// rendered at the scaled dimensions.
+	video := vhs.Options.Video
+	screenshot := vhs.Options.Screenshot
+	if vhs.Options.PixelRatio != defaultPixelRatio && video.Style != nil {
+		video.Style = video.Style.Scaled(vhs.Options.PixelRatio)
+		screenshot.style = video.Style
+	}
+
+	// Post-process the frames before they are encoded.
+	_, frameCommand := startSpan(ctx, "vhs.frame_command")
+	err = runFrameCommand(video)
+	endSpan(frameCommand, err)
+	if err != nil {
+		return err
+	}
+
 	// Generate the video(s) with the frames.
 	var cmds []*exec.Cmd
-	cmds = append(cmds, MakeGIF(vhs.Options.Video))
-	cmds = append(cmds, MakeMP4(vhs.Options.Video))
-	cmds = append(cmds, MakeWebM(vhs.Options.Video))
-	cmds = append(cmds, MakeScreenshots(vhs.Options.Screenshot)...)
+	cmds = append(cmds, MakeGIF(video))
+	cmds = append(cmds, MakeMP4(video))
+	cmds = append(cmds, MakeWebM(video))
+	if err := MakeChapterSidecar(video); err != nil {
+		log.Println(err)
+	}
+	cmds = append(cmds, MakeScreenshots(screenshot)...)
 
-	for _, cmd := range cmds {
-		if cmd == nil {
-			continue
-		}
+	cmds = slices.DeleteFunc(cmds, func(cmd *exec.Cmd) bool { return cmd == nil })
+	for i, cmd := range cmds {
+		_, encode := startSpan(ctx, "vhs.encode", attribute.String("vhs.output", cmd.Args[len(cmd.Args)-1]))
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			log.Println(string(out))
 		}
+		endSpan(encode, err)
+		vhs.events.Publish(progressEvent(PhaseEncode, i+1, len(cmds)))
+	}
+
+	// Re-encode the outputs which exceed the size budget.
+	_, fit := startSpan(ctx, "vhs.fit_size")
+	err = FitFileSize(video)
+	endSpan(fit, err)
+	if err != nil {
+		return err
 	}
 
-	// Generate SVG if requested
-	if err := MakeSVG(vhs); err != nil {
-		return fmt.Errorf("failed to generate SVG: %w", err)
+	// Render the outputs of renderers, e.g. SVG.
+	_, renderSpan := startSpan(ctx, "vhs.render_outputs", attribute.Int("vhs.frames", len(vhs.svgFrames)))
+	err = renderOutputs(vhs)
+	endSpan(renderSpan, err)
+	if err != nil {
+		return err
 	}
+	vhs.events.Publish(progressEvent(PhaseOptimize, 1, 1))
 
 	return nil
 }
@@ -358,20 +507,19 @@ const quality = 1.0
 // Record begins the goroutine which captures images from the xterm.js canvases.
 func (vhs *VHS) Record(ctx context.Context) <-chan error {
 	ch := make(chan error)
-	interval := time.Second / time.Duration(vhs.Options.Video.Framerate)
+	interval := vhs.Options.Video.captureInterval()
 
 	//nolint: mnd
 	go func() {
-		counter := 0
+		capture := vhs.traceCapture()
 		start := time.Now()
+		captures := 0
 		for {
 			select {
 			case <-ctx.Done():
+				capture.end()
 				_ = vhs.terminate()
 
-				// Save total # of frames for offset calculation
-				vhs.totalFrames = counter
-
 				// Signal caller that we're done recording.
 				close(ch)
 				return
@@ -386,51 +534,103 @@ func (vhs *VHS) Record(ctx context.Context) <-chan error {
 				if vhs.Page == nil {
 					continue
 				}
-
-				cursor, cursorErr := vhs.CursorCanvas.CanvasToImage("image/png", quality)
-				text, textErr := vhs.TextCanvas.CanvasToImage("image/png", quality)
-				if textErr != nil || cursorErr != nil {
-					ch <- fmt.Errorf("error: %v, %v", textErr, cursorErr)
+				// Deterministic recordings capture frames as commands
+				// advance their clock instead.
+				if vhs.Options.Video.Deterministic {
 					continue
 				}
 
-				counter++
-				if err := os.WriteFile(
-					filepath.Join(vhs.Options.Video.Input, fmt.Sprintf(cursorFrameFormat, counter)),
-					cursor,
-					0o600,
-				); err != nil {
-					ch <- fmt.Errorf("error writing cursor frame: %w", err)
-					continue
-				}
-				if err := os.WriteFile(
-					filepath.Join(vhs.Options.Video.Input, fmt.Sprintf(textFrameFormat, counter)),
-					text,
-					0o600,
-				); err != nil {
-					ch <- fmt.Errorf("error writing text frame: %w", err)
-					continue
+				captures++
+				n := vhs.Options.Video.capturedFrames(captures)
+				if err := capture.capture(vhs, n); err != nil && vhs.limits.Err() == nil {
+					ch <- err
 				}
+			}
+		}
+	}()
 
-				// Capture SVG frame data if SVG output is requested
-				if vhs.Options.Video.Output.SVG != "" {
-					svgFrame, err := CaptureSVGFrame(vhs.Page, counter, vhs.Options.Video.Framerate)
-					if err != nil {
-						log.Printf("Error capturing SVG frame %d: %v", counter, err)
-					} else if svgFrame != nil {
-						vhs.svgFrames = append(vhs.svgFrames, *svgFrame)
-					}
-				}
+	return ch
+}
+
+// captureFrames captures the terminal once and writes it as the next n
+// frames.
+func (vhs *VHS) captureFrames(n int) error {
+	if vhs.native != nil {
+		vhs.captureNativeFrames(n)
+		return nil
+	}
+
+	selection, expired := vhs.selection(vhs.totalFrames + 1)
+	if expired {
+		if _, err := vhs.Page.Eval("() => term.clearSelection()"); err != nil {
+			return fmt.Errorf("failed to clear highlight: %w", err)
+		}
+	}
+
+	// The cursor blinks without rendering the terminal, so it is always
+	// captured.
+	cursor, cursorErr := vhs.CursorCanvas.CanvasToImage("image/png", quality)
+	text, svgFrame := vhs.lastCapture.text, vhs.lastCapture.svg
+	var textErr error
+	if selection != nil || expired || vhs.terminalRendered() {
+		text, textErr = vhs.captureTextLayer()
+		svgFrame = nil
+	}
+	if textErr != nil || cursorErr != nil {
+		return fmt.Errorf("error: %v, %v", textErr, cursorErr)
+	}
+	vhs.lastCapture.text = text
+	defer func() { vhs.lastCapture.svg = svgFrame }()
 
-				// Capture current frame and disable frame capturing
-				if vhs.Options.Screenshot.frameCapture {
-					vhs.Options.Screenshot.makeScreenshot(counter)
+	for i := 0; i < n; i++ {
+		if vhs.skipIdleFrame(text, cursor) {
+			continue
+		}
+		vhs.mutex.Lock()
+		vhs.totalFrames++
+		counter := vhs.totalFrames
+		vhs.mutex.Unlock()
+		vhs.checkFrames(counter, int64(len(cursor)+len(text)))
+		vhs.events.Publish(Event{Type: EventFrame, Frame: counter, Image: text})
+		if err := os.WriteFile(
+			filepath.Join(vhs.Options.Video.Input, fmt.Sprintf(cursorFrameFormat, counter)),
+			cursor,
+			0o600,
+		); err != nil {
+			return fmt.Errorf("error writing cursor frame: %w", err)
+		}
+		if err := os.WriteFile(
+			filepath.Join(vhs.Options.Video.Input, fmt.Sprintf(textFrameFormat, counter)),
+			text,
+			0o600,
+		); err != nil {
+			return fmt.Errorf("error writing text frame: %w", err)
+		}
+
+		// Capture SVG frame data if SVG or PDF output is requested
+		if vhs.Options.Video.Output.textFrames() {
+			if svgFrame == nil {
+				var err error
+				svgFrame, err = CaptureSVGFrame(vhs.Page, counter, vhs.Options.Video.Framerate)
+				if err != nil {
+					log.Printf("Error capturing SVG frame %d: %v", counter, err)
 				}
 			}
+			if svgFrame != nil {
+				frame := *svgFrame
+				frame.Timestamp = float64(counter) / float64(vhs.Options.Video.Framerate)
+				frame.Selection = selection
+				vhs.svgFrames = append(vhs.svgFrames, frame)
+			}
 		}
-	}()
 
-	return ch
+		// Capture current frame and disable frame capturing
+		if vhs.Options.Screenshot.frameCapture {
+			vhs.Options.Screenshot.makeScreenshot(counter)
+		}
+	}
+
+	return nil
 }
 
 // ResumeRecording indicates to VHS that the recording should be resumed.
@@ -456,3 +656,459 @@ func (vhs *VHS) ScreenshotNextFrame(path string) {
 
 	vhs.Options.Screenshot.enableFrameCapture(path)
 }
+
+// moveCamera adds the move of the camera to the position (if any) and zoom
+// factor (if not 0) to the keyframes, starting at the next frame.
+func (vhs *VHS) moveCamera(rawDuration, position string, zoom float64) error {
+	duration, err := time.ParseDuration(rawDuration)
+	if err != nil {
+		duration = defaultCameraDuration
+	}
+
+	var x, y float64
+	if position != "" {
+		var row, col int
+		if _, err := fmt.Sscanf(position, "%d,%d", &row, &col); err != nil {
+			return fmt.Errorf("failed to parse camera position: %w", err)
+		}
+		cols, rows, err := vhs.terminalSize()
+		if err != nil {
+			return err
+		}
+		x = (float64(col) - 0.5) / float64(cols)
+		y = (float64(row) - 0.5) / float64(rows)
+	}
+
+	vhs.mutex.Lock()
+	defer vhs.mutex.Unlock()
+
+	// A move interrupts the previous one where the camera currently is.
+	start := cameraAt(vhs.camera, vhs.totalFrames+1)
+	for len(vhs.camera) > 0 && vhs.camera[len(vhs.camera)-1].Frame >= start.Frame {
+		vhs.camera = vhs.camera[:len(vhs.camera)-1]
+	}
+
+	end := start
+	end.Frame += max(1, int(math.Round(duration.Seconds()*float64(vhs.Options.Video.Framerate))))
+	if zoom > 0 {
+		end.Zoom = zoom
+	}
+	if position != "" {
+		end.X, end.Y = x, y
+	}
+	vhs.camera = append(vhs.camera, start, end.clamp())
+	return nil
+}
+
+// terminalSize returns the number of columns and rows of the terminal.
+func (vhs *VHS) terminalSize() (int, int, error) {
+	if vhs.native != nil {
+		cols, rows := vhs.native.screen.Size()
+		return cols, rows, nil
+	}
+
+	res, err := vhs.Page.Eval("() => [term.cols, term.rows]")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get terminal size: %w", err)
+	}
+	size := res.Value.Arr()
+	if len(size) != 2 {
+		return 0, 0, fmt.Errorf("failed to get terminal size: %s", res.Value)
+	}
+	return size[0].Int(), size[1].Int(), nil
+}
+
+// startHighlight makes the span of the command the active highlight.
+func (vhs *VHS) startHighlight(c parser.Command) (Selection, error) {
+	s, err := parseSelection(c.Args)
+	if err != nil {
+		return s, err
+	}
+	duration, err := time.ParseDuration(c.Options)
+	if err != nil {
+		duration = defaultHighlightDuration
+	}
+	frames := int(math.Ceil(duration.Seconds() * float64(vhs.Options.Video.Framerate)))
+
+	vhs.mutex.Lock()
+	defer vhs.mutex.Unlock()
+	vhs.highlight = &highlight{Selection: s, until: vhs.totalFrames + frames}
+	return s, nil
+}
+
+// selection returns the active highlight at the given frame. The highlight
+// is removed once it expires, in which case expired is true.
+func (vhs *VHS) selection(frame int) (s *Selection, expired bool) {
+	vhs.mutex.Lock()
+	defer vhs.mutex.Unlock()
+
+	if vhs.highlight == nil {
+		return nil, false
+	}
+	if frame > vhs.highlight.until {
+		vhs.highlight = nil
+		return nil, true
+	}
+	selection := vhs.highlight.Selection
+	return &selection, false
+}
+
+// durationFrames returns the number of frames captured in the duration.
+func (vhs *VHS) durationFrames(d time.Duration) int {
+	return int(math.Round(d.Seconds() * float64(vhs.Options.Video.Framerate)))
+}
+
+// watermark returns the watermark of the outputs (if any), with the text in
+// the foreground color of the theme by default.
+func (vhs *VHS) watermark() *Watermark {
+	if vhs.Options.Video.Watermark == nil {
+		return nil
+	}
+	w := *vhs.Options.Video.Watermark
+	if w.Color == "" {
+		w.Color = vhs.Options.Theme.Foreground
+	}
+	return &w
+}
+
+// WarnMissingGlyphs logs a warning for characters of the recording which
+// none of the configured fonts can draw. It must be called before the
+// browser is closed.
+func (vhs *VHS) WarnMissingGlyphs() error {
+	fonts, complete := getFontLoader().loadFontChain(withEmojiFont(vhs.Options.FontFamily, vhs.Options.EmojiFont))
+	if !complete || len(fonts) == 0 {
+		return nil
+	}
+
+	res, err := vhs.Page.Eval(captureScrollbackJS)
+	if err != nil {
+		return fmt.Errorf("failed to read terminal buffer: %w", err)
+	}
+	var text []string
+	for _, line := range res.Value.Arr() {
+		text = append(text, line.Str())
+	}
+	for _, frame := range vhs.svgFrames {
+		text = append(text, frame.Lines...)
+	}
+
+	if missing := missingGlyphs(fonts, text); len(missing) > 0 {
+		log.Println(ErrorStyle.Render(fmt.Sprintf(
+			"Missing glyphs in %s: %s. Add a fallback font, e.g. Set FontFamily \"%s, Symbols Nerd Font\"",
+			vhs.Options.FontFamily, formatGlyphs(missing), parseFontFamily(vhs.Options.FontFamily)[0])))
+	}
+	return nil
+}
+
+// CaptureSVGFrame captures the current terminal state and returns an SVGFrame.
+func CaptureSVGFrame(page *rod.Page, counter int, framerate int) (*SVGFrame, error) {
+	// Get cursor position and exact character positions from xterm.js
+	termInfo, err := page.Eval(`() => {
+		const term = window.term;
+		if (!term) {
+			console.error('term is not available');
+			return null;
+		}
+		const buffer = term.buffer.active;
+		const cursorX = buffer.cursorX;
+		// Cursor Y is relative to the viewport (0 = top of visible area)
+		const cursorY = buffer.cursorY;
+		
+		// Debug logging
+		const cursorLine = buffer.getLine(cursorY + buffer.viewportY);
+		if (cursorLine) {
+			const lineText = cursorLine.translateToString(true);
+			console.log('Cursor Debug - xterm.js cursor position:', cursorX, 'on line:', JSON.stringify(lineText));
+			console.log('Cursor Debug - Line length:', lineText.length, 'chars');
+			
+			// More detailed debugging
+			if (cursorX < lineText.length) {
+				console.log('Cursor Debug - Character at cursor position (' + cursorX + '):', JSON.stringify(lineText[cursorX]));
+				console.log('Cursor Debug - Text before cursor:', JSON.stringify(lineText.substring(0, cursorX)));
+				console.log('Cursor Debug - Text including cursor:', JSON.stringify(lineText.substring(0, cursorX + 1)));
+			} else {
+				console.log('Cursor Debug - Cursor is at end of line (position ' + cursorX + ')');
+			}
+			
+			// Check what xterm.js thinks about cursor positioning
+			console.log('Cursor Debug - buffer.cursorX:', buffer.cursorX);
+			console.log('Cursor Debug - Is cursor at line end?', cursorX >= lineText.length);
+		}
+		
+		// Get character dimensions
+		let charWidth = 0;
+		let charHeight = 0;
+		
+		// Get dimensions from the rendered canvas
+		// This is the most reliable source as it represents the actual rendered output
+		// The canvas is scaled by the device pixel ratio (Set PixelRatio), while
+		// the SVG uses CSS pixels
+		const textCanvas = document.querySelector('canvas.xterm-text-layer');
+		const cols = term.cols;
+		const rows = term.rows;
+		const pixelRatio = window.devicePixelRatio || 1;
+		charWidth = textCanvas.width / pixelRatio / cols;
+		charHeight = textCanvas.height / pixelRatio / rows;
+
+		// Inline images (Sixel, iTerm2) are drawn on a separate layer
+		const imageLayer = document.querySelector('` + imageLayerSelector + `');
+		const image = imageLayer ? imageLayer.toDataURL('image/png') : '';
+		
+		// Get cursor character from buffer
+		let cursorChar = term._core?.coreService?.isCursorHidden ? '' : '█'; // Default block cursor, none while hidden
+		
+		// Helper function to convert xterm.js color to hex
+		function xtermColorToHex(color) {
+			if (!color) return null;
+			// Handle RGB colors
+			if (color.mode === 'rgb') {
+				const r = color.r.toString(16).padStart(2, '0');
+				const g = color.g.toString(16).padStart(2, '0');
+				const b = color.b.toString(16).padStart(2, '0');
+				return '#' + r + g + b;
+			}
+			// Handle palette colors (0-255)
+			if (typeof color === 'number') {
+				// Use xterm.js's color palette
+				const palette = term.options.theme;
+				if (color < 16 && palette) {
+					// Basic 16 colors
+					const colorNames = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white',
+									   'brightBlack', 'brightRed', 'brightGreen', 'brightYellow', 
+									   'brightBlue', 'brightMagenta', 'brightCyan', 'brightWhite'];
+					return palette[colorNames[color]] || null;
+				}
+				// For extended colors, we'd need the full palette
+				return null;
+			}
+			return null;
+		}
+		
+		// OSC 8 hyperlinks are only exposed through xterm.js internals
+		function linkAt(cell) {
+			try {
+				const urlId = cell.extended && cell.extended.urlId;
+				if (!urlId) return '';
+				const link = term._core._oscLinkService.getLinkData(urlId);
+				return link ? link.uri : '';
+			} catch (e) {
+				return '';
+			}
+		}
+
+		// Get color information for all visible lines
+		const lineColors = [];
+		const activeBuffer = term.buffer.active;
+		console.log('term exists:', !!term, 'buffer exists:', !!term.buffer, 'active exists:', !!activeBuffer);
+		const viewportStart = activeBuffer ? activeBuffer.viewportY : 0;
+		const viewportEnd = viewportStart + term.rows;
+		
+		console.log('Capturing colors for viewport:', viewportStart, 'to', viewportEnd, 'buffer length:', activeBuffer ? activeBuffer.length : 'no buffer');
+		
+		let cellCount = 0;
+		for (let y = viewportStart; y < viewportEnd && activeBuffer && y < activeBuffer.length; y++) {
+			const line = activeBuffer.getLine(y);
+			const lineColorData = [];
+			
+			if (line) {
+				// Get the full line including trailing spaces
+				// translateToString(true) preserves trailing whitespace
+				const lineText = line.translateToString(true);
+				// Use term.cols to ensure we capture all columns, not just non-empty ones
+				for (let x = 0; x < term.cols; x++) {
+					const cell = line.getCell(x);
+					if (cell) {
+						cellCount++;
+						const chars = cell.getChars();
+						let fgColor = null;
+						let bgColor = null;
+						
+						
+						// Check if cell has foreground color
+						if (cell.isFgRGB()) {
+							const fg = cell.getFgColor();
+							fgColor = '#' + ((fg >> 16) & 0xff).toString(16).padStart(2, '0') +
+									 ((fg >> 8) & 0xff).toString(16).padStart(2, '0') +
+									 (fg & 0xff).toString(16).padStart(2, '0');
+						} else if (cell.isFgPalette()) {
+							// Handle palette colors
+							const paletteIndex = cell.getFgColor();
+							if (paletteIndex >= 0 && paletteIndex < 16) {
+								const colorNames = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white',
+												   'brightBlack', 'brightRed', 'brightGreen', 'brightYellow', 
+												   'brightBlue', 'brightMagenta', 'brightCyan', 'brightWhite'];
+								const palette = term.options.theme;
+								if (palette && palette[colorNames[paletteIndex]]) {
+									fgColor = palette[colorNames[paletteIndex]];
+								}
+							}
+						}
+						
+						// Check if cell has background color
+						if (cell.isBgRGB()) {
+							const bg = cell.getBgColor();
+							bgColor = '#' + ((bg >> 16) & 0xff).toString(16).padStart(2, '0') +
+									((bg >> 8) & 0xff).toString(16).padStart(2, '0') +
+									(bg & 0xff).toString(16).padStart(2, '0');
+							console.log('Found RGB background color:', bgColor, 'at', x, y);
+						} else if (cell.isBgPalette()) {
+							// Handle palette colors for background
+							const paletteIndex = cell.getBgColor();
+							if (paletteIndex >= 0 && paletteIndex < 16) {
+								const colorNames = ['black', 'red', 'green', 'yellow', 'blue', 'magenta', 'cyan', 'white',
+												   'brightBlack', 'brightRed', 'brightGreen', 'brightYellow', 
+												   'brightBlue', 'brightMagenta', 'brightCyan', 'brightWhite'];
+								const palette = term.options.theme;
+								const colorName = colorNames[paletteIndex];
+								console.log('Background - Palette index:', paletteIndex, 'colorName:', colorName, 'theme exists:', !!palette);
+								if (palette && palette[colorName]) {
+									bgColor = palette[colorName];
+									console.log('Found palette background color:', bgColor, 'for', colorName, 'at', x, y);
+								} else {
+									// Use default ANSI colors if theme doesn't have them
+									const defaultColors = ['#000000', '#cc0000', '#4e9a06', '#c4a000', '#3465a4', '#75507b', '#06989a', '#d3d7cf',
+														   '#555753', '#ef2929', '#8ae234', '#fce94f', '#729fcf', '#ad7fa8', '#34e2e2', '#eeeeec'];
+									bgColor = defaultColors[paletteIndex];
+									console.log('Using default color:', bgColor, 'for palette index:', paletteIndex);
+								}
+							}
+						}
+						
+						
+						lineColorData.push({
+							char: chars || ' ',
+							fgColor: fgColor === null ? '' : fgColor,
+							bgColor: bgColor === null ? '' : bgColor,
+							bold: cell.isBold() !== 0,
+							italic: cell.isItalic() !== 0,
+							underline: cell.isUnderline() !== 0,
+							strikethrough: cell.isStrikethrough() !== 0,
+							dim: cell.isDim() !== 0,
+							inverse: cell.isInverse() !== 0,
+							link: linkAt(cell),
+							width: cell.getWidth()
+						});
+					}
+				}
+			}
+			lineColors.push(lineColorData);
+		}
+		
+		// Note: We no longer need to calculate character positions since
+		// we're using text-push positioning in SVG
+		
+		
+		return {
+			cursorX: cursorX,
+			cursorY: cursorY,
+			charWidth: charWidth,
+			charHeight: charHeight,
+			lineColors: lineColors,
+			cursorChar: cursorChar,
+			cols: cols,
+			rows: rows,
+			image: image
+		};
+	}`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate terminal info: %w", err)
+	}
+
+	// Get buffer content as lines
+	bufferResult, err := page.Eval(`() => {
+		const term = window.term;
+		const buffer = term.buffer.active;
+		const lines = [];
+		
+		// Get all visible lines
+		const viewportStart = buffer.viewportY;
+		const viewportEnd = viewportStart + term.rows;
+		
+		for (let y = viewportStart; y < viewportEnd && y < buffer.length; y++) {
+			const line = buffer.getLine(y);
+			if (line) {
+				// translateToString(true) preserves trailing whitespace
+				lines.push(line.translateToString(true).trimEnd());
+			} else {
+				lines.push('');
+			}
+		}
+		
+		return lines;
+	}`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buffer content: %w", err)
+	}
+
+	// Convert buffer result to string slice
+	buffer := []string{}
+	bufferArray := bufferResult.Value.Arr()
+	for _, line := range bufferArray {
+		buffer = append(buffer, line.Str())
+	}
+
+	// Parse the terminal info
+	cursorX := termInfo.Value.Get("cursorX").Int()
+	cursorY := termInfo.Value.Get("cursorY").Int()
+	charWidth := termInfo.Value.Get("charWidth").Num()
+	charHeight := termInfo.Value.Get("charHeight").Num()
+	cursorChar := termInfo.Value.Get("cursorChar").Str()
+	cols := termInfo.Value.Get("cols").Int()
+	rows := termInfo.Value.Get("rows").Int()
+	image := termInfo.Value.Get("image").Str()
+
+	// Parse line colors
+	lineColors := [][]CharStyle{}
+	lineColorsJSON := termInfo.Value.Get("lineColors")
+	if !lineColorsJSON.Nil() {
+		lines := lineColorsJSON.Arr()
+		for _, line := range lines {
+			lineStyles := []CharStyle{}
+			chars := line.Arr()
+			for _, charData := range chars {
+				// Handle nil/null values properly
+				fgColor := ""
+				bgColor := ""
+				fgColorVal := charData.Get("fgColor")
+				bgColorVal := charData.Get("bgColor")
+				if !fgColorVal.Nil() && fgColorVal.Str() != nilValue {
+					fgColor = fgColorVal.Str()
+				}
+				if !bgColorVal.Nil() && bgColorVal.Str() != nilValue {
+					bgColor = bgColorVal.Str()
+				}
+
+				style := CharStyle{
+					FgColor:       fgColor,
+					BgColor:       bgColor,
+					Bold:          charData.Get("bold").Bool(),
+					Italic:        charData.Get("italic").Bool(),
+					Underline:     charData.Get("underline").Bool(),
+					Strikethrough: charData.Get("strikethrough").Bool(),
+					Dim:           charData.Get("dim").Bool(),
+					Inverse:       charData.Get("inverse").Bool(),
+					Link:          charData.Get("link").Str(),
+					Width:         charData.Get("width").Int(),
+				}
+				lineStyles = append(lineStyles, style)
+			}
+			lineColors = append(lineColors, lineStyles)
+		}
+	}
+
+	svgFrame := &SVGFrame{
+		Lines:      buffer,
+		LineColors: lineColors,
+		CursorX:    cursorX,
+		CursorY:    cursorY,
+		CharWidth:  charWidth,
+		CharHeight: charHeight,
+		Timestamp:  float64(counter) / float64(framerate),
+		CursorChar: cursorChar,
+		Cols:       cols,
+		Rows:       rows,
+		Image:      image,
+	}
+
+	return svgFrame, nil
+}
@@ -1,3 +1,5 @@
+//go:build !js
+
 // Package vhs video.go spawns the ffmpeg process to convert the frames,
 // collected by go-rod's  screenshots into the input folder, to a GIF, WebM,
 // MP4.
@@ -5,16 +7,20 @@
 // MakeGIF takes several options to modify the behaviour of the ffmpeg process,
 // which can be configured through the Set command.
 //
-// Set MaxColors 256
+// Set GIFColors 256
+// Set GIFDither sierra2_4a
+// Set GIFStatsMode full
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -27,6 +33,8 @@ const (
 	webm = ".webm"
 	gif  = ".gif"
 	svg  = ".svg"
+	md   = ".md"
+	pdf  = ".pdf"
 )
 
 // randomDir returns a random temporary directory to be used for storing frames
@@ -47,6 +55,22 @@ type VideoOutputs struct {
 	MP4    string
 	SVG    string
 	Frames string
+	// Transcript is a Markdown transcript of the text of every scene.
+	Transcript string
+	// PDF is a storyboard of the frames of the recording.
+	PDF string
+	// Lottie is a Lottie animation of the recording.
+	Lottie string
+	// Rendered are the outputs of other registered renderers, by extension.
+	Rendered map[string]string
+	// GIFLoops is the number of times the GIF plays, 0 loops forever.
+	GIFLoops int
+}
+
+// textFrames returns whether outputs are rendered from the text of the
+// captured frames, which must then be captured.
+func (o VideoOutputs) textFrames() bool {
+	return o.SVG != "" || o.PDF != "" || o.Lottie != "" || len(o.Rendered) > 0
 }
 
 // VideoOptions is the set of options for converting frames to a GIF.
@@ -55,9 +79,47 @@ type VideoOptions struct {
 	PlaybackSpeed float64
 	Input         string
 	MaxColors     int
-	Output        VideoOutputs
-	StartingFrame int
-	Style         *StyleOptions
+	GIFDither     string
+	GIFStatsMode  string
+	Quality       string
+	// MaxFileSize is the size budget (in bytes) of every video output.
+	MaxFileSize int64
+	// OutputFramerate and Scale reduce the framerate and dimensions of the
+	// rendered outputs when set.
+	OutputFramerate int
+	Scale           float64
+	Output          VideoOutputs
+	StartingFrame   int
+	Style           *StyleOptions
+	Transition      Transition
+	// Scenes holds the 0-based offsets into the rendered frame sequence at
+	// which a new scene starts.
+	Scenes []int
+	// Chapters holds the chapter markers embedded in (or written next to) the
+	// video outputs.
+	Chapters []Chapter
+	// Camera holds the keyframes of the virtual camera, as 0-based offsets
+	// into the rendered frame sequence.
+	Camera []CameraKeyframe
+	// Annotations holds the callouts overlaid on the terminal.
+	Annotations []Annotation
+	// Watermark is the logo or text shown on every frame, if any.
+	Watermark *Watermark
+	// LoopDelay holds the last frame of GIF outputs before they loop.
+	LoopDelay time.Duration
+	// MaxIdle is the longest stretch of identical frames, if set.
+	MaxIdle time.Duration
+	// CaptureRate is the number of captures of the terminal per second, the
+	// framerate if 0. AdaptiveCapture captures it only once it changed.
+	CaptureRate     int
+	AdaptiveCapture bool
+	// Deterministic captures frames on a virtual clock and encodes without
+	// metadata, so that the outputs are reproducible.
+	Deterministic bool
+	// Filter is an ffmpeg filter chain applied to the frames, and
+	// FrameCommand a shell command run over the frames, before encoding.
+	Filter       string
+	FrameCommand string
 }
 
 const (
@@ -127,28 +189,38 @@ func buildFFopts(opts VideoOptions, targetFile string) []string {
 		"-i", filepath.Join(opts.Input, cursorFrameFormat),
 	)
 
+	// Stream 2+: annotation images
 	streamBuilder = streamBuilder.
+		WithAnnotations(opts.Annotations).
 		WithMargin().
 		WithBar().
-		WithCorner()
+		WithCorner().
+		WithWatermark(opts.Watermark)
 
 	filterBuilder := NewVideoFilterBuilder(&opts).
 		WithWindowBar(streamBuilder.barStream).
 		WithBorderRadius(streamBuilder.cornerStream).
-		WithMarginFill(streamBuilder.marginStream)
+		WithMarginFill(streamBuilder.marginStream).
+		WithWatermark(streamBuilder.watermarkStream, opts.Watermark).
+		WithFilter(opts.Filter).
+		WithScale(opts.Scale)
 
 	// Format-specific options
 	switch filepath.Ext(targetFile) {
 	case gif:
-		filterBuilder = filterBuilder.WithGIF()
+		filterBuilder = filterBuilder.WithGIF(opts)
+		streamBuilder = streamBuilder.WithLoops(opts.Output.GIFLoops)
 	case webm:
-		streamBuilder = streamBuilder.WithWebm()
+		streamBuilder = streamBuilder.WithWebm(opts.Quality)
 	case mp4:
-		streamBuilder = streamBuilder.WithMP4()
+		streamBuilder = streamBuilder.WithChapters(opts.Chapters).WithMP4(opts.Quality)
 	}
 
 	args = append(args, streamBuilder.Build()...)
 	args = append(args, filterBuilder.Build()...)
+	if opts.Deterministic {
+		args = append(args, deterministicFFopts...)
+	}
 	args = append(args, targetFile)
 
 	return args
@@ -171,47 +243,59 @@ func MakeMP4(opts VideoOptions) *exec.Cmd {
 
 // MakeSVG generates an animated SVG from captured frames.
 func MakeSVG(v *VHS) error {
-	if v.Options.Video.Output.SVG == "" || len(v.svgFrames) == 0 {
-		if v.Options.Video.Output.SVG == "" {
-			log.Println("No SVG output path specified")
-		} else {
-			log.Printf("No SVG frames captured (0 frames)")
-		}
-		return nil
-	}
+	return renderOutput(v, svg, v.Options.Video.Output.SVG)
+}
 
-	log.Println(GrayStyle.Render("Creating " + v.Options.Video.Output.SVG + "..."))
-	ensureDir(v.Options.Video.Output.SVG)
+// MakeLottie generates a Lottie animation from captured frames.
+func MakeLottie(v *VHS) error {
+	return renderOutput(v, lottie, v.Options.Video.Output.Lottie)
+}
 
+// svgConfig returns the configuration of the SVG renderer for the captured
+// frames, which the other renderers share.
+func svgConfig(v *VHS) SVGConfig {
 	// Calculate total duration based on frame count and framerate
 	duration := float64(len(v.svgFrames)) / float64(v.Options.Video.Framerate)
+	style := cmp.Or(v.Options.Video.Style, DefaultStyleOptions())
 
 	// Create SVG config
 	svgOpts := SVGConfig{
-		Width:         v.Options.Video.Style.Width,
-		Height:        v.Options.Video.Style.Height,
-		FontSize:      v.Options.FontSize,
-		FontFamily:    v.Options.FontFamily,
-		Theme:         v.Options.Theme,
-		Frames:        v.svgFrames,
-		Duration:      duration,
-		Style:         v.Options.Video.Style,
-		LineHeight:    v.Options.LineHeight,
-		CursorBlink:   v.Options.CursorBlink,
-		PlaybackSpeed: v.Options.Video.PlaybackSpeed,
-		LoopOffset:    v.Options.LoopOffset,
-		OptimizeSize:  v.Options.SVG.OptimizeSize,
-		Debug:         v.Options.DebugConsole,
-	}
-
-	// Generate SVG
-	generator := NewSVGGenerator(svgOpts)
-	svgContent := generator.Generate()
+		Width:          style.Width,
+		Height:         style.Height,
+		FontSize:       v.Options.FontSize,
+		FontFamily:     withEmojiFont(v.Options.FontFamily, v.Options.EmojiFont),
+		Theme:          v.Options.Theme,
+		Frames:         v.svgFrames,
+		Duration:       duration,
+		Style:          style,
+		LineHeight:     v.Options.LineHeight,
+		LetterSpacing:  v.Options.LetterSpacing,
+		CursorBlink:    v.Options.CursorBlink,
+		PlaybackSpeed:  v.Options.Video.PlaybackSpeed,
+		LoopOffset:     v.Options.LoopOffset,
+		OptimizeSize:   v.Options.SVG.OptimizeSize,
+		Debug:          v.Options.DebugConsole,
+		SceneTimes:     sceneTimes(v.scenes, v.Options.Video.Framerate, len(v.svgFrames)),
+		Transition:     v.Options.Video.Transition,
+		UnderlineLinks: v.Options.SVG.UnderlineLinks,
+		Transcript:     v.transcript(),
+		NoLigatures:    !v.Options.SVG.FontLigatures,
+		Camera:         cameraKeyframes(v.camera, 1),
+		Annotations:    v.annotations,
+		Watermark:      v.watermark(),
+		Description:    v.Options.SVG.Description,
+		DarkTheme:      v.Options.darkTheme(),
 
-	// Write to file
-	if err := os.WriteFile(v.Options.Video.Output.SVG, []byte(svgContent), 0o600); err != nil {
-		return fmt.Errorf("failed to write SVG file: %w", err)
+		CursorColor:       v.Options.CursorColor,
+		CursorAccentColor: v.Options.CursorAccentColor,
+		CursorTween:       v.Options.SVG.CursorTween,
+		SyntaxHighlight:   v.Options.SVG.SyntaxHighlight,
 	}
-
-	return nil
+	if v.Options.SVG.AccessibleTranscript {
+		svgOpts.AccessibleTranscript = v.accessibleTranscript()
+	}
+	if v.Options.SVG.OptimizeSize {
+		svgOpts.OptimizeLevel = v.Options.SVG.OptimizeLevel
+	}
+	return svgOpts
 }
@@ -0,0 +1,74 @@
+//go:build js && wasm
+
+// Package vhs wasm.go exposes the tape parser and the SVG generator to
+// JavaScript.
+//
+// The WebAssembly build contains none of the recording, which needs a
+// terminal, a browser and ffmpeg, so that web playgrounds can validate tapes
+// and preview recordings captured beforehand in the browser. It defines a
+// global vhs object:
+//
+//	vhs.parse(tape) // {commands: [{type, args, options, line}], errors: [{line, column, message}]}
+//	vhs.renderSVG(recording) // {svg} of a JSON recording (object or string), or {error}
+//
+// GOOS=js GOARCH=wasm go build -o vhs.wasm
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/agentstation/vhs/lexer"
+	"github.com/agentstation/vhs/parser"
+)
+
+func main() {
+	js.Global().Set("vhs", js.ValueOf(map[string]any{
+		"parse":     js.FuncOf(jsParse),
+		"renderSVG": js.FuncOf(jsRenderSVG),
+	}))
+	select {}
+}
+
+// jsParse parses the tape of its argument into its commands and errors.
+func jsParse(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "parse expects a tape"}
+	}
+	p := parser.New(lexer.New(args[0].String()))
+	cmds := p.Parse()
+
+	commands := make([]any, 0, len(cmds))
+	for _, c := range cmds {
+		commands = append(commands, map[string]any{
+			"type":    string(c.Type),
+			"args":    c.Args,
+			"options": c.Options,
+			"line":    c.Line,
+		})
+	}
+	errs := make([]any, 0, len(p.Errors()))
+	for _, err := range p.Errors() {
+		errs = append(errs, map[string]any{
+			"line":    err.Token.Line,
+			"column":  err.Token.Column,
+			"message": err.Msg,
+		})
+	}
+	return map[string]any{"commands": commands, "errors": errs}
+}
+
+// jsRenderSVG renders the JSON recording of its argument into an SVG.
+func jsRenderSVG(_ js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return map[string]any{"error": "renderSVG expects a recording"}
+	}
+	recording := args[0]
+	if recording.Type() != js.TypeString {
+		recording = js.Global().Get("JSON").Call("stringify", recording)
+	}
+	cfg, err := ParseSVGRecording([]byte(recording.String()))
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"svg": NewSVGGenerator(cfg).Generate()}
+}
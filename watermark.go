@@ -0,0 +1,157 @@
+// Package vhs watermark.go brands the outputs with a logo or text.
+//
+// The watermark is an image (PNG, JPEG or GIF) or a line of text shown in a
+// corner (or the center) of every frame. Videos overlay it with ffmpeg, SVG
+// outputs embed it as a fixed element above the animation.
+//
+// Set Watermark ./logo.png --position bottom-right --opacity 0.4
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"  // register the GIF decoder for watermark images
+	_ "image/jpeg" // register the JPEG decoder for watermark images
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// watermarkInset is the distance (in pixels) between the watermark and the
+// edges of the output.
+const watermarkInset = 20
+
+// Watermark is a logo or text shown on every frame.
+type Watermark struct {
+	// Image is the path of the logo, Text is used when there is none.
+	Image string
+	Text  string
+	// Position is top-left, top-right, bottom-left, bottom-right or center.
+	Position string
+	Opacity  float64
+	// Color is the color of the text, the foreground of the theme by default.
+	Color string
+}
+
+// isWatermarkImage returns whether the value of Set Watermark is the path of
+// an image rather than text.
+func isWatermarkImage(value string) bool {
+	switch strings.ToLower(filepath.Ext(value)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// origin returns the top-left corner of a watermark of the given size on an
+// output of the given size.
+func (w Watermark) origin(width, height, markWidth, markHeight float64) (float64, float64) {
+	x, y := float64(watermarkInset), float64(watermarkInset)
+	if strings.HasSuffix(w.Position, "right") {
+		x = width - markWidth - watermarkInset
+	}
+	if strings.HasPrefix(w.Position, "bottom") {
+		y = height - markHeight - watermarkInset
+	}
+	if w.Position == "center" {
+		x, y = (width-markWidth)/2, (height-markHeight)/2
+	}
+	return x, y
+}
+
+// overlayPosition returns the position of the watermark as ffmpeg overlay
+// expressions.
+func (w Watermark) overlayPosition() string {
+	x, y := strconv.Itoa(watermarkInset), strconv.Itoa(watermarkInset)
+	if strings.HasSuffix(w.Position, "right") {
+		x = fmt.Sprintf("W-w-%d", watermarkInset)
+	}
+	if strings.HasPrefix(w.Position, "bottom") {
+		y = fmt.Sprintf("H-h-%d", watermarkInset)
+	}
+	if w.Position == "center" {
+		x, y = "(W-w)/2", "(H-h)/2"
+	}
+	return x + ":" + y
+}
+
+// MakeWatermarkImage draws the text of the watermark on a transparent image
+// and saves it to a file.
+func MakeWatermarkImage(w Watermark, fontFamily string, fontSize float64, file string) error {
+	face := getWindowBarFont(fontFamily, fontSize)
+	metrics := face.Metrics()
+	width := max(1, measureText(face, w.Text))
+	height := max(1, (metrics.Ascent + metrics.Descent).Ceil())
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fg, _ := parseHexColor(w.Color)
+	d := font.Drawer{
+		Dst:  img,
+		Src:  &image.Uniform{fg},
+		Face: face,
+		Dot:  fixed.Point26_6{Y: metrics.Ascent},
+	}
+	d.DrawString(w.Text)
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create watermark image: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("failed to encode watermark image: %w", err)
+	}
+	return nil
+}
+
+// generateWatermark draws the watermark over an output of the given size.
+func (g *SVGGenerator) generateWatermark(sb *strings.Builder, width, height int) {
+	w := g.options.Watermark
+	if w == nil {
+		return
+	}
+
+	if w.Image == "" {
+		// The text is anchored at the inset from the edges (or the center).
+		anchor := "start"
+		if w.Position == "center" {
+			anchor = "middle"
+		} else if strings.HasSuffix(w.Position, "right") {
+			anchor = "end"
+		}
+		x, y := w.origin(float64(width), float64(height), 0, g.fontSize)
+		fmt.Fprintf(sb, `<text x="%s" y="%s" font-family="%s" font-size="%s" fill="%s" opacity="%s" text-anchor="%s" dominant-baseline="hanging">%s</text>`,
+			formatCoord(x), formatCoord(y), html.EscapeString(buildSVGFontFamily(g.options.FontFamily)), formatCoord(g.fontSize),
+			w.Color, formatCoord(w.Opacity), anchor, html.EscapeString(w.Text))
+		g.writeNewline(sb)
+		return
+	}
+
+	data, err := os.ReadFile(w.Image)
+	if err != nil {
+		log.Printf("Couldn't read watermark image: %v", err)
+		return
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Couldn't decode watermark image: %v", err)
+		return
+	}
+
+	x, y := w.origin(float64(width), float64(height), float64(config.Width), float64(config.Height))
+	fmt.Fprintf(sb, `<image x="%s" y="%s" width="%d" height="%d" opacity="%s" href="data:%s;base64,%s"/>`,
+		formatCoord(x), formatCoord(y), config.Width, config.Height, formatCoord(w.Opacity),
+		http.DetectContentType(data), base64.StdEncoding.EncodeToString(data))
+	g.writeNewline(sb)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestWatermarkPosition(t *testing.T) {
+	tests := []struct {
+		position string
+		x, y     float64
+		overlay  string
+	}{
+		{"top-left", 20, 20, "20:20"},
+		{"top-right", 1070, 20, "W-w-20:20"},
+		{"bottom-left", 20, 550, "20:H-h-20"},
+		{"bottom-right", 1070, 550, "W-w-20:H-h-20"},
+		{"center", 545, 285, "(W-w)/2:(H-h)/2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.position, func(t *testing.T) {
+			w := Watermark{Position: tc.position}
+			if x, y := w.origin(1200, 600, 110, 30); x != tc.x || y != tc.y {
+				t.Errorf("expected the watermark at %v,%v, got %v,%v", tc.x, tc.y, x, y)
+			}
+			if got := w.overlayPosition(); got != tc.overlay {
+				t.Errorf("expected overlay position %q, got %q", tc.overlay, got)
+			}
+		})
+	}
+}
+
+func TestExecuteSetWatermark(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	writeTestPNG(t, logo, 8, 4)
+
+	v := &VHS{Options: &Options{Video: DefaultVideoOptions()}}
+	if err := ExecuteSetWatermark(parser.Command{Args: "top-left 0.4 " + logo}, v); err != nil {
+		t.Fatal(err)
+	}
+	if w := v.Options.Video.Watermark; w.Image != logo || w.Position != "top-left" || w.Opacity != 0.4 {
+		t.Errorf("unexpected image watermark %+v", w)
+	}
+
+	if err := ExecuteSetWatermark(parser.Command{Args: "bottom-right 1 ACME Inc."}, v); err != nil {
+		t.Fatal(err)
+	}
+	if w := v.Options.Video.Watermark; w.Text != "ACME Inc." || w.Image != "" {
+		t.Errorf("unexpected text watermark %+v", w)
+	}
+
+	if err := ExecuteSetWatermark(parser.Command{Args: "bottom-right 1 missing.png"}, v); err == nil {
+		t.Error("expected an error for a missing image")
+	}
+}
+
+func TestMakeWatermarkImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watermark.png")
+	w := Watermark{Text: "ACME", Color: "#ff0000"}
+	if err := MakeWatermarkImage(w, defaultFontFamily, 20, path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drawn := false
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y && !drawn; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if r, g, _, alpha := img.At(x, y).RGBA(); alpha == 0xffff && r == 0xffff && g == 0 {
+				drawn = true
+				break
+			}
+		}
+	}
+	if !drawn {
+		t.Error("expected the text to be drawn in its color")
+	}
+}
+
+func TestBuildFFopts_Watermark(t *testing.T) {
+	opts := DefaultVideoOptions()
+	opts.Style = DefaultStyleOptions()
+	opts.Style.MarginFill = ""
+	opts.Watermark = &Watermark{Image: "logo.png", Position: "bottom-right", Opacity: 0.4}
+
+	args := strings.Join(buildFFopts(opts, "out.mp4"), " ")
+	for _, want := range []string{
+		"-loop 1 -i logo.png",
+		"[2]format=rgba,colorchannelmixer=aa=0.4[watermark]",
+		"[padded][watermark]overlay=W-w-20:H-h-20:shortest=1[watermarked]",
+		"-map [watermarked]",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected %q in:\n%s", want, args)
+		}
+	}
+}
+
+func TestSVGGenerator_Watermark(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	writeTestPNG(t, logo, 8, 4)
+
+	opts := createTestSVGConfig()
+	opts.Watermark = &Watermark{Text: "ACME & Co", Position: "top-right", Opacity: 0.5, Color: "#ffffff"}
+	svg := NewSVGGenerator(opts).Generate()
+	if !strings.Contains(svg, `text-anchor="end"`) || !strings.Contains(svg, `opacity="0.5"`) ||
+		!strings.Contains(svg, ">ACME &amp; Co</text>") {
+		t.Errorf("expected the text watermark, got:\n%s", svg)
+	}
+
+	opts.Watermark = &Watermark{Image: logo, Position: "bottom-left", Opacity: 1}
+	svg = NewSVGGenerator(opts).Generate()
+	if !strings.Contains(svg, `width="8" height="4" opacity="1" href="data:image/png;base64,`) {
+		t.Errorf("expected the image watermark, got:\n%s", svg)
+	}
+	if strings.Index(svg, "data:image/png") < strings.LastIndex(svg, "animation-container") {
+		t.Error("expected the watermark above the animation")
+	}
+}
+
+// writeTestPNG writes a transparent PNG of the given size.
+func writeTestPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+//go:build !js
+
+// Package vhs workspace.go sets the directory the shell starts in.
+//
+// Set WorkingDirectory starts the shell in a directory other than the one VHS
+// runs in, e.g. a demo project, while the paths of the tape, such as outputs,
+// stay relative to VHS. With Set Sandbox, the shell starts in a throwaway copy
+// of that directory instead, which is removed once the recording ends, so
+// demos which create or delete files never change the real directory and can
+// be recorded again.
+//
+// Set WorkingDirectory ./demo-project
+// Set Sandbox true
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+// errSandboxedWorkingDirectory is returned by WorkingDirectory in sandboxed
+// recordings, whose shell is confined to the directory of the sandbox.
+var errSandboxedWorkingDirectory = errors.New("WorkingDirectory is not allowed in sandboxed recordings")
+
+// ExecuteSetWorkingDirectory sets the directory the shell starts in.
+func ExecuteSetWorkingDirectory(c parser.Command, v *VHS) error {
+	if v.Options.Sandbox.Dir != "" {
+		return errSandboxedWorkingDirectory
+	}
+	info, err := os.Stat(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to set working directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("failed to set working directory: %s is not a directory", c.Args)
+	}
+	v.Options.WorkingDirectory = c.Args
+	return nil
+}
+
+// ExecuteSetSandbox sets whether the shell starts in a throwaway copy of the
+// working directory.
+func ExecuteSetSandbox(c parser.Command, v *VHS) error {
+	var err error
+	v.Options.SandboxWorkspace, err = strconv.ParseBool(c.Args)
+	if err != nil {
+		return fmt.Errorf("failed to parse sandbox: %w", err)
+	}
+
+	return nil
+}
+
+// isWorkspaceSetting returns whether the setting sets the directory of the
+// shell, which must be applied before the shell starts.
+func isWorkspaceSetting(name string) bool {
+	return name == "WorkingDirectory" || name == "Sandbox"
+}
+
+// setupWorkspace copies the working directory into a temporary directory if
+// Sandbox is set, returning the function which removes it. Recordings which
+// are already sandboxed run in a throwaway directory.
+func (vhs *VHS) setupWorkspace() (func(), error) {
+	if !vhs.Options.SandboxWorkspace || vhs.Options.Sandbox.Dir != "" {
+		return func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "vhs-workspace-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	if err := copyDir(cmp.Or(vhs.Options.WorkingDirectory, "."), dir); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to copy the working directory into the sandbox: %w", err)
+	}
+	vhs.workspace = dir
+	return cleanup, nil
+}
+
+// shellDir returns the directory the shell starts in.
+func (vhs *VHS) shellDir(sandbox ShellSandbox) string {
+	return cmp.Or(sandbox.Dir, vhs.workspace, vhs.Options.WorkingDirectory)
+}
+
+// copyDir copies the files, directories and symbolic links of src into dst,
+// keeping their permissions. Other files, e.g. sockets, are skipped.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700) //nolint:wrapcheck
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			return os.Symlink(link, target) //nolint:wrapcheck
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return nil
+		}
+	})
+}
+
+// copyFile copies the regular file src to dst with the permissions.
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src) //nolint:gosec
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer in.Close() //nolint:errcheck
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm) //nolint:gosec
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err //nolint:wrapcheck
+	}
+	return out.Close() //nolint:wrapcheck
+}
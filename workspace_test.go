@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentstation/vhs/parser"
+)
+
+func TestCopyDir(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("echo hi"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/run.sh", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "sub", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("expected the permissions to be kept, got %v", info.Mode().Perm())
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "sub/run.sh" {
+		t.Errorf("expected the symbolic link to be kept, got %q, %v", link, err)
+	}
+}
+
+func TestExecuteSetWorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	v := New()
+	if err := ExecuteSetWorkingDirectory(parser.Command{Args: dir}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.shellDir(ShellSandbox{}); got != dir {
+		t.Errorf("expected the shell to start in %s, got %s", dir, got)
+	}
+	if got := v.shellDir(ShellSandbox{Dir: "/sandbox"}); got != "/sandbox" {
+		t.Errorf("expected the sandbox to take precedence, got %s", got)
+	}
+
+	if err := ExecuteSetWorkingDirectory(parser.Command{Args: filepath.Join(dir, "missing")}, &v); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+	v.Options.Sandbox.Dir = dir
+	if err := ExecuteSetWorkingDirectory(parser.Command{Args: dir}, &v); !errors.Is(err, errSandboxedWorkingDirectory) {
+		t.Errorf("expected the sandbox to refuse the working directory, got %v", err)
+	}
+}
+
+func TestEvaluateNativeSandbox(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tape := `Set Shell bash
+Set WorkingDirectory "` + dir + `"
+Set Sandbox true
+Type "ls; touch new.txt; rm old.txt; pwd"
+Enter
+Wait+Screen /vhs-workspace/
+Sleep 100ms`
+
+	var v *VHS
+	errs := Evaluate(context.Background(), tape, io.Discard, WithBackend(nativeBackend), func(vhs *VHS) {
+		vhs.Options.Video.Output.SVG = filepath.Join(t.TempDir(), "out.svg")
+		vhs.Options.Test.Output = ""
+		v = vhs
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	last := v.svgFrames[len(v.svgFrames)-1]
+	if !strings.Contains(strings.Join(last.Lines, "\n"), "old.txt") {
+		t.Errorf("expected the shell to start in a copy of the working directory, got %q", last.Lines)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); err != nil {
+		t.Errorf("expected the working directory to be left as is: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); err == nil {
+		t.Error("expected the files of the recording to be created in the sandbox")
+	}
+	if _, err := os.Stat(v.workspace); !os.IsNotExist(err) {
+		t.Errorf("expected the sandbox to be removed, got %v", err)
+	}
+}